@@ -27,7 +27,8 @@ type ClusterFederatedTrustDomainSpec struct {
 	TrustDomain string `json:"trustDomain"`
 
 	// BundleEndpointURL is the URL of the bundle endpoint. It must be an
-	// HTTPS URL and cannot contain userinfo (i.e. username/password).
+	// HTTPS URL and cannot contain userinfo (i.e. username/password). It may
+	// be a template, rendered with the trust domain (i.e. {{ .TrustDomain }}).
 	BundleEndpointURL string `json:"bundleEndpointURL"`
 
 	// BundleEndpointProfile is the profile for the bundle endpoint.
@@ -38,6 +39,12 @@ type ClusterFederatedTrustDomainSpec struct {
 	// +kubebuilder:validation:Optional
 	TrustDomainBundle string `json:"trustDomainBundle,omitempty"`
 
+	// ManageBundle indicates whether or not the controller writes the
+	// TrustDomainBundle into SPIRE. When false, the bundle is left to the
+	// bundle endpoint refresh of SPIRE. Defaults to true.
+	// +kubebuilder:validation:Optional
+	ManageBundle *bool `json:"manageBundle,omitempty"`
+
 	// Set which Controller Class will act on this object
 	// +kubebuilder:validation:Optional
 	ClassName string `json:"className,omitempty"`
@@ -54,7 +61,7 @@ type BundleEndpointProfile struct {
 	EndpointSPIFFEID string `json:"endpointSPIFFEID,omitempty"`
 }
 
-// +kubebuilder:validation:Enum=https_spiffe;https_web
+// +kubebuilder:validation:Enum=https_spiffe;https_web;oidc_discovery
 type BundleEndpointProfileType string
 
 const (
@@ -63,10 +70,25 @@ const (
 
 	// HTTPSWebProfileType indicates an "https_web" SPIFFE federation profile
 	HTTPSWebProfileType BundleEndpointProfileType = "https_web"
+
+	// OIDCDiscoveryProfileType indicates that the JWT authorities of the
+	// trust domain are fetched from an OpenID Connect discovery document
+	OIDCDiscoveryProfileType BundleEndpointProfileType = "oidc_discovery"
 )
 
 // ClusterFederatedTrustDomainStatus defines the observed state of ClusterFederatedTrustDomain
 type ClusterFederatedTrustDomainStatus struct {
+	// If the federation relationship in SPIRE matches the spec as of the
+	// last reconcile, i.e. it was created or updated successfully or already
+	// matched. For the "oidc_discovery" profile, if the federated bundle in
+	// SPIRE matches the fetched one.
+	Set bool `json:"set"`
+
+	// Error describes why the federation relationship could not be set on
+	// the last reconcile, e.g. a failure to create it or a conflict with
+	// another ClusterFederatedTrustDomain.
+	// +optional
+	Error string `json:"error,omitempty"`
 }
 
 //+kubebuilder:object:root=true
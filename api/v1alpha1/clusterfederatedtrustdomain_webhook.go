@@ -17,8 +17,16 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
@@ -39,6 +47,108 @@ func (r *ClusterFederatedTrustDomain) SetupWebhookWithManager(mgr ctrl.Manager)
 		Complete()
 }
 
+// ClusterFederatedTrustDomainValidator validates ClusterFederatedTrustDomains
+// like the webhook.Validator implementation of the type, with optional extra
+// checks.
+// +kubebuilder:object:generate=false
+type ClusterFederatedTrustDomainValidator struct {
+	// BundleEndpointReachabilityTimeout, if non-zero, enables a check that
+	// the bundle endpoint is reachable within the timeout. An unreachable
+	// endpoint only results in a warning; admission is never denied because
+	// of it.
+	BundleEndpointReachabilityTimeout time.Duration
+
+	// HTTPClient is used for the reachability check. Defaults to a client
+	// that does not verify the bundle endpoint certificate, since the
+	// certificate of an "https_spiffe" endpoint is not rooted in the web PKI
+	// and only reachability is checked.
+	HTTPClient *http.Client
+}
+
+var _ webhook.CustomValidator = &ClusterFederatedTrustDomainValidator{}
+
+func (v *ClusterFederatedTrustDomainValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&ClusterFederatedTrustDomain{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate implements webhook.CustomValidator
+func (v *ClusterFederatedTrustDomainValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	r, ok := obj.(*ClusterFederatedTrustDomain)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterFederatedTrustDomain but got %T", obj)
+	}
+	clusterfederatedtrustdomainlog.Info("validate create", "name", r.Name)
+	return v.validate(ctx, r)
+}
+
+// ValidateUpdate implements webhook.CustomValidator
+func (v *ClusterFederatedTrustDomainValidator) ValidateUpdate(ctx context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	r, ok := newObj.(*ClusterFederatedTrustDomain)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterFederatedTrustDomain but got %T", newObj)
+	}
+	clusterfederatedtrustdomainlog.Info("validate update", "name", r.Name)
+	return v.validate(ctx, r)
+}
+
+// ValidateDelete implements webhook.CustomValidator
+func (v *ClusterFederatedTrustDomainValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	// Deletes are not validated.
+	return nil, nil
+}
+
+func (v *ClusterFederatedTrustDomainValidator) validate(ctx context.Context, r *ClusterFederatedTrustDomain) (admission.Warnings, error) {
+	federationRelationship, err := ParseClusterFederatedTrustDomainSpec(&r.Spec)
+	if err != nil {
+		return nil, err
+	}
+	if v.BundleEndpointReachabilityTimeout <= 0 {
+		return nil, nil
+	}
+	if err := v.checkBundleEndpointReachable(ctx, federationRelationship.BundleEndpointURL); err != nil {
+		clusterfederatedtrustdomainlog.Info("Bundle endpoint is unreachable", "name", r.Name, "bundleEndpointURL", federationRelationship.BundleEndpointURL, "reason", err.Error())
+		return admission.Warnings{fmt.Sprintf("bundle endpoint %q is unreachable: %v", federationRelationship.BundleEndpointURL, err)}, nil
+	}
+	return nil, nil
+}
+
+func (v *ClusterFederatedTrustDomainValidator) checkBundleEndpointReachable(ctx context.Context, bundleEndpointURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, v.BundleEndpointReachabilityTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, bundleEndpointURL, nil)
+	if err != nil {
+		return err
+	}
+	httpClient := v.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // nolint: gosec // only reachability is checked
+			},
+		}
+		defer httpClient.CloseIdleConnections()
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// The URL is already part of the warning.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // TODO(user): EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 
 // TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
@@ -75,7 +185,12 @@ func ParseClusterFederatedTrustDomainSpec(spec *ClusterFederatedTrustDomainSpec)
 		return nil, fmt.Errorf("invalid trustDomain value: %w", err)
 	}
 
-	if err := spireapi.ValidateBundleEndpointURL(spec.BundleEndpointURL); err != nil {
+	bundleEndpointURL, err := renderBundleEndpointURL(spec.BundleEndpointURL, trustDomain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundleEndpointURL template: %w", err)
+	}
+
+	if err := spireapi.ValidateBundleEndpointURL(bundleEndpointURL); err != nil {
 		return nil, fmt.Errorf("invalid bundleEndpointURL value: %w", err)
 	}
 
@@ -94,10 +209,20 @@ func ParseClusterFederatedTrustDomainSpec(spec *ClusterFederatedTrustDomainSpec)
 		bundleEndpointProfile = spireapi.HTTPSSPIFFEProfile{
 			EndpointSPIFFEID: endpointSPIFFEID,
 		}
+	case OIDCDiscoveryProfileType:
+		if spec.BundleEndpointProfile.EndpointSPIFFEID != "" {
+			return nil, fmt.Errorf("invalid bundle endpoint profile endpointSPIFFEID value: not applicable to the %q profile", OIDCDiscoveryProfileType)
+		}
+		bundleEndpointProfile = spireapi.OIDCDiscoveryProfile{}
 	default:
 		return nil, fmt.Errorf("invalid bundle endpoint profile type value %q", spec.BundleEndpointProfile.Type)
 	}
 
+	manageBundle := spec.ManageBundle == nil || *spec.ManageBundle
+	if !manageBundle && spec.BundleEndpointProfile.Type == OIDCDiscoveryProfileType {
+		return nil, fmt.Errorf("invalid manageBundle value: the bundle is always managed for the %q profile", OIDCDiscoveryProfileType)
+	}
+
 	var trustDomainBundle *spiffebundle.Bundle
 	if spec.TrustDomainBundle != "" {
 		trustDomainBundle, err = spiffebundle.Read(trustDomain, strings.NewReader(spec.TrustDomainBundle))
@@ -105,11 +230,39 @@ func ParseClusterFederatedTrustDomainSpec(spec *ClusterFederatedTrustDomainSpec)
 			return nil, fmt.Errorf("invalid trustDomainBundle value: %w", err)
 		}
 	}
+	if !manageBundle {
+		// Leave the bundle to the bundle endpoint refresh of SPIRE.
+		trustDomainBundle = nil
+	}
 
 	return &spireapi.FederationRelationship{
 		TrustDomain:           trustDomain,
-		BundleEndpointURL:     spec.BundleEndpointURL,
+		BundleEndpointURL:     bundleEndpointURL,
 		BundleEndpointProfile: bundleEndpointProfile,
 		TrustDomainBundle:     trustDomainBundle,
 	}, nil
 }
+
+// bundleEndpointURLTemplateData is the data available to bundle endpoint URL
+// templates. The controller environment is intentionally not exposed since
+// the resource may be authored by any user allowed to create it.
+type bundleEndpointURLTemplateData struct {
+	TrustDomain string
+}
+
+// renderBundleEndpointURL renders the bundle endpoint URL as a template.
+// Literal URLs are returned as is.
+func renderBundleEndpointURL(bundleEndpointURL string, trustDomain spiffeid.TrustDomain) (string, error) {
+	if !strings.Contains(bundleEndpointURL, "{{") {
+		return bundleEndpointURL, nil
+	}
+	tmpl, err := template.New("bundleEndpointURL").Option("missingkey=error").Parse(bundleEndpointURL)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, bundleEndpointURLTemplateData{TrustDomain: trustDomain.Name()}); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,201 @@
+package v1alpha1_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestParseClusterFederatedTrustDomainSpecBundleEndpointURL(t *testing.T) {
+	for _, tt := range []struct {
+		name              string
+		bundleEndpointURL string
+		expectURL         string
+		expectErr         string
+	}{
+		{
+			name:              "literal",
+			bundleEndpointURL: "https://backend.test/bundle",
+			expectURL:         "https://backend.test/bundle",
+		},
+		{
+			name:              "templated",
+			bundleEndpointURL: "https://{{ .TrustDomain }}.test/bundle",
+			expectURL:         "https://backend.test/bundle",
+		},
+		{
+			name:              "rendered URL is validated",
+			bundleEndpointURL: "http://{{ .TrustDomain }}.test/bundle",
+			expectErr:         "invalid bundleEndpointURL value: scheme must be https",
+		},
+		{
+			name:              "malformed template",
+			bundleEndpointURL: "https://{{ .TrustDomain }/bundle",
+			expectErr:         `invalid bundleEndpointURL template: template: bundleEndpointURL:1: unexpected "}" in operand`,
+		},
+		{
+			name:              "unknown field",
+			bundleEndpointURL: "https://{{ .Nope }}/bundle",
+			expectErr:         "invalid bundleEndpointURL template: template: bundleEndpointURL:1:11: executing \"bundleEndpointURL\" at <.Nope>: can't evaluate field Nope",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fr, err := spirev1alpha1.ParseClusterFederatedTrustDomainSpec(&spirev1alpha1.ClusterFederatedTrustDomainSpec{
+				TrustDomain:           "backend",
+				BundleEndpointURL:     tt.bundleEndpointURL,
+				BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: spirev1alpha1.HTTPSWebProfileType},
+			})
+			if tt.expectErr != "" {
+				require.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectURL, fr.BundleEndpointURL)
+		})
+	}
+}
+
+func TestParseClusterFederatedTrustDomainSpecManageBundle(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	bundle := spiffebundle.FromJWTAuthorities(spiffeid.RequireTrustDomainFromString("backend"), map[string]crypto.PublicKey{"kid": key.Public()})
+	bundleContents, err := bundle.Marshal()
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name         string
+		manageBundle *bool
+		profileType  spirev1alpha1.BundleEndpointProfileType
+		expectBundle bool
+		expectErr    string
+	}{
+		{
+			name:         "default",
+			profileType:  spirev1alpha1.HTTPSWebProfileType,
+			expectBundle: true,
+		},
+		{
+			name:         "managed",
+			manageBundle: ptr.To(true),
+			profileType:  spirev1alpha1.HTTPSWebProfileType,
+			expectBundle: true,
+		},
+		{
+			name:         "not managed",
+			manageBundle: ptr.To(false),
+			profileType:  spirev1alpha1.HTTPSWebProfileType,
+		},
+		{
+			name:         "not managed with oidc_discovery",
+			manageBundle: ptr.To(false),
+			profileType:  spirev1alpha1.OIDCDiscoveryProfileType,
+			expectErr:    `invalid manageBundle value: the bundle is always managed for the "oidc_discovery" profile`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fr, err := spirev1alpha1.ParseClusterFederatedTrustDomainSpec(&spirev1alpha1.ClusterFederatedTrustDomainSpec{
+				TrustDomain:           "backend",
+				BundleEndpointURL:     "https://backend.test/bundle",
+				BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: tt.profileType},
+				TrustDomainBundle:     string(bundleContents),
+				ManageBundle:          tt.manageBundle,
+			})
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.expectBundle {
+				require.NotNil(t, fr.TrustDomainBundle)
+				require.True(t, bundle.Equal(fr.TrustDomainBundle))
+			} else {
+				require.Nil(t, fr.TrustDomainBundle)
+			}
+		})
+	}
+}
+
+func TestClusterFederatedTrustDomainValidatorBundleEndpointReachability(t *testing.T) {
+	reachable := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bundle" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer reachable.Close()
+	unreachable := httptest.NewTLSServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	for _, tt := range []struct {
+		name              string
+		timeout           time.Duration
+		bundleEndpointURL string
+		expectWarning     string
+	}{
+		{
+			name:              "reachable",
+			timeout:           time.Second,
+			bundleEndpointURL: reachable.URL + "/bundle",
+		},
+		{
+			name:              "unexpected status",
+			timeout:           time.Second,
+			bundleEndpointURL: reachable.URL + "/bundel",
+			expectWarning:     `bundle endpoint "` + reachable.URL + `/bundel" is unreachable: unexpected status 404`,
+		},
+		{
+			name:              "unreachable",
+			timeout:           time.Second,
+			bundleEndpointURL: unreachable.URL + "/bundle",
+			expectWarning:     `bundle endpoint "` + unreachable.URL + `/bundle" is unreachable: dial tcp ` + unreachable.Listener.Addr().String(),
+		},
+		{
+			name:              "check disabled",
+			bundleEndpointURL: unreachable.URL + "/bundle",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &spirev1alpha1.ClusterFederatedTrustDomainValidator{
+				BundleEndpointReachabilityTimeout: tt.timeout,
+			}
+			warnings, err := validator.ValidateCreate(context.Background(), &spirev1alpha1.ClusterFederatedTrustDomain{
+				Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+					TrustDomain:           "backend",
+					BundleEndpointURL:     tt.bundleEndpointURL,
+					BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: spirev1alpha1.HTTPSWebProfileType},
+				},
+			})
+			require.NoError(t, err)
+			if tt.expectWarning == "" {
+				require.Empty(t, warnings)
+				return
+			}
+			require.Len(t, warnings, 1)
+			require.Contains(t, warnings[0], tt.expectWarning)
+		})
+	}
+
+	t.Log("Invalid objects are still denied")
+	validator := &spirev1alpha1.ClusterFederatedTrustDomainValidator{BundleEndpointReachabilityTimeout: time.Second}
+	_, err := validator.ValidateCreate(context.Background(), &spirev1alpha1.ClusterFederatedTrustDomain{
+		Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+			TrustDomain:           "backend",
+			BundleEndpointURL:     "http://backend.test/bundle",
+			BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: spirev1alpha1.HTTPSWebProfileType},
+		},
+	})
+	require.EqualError(t, err, "invalid bundleEndpointURL value: scheme must be https")
+}
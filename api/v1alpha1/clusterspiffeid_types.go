@@ -43,6 +43,13 @@ type ClusterSPIFFEIDSpec struct {
 	// .NodeSpec, .PodSpec respectively.
 	DNSNameTemplates []string `json:"dnsNameTemplates,omitempty"`
 
+	// AliasSPIFFEIDTemplates are templates for additional SPIFFE IDs given
+	// to the selected pods, e.g. the old SPIFFE ID while migrating to a new
+	// one. Each renders an entry with the same parent ID, selectors and
+	// other fields as the entry for the SPIFFE ID template.
+	// +kubebuilder:validation:Optional
+	AliasSPIFFEIDTemplates []string `json:"aliasSPIFFEIDTemplates,omitempty"`
+
 	// WorkloadSelectorTemplates are templates to produce arbitrary workload
 	// selectors that apply to a given workload before it will receive this
 	// SPIFFE ID. The rendered value is interpreted by SPIRE and are of the
@@ -56,6 +63,13 @@ type ClusterSPIFFEIDSpec struct {
 	// obtain this SPIFFE ID will federate with.
 	FederatesWith []string `json:"federatesWith,omitempty"`
 
+	// FederatesWithTemplates are templates for additional trust domain
+	// names that workloads that obtain this SPIFFE ID will federate with.
+	// The node and pod spec are made available to the template under
+	// .NodeSpec, .PodSpec respectively.
+	// +kubebuilder:validation:Optional
+	FederatesWithTemplates []string `json:"federatesWithTemplates,omitempty"`
+
 	// NamespaceSelector selects the namespaces that are targeted by this
 	// CRD.
 	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
@@ -75,6 +89,17 @@ type ClusterSPIFFEIDSpec struct {
 	// AutoPopulateDNSNames indicates whether or not to auto populate service DNS names.
 	AutoPopulateDNSNames bool `json:"autoPopulateDNSNames,omitempty"`
 
+	// AutoPopulatePodIP indicates whether or not to add the pod IPs to the
+	// DNS names. Pods without an IP assigned yet are skipped.
+	AutoPopulatePodIP bool `json:"autoPopulatePodIP,omitempty"`
+
+	// PrimaryContainerName, if set, names the container of the pod made
+	// available to templates as .PrimaryContainer, e.g. to select its image
+	// with "k8s:container-image:{{ .PrimaryContainer.Image }}". Pods without
+	// a container of that name are skipped.
+	// +kubebuilder:validation:Optional
+	PrimaryContainerName string `json:"primaryContainerName,omitempty"`
+
 	// Set which Controller Class will act on this object
 	// +kubebuilder:validation:Optional
 	ClassName string `json:"className,omitempty"`
@@ -86,6 +111,46 @@ type ClusterSPIFFEIDSpec struct {
 	// Set the entry hint
 	// +kubebuilder:validation:Optional
 	Hint string `json:"hint,omitempty"`
+
+	// HintTemplate, if set, is rendered to set the entry hint, e.g.
+	// "{{ index .PodMeta.Labels \"app\" }}". Entries differing only by
+	// hint still mask each other, since SPIRE tells entries apart by SPIFFE
+	// ID, parent ID and selectors. It can not be set along with Hint.
+	// +kubebuilder:validation:Optional
+	HintTemplate string `json:"hintTemplate,omitempty"`
+
+	// Placeholder, if set, is used to create an entry for the SPIFFE ID
+	// while no pods are selected, so that the identity exists in advance.
+	// The entry is replaced by the pod entries once pods are selected.
+	// +kubebuilder:validation:Optional
+	Placeholder *ClusterSPIFFEIDPlaceholder `json:"placeholder,omitempty"`
+
+	// NamespaceFieldSelector further selects the namespaces that are
+	// targeted by this CRD by field (e.g. status.phase=Active). The
+	// supported fields are metadata.name and status.phase.
+	// +kubebuilder:validation:Optional
+	NamespaceFieldSelector string `json:"namespaceFieldSelector,omitempty"`
+
+	// PodFieldSelector further selects the pods that are targeted by this
+	// CRD by field (e.g. status.phase=Running). The supported fields are
+	// those Kubernetes supports for pods: metadata.name, metadata.namespace,
+	// spec.nodeName, spec.restartPolicy, spec.schedulerName,
+	// spec.serviceAccountName, spec.hostNetwork, status.phase, status.podIP
+	// and status.nominatedNodeName.
+	// +kubebuilder:validation:Optional
+	PodFieldSelector string `json:"podFieldSelector,omitempty"`
+}
+
+// ClusterSPIFFEIDPlaceholder defines the static parent ID and selectors of
+// the placeholder entry of a ClusterSPIFFEID. The SPIFFE ID template is
+// rendered without pod or node data.
+type ClusterSPIFFEIDPlaceholder struct {
+	// ParentID is the parent ID of the placeholder entry.
+	ParentID string `json:"parentID"`
+
+	// Selectors are the selectors of the placeholder entry, of the form
+	// type:value.
+	Selectors []string `json:"selectors"`
 }
 
 // ClusterSPIFFEIDStatus defines the observed state of ClusterSPIFFEID
@@ -109,6 +174,30 @@ type ClusterSPIFFEIDStats struct {
 	// +kubebuilder:validation:Optional
 	PodsSelected int `json:"podsSelected"`
 
+	// How many (selected) pods were excluded (based on configuration).
+	// +kubebuilder:validation:Optional
+	PodsExcluded int `json:"podsExcluded"`
+
+	// How many (selected) pods are waiting for an IP to be assigned before
+	// an entry can be rendered (see AutoPopulatePodIP).
+	// +kubebuilder:validation:Optional
+	PodsAwaitingIP int `json:"podsAwaitingIP"`
+
+	// How many (selected) pods were not started long enough ago for an
+	// entry to be rendered (see MinPodAgeForEntry).
+	// +kubebuilder:validation:Optional
+	PodsTooYoung int `json:"podsTooYoung"`
+
+	// How many (selected) pods were skipped because they are being deleted
+	// or have terminated (see SkipTerminatingPods).
+	// +kubebuilder:validation:Optional
+	PodsSkipped int `json:"podsSkipped"`
+
+	// How many (selected) pods were deleted before their entry could be
+	// created (see RevalidatePodsBeforeCreate).
+	// +kubebuilder:validation:Optional
+	PodsDeleted int `json:"podsDeleted"`
+
 	// How many failures were encountered rendering an entry selected pods.
 	// This could be due to either a bad template in the ClusterSPIFFEID or
 	// Pod metadata that when applied to the template did not produce valid
@@ -133,6 +222,12 @@ type ClusterSPIFFEIDStats struct {
 	// update the entries via the SPIRE Server API.
 	// +kubebuilder:validation:Optional
 	EntryFailures int `json:"entryFailures"`
+
+	// How many of the entry failures are entries not attempted because
+	// they are backed off after failing repeatedly (see
+	// entryFailureBackoffAfter in the controller configuration).
+	// +kubebuilder:validation:Optional
+	EntriesBackedOff int `json:"entriesBackedOff"`
 }
 
 //+kubebuilder:object:root=true
@@ -17,13 +17,17 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"text/template"
 	"time"
 
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -34,7 +38,10 @@ import (
 
 const (
 	dnsNameTemplateName          = "dnsNameTemplate"
+	hintTemplateName             = "hintTemplate"
 	spiffeIDTemplateName         = "spiffeIDTemplate"
+	aliasSPIFFEIDTemplateName    = "aliasSPIFFEIDTemplate"
+	federatesWithTemplateName    = "federatesWithTemplate"
 	workloadSelectorTemplateName = "workloadSelectorTemplate"
 )
 
@@ -79,21 +86,120 @@ func (r *ClusterSPIFFEID) validate() (admission.Warnings, error) {
 	return nil, err
 }
 
+// ClusterSPIFFEIDValidator validates ClusterSPIFFEIDs like the
+// webhook.Validator implementation of the type, with optional extra checks.
+// +kubebuilder:object:generate=false
+type ClusterSPIFFEIDValidator struct {
+	// MinTTL, if non-zero, is the minimum X509-SVID and JWT-SVID TTL. Lower
+	// TTLs are warned about, since the controller raises them to the
+	// minimum, unless RejectBelowMinTTL is set.
+	MinTTL time.Duration
+
+	// RejectBelowMinTTL denies ClusterSPIFFEIDs with TTLs below MinTTL.
+	RejectBelowMinTTL bool
+
+	// MaxJWTTTL, if non-zero, is the maximum JWT-SVID TTL, e.g. the
+	// lifetime of the JWT signing keys of the SPIRE server, past which SPIRE
+	// rejects the entry.
+	MaxJWTTTL time.Duration
+}
+
+var _ webhook.CustomValidator = &ClusterSPIFFEIDValidator{}
+
+func (v *ClusterSPIFFEIDValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&ClusterSPIFFEID{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate implements webhook.CustomValidator
+func (v *ClusterSPIFFEIDValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	r, ok := obj.(*ClusterSPIFFEID)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterSPIFFEID but got %T", obj)
+	}
+	clusterspiffeidlog.Info("validate create", "name", r.Name)
+	return v.validate(r)
+}
+
+// ValidateUpdate implements webhook.CustomValidator
+func (v *ClusterSPIFFEIDValidator) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	r, ok := newObj.(*ClusterSPIFFEID)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterSPIFFEID but got %T", newObj)
+	}
+	clusterspiffeidlog.Info("validate update", "name", r.Name)
+	return v.validate(r)
+}
+
+// ValidateDelete implements webhook.CustomValidator
+func (v *ClusterSPIFFEIDValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	// Deletes are not validated.
+	return nil, nil
+}
+
+func (v *ClusterSPIFFEIDValidator) validate(r *ClusterSPIFFEID) (admission.Warnings, error) {
+	spec, err := ParseClusterSPIFFEIDSpec(&r.Spec)
+	if err != nil {
+		return nil, err
+	}
+	if v.MaxJWTTTL > 0 && spec.JWTTTL > v.MaxJWTTTL {
+		return nil, fmt.Errorf("invalid jwtTtl value: %s exceeds the maximum of %s", spec.JWTTTL, v.MaxJWTTTL)
+	}
+	if v.MinTTL <= 0 {
+		return nil, nil
+	}
+	var warnings admission.Warnings
+	for _, ttl := range []struct {
+		field string
+		value time.Duration
+	}{
+		{field: "ttl", value: spec.TTL},
+		{field: "jwtTtl", value: spec.JWTTTL},
+	} {
+		// Zero means the default TTL of the SPIRE server.
+		if ttl.value == 0 || ttl.value >= v.MinTTL {
+			continue
+		}
+		if v.RejectBelowMinTTL {
+			return nil, fmt.Errorf("invalid %s value: %s is below the minimum of %s", ttl.field, ttl.value, v.MinTTL)
+		}
+		warnings = append(warnings, fmt.Sprintf("%s %s is below the minimum of %s and will be raised to it", ttl.field, ttl.value, v.MinTTL))
+	}
+	return warnings, nil
+}
+
 // +kubebuilder:object:generate=false
 // ParsedClusterSPIFFEIDSpec is a parsed and validated ClusterSPIFFEIDSpec
 type ParsedClusterSPIFFEIDSpec struct {
 	SPIFFEIDTemplate          *template.Template
+	AliasSPIFFEIDTemplates    []*template.Template
 	NamespaceSelector         labels.Selector
 	PodSelector               labels.Selector
+	NamespaceFieldSelector    fields.Selector
+	PodFieldSelector          fields.Selector
 	TTL                       time.Duration
 	JWTTTL                    time.Duration
 	FederatesWith             []spiffeid.TrustDomain
+	FederatesWithTemplates    []*template.Template
 	DNSNameTemplates          []*template.Template
 	WorkloadSelectorTemplates []*template.Template
 	Admin                     bool
 	Downstream                bool
 	AutoPopulateDNSNames      bool
+	AutoPopulatePodIP         bool
+	PrimaryContainerName      string
 	Hint                      string
+	HintTemplate              *template.Template
+	Placeholder               *ParsedClusterSPIFFEIDPlaceholder
+}
+
+// +kubebuilder:object:generate=false
+// ParsedClusterSPIFFEIDPlaceholder is a parsed and validated ClusterSPIFFEIDPlaceholder
+type ParsedClusterSPIFFEIDPlaceholder struct {
+	ParentID  spiffeid.ID
+	Selectors []string
 }
 
 // ParseClusterSPIFFEIDSpec parses and validates the fields in the ClusterSPIFFEIDSpec
@@ -107,6 +213,22 @@ func ParseClusterSPIFFEIDSpec(spec *ClusterSPIFFEIDSpec) (*ParsedClusterSPIFFEID
 		return nil, fmt.Errorf("invalid SPIFFEID template: %w", err)
 	}
 
+	if spec.TTL.Duration < 0 {
+		return nil, errors.New("invalid ttl value: can not be negative")
+	}
+	if spec.JWTTTL.Duration < 0 {
+		return nil, errors.New("invalid jwtTtl value: can not be negative")
+	}
+
+	var aliasSPIFFEIDTemplates []*template.Template
+	for _, value := range spec.AliasSPIFFEIDTemplates {
+		aliasSPIFFEIDTemplate, err := template.New(aliasSPIFFEIDTemplateName).Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid aliasSPIFFEIDTemplates value: %w", err)
+		}
+		aliasSPIFFEIDTemplates = append(aliasSPIFFEIDTemplates, aliasSPIFFEIDTemplate)
+	}
+
 	var namespaceSelector labels.Selector
 	if spec.NamespaceSelector != nil {
 		namespaceSelector, err = metav1.LabelSelectorAsSelector(spec.NamespaceSelector)
@@ -123,6 +245,22 @@ func ParseClusterSPIFFEIDSpec(spec *ClusterSPIFFEIDSpec) (*ParsedClusterSPIFFEID
 		}
 	}
 
+	var namespaceFieldSelector fields.Selector
+	if spec.NamespaceFieldSelector != "" {
+		namespaceFieldSelector, err = parseFieldSelector(spec.NamespaceFieldSelector, NamespaceFields(&corev1.Namespace{}))
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespaceFieldSelector value: %w", err)
+		}
+	}
+
+	var podFieldSelector fields.Selector
+	if spec.PodFieldSelector != "" {
+		podFieldSelector, err = parseFieldSelector(spec.PodFieldSelector, PodFields(&corev1.Pod{}))
+		if err != nil {
+			return nil, fmt.Errorf("invalid podFieldSelector value: %w", err)
+		}
+	}
+
 	federatesWith := make([]spiffeid.TrustDomain, 0, len(spec.FederatesWith))
 	for _, value := range spec.FederatesWith {
 		td, err := spiffeid.TrustDomainFromString(value)
@@ -132,6 +270,15 @@ func ParseClusterSPIFFEIDSpec(spec *ClusterSPIFFEIDSpec) (*ParsedClusterSPIFFEID
 		federatesWith = append(federatesWith, td)
 	}
 
+	var federatesWithTemplates []*template.Template
+	for _, value := range spec.FederatesWithTemplates {
+		federatesWithTemplate, err := template.New(federatesWithTemplateName).Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid federatesWithTemplates value: %w", err)
+		}
+		federatesWithTemplates = append(federatesWithTemplates, federatesWithTemplate)
+	}
+
 	var dnsNameTemplates []*template.Template
 	for _, value := range spec.DNSNameTemplates {
 		dnsNameTemplate, err := template.New(dnsNameTemplateName).Parse(value)
@@ -150,18 +297,93 @@ func ParseClusterSPIFFEIDSpec(spec *ClusterSPIFFEIDSpec) (*ParsedClusterSPIFFEID
 		workloadSelectorTemplates = append(workloadSelectorTemplates, workloadSelectorTemplate)
 	}
 
+	var hintTemplate *template.Template
+	if spec.HintTemplate != "" {
+		if spec.Hint != "" {
+			return nil, errors.New("hint and hintTemplate can not both be set")
+		}
+		hintTemplate, err = template.New(hintTemplateName).Parse(spec.HintTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hintTemplate value: %w", err)
+		}
+	}
+
+	var placeholder *ParsedClusterSPIFFEIDPlaceholder
+	if spec.Placeholder != nil {
+		parentID, err := spiffeid.FromString(spec.Placeholder.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid placeholder parentID value: %w", err)
+		}
+		if len(spec.Placeholder.Selectors) == 0 {
+			return nil, errors.New("placeholder selectors are required")
+		}
+		placeholder = &ParsedClusterSPIFFEIDPlaceholder{
+			ParentID:  parentID,
+			Selectors: spec.Placeholder.Selectors,
+		}
+	}
+
 	return &ParsedClusterSPIFFEIDSpec{
 		SPIFFEIDTemplate:          spiffeIDTemplate,
+		AliasSPIFFEIDTemplates:    aliasSPIFFEIDTemplates,
 		NamespaceSelector:         namespaceSelector,
 		PodSelector:               podSelector,
+		NamespaceFieldSelector:    namespaceFieldSelector,
+		PodFieldSelector:          podFieldSelector,
 		TTL:                       spec.TTL.Duration,
 		JWTTTL:                    spec.JWTTTL.Duration,
 		FederatesWith:             federatesWith,
+		FederatesWithTemplates:    federatesWithTemplates,
 		DNSNameTemplates:          dnsNameTemplates,
 		WorkloadSelectorTemplates: workloadSelectorTemplates,
 		Admin:                     spec.Admin,
 		Downstream:                spec.Downstream,
 		AutoPopulateDNSNames:      spec.AutoPopulateDNSNames,
+		AutoPopulatePodIP:         spec.AutoPopulatePodIP,
+		PrimaryContainerName:      spec.PrimaryContainerName,
 		Hint:                      spec.Hint,
+		HintTemplate:              hintTemplate,
+		Placeholder:               placeholder,
 	}, nil
 }
+
+// parseFieldSelector parses a field selector, only allowing the given fields.
+func parseFieldSelector(selector string, supported fields.Set) (fields.Selector, error) {
+	parsed, err := fields.ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, requirement := range parsed.Requirements() {
+		if !supported.Has(requirement.Field) {
+			return nil, fmt.Errorf("unsupported field %q", requirement.Field)
+		}
+	}
+	return parsed, nil
+}
+
+// NamespaceFields returns the fields of the namespace that can be selected
+// with a NamespaceFieldSelector.
+func NamespaceFields(namespace *corev1.Namespace) fields.Set {
+	return fields.Set{
+		"metadata.name": namespace.Name,
+		"status.phase":  string(namespace.Status.Phase),
+	}
+}
+
+// PodFields returns the fields of the pod that can be selected with a
+// PodFieldSelector. They are the fields Kubernetes supports in pod field
+// selectors.
+func PodFields(pod *corev1.Pod) fields.Set {
+	return fields.Set{
+		"metadata.name":            pod.Name,
+		"metadata.namespace":       pod.Namespace,
+		"spec.nodeName":            pod.Spec.NodeName,
+		"spec.restartPolicy":       string(pod.Spec.RestartPolicy),
+		"spec.schedulerName":       pod.Spec.SchedulerName,
+		"spec.serviceAccountName":  pod.Spec.ServiceAccountName,
+		"spec.hostNetwork":         strconv.FormatBool(pod.Spec.HostNetwork),
+		"status.phase":             string(pod.Status.Phase),
+		"status.podIP":             pod.Status.PodIP,
+		"status.nominatedNodeName": pod.Status.NominatedNodeName,
+	}
+}
@@ -0,0 +1,219 @@
+package v1alpha1_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestParseClusterSPIFFEIDSpecFieldSelectors(t *testing.T) {
+	for _, tt := range []struct {
+		name                   string
+		namespaceFieldSelector string
+		podFieldSelector       string
+		expectErr              string
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:                   "supported fields",
+			namespaceFieldSelector: "status.phase=Active",
+			podFieldSelector:       "spec.nodeName=node,status.phase!=Failed",
+		},
+		{
+			name:             "malformed",
+			podFieldSelector: "status.phase",
+			expectErr:        "invalid podFieldSelector value",
+		},
+		{
+			name:             "unsupported pod field",
+			podFieldSelector: "spec.priority=1",
+			expectErr:        `invalid podFieldSelector value: unsupported field "spec.priority"`,
+		},
+		{
+			name:                   "unsupported namespace field",
+			namespaceFieldSelector: "spec.nodeName=node",
+			expectErr:              `invalid namespaceFieldSelector value: unsupported field "spec.nodeName"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate:       "spiffe://example.org/workload",
+				NamespaceFieldSelector: tt.namespaceFieldSelector,
+				PodFieldSelector:       tt.podFieldSelector,
+			})
+			if tt.expectErr != "" {
+				require.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.namespaceFieldSelector == "", spec.NamespaceFieldSelector == nil)
+			require.Equal(t, tt.podFieldSelector == "", spec.PodFieldSelector == nil)
+		})
+	}
+}
+
+func TestParseClusterSPIFFEIDSpecHintTemplate(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		hint         string
+		hintTemplate string
+		expectErr    string
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:         "valid",
+			hintTemplate: "{{ .PodMeta.Name }}",
+		},
+		{
+			name:         "malformed",
+			hintTemplate: "{{ .PodMeta.Name ",
+			expectErr:    "invalid hintTemplate value",
+		},
+		{
+			name:         "set along with hint",
+			hint:         "hint",
+			hintTemplate: "{{ .PodMeta.Name }}",
+			expectErr:    "hint and hintTemplate can not both be set",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://example.org/workload",
+				Hint:             tt.hint,
+				HintTemplate:     tt.hintTemplate,
+			})
+			if tt.expectErr != "" {
+				require.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.hintTemplate == "", spec.HintTemplate == nil)
+		})
+	}
+}
+
+func TestPodFields(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod"},
+		Spec:       corev1.PodSpec{NodeName: "node", HostNetwork: true},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	podFields := spirev1alpha1.PodFields(pod)
+	require.Equal(t, "pod", podFields.Get("metadata.name"))
+	require.Equal(t, "default", podFields.Get("metadata.namespace"))
+	require.Equal(t, "node", podFields.Get("spec.nodeName"))
+	require.Equal(t, "true", podFields.Get("spec.hostNetwork"))
+	require.Equal(t, "Running", podFields.Get("status.phase"))
+}
+
+func TestClusterSPIFFEIDValidatorMinTTL(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		ttl            time.Duration
+		jwtTTL         time.Duration
+		reject         bool
+		expectWarnings admission.Warnings
+		expectErr      string
+	}{
+		{
+			name: "default TTLs",
+		},
+		{
+			name:   "above the minimum",
+			ttl:    time.Hour,
+			jwtTTL: 5 * time.Minute,
+		},
+		{
+			name:           "below the minimum",
+			ttl:            time.Minute,
+			jwtTTL:         time.Hour,
+			expectWarnings: admission.Warnings{"ttl 1m0s is below the minimum of 5m0s and will be raised to it"},
+		},
+		{
+			name:      "below the minimum rejected",
+			ttl:       time.Hour,
+			jwtTTL:    time.Minute,
+			reject:    true,
+			expectErr: "invalid jwtTtl value: 1m0s is below the minimum of 5m0s",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &spirev1alpha1.ClusterSPIFFEIDValidator{
+				MinTTL:            5 * time.Minute,
+				RejectBelowMinTTL: tt.reject,
+			}
+			warnings, err := validator.ValidateCreate(context.Background(), &spirev1alpha1.ClusterSPIFFEID{
+				Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+					SPIFFEIDTemplate: "spiffe://example.org/workload",
+					TTL:              metav1.Duration{Duration: tt.ttl},
+					JWTTTL:           metav1.Duration{Duration: tt.jwtTTL},
+				},
+			})
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectWarnings, warnings)
+		})
+	}
+}
+
+func TestClusterSPIFFEIDValidatorTTLBounds(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		ttl       time.Duration
+		jwtTTL    time.Duration
+		expectErr string
+	}{
+		{
+			name: "default TTLs",
+		},
+		{
+			name:   "within bounds",
+			ttl:    24 * time.Hour,
+			jwtTTL: time.Hour,
+		},
+		{
+			name:      "negative TTL",
+			ttl:       -time.Minute,
+			expectErr: "invalid ttl value: can not be negative",
+		},
+		{
+			name:      "negative JWT TTL",
+			jwtTTL:    -time.Minute,
+			expectErr: "invalid jwtTtl value: can not be negative",
+		},
+		{
+			name:      "JWT TTL exceeds the maximum",
+			jwtTTL:    2 * time.Hour,
+			expectErr: "invalid jwtTtl value: 2h0m0s exceeds the maximum of 1h0m0s",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &spirev1alpha1.ClusterSPIFFEIDValidator{MaxJWTTTL: time.Hour}
+			_, err := validator.ValidateCreate(context.Background(), &spirev1alpha1.ClusterSPIFFEID{
+				Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+					SPIFFEIDTemplate: "spiffe://example.org/workload",
+					TTL:              metav1.Duration{Duration: tt.ttl},
+					JWTTTL:           metav1.Duration{Duration: tt.jwtTTL},
+				},
+			})
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -51,6 +51,10 @@ type ClusterStaticEntryStatus struct {
 
 	// If the static entry was successfully created/updated.
 	Set bool `json:"set"`
+
+	// The ID of the SPIRE entry the static entry was created as or matched
+	// to, if any.
+	EntryID string `json:"entryID,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -257,3 +257,49 @@ func TestLoadOptionsWithCacheNamespaces(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadOptionsWithDefaultSVIDTTLs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(spirev1alpha1.AddToScheme(scheme))
+
+	for _, tt := range []struct {
+		name                     string
+		defaultSVIDTTLs          string
+		expectDefaultX509SVIDTTL *metav1.Duration
+		expectDefaultJWTSVIDTTL  *metav1.Duration
+	}{
+		{
+			name: "not set",
+		},
+		{
+			name: "both set",
+			defaultSVIDTTLs: `
+defaultX509SVIDTTL: 2h
+defaultJWTSVIDTTL: 5m
+`,
+			expectDefaultX509SVIDTTL: &metav1.Duration{Duration: 2 * time.Hour},
+			expectDefaultJWTSVIDTTL:  &metav1.Duration{Duration: 5 * time.Minute},
+		},
+		{
+			name: "only X509-SVID TTL set",
+			defaultSVIDTTLs: `
+defaultX509SVIDTTL: 90m
+`,
+			expectDefaultX509SVIDTTL: &metav1.Duration{Duration: 90 * time.Minute},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			path := filepath.Join(tempDir, "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(fileContent+tt.defaultSVIDTTLs), 0600))
+
+			options := ctrl.Options{Scheme: scheme}
+			ctrlConfig := spirev1alpha1.ControllerManagerConfig{}
+
+			require.NoError(t, spirev1alpha1.LoadOptionsFromFile(path, scheme, &options, &ctrlConfig, false))
+			require.Equal(t, tt.expectDefaultX509SVIDTTL, ctrlConfig.DefaultX509SVIDTTL)
+			require.Equal(t, tt.expectDefaultJWTSVIDTTL, ctrlConfig.DefaultJWTSVIDTTL)
+		})
+	}
+}
@@ -57,6 +57,11 @@ type ControllerManagerConfig struct {
 	// SPIREServerSocketPath is the path to the SPIRE Server API socket
 	SPIREServerSocketPath string `json:"spireServerSocketPath"`
 
+	// RejectDeprecatedFlags, if true, fails startup when a deprecated
+	// command-line flag (e.g. spire-api-socket) is used instead of only
+	// warning about it.
+	RejectDeprecatedFlags bool `json:"rejectDeprecatedFlags"`
+
 	// LogLevel is the log level for the controller manager
 	LogLevel string `json:"logLevel"`
 }
@@ -129,6 +134,24 @@ type ControllerManagerConfigurationSpec struct {
 	// +optional
 	ParentIDTemplate string `json:"parentIDTemplate,omitempty"`
 
+	// If specified, picks the parent id template by the labels of the node
+	// a pod runs on, e.g. for node pools attested differently. The first
+	// rule whose node selector matches is used. Pods on nodes matching no
+	// rule use ParentIDTemplate.
+	// +optional
+	ParentIDTemplateRules []ParentIDTemplateRule `json:"parentIDTemplateRules,omitempty"`
+
+	// If specified, this prefix is prepended to the path of every SPIFFE ID
+	// rendered by the controller (e.g. "/{{ .ClusterName }}"). It is a
+	// template with access to the ClusterName and TrustDomain.
+	// +optional
+	SPIFFEIDPathPrefix string `json:"spiffeIDPathPrefix,omitempty"`
+
+	// If specified, pods matching this selector are excluded from all
+	// ClusterSPIFFEIDs.
+	// +optional
+	GlobalPodExclusionSelector *metav1.LabelSelector `json:"globalPodExclusionSelector,omitempty"`
+
 	// If specified, only syncs the specified CR types. Defaults to all.
 	// +optional
 	Reconcile *ReconcileConfig `json:"reconcile,omitempty"`
@@ -142,6 +165,502 @@ type ControllerManagerConfigurationSpec struct {
 	// Generally useful when switching from nonprefixed to prefixed, or between two different prefixes.
 	// +optiional
 	EntryIDPrefixCleanup *string `json:"entryIDPrefixCleanup,omitempty"`
+
+	// Confirms that EntryIDPrefixCleanup is meant to be "", which deletes
+	// all unprefixed entries, including those owned by other controllers or
+	// registered manually. The controller refuses to start otherwise.
+	// +optional
+	ConfirmBroadCleanup bool `json:"confirmBroadCleanup,omitempty"`
+
+	// If set along with ClassName, entry ids are additionally prefixed with
+	// `<className>.` so that controllers of different classes sharing a SPIRE
+	// server never delete each other's entries.
+	// +optional
+	ClassScopedEntryIDs bool `json:"classScopedEntryIDs,omitempty"`
+
+	// If specified, the first BootstrapPasses entry reconciliation passes only
+	// create declared entries. Listing, updating and deleting SPIRE entries is
+	// skipped during these passes. Useful when first bootstrapping against an
+	// empty SPIRE server.
+	// +optional
+	BootstrapPasses int `json:"bootstrapPasses,omitempty"`
+
+	// If set, duplicate SPIRE entries (i.e. those sharing the same SPIFFE ID,
+	// parent ID and selectors as a declared entry) are left in place instead
+	// of being deleted. Duplicates are logged and counted either way.
+	// +optional
+	PreserveDuplicateEntries bool `json:"preserveDuplicateEntries,omitempty"`
+
+	// If specified, this selector (in the form of "type:value") is appended
+	// to every admin entry, restricting which workloads can receive them.
+	// +optional
+	AdminSelector string `json:"adminSelector,omitempty"`
+
+	// If specified, entries are validated against an external policy
+	// service before being created or updated.
+	// +optional
+	EntryPolicy *EntryPolicyConfig `json:"entryPolicy,omitempty"`
+
+	// If specified, only federation relationships for these trust domains
+	// are managed. Relationships for other trust domains are left alone so
+	// that they can be managed externally.
+	// +optional
+	ManagedTrustDomains []string `json:"managedTrustDomains,omitempty"`
+
+	// If set, downstream entries are created ahead of all other entries so
+	// that downstream SPIRE servers can attest the workloads that depend on
+	// them.
+	// +optional
+	CreateDownstreamEntriesFirst bool `json:"createDownstreamEntriesFirst,omitempty"`
+
+	// If set, the entries of pods with a higher priority (as resolved from
+	// their priorityClassName) are created ahead of those of lower priority
+	// pods, so that important workloads get their identities sooner when
+	// there is a backlog of entries to create.
+	// +optional
+	CreateEntriesByPodPriority bool `json:"createEntriesByPodPriority,omitempty"`
+
+	// If specified, entries whose X509-SVID and JWT-SVID TTLs differ from
+	// the declared ones by no more than TTLTolerance are not updated.
+	// Defaults to 0 (i.e. TTLs must match exactly).
+	// +optional
+	TTLTolerance *metav1.Duration `json:"ttlTolerance,omitempty"`
+
+	// If specified, pods can pick the TTLs of their SVIDs from a set of
+	// approved tiers using an annotation.
+	// +optional
+	TTLTiers *TTLTiersConfig `json:"ttlTiers,omitempty"`
+
+	// If specified, pods can federate with additional trust domains using
+	// a label.
+	// +optional
+	FederationByLabel *FederationByLabelConfig `json:"federationByLabel,omitempty"`
+
+	// If specified, limits how many endpoints contribute DNS names to an
+	// entry when AutoPopulateDNSNames is set. Endpoints are selected by
+	// namespace and name. Defaults to 0 (i.e. no limit).
+	// +optional
+	MaxDNSNameEndpoints int `json:"maxDNSNameEndpoints,omitempty"`
+
+	// If specified, tunes how the SPIRE server is probed for the entry
+	// fields it supports.
+	// +optional
+	UnsupportedFieldsProbe *UnsupportedFieldsProbeConfig `json:"unsupportedFieldsProbe,omitempty"`
+
+	// If set, X509-SVID TTLs that exceed the remaining lifetime of the SPIRE
+	// CA are lowered so that SVIDs do not outlive the CA.
+	// +optional
+	ClampX509SVIDTTLToCA bool `json:"clampX509SVIDTTLToCA,omitempty"`
+
+	// If specified, entries that are no longer declared (e.g. because the
+	// ClusterSPIFFEID declaring them is briefly missing from the cache) are
+	// only deleted once they have not been declared for this long.
+	// Defaults to 0 (i.e. entries are deleted right away).
+	// +optional
+	DeclaredEntryGracePeriod *metav1.Duration `json:"declaredEntryGracePeriod,omitempty"`
+
+	// If specified, entries about to be deleted are first marked and only
+	// deleted once they have been marked for this long, giving a window to
+	// notice entries orphaned by mistake. Defaults to 0 (i.e. entries are
+	// deleted right away).
+	// +optional
+	DeleteConfirmationDelay *metav1.Duration `json:"deleteConfirmationDelay,omitempty"`
+
+	// If set, the metrics server serves a JSON trace of the reconcile of a
+	// single ClusterSPIFFEID at /debug/trace/clusterspiffeid?name=<name>.
+	// The trace does not modify SPIRE state.
+	// +optional
+	EnableTraceEndpoint bool `json:"enableTraceEndpoint,omitempty"`
+
+	// If set, the pods of entries about to be created are checked to still
+	// exist, so that no entries are created for pods deleted during a
+	// reconcile.
+	// +optional
+	RevalidatePodsBeforeCreate bool `json:"revalidatePodsBeforeCreate,omitempty"`
+
+	// If set, the entries about to be created or updated are validated and
+	// invalid entries are dropped instead of being sent to the SPIRE server.
+	// +optional
+	ValidateEntriesBeforeSend bool `json:"validateEntriesBeforeSend,omitempty"`
+
+	// If set, the ClusterSPIFFEIDs are checked at startup and a warning is
+	// logged for those whose SPIFFE ID template targets a trust domain other
+	// than the configured one.
+	// +optional
+	ValidateTrustDomainsAtStartup bool `json:"validateTrustDomainsAtStartup,omitempty"`
+
+	// How many consecutive SPIRE Server API calls have to fail as
+	// unavailable before the socket is re-dialed. The failing call is
+	// retried once if it only reads; calls that write, e.g. entry
+	// creations the server may have applied, are not retried. Zero, the
+	// default, disables re-dialing.
+	// +optional
+	SPIREServerRedialAfterFailures *int `json:"spireServerRedialAfterFailures,omitempty"`
+
+	// If specified, how long each page of a list of SPIRE entries may take.
+	// If a page exceeds it, the reconcile is aborted instead of waiting on a
+	// slow SPIRE Server. Defaults to no limit.
+	// +optional
+	SPIREServerListPageTimeout *metav1.Duration `json:"spireServerListPageTimeout,omitempty"`
+
+	// If specified, the most SPIRE entries a list may return. If a list
+	// returns more, e.g. from a SPIRE Server that never stops paginating, it
+	// is aborted instead of exhausting the memory of the controller.
+	// Defaults to no limit.
+	// +optional
+	SPIREServerMaxListEntries int `json:"spireServerMaxListEntries,omitempty"`
+
+	// If set, the controller remembers the entries it last wrote, and
+	// entries whose fields no longer match are reported as modified outside
+	// of the controller. The revisions are kept in memory only, since the
+	// only entry field they could be stored in is the hint, which is served
+	// to workloads and set by ClusterSPIFFEIDs. Entries modified while the
+	// controller is not running, or before a restart or leader election
+	// change, are therefore not reported, only restored.
+	// +optional
+	EntryRevisions bool `json:"entryRevisions,omitempty"`
+
+	// If specified, the X509-SVID TTL of entries that do not set one,
+	// instead of the default of the SPIRE server.
+	// +optional
+	DefaultX509SVIDTTL *metav1.Duration `json:"defaultX509SVIDTTL,omitempty"`
+
+	// If specified, the JWT-SVID TTL of entries that do not set one,
+	// instead of the default of the SPIRE server.
+	// +optional
+	DefaultJWTSVIDTTL *metav1.Duration `json:"defaultJWTSVIDTTL,omitempty"`
+
+	// If specified, the webhook denies ClusterSPIFFEIDs with a JWT-SVID TTL
+	// exceeding it, e.g. the lifetime of the JWT signing keys of the SPIRE
+	// server, which SPIRE would reject at reconcile time.
+	// +optional
+	MaxJWTSVIDTTL *metav1.Duration `json:"maxJWTSVIDTTL,omitempty"`
+
+	// If specified, entries are only rendered for pods started at least
+	// this long ago, so that short-lived pods are not issued SVIDs. The age
+	// is that of the pod, so container restarts do not reset it.
+	// +optional
+	MinPodAgeForEntry *metav1.Duration `json:"minPodAgeForEntry,omitempty"`
+
+	// If set, no entries are rendered for pods that are being deleted or
+	// that have terminated (i.e. in the Succeeded or Failed phase).
+	// +optional
+	SkipTerminatingPods bool `json:"skipTerminatingPods,omitempty"`
+
+	// If specified, how many of the namespaces selected by a
+	// ClusterSPIFFEID have their pods listed and entries rendered
+	// concurrently. Defaults to 1 (i.e. namespaces are processed serially).
+	// +optional
+	NamespaceConcurrency int `json:"namespaceConcurrency,omitempty"`
+
+	// If specified, how long creating the entries of a parent is backed off
+	// for once the SPIRE server reports the parent has reached its entry
+	// limit. The backoff doubles while the limit keeps being hit. Defaults
+	// to 30s.
+	// +optional
+	ParentEntryLimitBackoff *metav1.Duration `json:"parentEntryLimitBackoff,omitempty"`
+
+	// If set, a DNS name declared on the entries of more than one SPIFFE ID
+	// (e.g. a service DNS name auto-populated for pods of two
+	// ClusterSPIFFEIDs) is only kept on the entry of the oldest object.
+	// Such DNS names are reported either way.
+	// +optional
+	DeduplicateDNSNames bool `json:"deduplicateDNSNames,omitempty"`
+
+	// If non-zero, how many consecutive passes every entry of a
+	// ClusterSPIFFEID or SPIFFEID has to fail to render before the object
+	// is only re-attempted with a backoff, starting at 30s and doubling up
+	// to 10m. The backoff is reset once an entry renders or the object
+	// changes. Defaults to 0 (disabled).
+	// +optional
+	RenderFailureBackoffAfter int `json:"renderFailureBackoffAfter,omitempty"`
+
+	// If non-zero, how many consecutive times creating or updating an entry
+	// has to fail before it is only re-attempted with a backoff, starting at
+	// 30s and doubling up to 10m, so that entries the SPIRE server keeps
+	// rejecting do not weigh on every pass. The backoff is reset once the
+	// entry is written or no longer declared. Defaults to 0 (disabled).
+	// +optional
+	EntryFailureBackoffAfter int `json:"entryFailureBackoffAfter,omitempty"`
+
+	// If set, the services referenced by the validating webhook
+	// configuration are checked to exist whenever the webhook certificate is
+	// minted. Missing services are logged and counted in the
+	// spire_controller_manager_webhook_services_missing_total metric.
+	// +optional
+	ValidateWebhookServices bool `json:"validateWebhookServices,omitempty"`
+
+	// If specified, how long a single reconcile of entries or federation
+	// relationships may run before it is aborted, so that the next one
+	// starts fresh. Defaults to no limit.
+	// +optional
+	MaxReconcileDuration *metav1.Duration `json:"maxReconcileDuration,omitempty"`
+
+	// If specified, how long to wait after startup before the first
+	// reconcile of entries or federation relationships. Defaults to
+	// reconciling immediately.
+	// +optional
+	InitialReconcileDelay *metav1.Duration `json:"initialReconcileDelay,omitempty"`
+
+	// If specified, how long to wait after a ClusterFederatedTrustDomain
+	// changes before reconciling federation relationships, so that a burst of
+	// changes results in a single reconcile. Defaults to reconciling
+	// immediately.
+	// +optional
+	FederationReconcileCoalesceDelay *metav1.Duration `json:"federationReconcileCoalesceDelay,omitempty"`
+
+	// If specified, the ClusterFederatedTrustDomain webhook checks that the
+	// bundle endpoint is reachable within this timeout and warns if it is
+	// not. Admission is never denied because of it. Disabled by default.
+	// +optional
+	BundleEndpointReachabilityTimeout *metav1.Duration `json:"bundleEndpointReachabilityTimeout,omitempty"`
+
+	// If set, when entries declared by different objects for the same pod
+	// have the same SPIFFE ID, parent ID and selectors, the federated trust
+	// domains and DNS names of the masked entries are merged into the
+	// surviving one instead of being dropped.
+	// +optional
+	MergeMaskedEntries bool `json:"mergeMaskedEntries,omitempty"`
+
+	// EntryIDPrefixOverrides, if specified, lets ClusterSPIFFEIDs and
+	// ClusterStaticEntries override EntryIDPrefix for their entries.
+	// +optional
+	EntryIDPrefixOverrides *EntryIDPrefixOverridesConfig `json:"entryIDPrefixOverrides,omitempty"`
+
+	// ControllerSVID, if specified, has the controller maintain an X509-SVID
+	// of its own, minted by the SPIRE server, to authenticate to other
+	// services with (see EntryPolicy.PresentControllerSVID).
+	// +optional
+	ControllerSVID *ControllerSVIDConfig `json:"controllerSVID,omitempty"`
+
+	// ClockSkewCheck, if specified, has the controller periodically compare
+	// its clock with the clock of the SPIRE server, warning when they drift
+	// apart.
+	// +optional
+	ClockSkewCheck *ClockSkewCheckConfig `json:"clockSkewCheck,omitempty"`
+
+	// MinSVIDTTL, if specified, sets a floor on the SVID TTLs of entries so
+	// that agents have time to renew SVIDs before they expire.
+	// +optional
+	MinSVIDTTL *MinSVIDTTLConfig `json:"minSVIDTTL,omitempty"`
+
+	// MaxSelectorsPerEntry, if specified, guards against pod entries with
+	// more selectors than the SPIRE server accepts.
+	// +optional
+	MaxSelectorsPerEntry *MaxSelectorsPerEntryConfig `json:"maxSelectorsPerEntry,omitempty"`
+
+	// ParentIDScope, if specified, is a regular expression restricting the
+	// entries managed by the controller to those with a matching parent ID,
+	// e.g. the agents attested by one of several SPIRE servers sharing a
+	// datastore. Other entries are never updated or deleted.
+	// +optional
+	ParentIDScope string `json:"parentIDScope,omitempty"`
+
+	// If set, a line of JSON summarizing each entry reconcile pass is
+	// written to stdout, independently of the logger, so that CI systems can
+	// parse the results.
+	// +optional
+	ReconcileSummary bool `json:"reconcileSummary,omitempty"`
+
+	// If specified, entries are reconciled again this long after a
+	// namespace is created, giving the pod cache time to catch up with pods
+	// created along with the namespace. Defaults to only reconciling on pod
+	// events.
+	// +optional
+	NewNamespaceGracePeriod *metav1.Duration `json:"newNamespaceGracePeriod,omitempty"`
+
+	// If set, the entries and federation relationships the controller would
+	// create, update or delete are logged instead of being written to the
+	// SPIRE server. The unsupported fields probe is skipped. Statuses are
+	// still updated so that their stats reflect what would be set.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// DryRunEvent, if specified, records an event summarizing how many
+	// entries each dry-run pass would create, update and delete on the given
+	// object, so that dry runs can be watched with kubectl get events.
+	// +optional
+	DryRunEvent *DryRunEventConfig `json:"dryRunEvent,omitempty"`
+}
+
+// UnsupportedFieldsProbeConfig configures the probe for the entry fields
+// supported by the SPIRE server
+type UnsupportedFieldsProbeConfig struct {
+	// Skip disables the probe, assuming the SPIRE server supports every
+	// field. Only set this for SPIRE servers known to be recent enough.
+	// +optional
+	Skip bool `json:"skip,omitempty"`
+
+	// Interval is how long the probe result is cached for. Defaults to 10m.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Retries is how many times a probe failing with a transient error is
+	// retried before giving up until the next pass. Defaults to 2.
+	// +optional
+	Retries *int `json:"retries,omitempty"`
+
+	// Supported lists entry fields to treat as supported regardless of the
+	// probe result, for SPIRE servers the probe is unreliable against. Only
+	// the fields the probe detects are accepted: "hint", "jwtSVIDTTL" and
+	// "storeSVID".
+	// +optional
+	Supported []string `json:"supported,omitempty"`
+
+	// Unsupported lists entry fields to treat as unsupported regardless of
+	// the probe result.
+	// +optional
+	Unsupported []string `json:"unsupported,omitempty"`
+}
+
+// ParentIDTemplateRule is the parent id template used for pods on the nodes
+// matching a selector
+type ParentIDTemplateRule struct {
+	// NodeSelector selects the nodes the rule applies to.
+	NodeSelector metav1.LabelSelector `json:"nodeSelector"`
+
+	// ParentIDTemplate is the parent id template for pods on the selected
+	// nodes.
+	ParentIDTemplate string `json:"parentIDTemplate"`
+}
+
+// TTLTiersConfig maps the value of a pod annotation to approved SVID TTLs
+type TTLTiersConfig struct {
+	// Annotation is the pod annotation holding the name of the tier.
+	Annotation string `json:"annotation"`
+
+	// Tiers maps tier names to TTLs.
+	Tiers map[string]TTLTier `json:"tiers"`
+
+	// MaxTTL, if specified, is the maximum TTL a tier can specify.
+	// +optional
+	MaxTTL *metav1.Duration `json:"maxTTL,omitempty"`
+}
+
+// TTLTier are the SVID TTLs of a tier
+type TTLTier struct {
+	// TTL is the X509-SVID TTL. If unset, the TTL of the ClusterSPIFFEID is
+	// used.
+	// +optional
+	TTL metav1.Duration `json:"ttl,omitempty"`
+
+	// JWTTTL is the JWT-SVID TTL. If unset, the JWT TTL of the
+	// ClusterSPIFFEID is used.
+	// +optional
+	JWTTTL metav1.Duration `json:"jwtTtl,omitempty"`
+}
+
+// FederationByLabelConfig maps the value of a pod label to trust domains
+type FederationByLabelConfig struct {
+	// Label is the pod label holding the name of the federation group.
+	Label string `json:"label"`
+
+	// Groups maps group names to the trust domains the entries of the pods
+	// federate with.
+	Groups map[string][]string `json:"groups"`
+
+	// WarnOnUnknownGroup, if true, logs a warning when a pod names a group
+	// that is not configured. Unknown groups are ignored either way.
+	// +optional
+	WarnOnUnknownGroup bool `json:"warnOnUnknownGroup,omitempty"`
+}
+
+// EntryIDPrefixOverridesConfig maps the value of an annotation to an approved
+// entry id prefix
+type EntryIDPrefixOverridesConfig struct {
+	// Annotation is the ClusterSPIFFEID and ClusterStaticEntry annotation
+	// holding the prefix.
+	Annotation string `json:"annotation"`
+
+	// Prefixes are the approved prefixes. Entries with these prefixes are
+	// managed in addition to those prefixed with EntryIDPrefix.
+	Prefixes []string `json:"prefixes"`
+}
+
+// EntryPolicyConfig configures the external entry policy service
+type EntryPolicyConfig struct {
+	// URL is the policy service endpoint proposed entries are POSTed to.
+	URL string `json:"url"`
+
+	// Timeout for each call to the policy service. Defaults to 5s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// FailOpen allows entries to be created or updated when the policy
+	// service cannot be reached. Otherwise, they are rejected.
+	// +optional
+	FailOpen bool `json:"failOpen,omitempty"`
+
+	// PresentControllerSVID presents the controller X509-SVID as a client
+	// certificate to the policy service. Requires ControllerSVID.
+	// +optional
+	PresentControllerSVID bool `json:"presentControllerSVID,omitempty"`
+}
+
+// ControllerSVIDConfig configures the X509-SVID of the controller itself
+type ControllerSVIDConfig struct {
+	// Path is the path of the SPIFFE ID of the X509-SVID, in the trust
+	// domain of the controller. Defaults to /spire-controller-manager.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// TTL is the requested TTL of the X509-SVID. It is rotated at half its
+	// lifetime. Defaults to 1h.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+}
+
+// ClockSkewCheckConfig configures the clock skew check
+type ClockSkewCheckConfig struct {
+	// Threshold is how far the clocks may drift apart before a warning is
+	// logged. Defaults to 30s.
+	// +optional
+	Threshold *metav1.Duration `json:"threshold,omitempty"`
+
+	// Interval is how often the clocks are compared. Defaults to 10m.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// DryRunEventConfig configures the event summarizing dry-run passes
+type DryRunEventConfig struct {
+	// APIVersion, Kind, Namespace and Name identify the object the event is
+	// recorded on, e.g. the Deployment of the controller.
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+
+	// Interval is the minimum time between two events. Defaults to 5m.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// MinSVIDTTLConfig configures the floor on SVID TTLs
+type MinSVIDTTLConfig struct {
+	// TTL is the minimum X509-SVID and JWT-SVID TTL. Entries using the
+	// default TTLs of the SPIRE server are not affected.
+	TTL metav1.Duration `json:"ttl"`
+
+	// Action is what to do with TTLs below the minimum: "bump" raises them
+	// to the minimum, "reject" fails to render the entry and denies
+	// ClusterSPIFFEIDs declaring them. Defaults to "bump".
+	// +optional
+	Action string `json:"action,omitempty"`
+}
+
+// MaxSelectorsPerEntryConfig configures the cap on the selectors of pod entries
+type MaxSelectorsPerEntryConfig struct {
+	// Max is the maximum number of selectors of a pod entry, including the
+	// pod-uid selector.
+	Max int `json:"max"`
+
+	// Action is what to do with entries exceeding the maximum: "reject"
+	// fails to render the entry, "truncate" drops the selectors past the
+	// maximum in the order they were rendered in. Defaults to "reject".
+	// +optional
+	Action string `json:"action,omitempty"`
 }
 
 // ReconcileConfig configuration used to enable/disable syncing various types
@@ -157,6 +676,10 @@ type ReconcileConfig struct {
 	// ClusterStaticEntries enable syncing of clusterstaticentries
 	// +optional
 	ClusterStaticEntries bool `json:"clusterStaticEntries,omitempty"`
+
+	// SPIFFEIDs enable syncing of the namespaced spiffeids
+	// +optional
+	SPIFFEIDs bool `json:"spiffeIDs,omitempty"`
 }
 
 // NamespaceConfig configuration used to filter cached namespaces
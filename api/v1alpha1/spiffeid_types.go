@@ -0,0 +1,111 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SPIFFEIDSpec defines the desired state of SPIFFEID. Since SPIFFEIDs can be
+// managed by namespace owners, it has no arbitrary DNS names or federated
+// trust domains; those are left to ClusterSPIFFEIDs.
+type SPIFFEIDSpec struct {
+	// SPIFFEID is the SPIFFE ID template. The node and pod spec are made
+	// available to the template under .NodeSpec, .PodSpec respectively.
+	// The rendered SPIFFE ID must be in the namespace of the SPIFFEID,
+	// i.e. its path must start with /ns/<namespace>/.
+	SPIFFEIDTemplate string `json:"spiffeIDTemplate"`
+
+	// TTL indicates an upper-bound time-to-live for X509 SVIDs minted for this
+	// SPIFFEID. If unset, a default will be chosen.
+	TTL metav1.Duration `json:"ttl,omitempty"`
+
+	// JWTTTL indicates an upper-bound time-to-live for JWT SVIDs minted for this
+	// SPIFFEID.
+	JWTTTL metav1.Duration `json:"jwtTtl,omitempty"`
+
+	// WorkloadSelectorTemplates are templates to produce arbitrary workload
+	// selectors that apply to a given workload before it will receive this
+	// SPIFFE ID. The rendered value is interpreted by SPIRE and are of the
+	// form type:value, where the value may, and often does, contain
+	// semicolons, .e.g., k8s:container-image:docker/hello-world
+	// The node and pod spec are made available to the template under
+	// .NodeSpec, .PodSpec respectively.
+	WorkloadSelectorTemplates []string `json:"workloadSelectorTemplates,omitempty"`
+
+	// PodSelector selects the pods, in the namespace of the SPIFFEID, that
+	// are targeted by this CRD.
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+
+	// AutoPopulateDNSNames indicates whether or not to auto populate service
+	// DNS names. Only the services of the namespace can be populated.
+	AutoPopulateDNSNames bool `json:"autoPopulateDNSNames,omitempty"`
+
+	// AutoPopulatePodIP indicates whether or not to add the pod IPs to the
+	// DNS names. Pods without an IP assigned yet are skipped.
+	AutoPopulatePodIP bool `json:"autoPopulatePodIP,omitempty"`
+
+	// PrimaryContainerName, if set, names the container of the pod made
+	// available to templates as .PrimaryContainer, e.g. to select its image
+	// with "k8s:container-image:{{ .PrimaryContainer.Image }}". Pods without
+	// a container of that name are skipped.
+	// +kubebuilder:validation:Optional
+	PrimaryContainerName string `json:"primaryContainerName,omitempty"`
+
+	// Set which Controller Class will act on this object
+	// +kubebuilder:validation:Optional
+	ClassName string `json:"className,omitempty"`
+
+	// Set the entry hint
+	// +kubebuilder:validation:Optional
+	Hint string `json:"hint,omitempty"`
+}
+
+// SPIFFEIDStatus defines the observed state of SPIFFEID
+type SPIFFEIDStatus struct {
+	// Stats produced by the last entry reconciliation run. The namespace
+	// stats are not reported.
+	// +kubebuilder:validation:Optional
+	Stats ClusterSPIFFEIDStats `json:"stats"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// SPIFFEID is the Schema for the spiffeids API. It is the namespaced
+// counterpart of ClusterSPIFFEID, targeting pods in its own namespace.
+type SPIFFEID struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SPIFFEIDSpec `json:"spec,omitempty"`
+	// +optional
+	Status SPIFFEIDStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SPIFFEIDList contains a list of SPIFFEID
+type SPIFFEIDList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SPIFFEID `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SPIFFEID{}, &SPIFFEIDList{})
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// log is for logging in this package.
+var spiffeidlog = logf.Log.WithName("spiffeid-resource")
+
+func (r *SPIFFEID) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-spire-spiffe-io-v1alpha1-spiffeid,mutating=false,failurePolicy=fail,sideEffects=None,groups=spire.spiffe.io,resources=spiffeids,verbs=create;update,versions=v1alpha1,name=vspiffeid.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &SPIFFEID{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *SPIFFEID) ValidateCreate() (admission.Warnings, error) {
+	spiffeidlog.Info("validate create", "namespace", r.Namespace, "name", r.Name)
+
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *SPIFFEID) ValidateUpdate(runtime.Object) (admission.Warnings, error) {
+	spiffeidlog.Info("validate update", "namespace", r.Namespace, "name", r.Name)
+
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *SPIFFEID) ValidateDelete() (admission.Warnings, error) {
+	// Deletes are not validated.
+	return nil, nil
+}
+
+func (r *SPIFFEID) validate() (admission.Warnings, error) {
+	_, err := ParseSPIFFEIDSpec(&r.Spec)
+	return nil, err
+}
+
+// ParseSPIFFEIDSpec parses and validates the fields in the SPIFFEIDSpec. The
+// parsed spec has no namespace selector; the pods are selected from the
+// namespace of the SPIFFEID.
+func ParseSPIFFEIDSpec(spec *SPIFFEIDSpec) (*ParsedClusterSPIFFEIDSpec, error) {
+	return ParseClusterSPIFFEIDSpec(&ClusterSPIFFEIDSpec{
+		SPIFFEIDTemplate:          spec.SPIFFEIDTemplate,
+		TTL:                       spec.TTL,
+		JWTTTL:                    spec.JWTTTL,
+		WorkloadSelectorTemplates: spec.WorkloadSelectorTemplates,
+		PodSelector:               spec.PodSelector,
+		AutoPopulateDNSNames:      spec.AutoPopulateDNSNames,
+		AutoPopulatePodIP:         spec.AutoPopulatePodIP,
+		PrimaryContainerName:      spec.PrimaryContainerName,
+		ClassName:                 spec.ClassName,
+		Hint:                      spec.Hint,
+	})
+}
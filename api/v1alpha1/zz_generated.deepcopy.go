@@ -42,12 +42,37 @@ func (in *BundleEndpointProfile) DeepCopy() *BundleEndpointProfile {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClockSkewCheckConfig) DeepCopyInto(out *ClockSkewCheckConfig) {
+	*out = *in
+	if in.Threshold != nil {
+		in, out := &in.Threshold, &out.Threshold
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClockSkewCheckConfig.
+func (in *ClockSkewCheckConfig) DeepCopy() *ClockSkewCheckConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ClockSkewCheckConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterFederatedTrustDomain) DeepCopyInto(out *ClusterFederatedTrustDomain) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	out.Status = in.Status
 }
 
@@ -105,6 +130,11 @@ func (in *ClusterFederatedTrustDomainList) DeepCopyObject() runtime.Object {
 func (in *ClusterFederatedTrustDomainSpec) DeepCopyInto(out *ClusterFederatedTrustDomainSpec) {
 	*out = *in
 	out.BundleEndpointProfile = in.BundleEndpointProfile
+	if in.ManageBundle != nil {
+		in, out := &in.ManageBundle, &out.ManageBundle
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFederatedTrustDomainSpec.
@@ -191,6 +221,26 @@ func (in *ClusterSPIFFEIDList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSPIFFEIDPlaceholder) DeepCopyInto(out *ClusterSPIFFEIDPlaceholder) {
+	*out = *in
+	if in.Selectors != nil {
+		in, out := &in.Selectors, &out.Selectors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSPIFFEIDPlaceholder.
+func (in *ClusterSPIFFEIDPlaceholder) DeepCopy() *ClusterSPIFFEIDPlaceholder {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSPIFFEIDPlaceholder)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterSPIFFEIDSpec) DeepCopyInto(out *ClusterSPIFFEIDSpec) {
 	*out = *in
@@ -201,6 +251,11 @@ func (in *ClusterSPIFFEIDSpec) DeepCopyInto(out *ClusterSPIFFEIDSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.AliasSPIFFEIDTemplates != nil {
+		in, out := &in.AliasSPIFFEIDTemplates, &out.AliasSPIFFEIDTemplates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.WorkloadSelectorTemplates != nil {
 		in, out := &in.WorkloadSelectorTemplates, &out.WorkloadSelectorTemplates
 		*out = make([]string, len(*in))
@@ -211,6 +266,11 @@ func (in *ClusterSPIFFEIDSpec) DeepCopyInto(out *ClusterSPIFFEIDSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.FederatesWithTemplates != nil {
+		in, out := &in.FederatesWithTemplates, &out.FederatesWithTemplates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.NamespaceSelector != nil {
 		in, out := &in.NamespaceSelector, &out.NamespaceSelector
 		*out = new(v1.LabelSelector)
@@ -221,6 +281,11 @@ func (in *ClusterSPIFFEIDSpec) DeepCopyInto(out *ClusterSPIFFEIDSpec) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Placeholder != nil {
+		in, out := &in.Placeholder, &out.Placeholder
+		*out = new(ClusterSPIFFEIDPlaceholder)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSPIFFEIDSpec.
@@ -489,6 +554,18 @@ func (in *ControllerManagerConfigurationSpec) DeepCopyInto(out *ControllerManage
 	out.Metrics = in.Metrics
 	out.Health = in.Health
 	in.Webhook.DeepCopyInto(&out.Webhook)
+	if in.ParentIDTemplateRules != nil {
+		in, out := &in.ParentIDTemplateRules, &out.ParentIDTemplateRules
+		*out = make([]ParentIDTemplateRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GlobalPodExclusionSelector != nil {
+		in, out := &in.GlobalPodExclusionSelector, &out.GlobalPodExclusionSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Reconcile != nil {
 		in, out := &in.Reconcile, &out.Reconcile
 		*out = new(ReconcileConfig)
@@ -499,6 +576,136 @@ func (in *ControllerManagerConfigurationSpec) DeepCopyInto(out *ControllerManage
 		*out = new(string)
 		**out = **in
 	}
+	if in.EntryPolicy != nil {
+		in, out := &in.EntryPolicy, &out.EntryPolicy
+		*out = new(EntryPolicyConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ManagedTrustDomains != nil {
+		in, out := &in.ManagedTrustDomains, &out.ManagedTrustDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TTLTolerance != nil {
+		in, out := &in.TTLTolerance, &out.TTLTolerance
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.TTLTiers != nil {
+		in, out := &in.TTLTiers, &out.TTLTiers
+		*out = new(TTLTiersConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FederationByLabel != nil {
+		in, out := &in.FederationByLabel, &out.FederationByLabel
+		*out = new(FederationByLabelConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UnsupportedFieldsProbe != nil {
+		in, out := &in.UnsupportedFieldsProbe, &out.UnsupportedFieldsProbe
+		*out = new(UnsupportedFieldsProbeConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeclaredEntryGracePeriod != nil {
+		in, out := &in.DeclaredEntryGracePeriod, &out.DeclaredEntryGracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.DeleteConfirmationDelay != nil {
+		in, out := &in.DeleteConfirmationDelay, &out.DeleteConfirmationDelay
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.SPIREServerRedialAfterFailures != nil {
+		in, out := &in.SPIREServerRedialAfterFailures, &out.SPIREServerRedialAfterFailures
+		*out = new(int)
+		**out = **in
+	}
+	if in.SPIREServerListPageTimeout != nil {
+		in, out := &in.SPIREServerListPageTimeout, &out.SPIREServerListPageTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.DefaultX509SVIDTTL != nil {
+		in, out := &in.DefaultX509SVIDTTL, &out.DefaultX509SVIDTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.DefaultJWTSVIDTTL != nil {
+		in, out := &in.DefaultJWTSVIDTTL, &out.DefaultJWTSVIDTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxJWTSVIDTTL != nil {
+		in, out := &in.MaxJWTSVIDTTL, &out.MaxJWTSVIDTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MinPodAgeForEntry != nil {
+		in, out := &in.MinPodAgeForEntry, &out.MinPodAgeForEntry
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ParentEntryLimitBackoff != nil {
+		in, out := &in.ParentEntryLimitBackoff, &out.ParentEntryLimitBackoff
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxReconcileDuration != nil {
+		in, out := &in.MaxReconcileDuration, &out.MaxReconcileDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.InitialReconcileDelay != nil {
+		in, out := &in.InitialReconcileDelay, &out.InitialReconcileDelay
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.FederationReconcileCoalesceDelay != nil {
+		in, out := &in.FederationReconcileCoalesceDelay, &out.FederationReconcileCoalesceDelay
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.BundleEndpointReachabilityTimeout != nil {
+		in, out := &in.BundleEndpointReachabilityTimeout, &out.BundleEndpointReachabilityTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.EntryIDPrefixOverrides != nil {
+		in, out := &in.EntryIDPrefixOverrides, &out.EntryIDPrefixOverrides
+		*out = new(EntryIDPrefixOverridesConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ControllerSVID != nil {
+		in, out := &in.ControllerSVID, &out.ControllerSVID
+		*out = new(ControllerSVIDConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClockSkewCheck != nil {
+		in, out := &in.ClockSkewCheck, &out.ClockSkewCheck
+		*out = new(ClockSkewCheckConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MinSVIDTTL != nil {
+		in, out := &in.MinSVIDTTL, &out.MinSVIDTTL
+		*out = new(MinSVIDTTLConfig)
+		**out = **in
+	}
+	if in.MaxSelectorsPerEntry != nil {
+		in, out := &in.MaxSelectorsPerEntry, &out.MaxSelectorsPerEntry
+		*out = new(MaxSelectorsPerEntryConfig)
+		**out = **in
+	}
+	if in.NewNamespaceGracePeriod != nil {
+		in, out := &in.NewNamespaceGracePeriod, &out.NewNamespaceGracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.DryRunEvent != nil {
+		in, out := &in.DryRunEvent, &out.DryRunEvent
+		*out = new(DryRunEventConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllerManagerConfigurationSpec.
@@ -526,6 +733,26 @@ func (in *ControllerMetrics) DeepCopy() *ControllerMetrics {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControllerSVIDConfig) DeepCopyInto(out *ControllerSVIDConfig) {
+	*out = *in
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllerSVIDConfig.
+func (in *ControllerSVIDConfig) DeepCopy() *ControllerSVIDConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ControllerSVIDConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControllerWebhook) DeepCopyInto(out *ControllerWebhook) {
 	*out = *in
@@ -546,6 +773,128 @@ func (in *ControllerWebhook) DeepCopy() *ControllerWebhook {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunEventConfig) DeepCopyInto(out *DryRunEventConfig) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunEventConfig.
+func (in *DryRunEventConfig) DeepCopy() *DryRunEventConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunEventConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EntryIDPrefixOverridesConfig) DeepCopyInto(out *EntryIDPrefixOverridesConfig) {
+	*out = *in
+	if in.Prefixes != nil {
+		in, out := &in.Prefixes, &out.Prefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EntryIDPrefixOverridesConfig.
+func (in *EntryIDPrefixOverridesConfig) DeepCopy() *EntryIDPrefixOverridesConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EntryIDPrefixOverridesConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EntryPolicyConfig) DeepCopyInto(out *EntryPolicyConfig) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EntryPolicyConfig.
+func (in *EntryPolicyConfig) DeepCopy() *EntryPolicyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EntryPolicyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationByLabelConfig) DeepCopyInto(out *FederationByLabelConfig) {
+	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationByLabelConfig.
+func (in *FederationByLabelConfig) DeepCopy() *FederationByLabelConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationByLabelConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaxSelectorsPerEntryConfig) DeepCopyInto(out *MaxSelectorsPerEntryConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaxSelectorsPerEntryConfig.
+func (in *MaxSelectorsPerEntryConfig) DeepCopy() *MaxSelectorsPerEntryConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MaxSelectorsPerEntryConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MinSVIDTTLConfig) DeepCopyInto(out *MinSVIDTTLConfig) {
+	*out = *in
+	out.TTL = in.TTL
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MinSVIDTTLConfig.
+func (in *MinSVIDTTLConfig) DeepCopy() *MinSVIDTTLConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MinSVIDTTLConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NamespaceConfig) DeepCopyInto(out *NamespaceConfig) {
 	*out = *in
@@ -575,6 +924,22 @@ func (in *NamespaceConfig) DeepCopy() *NamespaceConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParentIDTemplateRule) DeepCopyInto(out *ParentIDTemplateRule) {
+	*out = *in
+	in.NodeSelector.DeepCopyInto(&out.NodeSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParentIDTemplateRule.
+func (in *ParentIDTemplateRule) DeepCopy() *ParentIDTemplateRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ParentIDTemplateRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReconcileConfig) DeepCopyInto(out *ReconcileConfig) {
 	*out = *in
@@ -589,3 +954,184 @@ func (in *ReconcileConfig) DeepCopy() *ReconcileConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SPIFFEID) DeepCopyInto(out *SPIFFEID) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SPIFFEID.
+func (in *SPIFFEID) DeepCopy() *SPIFFEID {
+	if in == nil {
+		return nil
+	}
+	out := new(SPIFFEID)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SPIFFEID) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SPIFFEIDList) DeepCopyInto(out *SPIFFEIDList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SPIFFEID, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SPIFFEIDList.
+func (in *SPIFFEIDList) DeepCopy() *SPIFFEIDList {
+	if in == nil {
+		return nil
+	}
+	out := new(SPIFFEIDList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SPIFFEIDList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SPIFFEIDSpec) DeepCopyInto(out *SPIFFEIDSpec) {
+	*out = *in
+	out.TTL = in.TTL
+	out.JWTTTL = in.JWTTTL
+	if in.WorkloadSelectorTemplates != nil {
+		in, out := &in.WorkloadSelectorTemplates, &out.WorkloadSelectorTemplates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PodSelector != nil {
+		in, out := &in.PodSelector, &out.PodSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SPIFFEIDSpec.
+func (in *SPIFFEIDSpec) DeepCopy() *SPIFFEIDSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SPIFFEIDSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SPIFFEIDStatus) DeepCopyInto(out *SPIFFEIDStatus) {
+	*out = *in
+	out.Stats = in.Stats
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SPIFFEIDStatus.
+func (in *SPIFFEIDStatus) DeepCopy() *SPIFFEIDStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SPIFFEIDStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TTLTier) DeepCopyInto(out *TTLTier) {
+	*out = *in
+	out.TTL = in.TTL
+	out.JWTTTL = in.JWTTTL
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TTLTier.
+func (in *TTLTier) DeepCopy() *TTLTier {
+	if in == nil {
+		return nil
+	}
+	out := new(TTLTier)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TTLTiersConfig) DeepCopyInto(out *TTLTiersConfig) {
+	*out = *in
+	if in.Tiers != nil {
+		in, out := &in.Tiers, &out.Tiers
+		*out = make(map[string]TTLTier, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MaxTTL != nil {
+		in, out := &in.MaxTTL, &out.MaxTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TTLTiersConfig.
+func (in *TTLTiersConfig) DeepCopy() *TTLTiersConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TTLTiersConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnsupportedFieldsProbeConfig) DeepCopyInto(out *UnsupportedFieldsProbeConfig) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Retries != nil {
+		in, out := &in.Retries, &out.Retries
+		*out = new(int)
+		**out = **in
+	}
+	if in.Supported != nil {
+		in, out := &in.Supported, &out.Supported
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Unsupported != nil {
+		in, out := &in.Unsupported, &out.Unsupported
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnsupportedFieldsProbeConfig.
+func (in *UnsupportedFieldsProbeConfig) DeepCopy() *UnsupportedFieldsProbeConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(UnsupportedFieldsProbeConfig)
+	in.DeepCopyInto(out)
+	return out
+}
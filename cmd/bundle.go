@@ -0,0 +1,91 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// Formats the trust bundle can be dumped in.
+const (
+	// bundleFormatPEM is the X.509 authorities as PEM encoded certificates.
+	bundleFormatPEM = "pem"
+	// bundleFormatSPIFFE is the SPIFFE bundle JSON document, holding both
+	// the X.509 and JWT authorities.
+	bundleFormatSPIFFE = "spiffe"
+	// bundleFormatJWKS is the JWT authorities as a JWKS document.
+	bundleFormatJWKS = "jwks"
+)
+
+func checkBundleFormat(format string) error {
+	switch format {
+	case bundleFormatPEM, bundleFormatSPIFFE, bundleFormatJWKS:
+		return nil
+	default:
+		return fmt.Errorf("unknown bundle format %q; expected one of %q, %q or %q", format, bundleFormatPEM, bundleFormatSPIFFE, bundleFormatJWKS)
+	}
+}
+
+func marshalBundle(bundle *spiffebundle.Bundle, format string) ([]byte, error) {
+	switch format {
+	case bundleFormatPEM:
+		return bundle.X509Bundle().Marshal()
+	case bundleFormatSPIFFE:
+		return bundle.Marshal()
+	case bundleFormatJWKS:
+		return bundle.JWTBundle().Marshal()
+	default:
+		return nil, checkBundleFormat(format)
+	}
+}
+
+// dumpBundle fetches the trust bundle from the SPIRE server and writes it to
+// w in the given format.
+func dumpBundle(ctx context.Context, bundleClient spireapi.BundleClient, format string, w io.Writer) error {
+	bundle, err := bundleClient.GetBundle(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch bundle: %w", err)
+	}
+	data, err := marshalBundle(bundle, format)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// runDumpBundle dumps the trust bundle of the SPIRE server to w instead of
+// running the controller manager.
+func runDumpBundle(mainConfig Config, w io.Writer) error {
+	spireClient, err := spireapi.Dial(spireapi.DialConfig{
+		SocketPath: mainConfig.ctrlConfig.SPIREServerSocketPath,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to dial SPIRE Server socket: %w", err)
+	}
+	defer spireClient.Close()
+
+	return dumpBundle(ctrl.SetupSignalHandler(), spireClient, mainConfig.bundleFormat, w)
+}
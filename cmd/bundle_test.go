@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpBundle(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString("example.org")
+	bundle := newTestBundle(t, td)
+
+	t.Run("pem", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		require.NoError(t, dumpBundle(context.Background(), fakeBundleClient{bundle: bundle}, bundleFormatPEM, out))
+		actual, err := x509bundle.Parse(td, out.Bytes())
+		require.NoError(t, err)
+		require.True(t, actual.Equal(bundle.X509Bundle()))
+	})
+
+	t.Run("spiffe", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		require.NoError(t, dumpBundle(context.Background(), fakeBundleClient{bundle: bundle}, bundleFormatSPIFFE, out))
+		actual, err := spiffebundle.Parse(td, out.Bytes())
+		require.NoError(t, err)
+		require.True(t, actual.X509Bundle().Equal(bundle.X509Bundle()))
+		require.True(t, actual.JWTBundle().Equal(bundle.JWTBundle()))
+	})
+
+	t.Run("jwks", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		require.NoError(t, dumpBundle(context.Background(), fakeBundleClient{bundle: bundle}, bundleFormatJWKS, out))
+		actual, err := jwtbundle.Parse(td, out.Bytes())
+		require.NoError(t, err)
+		require.True(t, actual.Equal(bundle.JWTBundle()))
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		err := dumpBundle(context.Background(), fakeBundleClient{bundle: bundle}, "der", out)
+		require.EqualError(t, err, `failed to marshal bundle: unknown bundle format "der"; expected one of "pem", "spiffe" or "jwks"`)
+		require.Empty(t, out.Bytes())
+	})
+
+	t.Run("fetch failure", func(t *testing.T) {
+		err := dumpBundle(context.Background(), fakeBundleClient{err: errors.New("oh no")}, bundleFormatPEM, new(bytes.Buffer))
+		require.EqualError(t, err, "failed to fetch bundle: oh no")
+	})
+}
+
+func newTestBundle(t *testing.T, td spiffeid.TrustDomain) *spiffebundle.Bundle {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	bundle := spiffebundle.New(td)
+	bundle.AddX509Authority(cert)
+	require.NoError(t, bundle.AddJWTAuthority("key-id", key.Public()))
+	return bundle
+}
+
+type fakeBundleClient struct {
+	bundle *spiffebundle.Bundle
+	err    error
+}
+
+func (c fakeBundleClient) GetBundle(context.Context) (*spiffebundle.Bundle, error) {
+	return c.bundle, c.err
+}
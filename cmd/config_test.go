@@ -1,10 +1,21 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/spiffe/spire-controller-manager/pkg/spireentry"
 	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
 )
 
 func TestParseClusterDomainCNAME(t *testing.T) {
@@ -52,3 +63,264 @@ func TestParseClusterDomainCNAME(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderSPIFFEIDPathPrefix(t *testing.T) {
+	for _, test := range []struct {
+		name           string
+		prefix         string
+		expectedPrefix string
+		expectedErr    string
+	}{
+		{
+			name:           "Static prefix",
+			prefix:         "/clusters/a",
+			expectedPrefix: "/clusters/a",
+		},
+		{
+			name:           "Templated prefix",
+			prefix:         "/{{ .ClusterName }}",
+			expectedPrefix: "/cluster-a",
+		},
+		{
+			name:        "Missing leading slash",
+			prefix:      "{{ .ClusterName }}",
+			expectedErr: `invalid SPIFFE ID path prefix "cluster-a": path must have a leading slash`,
+		},
+		{
+			name:        "Trailing slash",
+			prefix:      "/{{ .ClusterName }}/",
+			expectedErr: `invalid SPIFFE ID path prefix "/cluster-a/": path cannot have a trailing slash`,
+		},
+		{
+			name:        "Unknown field",
+			prefix:      "/{{ .Nope }}",
+			expectedErr: "unable to render SPIFFE ID path prefix",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			prefix, err := renderSPIFFEIDPathPrefix(test.prefix, "cluster-a", "example.org")
+			if test.expectedErr != "" {
+				require.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expectedPrefix, prefix)
+		})
+	}
+}
+
+func TestParseParentIDTemplateRules(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		rules       []spirev1alpha1.ParentIDTemplateRule
+		expectedErr string
+	}{
+		{
+			name: "No rules",
+		},
+		{
+			name: "Valid rules",
+			rules: []spirev1alpha1.ParentIDTemplateRule{
+				{
+					NodeSelector:     metav1.LabelSelector{MatchLabels: map[string]string{"pool": "a"}},
+					ParentIDTemplate: "spiffe://{{ .TrustDomain }}/spire/agent/x509pop/{{ .NodeMeta.Name }}",
+				},
+			},
+		},
+		{
+			name: "Invalid node selector",
+			rules: []spirev1alpha1.ParentIDTemplateRule{
+				{
+					NodeSelector:     metav1.LabelSelector{MatchLabels: map[string]string{"pool": "a b"}},
+					ParentIDTemplate: "spiffe://{{ .TrustDomain }}/node",
+				},
+			},
+			expectedErr: "unable to parse node selector of parent ID template rule 0",
+		},
+		{
+			name: "Missing parent ID template",
+			rules: []spirev1alpha1.ParentIDTemplateRule{
+				{
+					NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pool": "a"}},
+				},
+			},
+			expectedErr: "parent ID template rule 0 is missing a parent ID template",
+		},
+		{
+			name: "Invalid parent ID template",
+			rules: []spirev1alpha1.ParentIDTemplateRule{
+				{
+					NodeSelector:     metav1.LabelSelector{MatchLabels: map[string]string{"pool": "a"}},
+					ParentIDTemplate: "spiffe://{{ .TrustDomain }}/node",
+				},
+				{
+					NodeSelector:     metav1.LabelSelector{MatchLabels: map[string]string{"pool": "b"}},
+					ParentIDTemplate: "spiffe://{{ .TrustDomain }",
+				},
+			},
+			expectedErr: "unable to parse parent ID template of rule 1",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			rules, err := parseParentIDTemplateRules(test.rules)
+			if test.expectedErr != "" {
+				require.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, rules, len(test.rules))
+		})
+	}
+}
+
+func TestParseFieldSupportOverrides(t *testing.T) {
+	overrides, err := parseFieldSupportOverrides([]string{"jwtSVIDTTL"}, []string{"hint", "storeSVID"})
+	require.NoError(t, err)
+	require.Equal(t, map[spireapi.Field]bool{
+		spireapi.JWTSVIDTTLField: true,
+		spireapi.HintField:       false,
+		spireapi.StoreSVIDField:  false,
+	}, overrides)
+
+	_, err = parseFieldSupportOverrides([]string{"jwtSvidTtl"}, nil)
+	require.EqualError(t, err, `invalid unsupportedFieldsProbe field: unknown entry field "jwtSvidTtl"`)
+
+	_, err = parseFieldSupportOverrides(nil, []string{"dnsNames"})
+	require.EqualError(t, err, `invalid unsupportedFieldsProbe field: entry field "dnsNames" is supported by every SPIRE server; expected one of "hint", "jwtSVIDTTL" or "storeSVID"`)
+
+	_, err = parseFieldSupportOverrides([]string{"hint"}, []string{"hint"})
+	require.EqualError(t, err, `unsupportedFieldsProbe field "hint" can not be both supported and unsupported`)
+}
+
+func TestValidateEntryIDPrefixCleanup(t *testing.T) {
+	for _, test := range []struct {
+		name                string
+		entryIDPrefix       string
+		cleanup             string
+		confirmBroadCleanup bool
+		expectedErr         string
+	}{
+		{
+			name:          "Different prefix",
+			entryIDPrefix: "new.",
+			cleanup:       "old.",
+		},
+		{
+			name:          "Same prefix",
+			entryIDPrefix: "new.",
+			cleanup:       "new.",
+			expectedErr:   "if entryIDPrefixCleanup is specified, it can not be the same value as entryIDPrefix",
+		},
+		{
+			name:          "Unprefixed without confirmation",
+			entryIDPrefix: "new.",
+			expectedErr:   `entryIDPrefixCleanup is "", which deletes all unprefixed entries, including those owned by other controllers; set confirmBroadCleanup to confirm`,
+		},
+		{
+			name:                "Unprefixed with confirmation",
+			entryIDPrefix:       "new.",
+			confirmBroadCleanup: true,
+		},
+		{
+			name: "No entry ID prefix",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateEntryIDPrefixCleanup(test.entryIDPrefix, test.cleanup, test.confirmBroadCleanup)
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestNewWebhookManagerClients(t *testing.T) {
+	// The API server stands in for a cluster reached through a kubeconfig
+	// rather than the in-cluster config.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/apis/admissionregistration.k8s.io/v1/validatingwebhookconfigurations/webhook" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&admissionregistrationv1.ValidatingWebhookConfiguration{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "admissionregistration.k8s.io/v1", Kind: "ValidatingWebhookConfiguration"},
+			ObjectMeta: metav1.ObjectMeta{Name: "webhook"},
+		})
+	}))
+	defer server.Close()
+
+	for _, test := range []struct {
+		name             string
+		validateServices bool
+	}{
+		{name: "Without service validation"},
+		{name: "With service validation", validateServices: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			webhookClient, serviceClient, err := newWebhookManagerClients(&rest.Config{Host: server.URL}, test.validateServices)
+			require.NoError(t, err)
+
+			webhookConfig, err := webhookClient.Get(context.Background(), "webhook", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, "webhook", webhookConfig.Name)
+
+			if test.validateServices {
+				require.NotNil(t, serviceClient)
+			} else {
+				require.Nil(t, serviceClient)
+			}
+		})
+	}
+}
+
+func TestParseFederationByLabel(t *testing.T) {
+	policy, err := parseFederationByLabel(&spirev1alpha1.FederationByLabelConfig{
+		Label:  "example.org/federation",
+		Groups: map[string][]string{"payments": {"bank.test", "partner.test"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &spireentry.FederationLabelPolicy{
+		Label: "example.org/federation",
+		Groups: map[string][]spiffeid.TrustDomain{
+			"payments": {spiffeid.RequireTrustDomainFromString("bank.test"), spiffeid.RequireTrustDomainFromString("partner.test")},
+		},
+	}, policy)
+
+	_, err = parseFederationByLabel(&spirev1alpha1.FederationByLabelConfig{
+		Label:  "example.org/federation",
+		Groups: map[string][]string{"payments": {"bank test"}},
+	})
+	require.ErrorContains(t, err, `invalid federation by label: group "payments": invalid trust domain "bank test"`)
+
+	_, err = parseFederationByLabel(&spirev1alpha1.FederationByLabelConfig{
+		Groups: map[string][]string{"payments": {"bank.test"}},
+	})
+	require.EqualError(t, err, "invalid federation by label: label is required")
+}
+
+func TestResolveSPIREServerSocketPath(t *testing.T) {
+	path, err := resolveSPIREServerSocketPath("", "", false)
+	require.NoError(t, err)
+	require.Equal(t, defaultSPIREServerSocketPath, path)
+
+	path, err = resolveSPIREServerSocketPath("/config.sock", "", true)
+	require.NoError(t, err)
+	require.Equal(t, "/config.sock", path)
+
+	path, err = resolveSPIREServerSocketPath("", "/flag.sock", false)
+	require.NoError(t, err)
+	require.Equal(t, "/flag.sock", path)
+
+	path, err = resolveSPIREServerSocketPath("/config.sock", "/flag.sock", false)
+	require.NoError(t, err)
+	require.Equal(t, "/config.sock", path)
+
+	_, err = resolveSPIREServerSocketPath("", "/flag.sock", true)
+	require.EqualError(t, err, "the deprecated spire-api-socket flag is rejected; set spireServerSocketPath in the configuration file instead")
+
+	_, err = resolveSPIREServerSocketPath("/config.sock", "/flag.sock", true)
+	require.Error(t, err)
+}
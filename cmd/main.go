@@ -22,6 +22,8 @@ import (
 	"flag"
 	"fmt"
 	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -32,10 +34,15 @@ import (
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	"k8s.io/client-go/kubernetes"
+	admissionregistrationv1client "k8s.io/client-go/kubernetes/typed/admissionregistration/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
 
 	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -47,29 +54,80 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
 	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
 	"github.com/spiffe/spire-controller-manager/internal/controller"
+	"github.com/spiffe/spire-controller-manager/pkg/clockskew"
+	"github.com/spiffe/spire-controller-manager/pkg/entrypolicy"
 	"github.com/spiffe/spire-controller-manager/pkg/metrics"
 	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
 	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
 	"github.com/spiffe/spire-controller-manager/pkg/spireentry"
 	"github.com/spiffe/spire-controller-manager/pkg/spirefederationrelationship"
+	"github.com/spiffe/spire-controller-manager/pkg/svidsource"
 	"github.com/spiffe/spire-controller-manager/pkg/webhookmanager"
 	//+kubebuilder:scaffold:imports
 )
 
 type Config struct {
-	ctrlConfig            spirev1alpha1.ControllerManagerConfig
-	options               ctrl.Options
-	ignoreNamespacesRegex []*regexp.Regexp
-	parentIDTemplate      *template.Template
-	reconcile             spirev1alpha1.ReconcileConfig
+	ctrlConfig              spirev1alpha1.ControllerManagerConfig
+	options                 ctrl.Options
+	ignoreNamespacesRegex   []*regexp.Regexp
+	parentIDTemplate        *template.Template
+	parentIDTemplateRules   []spireentry.ParentIDTemplateRule
+	reconcile               spirev1alpha1.ReconcileConfig
+	adminSelector           *spireapi.Selector
+	podExclusionSelector    labels.Selector
+	entryPolicyConfig       *entrypolicy.Config
+	controllerSVIDTTL       time.Duration
+	clockSkewThreshold      time.Duration
+	clockSkewInterval       time.Duration
+	managedTrustDomains     []spiffeid.TrustDomain
+	ttlTierPolicy           *spireentry.TTLTierPolicy
+	federationLabelPolicy   *spireentry.FederationLabelPolicy
+	minTTLPolicy            *spireentry.MinTTLPolicy
+	maxSelectorsPolicy      *spireentry.MaxSelectorsPolicy
+	parentIDScope           *regexp.Regexp
+	entryIDPrefixPolicy     *spireentry.EntryIDPrefixPolicy
+	ttlTolerance            time.Duration
+	spiffeIDPathPrefix      string
+	probeInterval           time.Duration
+	probeRetries            int
+	fieldSupport            map[spireapi.Field]bool
+	entryGracePeriod        time.Duration
+	deleteConfirmationDelay time.Duration
+	redialAfterFailures     int
+	listPageTimeout         time.Duration
+	defaultX509SVIDTTL      time.Duration
+	defaultJWTSVIDTTL       time.Duration
+	maxJWTSVIDTTL           time.Duration
+	minPodAgeForEntry       time.Duration
+	parentLimitBackoff      time.Duration
+	maxReconcileDuration    time.Duration
+	initialReconcileDelay   time.Duration
+	federationCoalesceDelay time.Duration
+	newNamespaceGracePeriod time.Duration
+	bundleEndpointTimeout   time.Duration
+	dryRunEventTarget       *corev1.ObjectReference
+	dryRunEventInterval     time.Duration
+	dumpBundle              bool
+	bundleFormat            string
 }
 
 const (
 	defaultSPIREServerSocketPath = "/spire-server/api.sock"
 	defaultGCInterval            = 10 * time.Second
 	k8sDefaultService            = "kubernetes.default.svc"
+
+	defaultUnsupportedFieldsProbeRetries = 2
+
+	defaultControllerSVIDPath = "/spire-controller-manager"
+
+	// maxBundleEndpointReachabilityTimeout keeps the reachability check well
+	// within the default admission webhook timeout of 10s.
+	maxBundleEndpointReachabilityTimeout = 5 * time.Second
+
+	traceEndpointPath = "/debug/trace/clusterspiffeid"
 )
 
 var (
@@ -82,9 +140,7 @@ func init() {
 
 	utilruntime.Must(spirev1alpha1.AddToScheme(scheme))
 
-	k8sMetrics.Registry.MustRegister(
-		metrics.PromCounters[metrics.StaticEntryFailures],
-	)
+	utilruntime.Must(metrics.Register(k8sMetrics.Registry))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -95,6 +151,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	if mainConfig.dumpBundle {
+		if err := runDumpBundle(mainConfig, os.Stdout); err != nil {
+			setupLog.Error(err, "unable to dump bundle")
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := run(mainConfig); err != nil {
 		os.Exit(1)
 	}
@@ -107,6 +171,143 @@ func addDotSuffix(val string) string {
 	return val
 }
 
+// renderSPIFFEIDPathPrefix renders the SPIFFE ID path prefix template and
+// validates the result.
+func renderSPIFFEIDPathPrefix(prefixTemplate, clusterName, trustDomain string) (string, error) {
+	tmpl, err := template.New("spiffeIDPathPrefix").Option("missingkey=error").Parse(prefixTemplate)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse SPIFFE ID path prefix: %w", err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, struct {
+		ClusterName string
+		TrustDomain string
+	}{
+		ClusterName: clusterName,
+		TrustDomain: trustDomain,
+	}); err != nil {
+		return "", fmt.Errorf("unable to render SPIFFE ID path prefix: %w", err)
+	}
+	prefix := buf.String()
+	if err := spiffeid.ValidatePath(prefix); err != nil {
+		return "", fmt.Errorf("invalid SPIFFE ID path prefix %q: %w", prefix, err)
+	}
+	return prefix, nil
+}
+
+// validateEntryIDPrefixCleanup checks that the entry ID prefix to clean up does
+// not overlap with the entry ID prefix of the controller and, if it is empty,
+// that deleting all unprefixed entries has been confirmed. Without an entry
+// ID prefix, the controller manages the unprefixed entries anyway, so there
+// is nothing to check.
+func validateEntryIDPrefixCleanup(entryIDPrefix, entryIDPrefixCleanup string, confirmBroadCleanup bool) error {
+	if entryIDPrefix == "" {
+		return nil
+	}
+	switch {
+	case entryIDPrefix == entryIDPrefixCleanup:
+		return errors.New("if entryIDPrefixCleanup is specified, it can not be the same value as entryIDPrefix")
+	case entryIDPrefixCleanup == "" && !confirmBroadCleanup:
+		return errors.New(`entryIDPrefixCleanup is "", which deletes all unprefixed entries, including those owned by other controllers; set confirmBroadCleanup to confirm`)
+	}
+	return nil
+}
+
+// parseParentIDTemplateRules compiles the node selectors and parent ID
+// templates of the rules.
+func parseParentIDTemplateRules(rules []spirev1alpha1.ParentIDTemplateRule) ([]spireentry.ParentIDTemplateRule, error) {
+	var parsed []spireentry.ParentIDTemplateRule
+	for i, rule := range rules {
+		nodeSelector, err := metav1.LabelSelectorAsSelector(&rule.NodeSelector)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse node selector of parent ID template rule %d: %w", i, err)
+		}
+		if rule.ParentIDTemplate == "" {
+			return nil, fmt.Errorf("parent ID template rule %d is missing a parent ID template", i)
+		}
+		parentIDTemplate, err := template.New(fmt.Sprintf("parentIDTemplateRule%d", i)).Parse(rule.ParentIDTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse parent ID template of rule %d: %w", i, err)
+		}
+		parsed = append(parsed, spireentry.ParentIDTemplateRule{
+			NodeSelector:     nodeSelector,
+			ParentIDTemplate: parentIDTemplate,
+		})
+	}
+	return parsed, nil
+}
+
+// parseFieldSupportOverrides maps the entry fields declared as supported or
+// unsupported to whether they are supported.
+func parseFieldSupportOverrides(supported, unsupported []string) (map[spireapi.Field]bool, error) {
+	overrides := make(map[spireapi.Field]bool)
+	add := func(names []string, isSupported bool) error {
+		for _, name := range names {
+			field, err := spireapi.ParseField(name)
+			if err != nil {
+				return fmt.Errorf("invalid unsupportedFieldsProbe field: %w", err)
+			}
+			if wasSupported, ok := overrides[field]; ok && wasSupported != isSupported {
+				return fmt.Errorf("unsupportedFieldsProbe field %q can not be both supported and unsupported", field)
+			}
+			overrides[field] = isSupported
+		}
+		return nil
+	}
+	if err := add(supported, true); err != nil {
+		return nil, err
+	}
+	if err := add(unsupported, false); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+func parseFederationByLabel(config *spirev1alpha1.FederationByLabelConfig) (*spireentry.FederationLabelPolicy, error) {
+	policy := &spireentry.FederationLabelPolicy{
+		Label:              config.Label,
+		Groups:             make(map[string][]spiffeid.TrustDomain, len(config.Groups)),
+		WarnOnUnknownGroup: config.WarnOnUnknownGroup,
+	}
+	for name, trustDomains := range config.Groups {
+		for _, trustDomain := range trustDomains {
+			td, err := spiffeid.TrustDomainFromString(trustDomain)
+			if err != nil {
+				return nil, fmt.Errorf("invalid federation by label: group %q: invalid trust domain %q: %w", name, trustDomain, err)
+			}
+			policy.Groups[name] = append(policy.Groups[name], td)
+		}
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid federation by label: %w", err)
+	}
+	return policy, nil
+}
+
+// resolveSPIREServerSocketPath picks the SPIRE Server socket path from the
+// configuration file value and the deprecated spire-api-socket flag.
+func resolveSPIREServerSocketPath(configPath, flagPath string, rejectDeprecatedFlags bool) (string, error) {
+	if flagPath != "" && rejectDeprecatedFlags {
+		return "", errors.New("the deprecated spire-api-socket flag is rejected; set spireServerSocketPath in the configuration file instead")
+	}
+	switch {
+	case configPath == "" && flagPath == "":
+		// Neither is set. Use the default.
+		return defaultSPIREServerSocketPath, nil
+	case configPath != "" && flagPath == "":
+		// Configuration file value is set. Use it.
+		return configPath, nil
+	case configPath == "" && flagPath != "":
+		// Deprecated flag value is set. Use it but warn.
+		setupLog.Error(nil, "The spire-api-socket flag is deprecated and will be removed in a future release; use the configuration file instead")
+		return flagPath, nil
+	default:
+		// Both are set. Warn and ignore the deprecated flag.
+		setupLog.Error(nil, "Ignoring deprecated spire-api-socket flag which will be removed in a future release")
+		return configPath, nil
+	}
+}
+
 func parseConfig() (Config, error) {
 	var retval Config
 	var configFileFlag string
@@ -118,8 +319,17 @@ func parseConfig() (Config, error) {
 			"Command-line flags override configuration from this file.")
 	flag.StringVar(&spireAPISocketFlag, "spire-api-socket", "", "The path to the SPIRE API socket (deprecated; use the config file)")
 	flag.BoolVar(&expandEnvFlag, "expand-env", false, "Expand environment variables in SPIRE Controller Manager config file")
+	flag.BoolVar(&retval.dumpBundle, "dump-bundle", false, "Write the trust bundle of the SPIRE Server to stdout and exit")
+	flag.StringVar(&retval.bundleFormat, "bundle-format", bundleFormatPEM,
+		fmt.Sprintf("The format of the bundle written by -dump-bundle (%q, %q or %q)", bundleFormatPEM, bundleFormatSPIFFE, bundleFormatJWKS))
 	flag.Parse()
 
+	if retval.dumpBundle {
+		if err := checkBundleFormat(retval.bundleFormat); err != nil {
+			return retval, err
+		}
+	}
+
 	// Set default values
 	retval.ctrlConfig = spirev1alpha1.ControllerManagerConfig{
 		IgnoreNamespaces:                   []string{"kube-system", "kube-public", "spire-system"},
@@ -158,19 +368,9 @@ func parseConfig() (Config, error) {
 	ctrl.Log.V(0).Info("Logger configured", "level", opts.Level)
 
 	// Determine the SPIRE Server socket path
-	switch {
-	case retval.ctrlConfig.SPIREServerSocketPath == "" && spireAPISocketFlag == "":
-		// Neither is set. Use the default.
-		retval.ctrlConfig.SPIREServerSocketPath = defaultSPIREServerSocketPath
-	case retval.ctrlConfig.SPIREServerSocketPath != "" && spireAPISocketFlag == "":
-		// Configuration file value is set. Use it.
-	case retval.ctrlConfig.SPIREServerSocketPath == "" && spireAPISocketFlag != "":
-		// Deprecated flag value is set. Use it but warn.
-		retval.ctrlConfig.SPIREServerSocketPath = spireAPISocketFlag
-		setupLog.Error(nil, "The spire-api-socket flag is deprecated and will be removed in a future release; use the configuration file instead")
-	case retval.ctrlConfig.SPIREServerSocketPath != "" && spireAPISocketFlag != "":
-		// Both are set. Warn and ignore the deprecated flag.
-		setupLog.Error(nil, "Ignoring deprecated spire-api-socket flag which will be removed in a future release")
+	retval.ctrlConfig.SPIREServerSocketPath, err = resolveSPIREServerSocketPath(retval.ctrlConfig.SPIREServerSocketPath, spireAPISocketFlag, retval.ctrlConfig.RejectDeprecatedFlags)
+	if err != nil {
+		return retval, err
 	}
 
 	// Attempt to auto detect cluster domain if it wasn't specified
@@ -191,6 +391,18 @@ func parseConfig() (Config, error) {
 		}
 	}
 
+	retval.parentIDTemplateRules, err = parseParentIDTemplateRules(retval.ctrlConfig.ParentIDTemplateRules)
+	if err != nil {
+		return retval, err
+	}
+
+	if retval.ctrlConfig.SPIFFEIDPathPrefix != "" {
+		retval.spiffeIDPathPrefix, err = renderSPIFFEIDPathPrefix(retval.ctrlConfig.SPIFFEIDPathPrefix, retval.ctrlConfig.ClusterName, retval.ctrlConfig.TrustDomain)
+		if err != nil {
+			return retval, err
+		}
+	}
+
 	if retval.ctrlConfig.Reconcile == nil {
 		retval.reconcile.ClusterSPIFFEIDs = true
 		retval.reconcile.ClusterFederatedTrustDomains = true
@@ -205,11 +417,330 @@ func parseConfig() (Config, error) {
 	if retval.ctrlConfig.EntryIDPrefixCleanup != nil {
 		printCleanup = *retval.ctrlConfig.EntryIDPrefixCleanup
 		*retval.ctrlConfig.EntryIDPrefixCleanup = addDotSuffix(*retval.ctrlConfig.EntryIDPrefixCleanup)
-		if retval.ctrlConfig.EntryIDPrefix != "" && retval.ctrlConfig.EntryIDPrefix == *retval.ctrlConfig.EntryIDPrefixCleanup {
-			return retval, fmt.Errorf("if entryIDPrefixCleanup is specified, it can not be the same value as entryIDPrefix")
+		if err := validateEntryIDPrefixCleanup(retval.ctrlConfig.EntryIDPrefix, *retval.ctrlConfig.EntryIDPrefixCleanup, retval.ctrlConfig.ConfirmBroadCleanup); err != nil {
+			return retval, err
+		}
+	}
+
+	if overrides := retval.ctrlConfig.EntryIDPrefixOverrides; overrides != nil {
+		retval.entryIDPrefixPolicy = &spireentry.EntryIDPrefixPolicy{
+			Annotation: overrides.Annotation,
+		}
+		for _, prefix := range overrides.Prefixes {
+			retval.entryIDPrefixPolicy.Prefixes = append(retval.entryIDPrefixPolicy.Prefixes, addDotSuffix(prefix))
+		}
+		if err := retval.entryIDPrefixPolicy.Validate(); err != nil {
+			return retval, fmt.Errorf("invalid entry ID prefix overrides: %w", err)
+		}
+		for _, prefix := range retval.entryIDPrefixPolicy.Prefixes {
+			if prefix == retval.ctrlConfig.EntryIDPrefix || (retval.ctrlConfig.EntryIDPrefixCleanup != nil && prefix == *retval.ctrlConfig.EntryIDPrefixCleanup) {
+				return retval, fmt.Errorf("entry ID prefix override %q can not be the same value as entryIDPrefix or entryIDPrefixCleanup", prefix)
+			}
+		}
+	}
+
+	printPodExclusion := "<unset>"
+	if retval.ctrlConfig.GlobalPodExclusionSelector != nil {
+		retval.podExclusionSelector, err = metav1.LabelSelectorAsSelector(retval.ctrlConfig.GlobalPodExclusionSelector)
+		if err != nil {
+			return retval, fmt.Errorf("unable to parse global pod exclusion selector: %w", err)
+		}
+		printPodExclusion = retval.podExclusionSelector.String()
+	}
+
+	if retval.ctrlConfig.ClassScopedEntryIDs && retval.ctrlConfig.ClassName == "" {
+		setupLog.Info("classScopedEntryIDs has no effect without className")
+	}
+
+	if retval.ctrlConfig.AdminSelector != "" {
+		adminSelector, err := spireentry.ParseSelector(retval.ctrlConfig.AdminSelector)
+		if err != nil {
+			return retval, fmt.Errorf("unable to parse admin selector: %w", err)
+		}
+		retval.adminSelector = &adminSelector
+	}
+
+	if entryPolicy := retval.ctrlConfig.EntryPolicy; entryPolicy != nil {
+		if _, err := url.ParseRequestURI(entryPolicy.URL); err != nil {
+			return retval, fmt.Errorf("invalid entry policy URL: %w", err)
+		}
+		var timeout time.Duration
+		if entryPolicy.Timeout != nil {
+			timeout = entryPolicy.Timeout.Duration
+		}
+		if entryPolicy.PresentControllerSVID && retval.ctrlConfig.ControllerSVID == nil {
+			return retval, errors.New("entryPolicy.presentControllerSVID requires controllerSVID")
+		}
+		retval.entryPolicyConfig = &entrypolicy.Config{
+			URL:     entryPolicy.URL,
+			Timeout: timeout,
+		}
+	}
+
+	if controllerSVID := retval.ctrlConfig.ControllerSVID; controllerSVID != nil {
+		if controllerSVID.Path == "" {
+			controllerSVID.Path = defaultControllerSVIDPath
+		}
+		if err := spiffeid.ValidatePath(controllerSVID.Path); err != nil {
+			return retval, fmt.Errorf("invalid controller SVID path: %w", err)
+		}
+		if controllerSVID.TTL != nil {
+			if controllerSVID.TTL.Duration < 0 {
+				return retval, errors.New("controllerSVID.ttl can not be negative")
+			}
+			retval.controllerSVIDTTL = controllerSVID.TTL.Duration
+		}
+	}
+
+	if clockSkewCheck := retval.ctrlConfig.ClockSkewCheck; clockSkewCheck != nil {
+		if clockSkewCheck.Threshold != nil {
+			if clockSkewCheck.Threshold.Duration < 0 {
+				return retval, errors.New("clockSkewCheck.threshold can not be negative")
+			}
+			retval.clockSkewThreshold = clockSkewCheck.Threshold.Duration
+		}
+		if clockSkewCheck.Interval != nil {
+			if clockSkewCheck.Interval.Duration < 0 {
+				return retval, errors.New("clockSkewCheck.interval can not be negative")
+			}
+			retval.clockSkewInterval = clockSkewCheck.Interval.Duration
+		}
+	}
+
+	for _, managedTrustDomain := range retval.ctrlConfig.ManagedTrustDomains {
+		td, err := spiffeid.TrustDomainFromString(managedTrustDomain)
+		if err != nil {
+			return retval, fmt.Errorf("invalid managed trust domain %q: %w", managedTrustDomain, err)
+		}
+		retval.managedTrustDomains = append(retval.managedTrustDomains, td)
+	}
+
+	if ttlTiers := retval.ctrlConfig.TTLTiers; ttlTiers != nil {
+		retval.ttlTierPolicy = &spireentry.TTLTierPolicy{
+			Annotation: ttlTiers.Annotation,
+			Tiers:      make(map[string]spireentry.TTLTier, len(ttlTiers.Tiers)),
+		}
+		if ttlTiers.MaxTTL != nil {
+			retval.ttlTierPolicy.MaxTTL = ttlTiers.MaxTTL.Duration
+		}
+		for name, tier := range ttlTiers.Tiers {
+			retval.ttlTierPolicy.Tiers[name] = spireentry.TTLTier{
+				X509SVIDTTL: tier.TTL.Duration,
+				JWTSVIDTTL:  tier.JWTTTL.Duration,
+			}
+		}
+		if err := retval.ttlTierPolicy.Validate(); err != nil {
+			return retval, fmt.Errorf("invalid TTL tiers: %w", err)
+		}
+	}
+
+	if federationByLabel := retval.ctrlConfig.FederationByLabel; federationByLabel != nil {
+		retval.federationLabelPolicy, err = parseFederationByLabel(federationByLabel)
+		if err != nil {
+			return retval, err
+		}
+	}
+
+	if minSVIDTTL := retval.ctrlConfig.MinSVIDTTL; minSVIDTTL != nil {
+		retval.minTTLPolicy = &spireentry.MinTTLPolicy{
+			MinTTL: minSVIDTTL.TTL.Duration,
+			Action: spireentry.MinTTLAction(minSVIDTTL.Action),
+		}
+		if err := retval.minTTLPolicy.Validate(); err != nil {
+			return retval, fmt.Errorf("invalid minimum SVID TTL: %w", err)
+		}
+	}
+
+	if maxSelectors := retval.ctrlConfig.MaxSelectorsPerEntry; maxSelectors != nil {
+		retval.maxSelectorsPolicy = &spireentry.MaxSelectorsPolicy{
+			MaxSelectors: maxSelectors.Max,
+			Action:       spireentry.MaxSelectorsAction(maxSelectors.Action),
+		}
+		if err := retval.maxSelectorsPolicy.Validate(); err != nil {
+			return retval, fmt.Errorf("invalid maximum selectors per entry: %w", err)
+		}
+	}
+
+	if retval.ctrlConfig.ParentIDScope != "" {
+		retval.parentIDScope, err = regexp.Compile(retval.ctrlConfig.ParentIDScope)
+		if err != nil {
+			return retval, fmt.Errorf("unable to compile parent ID scope regex: %w", err)
+		}
+	}
+
+	if retval.ctrlConfig.TTLTolerance != nil {
+		retval.ttlTolerance = retval.ctrlConfig.TTLTolerance.Duration
+		if retval.ttlTolerance < 0 {
+			return retval, errors.New("ttlTolerance can not be negative")
+		}
+	}
+
+	if retval.ctrlConfig.DeclaredEntryGracePeriod != nil {
+		retval.entryGracePeriod = retval.ctrlConfig.DeclaredEntryGracePeriod.Duration
+		if retval.entryGracePeriod < 0 {
+			return retval, errors.New("declaredEntryGracePeriod can not be negative")
+		}
+	}
+
+	if retval.ctrlConfig.DeleteConfirmationDelay != nil {
+		retval.deleteConfirmationDelay = retval.ctrlConfig.DeleteConfirmationDelay.Duration
+		if retval.deleteConfirmationDelay < 0 {
+			return retval, errors.New("deleteConfirmationDelay can not be negative")
+		}
+	}
+
+	if retval.ctrlConfig.MaxDNSNameEndpoints < 0 {
+		return retval, errors.New("maxDNSNameEndpoints can not be negative")
+	}
+
+	if retval.ctrlConfig.NamespaceConcurrency < 0 {
+		return retval, errors.New("namespaceConcurrency can not be negative")
+	}
+
+	if retval.ctrlConfig.RenderFailureBackoffAfter < 0 {
+		return retval, errors.New("renderFailureBackoffAfter can not be negative")
+	}
+
+	if retval.ctrlConfig.EntryFailureBackoffAfter < 0 {
+		return retval, errors.New("entryFailureBackoffAfter can not be negative")
+	}
+
+	retval.probeRetries = defaultUnsupportedFieldsProbeRetries
+	if probe := retval.ctrlConfig.UnsupportedFieldsProbe; probe != nil {
+		if probe.Interval != nil {
+			retval.probeInterval = probe.Interval.Duration
+			if retval.probeInterval < 0 {
+				return retval, errors.New("unsupportedFieldsProbe interval can not be negative")
+			}
+		}
+		if probe.Retries != nil {
+			if *probe.Retries < 0 {
+				return retval, errors.New("unsupportedFieldsProbe retries can not be negative")
+			}
+			retval.probeRetries = *probe.Retries
+		}
+		retval.fieldSupport, err = parseFieldSupportOverrides(probe.Supported, probe.Unsupported)
+		if err != nil {
+			return retval, err
+		}
+	}
+
+	if retval.ctrlConfig.DefaultX509SVIDTTL != nil {
+		retval.defaultX509SVIDTTL = retval.ctrlConfig.DefaultX509SVIDTTL.Duration
+		if retval.defaultX509SVIDTTL < 0 {
+			return retval, errors.New("defaultX509SVIDTTL can not be negative")
+		}
+	}
+
+	if retval.ctrlConfig.DefaultJWTSVIDTTL != nil {
+		retval.defaultJWTSVIDTTL = retval.ctrlConfig.DefaultJWTSVIDTTL.Duration
+		if retval.defaultJWTSVIDTTL < 0 {
+			return retval, errors.New("defaultJWTSVIDTTL can not be negative")
+		}
+	}
+
+	if retval.ctrlConfig.MaxJWTSVIDTTL != nil {
+		retval.maxJWTSVIDTTL = retval.ctrlConfig.MaxJWTSVIDTTL.Duration
+		if retval.maxJWTSVIDTTL < 0 {
+			return retval, errors.New("maxJWTSVIDTTL can not be negative")
+		}
+		if retval.maxJWTSVIDTTL > 0 && retval.defaultJWTSVIDTTL > retval.maxJWTSVIDTTL {
+			return retval, errors.New("defaultJWTSVIDTTL can not exceed maxJWTSVIDTTL")
+		}
+	}
+
+	if retval.ctrlConfig.MinPodAgeForEntry != nil {
+		retval.minPodAgeForEntry = retval.ctrlConfig.MinPodAgeForEntry.Duration
+		if retval.minPodAgeForEntry < 0 {
+			return retval, errors.New("minPodAgeForEntry can not be negative")
+		}
+	}
+
+	if retval.ctrlConfig.ParentEntryLimitBackoff != nil {
+		retval.parentLimitBackoff = retval.ctrlConfig.ParentEntryLimitBackoff.Duration
+		if retval.parentLimitBackoff < 0 {
+			return retval, errors.New("parentEntryLimitBackoff can not be negative")
+		}
+	}
+
+	if retval.ctrlConfig.MaxReconcileDuration != nil {
+		retval.maxReconcileDuration = retval.ctrlConfig.MaxReconcileDuration.Duration
+		if retval.maxReconcileDuration < 0 {
+			return retval, errors.New("maxReconcileDuration can not be negative")
+		}
+	}
+
+	if retval.ctrlConfig.InitialReconcileDelay != nil {
+		retval.initialReconcileDelay = retval.ctrlConfig.InitialReconcileDelay.Duration
+		if retval.initialReconcileDelay < 0 {
+			return retval, errors.New("initialReconcileDelay can not be negative")
+		}
+	}
+
+	if retval.ctrlConfig.FederationReconcileCoalesceDelay != nil {
+		retval.federationCoalesceDelay = retval.ctrlConfig.FederationReconcileCoalesceDelay.Duration
+		if retval.federationCoalesceDelay < 0 {
+			return retval, errors.New("federationReconcileCoalesceDelay can not be negative")
 		}
 	}
 
+	if retval.ctrlConfig.NewNamespaceGracePeriod != nil {
+		retval.newNamespaceGracePeriod = retval.ctrlConfig.NewNamespaceGracePeriod.Duration
+		if retval.newNamespaceGracePeriod < 0 {
+			return retval, errors.New("newNamespaceGracePeriod can not be negative")
+		}
+	}
+
+	if dryRunEvent := retval.ctrlConfig.DryRunEvent; dryRunEvent != nil {
+		if dryRunEvent.Kind == "" || dryRunEvent.Name == "" {
+			return retval, errors.New("dryRunEvent.kind and dryRunEvent.name are required")
+		}
+		retval.dryRunEventTarget = &corev1.ObjectReference{
+			APIVersion: dryRunEvent.APIVersion,
+			Kind:       dryRunEvent.Kind,
+			Namespace:  dryRunEvent.Namespace,
+			Name:       dryRunEvent.Name,
+		}
+		if dryRunEvent.Interval != nil {
+			if dryRunEvent.Interval.Duration < 0 {
+				return retval, errors.New("dryRunEvent.interval can not be negative")
+			}
+			retval.dryRunEventInterval = dryRunEvent.Interval.Duration
+		}
+	}
+
+	if retval.ctrlConfig.BundleEndpointReachabilityTimeout != nil {
+		retval.bundleEndpointTimeout = retval.ctrlConfig.BundleEndpointReachabilityTimeout.Duration
+		switch {
+		case retval.bundleEndpointTimeout < 0:
+			return retval, errors.New("bundleEndpointReachabilityTimeout can not be negative")
+		case retval.bundleEndpointTimeout > maxBundleEndpointReachabilityTimeout:
+			return retval, fmt.Errorf("bundleEndpointReachabilityTimeout can not be more than %s", maxBundleEndpointReachabilityTimeout)
+		}
+	}
+
+	if retval.ctrlConfig.SPIREServerRedialAfterFailures != nil {
+		if *retval.ctrlConfig.SPIREServerRedialAfterFailures < 0 {
+			return retval, errors.New("spireServerRedialAfterFailures can not be negative")
+		}
+		retval.redialAfterFailures = *retval.ctrlConfig.SPIREServerRedialAfterFailures
+	}
+
+	if retval.ctrlConfig.SPIREServerListPageTimeout != nil {
+		retval.listPageTimeout = retval.ctrlConfig.SPIREServerListPageTimeout.Duration
+		if retval.listPageTimeout < 0 {
+			return retval, errors.New("spireServerListPageTimeout can not be negative")
+		}
+	}
+
+	if retval.ctrlConfig.SPIREServerMaxListEntries < 0 {
+		return retval, errors.New("spireServerMaxListEntries can not be negative")
+	}
+
+	if retval.ctrlConfig.BootstrapPasses < 0 {
+		return retval, errors.New("bootstrapPasses can not be negative")
+	}
+
 	setupLog.Info("Config loaded",
 		"cluster name", retval.ctrlConfig.ClusterName,
 		"cluster domain", retval.ctrlConfig.ClusterDomain,
@@ -222,8 +753,63 @@ func parseConfig() (Config, error) {
 		"reconcile ClusterSPIFFEIDs", retval.reconcile.ClusterSPIFFEIDs,
 		"reconcile ClusterFederatedTrustDomains", retval.reconcile.ClusterFederatedTrustDomains,
 		"reconcile ClusterStaticEntries", retval.reconcile.ClusterStaticEntries,
+		"reconcile SPIFFEIDs", retval.reconcile.SPIFFEIDs,
 		"entryIDPrefix", retval.ctrlConfig.EntryIDPrefix,
-		"entryIDPrefixCleanup", printCleanup)
+		"entryIDPrefixCleanup", printCleanup,
+		"confirmBroadCleanup", retval.ctrlConfig.ConfirmBroadCleanup,
+		"classScopedEntryIDs", retval.ctrlConfig.ClassScopedEntryIDs,
+		"bootstrapPasses", retval.ctrlConfig.BootstrapPasses,
+		"preserveDuplicateEntries", retval.ctrlConfig.PreserveDuplicateEntries,
+		"adminSelector", retval.ctrlConfig.AdminSelector,
+		"globalPodExclusionSelector", printPodExclusion,
+		"entryPolicy", retval.entryPolicyConfig != nil,
+		"managedTrustDomains", retval.ctrlConfig.ManagedTrustDomains,
+		"ttlTiers", retval.ttlTierPolicy != nil,
+		"federationByLabel", retval.federationLabelPolicy != nil,
+		"minSVIDTTL", retval.minTTLPolicy != nil,
+		"maxSelectorsPerEntry", retval.maxSelectorsPolicy != nil,
+		"parentIDScope", retval.ctrlConfig.ParentIDScope,
+		"reconcileSummary", retval.ctrlConfig.ReconcileSummary,
+		"newNamespaceGracePeriod", retval.newNamespaceGracePeriod,
+		"ttlTolerance", retval.ttlTolerance,
+		"createDownstreamEntriesFirst", retval.ctrlConfig.CreateDownstreamEntriesFirst,
+		"createEntriesByPodPriority", retval.ctrlConfig.CreateEntriesByPodPriority,
+		"spiffeIDPathPrefix", retval.spiffeIDPathPrefix,
+		"maxDNSNameEndpoints", retval.ctrlConfig.MaxDNSNameEndpoints,
+		"unsupportedFieldsProbe", retval.ctrlConfig.UnsupportedFieldsProbe,
+		"clampX509SVIDTTLToCA", retval.ctrlConfig.ClampX509SVIDTTLToCA,
+		"declaredEntryGracePeriod", retval.entryGracePeriod,
+		"deleteConfirmationDelay", retval.deleteConfirmationDelay,
+		"enableTraceEndpoint", retval.ctrlConfig.EnableTraceEndpoint,
+		"revalidatePodsBeforeCreate", retval.ctrlConfig.RevalidatePodsBeforeCreate,
+		"validateEntriesBeforeSend", retval.ctrlConfig.ValidateEntriesBeforeSend,
+		"validateTrustDomainsAtStartup", retval.ctrlConfig.ValidateTrustDomainsAtStartup,
+		"spireServerRedialAfterFailures", retval.redialAfterFailures,
+		"spireServerListPageTimeout", retval.listPageTimeout,
+		"spireServerMaxListEntries", retval.ctrlConfig.SPIREServerMaxListEntries,
+		"entryRevisions", retval.ctrlConfig.EntryRevisions,
+		"defaultX509SVIDTTL", retval.defaultX509SVIDTTL,
+		"defaultJWTSVIDTTL", retval.defaultJWTSVIDTTL,
+		"maxJWTSVIDTTL", retval.maxJWTSVIDTTL,
+		"minPodAgeForEntry", retval.minPodAgeForEntry,
+		"skipTerminatingPods", retval.ctrlConfig.SkipTerminatingPods,
+		"namespaceConcurrency", retval.ctrlConfig.NamespaceConcurrency,
+		"parentIDTemplateRules", len(retval.parentIDTemplateRules),
+		"parentEntryLimitBackoff", retval.parentLimitBackoff,
+		"deduplicateDNSNames", retval.ctrlConfig.DeduplicateDNSNames,
+		"renderFailureBackoffAfter", retval.ctrlConfig.RenderFailureBackoffAfter,
+		"entryFailureBackoffAfter", retval.ctrlConfig.EntryFailureBackoffAfter,
+		"validateWebhookServices", retval.ctrlConfig.ValidateWebhookServices,
+		"maxReconcileDuration", retval.maxReconcileDuration,
+		"initialReconcileDelay", retval.initialReconcileDelay,
+		"federationReconcileCoalesceDelay", retval.federationCoalesceDelay,
+		"bundleEndpointReachabilityTimeout", retval.bundleEndpointTimeout,
+		"mergeMaskedEntries", retval.ctrlConfig.MergeMaskedEntries,
+		"entryIDPrefixOverrides", retval.entryIDPrefixPolicy != nil,
+		"controllerSVID", retval.ctrlConfig.ControllerSVID != nil,
+		"clockSkewCheck", retval.ctrlConfig.ClockSkewCheck != nil,
+		"dryRun", retval.ctrlConfig.DryRun,
+		"dryRunEvent", retval.dryRunEventTarget != nil)
 
 	switch {
 	case retval.ctrlConfig.TrustDomain == "":
@@ -249,16 +835,60 @@ func run(mainConfig Config) (err error) {
 		return err
 	}
 
+	// The webhook manager and the manager share the same REST config, which
+	// is loaded from the --kubeconfig flag, the KUBECONFIG environment
+	// variable, the in-cluster config or ~/.kube/config, in that order, so
+	// that the controller can also run out-of-cluster during development.
+	restConfig, err := ctrl.GetConfig()
+	if err != nil {
+		setupLog.Error(err, "unable to load the Kubernetes client configuration")
+		return err
+	}
+
 	ctx := ctrl.SetupSignalHandler()
 
 	setupLog.Info("Dialing SPIRE Server socket")
-	spireClient, err := spireapi.DialSocket(mainConfig.ctrlConfig.SPIREServerSocketPath)
+	spireClient, err := spireapi.Dial(spireapi.DialConfig{
+		SocketPath:          mainConfig.ctrlConfig.SPIREServerSocketPath,
+		RedialAfterFailures: mainConfig.redialAfterFailures,
+		ListPageTimeout:     mainConfig.listPageTimeout,
+		MaxListEntries:      mainConfig.ctrlConfig.SPIREServerMaxListEntries,
+	})
 	if err != nil {
 		setupLog.Error(err, "unable to dial SPIRE Server socket")
 		return err
 	}
 	defer spireClient.Close()
 
+	var controllerSVIDSource *svidsource.Source
+	if controllerSVID := mainConfig.ctrlConfig.ControllerSVID; controllerSVID != nil {
+		controllerSVIDSource = svidsource.New(svidsource.Config{
+			ID:         spiffeid.RequireFromPath(trustDomain, controllerSVID.Path),
+			TTL:        mainConfig.controllerSVIDTTL,
+			SVIDClient: spireClient,
+		})
+		if err := controllerSVIDSource.Init(ctx); err != nil {
+			setupLog.Error(err, "failed to mint initial controller SVID")
+			return err
+		}
+	}
+
+	var entryPolicy entrypolicy.Client
+	if mainConfig.entryPolicyConfig != nil {
+		entryPolicyConfig := *mainConfig.entryPolicyConfig
+		if mainConfig.ctrlConfig.EntryPolicy.PresentControllerSVID {
+			entryPolicyConfig.HTTPClient = &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{
+						MinVersion:           tls.VersionTLS12,
+						GetClientCertificate: tlsconfig.GetClientCertificate(controllerSVIDSource),
+					},
+				},
+			}
+		}
+		entryPolicy = entrypolicy.NewClient(entryPolicyConfig)
+	}
+
 	// It's unfortunate that we have to keep credentials on disk so that the
 	// manager can load them. Webhook server credentials are stored in a single
 	// file to keep rotation simple.
@@ -292,13 +922,7 @@ func run(mainConfig Config) (err error) {
 		// the controller runtime client for this because we can't start the manager
 		// without the webhook credentials being in place, and the webhook credentials
 		// need the DNS name of the webhook service from the configuration.
-		config, err := rest.InClusterConfig()
-		if err != nil {
-			setupLog.Error(err, "failed to get in cluster configuration")
-			return err
-		}
-		// creates the clientset
-		clientset, err := kubernetes.NewForConfig(config)
+		webhookClient, serviceClient, err := newWebhookManagerClients(restConfig, mainConfig.ctrlConfig.ValidateWebhookServices)
 		if err != nil {
 			setupLog.Error(err, "failed to create an API client")
 			return err
@@ -308,9 +932,10 @@ func run(mainConfig Config) (err error) {
 			ID:            spiffeid.RequireFromPath(trustDomain, "/spire-controller-manager-webhook"),
 			KeyPairPath:   filepath.Join(certDir, keyPairName),
 			WebhookName:   mainConfig.ctrlConfig.ValidatingWebhookConfigurationName,
-			WebhookClient: clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations(),
+			WebhookClient: webhookClient,
 			SVIDClient:    spireClient,
 			BundleClient:  spireClient,
+			ServiceClient: serviceClient,
 		})
 
 		if err := webhookManager.Init(ctx); err != nil {
@@ -321,29 +946,90 @@ func run(mainConfig Config) (err error) {
 		webhookRunnable = webhookManager
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), mainConfig.options)
+	mgr, err := ctrl.NewManager(restConfig, mainConfig.options)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		return err
 	}
 
+	entryReconcilerConfig := spireentry.ReconcilerConfig{
+		TrustDomain:                trustDomain,
+		ClusterName:                mainConfig.ctrlConfig.ClusterName,
+		ClusterDomain:              mainConfig.ctrlConfig.ClusterDomain,
+		K8sClient:                  mgr.GetClient(),
+		EntryClient:                spireClient,
+		IgnoreNamespaces:           mainConfig.ignoreNamespacesRegex,
+		GlobalPodExclusionSelector: mainConfig.podExclusionSelector,
+		GCInterval:                 mainConfig.ctrlConfig.GCInterval,
+		ClassName:                  mainConfig.ctrlConfig.ClassName,
+		WatchClassless:             mainConfig.ctrlConfig.WatchClassless,
+		ParentIDTemplate:           mainConfig.parentIDTemplate,
+		ParentIDTemplateRules:      mainConfig.parentIDTemplateRules,
+		Reconcile:                  mainConfig.reconcile,
+		EntryIDPrefix:              mainConfig.ctrlConfig.EntryIDPrefix,
+		EntryIDPrefixCleanup:       mainConfig.ctrlConfig.EntryIDPrefixCleanup,
+		EntryIDPrefixPolicy:        mainConfig.entryIDPrefixPolicy,
+		ClassScopedEntryIDs:        mainConfig.ctrlConfig.ClassScopedEntryIDs,
+		BootstrapPasses:            mainConfig.ctrlConfig.BootstrapPasses,
+		PreserveDuplicates:         mainConfig.ctrlConfig.PreserveDuplicateEntries,
+		AdminSelector:              mainConfig.adminSelector,
+		EntryPolicy:                entryPolicy,
+		EntryPolicyFailOpen:        mainConfig.ctrlConfig.EntryPolicy != nil && mainConfig.ctrlConfig.EntryPolicy.FailOpen,
+		TTLTolerance:               mainConfig.ttlTolerance,
+		TTLTierPolicy:              mainConfig.ttlTierPolicy,
+		FederationLabelPolicy:      mainConfig.federationLabelPolicy,
+		MinTTLPolicy:               mainConfig.minTTLPolicy,
+		MaxSelectorsPolicy:         mainConfig.maxSelectorsPolicy,
+		ParentIDScope:              mainConfig.parentIDScope,
+		EventRecorder:              mgr.GetEventRecorderFor("spire-controller-manager"),
+
+		CreateDownstreamEntriesFirst: mainConfig.ctrlConfig.CreateDownstreamEntriesFirst,
+		CreateEntriesByPodPriority:   mainConfig.ctrlConfig.CreateEntriesByPodPriority,
+		SPIFFEIDPathPrefix:           mainConfig.spiffeIDPathPrefix,
+		MaxDNSNameEndpoints:          mainConfig.ctrlConfig.MaxDNSNameEndpoints,
+
+		SkipUnsupportedFieldsProbe:     mainConfig.ctrlConfig.UnsupportedFieldsProbe != nil && mainConfig.ctrlConfig.UnsupportedFieldsProbe.Skip,
+		UnsupportedFieldsProbeInterval: mainConfig.probeInterval,
+		UnsupportedFieldsProbeRetries:  mainConfig.probeRetries,
+		FieldSupportOverrides:          mainConfig.fieldSupport,
+		ClampX509SVIDTTLToCA:           mainConfig.ctrlConfig.ClampX509SVIDTTLToCA,
+		BundleClient:                   spireClient,
+		DeclaredEntryGracePeriod:       mainConfig.entryGracePeriod,
+		DeleteConfirmationDelay:        mainConfig.deleteConfirmationDelay,
+		RevalidatePodsBeforeCreate:     mainConfig.ctrlConfig.RevalidatePodsBeforeCreate,
+		ValidateEntriesBeforeSend:      mainConfig.ctrlConfig.ValidateEntriesBeforeSend,
+		EntryRevisions:                 mainConfig.ctrlConfig.EntryRevisions,
+		DefaultX509SVIDTTL:             mainConfig.defaultX509SVIDTTL,
+		DefaultJWTSVIDTTL:              mainConfig.defaultJWTSVIDTTL,
+		MinPodAgeForEntry:              mainConfig.minPodAgeForEntry,
+		SkipTerminatingPods:            mainConfig.ctrlConfig.SkipTerminatingPods,
+		NamespaceConcurrency:           mainConfig.ctrlConfig.NamespaceConcurrency,
+		ParentEntryLimitBackoff:        mainConfig.parentLimitBackoff,
+		DeduplicateDNSNames:            mainConfig.ctrlConfig.DeduplicateDNSNames,
+		RenderFailureBackoffAfter:      mainConfig.ctrlConfig.RenderFailureBackoffAfter,
+		EntryFailureBackoffAfter:       mainConfig.ctrlConfig.EntryFailureBackoffAfter,
+		MaxReconcileDuration:           mainConfig.maxReconcileDuration,
+		InitialReconcileDelay:          mainConfig.initialReconcileDelay,
+		MergeMaskedEntries:             mainConfig.ctrlConfig.MergeMaskedEntries,
+		DryRun:                         mainConfig.ctrlConfig.DryRun,
+		DryRunEventTarget:              mainConfig.dryRunEventTarget,
+		DryRunEventInterval:            mainConfig.dryRunEventInterval,
+		Cache:                          mgr.GetCache(),
+	}
+	if mainConfig.ctrlConfig.ReconcileSummary {
+		entryReconcilerConfig.SummaryWriter = os.Stdout
+	}
+
 	var entryReconciler reconciler.Reconciler
-	if mainConfig.reconcile.ClusterSPIFFEIDs || mainConfig.reconcile.ClusterStaticEntries {
-		entryReconciler = spireentry.Reconciler(spireentry.ReconcilerConfig{
-			TrustDomain:          trustDomain,
-			ClusterName:          mainConfig.ctrlConfig.ClusterName,
-			ClusterDomain:        mainConfig.ctrlConfig.ClusterDomain,
-			K8sClient:            mgr.GetClient(),
-			EntryClient:          spireClient,
-			IgnoreNamespaces:     mainConfig.ignoreNamespacesRegex,
-			GCInterval:           mainConfig.ctrlConfig.GCInterval,
-			ClassName:            mainConfig.ctrlConfig.ClassName,
-			WatchClassless:       mainConfig.ctrlConfig.WatchClassless,
-			ParentIDTemplate:     mainConfig.parentIDTemplate,
-			Reconcile:            mainConfig.reconcile,
-			EntryIDPrefix:        mainConfig.ctrlConfig.EntryIDPrefix,
-			EntryIDPrefixCleanup: mainConfig.ctrlConfig.EntryIDPrefixCleanup,
-		})
+	var traceHandler http.Handler
+	if mainConfig.reconcile.ClusterSPIFFEIDs || mainConfig.reconcile.ClusterStaticEntries || mainConfig.reconcile.SPIFFEIDs {
+		entryReconciler, traceHandler = spireentry.ReconcilerWithTraceHandler(entryReconcilerConfig)
+	}
+	if mainConfig.reconcile.ClusterSPIFFEIDs && mainConfig.ctrlConfig.EnableTraceEndpoint {
+		if err := mgr.AddMetricsServerExtraHandler(traceEndpointPath, traceHandler); err != nil {
+			setupLog.Error(err, "unable to add trace endpoint")
+			return err
+		}
 	}
 
 	var federationRelationshipReconciler reconciler.Reconciler
@@ -354,6 +1040,15 @@ func run(mainConfig Config) (err error) {
 			GCInterval:        mainConfig.ctrlConfig.GCInterval,
 			ClassName:         mainConfig.ctrlConfig.ClassName,
 			WatchClassless:    mainConfig.ctrlConfig.WatchClassless,
+
+			FederatedBundleClient: spireClient,
+
+			ManagedTrustDomains:   mainConfig.managedTrustDomains,
+			MaxReconcileDuration:  mainConfig.maxReconcileDuration,
+			InitialReconcileDelay: mainConfig.initialReconcileDelay,
+
+			ReconcileCoalesceDelay: mainConfig.federationCoalesceDelay,
+			DryRun:                 mainConfig.ctrlConfig.DryRun,
 		})
 		if err = (&controller.ClusterFederatedTrustDomainReconciler{
 			Client:    mgr.GetClient(),
@@ -385,19 +1080,44 @@ func run(mainConfig Config) (err error) {
 			return err
 		}
 	}
+	if mainConfig.reconcile.SPIFFEIDs {
+		if err = (&controller.SPIFFEIDReconciler{
+			Client:    mgr.GetClient(),
+			Scheme:    mgr.GetScheme(),
+			Triggerer: entryReconciler,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "SPIFFEID")
+			return err
+		}
+	}
 	if webhookEnabled {
-		if err = (&spirev1alpha1.ClusterFederatedTrustDomain{}).SetupWebhookWithManager(mgr); err != nil {
+		if err = (&spirev1alpha1.ClusterFederatedTrustDomainValidator{
+			BundleEndpointReachabilityTimeout: mainConfig.bundleEndpointTimeout,
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "ClusterFederatedTrustDomain")
 			return err
 		}
-		if err = (&spirev1alpha1.ClusterSPIFFEID{}).SetupWebhookWithManager(mgr); err != nil {
+		clusterSPIFFEIDValidator := &spirev1alpha1.ClusterSPIFFEIDValidator{
+			MaxJWTTTL: mainConfig.maxJWTSVIDTTL,
+		}
+		if mainConfig.minTTLPolicy != nil {
+			clusterSPIFFEIDValidator.MinTTL = mainConfig.minTTLPolicy.MinTTL
+			clusterSPIFFEIDValidator.RejectBelowMinTTL = mainConfig.minTTLPolicy.Action == spireentry.MinTTLActionReject
+		}
+		if err = clusterSPIFFEIDValidator.SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "ClusterSPIFFEID")
 			return err
 		}
+		if mainConfig.reconcile.SPIFFEIDs {
+			if err = (&spirev1alpha1.SPIFFEID{}).SetupWebhookWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create webhook", "webhook", "SPIFFEID")
+				return err
+			}
+		}
 	}
 	//+kubebuilder:scaffold:builder
 
-	if mainConfig.reconcile.ClusterSPIFFEIDs {
+	if mainConfig.reconcile.ClusterSPIFFEIDs || mainConfig.reconcile.SPIFFEIDs {
 		if err = (&controller.PodReconciler{
 			Client:           mgr.GetClient(),
 			Scheme:           mgr.GetScheme(),
@@ -416,6 +1136,18 @@ func run(mainConfig Config) (err error) {
 			setupLog.Error(err, "unable to create controller", "controller", "Endpoints")
 			return err
 		}
+		if mainConfig.newNamespaceGracePeriod > 0 {
+			if err = (&controller.NamespaceReconciler{
+				Client:           mgr.GetClient(),
+				Scheme:           mgr.GetScheme(),
+				Triggerer:        entryReconciler,
+				IgnoreNamespaces: mainConfig.ignoreNamespacesRegex,
+				GracePeriod:      mainConfig.newNamespaceGracePeriod,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "Namespace")
+				return err
+			}
+		}
 	}
 
 	if entryReconciler != nil {
@@ -432,6 +1164,32 @@ func run(mainConfig Config) (err error) {
 		}
 	}
 
+	if controllerSVIDSource != nil {
+		if err = mgr.Add(manager.RunnableFunc(controllerSVIDSource.Start)); err != nil {
+			setupLog.Error(err, "unable to manage controller SVID source")
+			return err
+		}
+	}
+
+	if mainConfig.ctrlConfig.ClockSkewCheck != nil {
+		// The SPIRE server clock is read from X509-SVIDs minted for the
+		// controller SPIFFE ID.
+		clockSkewIDPath := defaultControllerSVIDPath
+		if controllerSVID := mainConfig.ctrlConfig.ControllerSVID; controllerSVID != nil {
+			clockSkewIDPath = controllerSVID.Path
+		}
+		clockSkewChecker := clockskew.New(clockskew.Config{
+			ID:         spiffeid.RequireFromPath(trustDomain, clockSkewIDPath),
+			Threshold:  mainConfig.clockSkewThreshold,
+			Interval:   mainConfig.clockSkewInterval,
+			SVIDClient: spireClient,
+		})
+		if err = mgr.Add(manager.RunnableFunc(clockSkewChecker.Start)); err != nil {
+			setupLog.Error(err, "unable to manage clock skew checker")
+			return err
+		}
+	}
+
 	if webhookRunnable != nil {
 		if err = mgr.Add(webhookRunnable); err != nil {
 			setupLog.Error(err, "unable to manage federation relationship reconciler")
@@ -447,6 +1205,13 @@ func run(mainConfig Config) (err error) {
 		return err
 	}
 
+	if mainConfig.reconcile.ClusterSPIFFEIDs && mainConfig.ctrlConfig.ValidateTrustDomainsAtStartup {
+		// The cache is not started yet, so read through the API server.
+		if _, err := spireentry.ValidateTrustDomains(ctrl.LoggerInto(ctx, setupLog), entryReconcilerConfig, mgr.GetAPIReader()); err != nil {
+			setupLog.Error(err, "unable to validate ClusterSPIFFEID trust domains")
+		}
+	}
+
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
@@ -456,6 +1221,21 @@ func run(mainConfig Config) (err error) {
 	return nil
 }
 
+// newWebhookManagerClients returns the clients the webhook manager uses to
+// patch the webhook configuration and, if validateServices is set, to look up
+// the webhook services.
+func newWebhookManagerClients(restConfig *rest.Config, validateServices bool) (admissionregistrationv1client.ValidatingWebhookConfigurationInterface, corev1client.ServicesGetter, error) {
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	var serviceClient corev1client.ServicesGetter
+	if validateServices {
+		serviceClient = clientset.CoreV1()
+	}
+	return clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations(), serviceClient, nil
+}
+
 func autoDetectClusterDomain() (string, error) {
 	cname, err := net.LookupCNAME(k8sDefaultService)
 	if err != nil {
@@ -25,8 +25,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 // EndpointReconciler reconciles a Pod object
@@ -61,6 +64,54 @@ func (r *EndpointsReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 // SetupWithManager sets up the controller with the Manager.
 func (r *EndpointsReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Endpoints{}).
+		For(&corev1.Endpoints{}, builder.WithPredicates(r.predicate())).
 		Complete(r)
 }
+
+// predicate filters out changes to endpoints that can't affect any entry,
+// i.e. those in ignored namespaces or that aren't backed by pods (e.g.
+// external IPs), since only pod-backed endpoints contribute DNS names.
+func (r *EndpointsReconciler) predicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return r.isRelevant(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			// Pod-backed addresses going away are as relevant as new ones.
+			return r.isRelevant(e.ObjectOld) || r.isRelevant(e.ObjectNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return r.isRelevant(e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return r.isRelevant(e.Object)
+		},
+	}
+}
+
+func (r *EndpointsReconciler) isRelevant(obj client.Object) bool {
+	if namespace.IsIgnored(r.IgnoreNamespaces, obj.GetNamespace()) {
+		return false
+	}
+	endpoints, ok := obj.(*corev1.Endpoints)
+	if !ok {
+		return false
+	}
+	return hasPodTargetRef(endpoints)
+}
+
+func hasPodTargetRef(endpoints *corev1.Endpoints) bool {
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
+				return true
+			}
+		}
+		for _, address := range subset.NotReadyAddresses {
+			if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestEndpointsPredicate(t *testing.T) {
+	newEndpoints := func(namespace string, addresses, notReadyAddresses []corev1.EndpointAddress) *corev1.Endpoints {
+		return &corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "svc"},
+			Subsets: []corev1.EndpointSubset{
+				{Addresses: addresses, NotReadyAddresses: notReadyAddresses},
+			},
+		}
+	}
+	podAddress := corev1.EndpointAddress{
+		IP:        "10.0.0.1",
+		TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "app", UID: "app-uid"},
+	}
+	externalAddress := corev1.EndpointAddress{IP: "192.0.2.1"}
+	nodeAddress := corev1.EndpointAddress{
+		IP:        "10.0.0.2",
+		TargetRef: &corev1.ObjectReference{Kind: "Node", Name: "node"},
+	}
+
+	podBacked := newEndpoints("default", []corev1.EndpointAddress{podAddress}, nil)
+	notReadyPodBacked := newEndpoints("default", nil, []corev1.EndpointAddress{podAddress})
+	externalBacked := newEndpoints("default", []corev1.EndpointAddress{externalAddress, nodeAddress}, nil)
+	ignoredPodBacked := newEndpoints("kube-system", []corev1.EndpointAddress{podAddress}, nil)
+
+	p := (&EndpointsReconciler{
+		IgnoreNamespaces: []*regexp.Regexp{regexp.MustCompile("^kube-system$")},
+	}).predicate()
+
+	t.Run("create", func(t *testing.T) {
+		require.True(t, p.Create(event.CreateEvent{Object: podBacked}))
+		require.True(t, p.Create(event.CreateEvent{Object: notReadyPodBacked}))
+		require.False(t, p.Create(event.CreateEvent{Object: externalBacked}))
+		require.False(t, p.Create(event.CreateEvent{Object: ignoredPodBacked}))
+	})
+
+	t.Run("update", func(t *testing.T) {
+		require.True(t, p.Update(event.UpdateEvent{ObjectOld: externalBacked, ObjectNew: podBacked}))
+		require.True(t, p.Update(event.UpdateEvent{ObjectOld: podBacked, ObjectNew: externalBacked}))
+		require.False(t, p.Update(event.UpdateEvent{ObjectOld: externalBacked, ObjectNew: externalBacked}))
+		require.False(t, p.Update(event.UpdateEvent{ObjectOld: ignoredPodBacked, ObjectNew: ignoredPodBacked}))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.True(t, p.Delete(event.DeleteEvent{Object: podBacked}))
+		require.False(t, p.Delete(event.DeleteEvent{Object: externalBacked}))
+	})
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/pkg/namespace"
+	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// NamespaceReconciler reconciles a Namespace object. When a namespace is
+// created, it triggers entry reconciliation right away and again once the
+// grace period has passed, since pods created along with the namespace may
+// not be in the pod cache yet when the first pass lists them.
+type NamespaceReconciler struct {
+	client.Client
+	Scheme           *runtime.Scheme
+	Triggerer        reconciler.Triggerer
+	IgnoreNamespaces []*regexp.Regexp
+	GracePeriod      time.Duration
+
+	// Clock defaults to the real clock.
+	Clock clock.PassiveClock
+}
+
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *NamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	if namespace.IsIgnored(r.IgnoreNamespaces, req.Name) {
+		return ctrl.Result{}, nil
+	}
+
+	ns := new(corev1.Namespace)
+	if err := r.Get(ctx, req.NamespacedName, ns); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	log.FromContext(ctx).V(1).Info("Triggering reconciliation")
+	r.Triggerer.Trigger()
+
+	// Requeue until the grace period has passed, to trigger once more.
+	clk := r.Clock
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+	if remaining := ns.CreationTimestamp.Add(r.GracePeriod).Sub(clk.Now()); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Namespace{}, builder.WithPredicates(r.predicate())).
+		Complete(r)
+}
+
+// predicate only lets through the creation of namespaces that aren't
+// ignored. Namespaces listed when the controller starts are reported as
+// created too, but are past their grace period, so they only trigger once.
+func (r *NamespaceReconciler) predicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return !namespace.IsIgnored(r.IgnoreNamespaces, e.Object.GetName())
+		},
+		UpdateFunc: func(event.UpdateEvent) bool {
+			return false
+		},
+		DeleteFunc: func(event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(event.GenericEvent) bool {
+			return false
+		},
+	}
+}
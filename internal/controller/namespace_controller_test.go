@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestNamespaceReconcilerRetriggersAfterGracePeriod(t *testing.T) {
+	clk := clocktesting.NewFakePassiveClock(time.Now().Truncate(time.Second))
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "new", CreationTimestamp: metav1.NewTime(clk.Now())},
+	}
+	triggerer := new(countingTriggerer)
+	r := &NamespaceReconciler{
+		Client:      fake.NewClientBuilder().WithObjects(ns).Build(),
+		Triggerer:   triggerer,
+		GracePeriod: 5 * time.Second,
+		Clock:       clk,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "new"}}
+
+	// The new namespace triggers right away and is requeued for when the
+	// grace period is over.
+	result, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, 1, triggerer.count)
+	require.Equal(t, 5*time.Second, result.RequeueAfter)
+
+	// Once it is over, it triggers again and is not requeued.
+	clk.SetTime(clk.Now().Add(5 * time.Second))
+	result, err = r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, 2, triggerer.count)
+	require.Zero(t, result.RequeueAfter)
+
+	// A namespace deleted in the meantime is not triggered for.
+	result, err = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "deleted"}})
+	require.NoError(t, err)
+	require.Equal(t, 2, triggerer.count)
+	require.Zero(t, result.RequeueAfter)
+}
+
+func TestNamespacePredicate(t *testing.T) {
+	newNamespace := func(name string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	p := (&NamespaceReconciler{
+		IgnoreNamespaces: []*regexp.Regexp{regexp.MustCompile("^kube-system$")},
+	}).predicate()
+
+	require.True(t, p.Create(event.CreateEvent{Object: newNamespace("default")}))
+	require.False(t, p.Create(event.CreateEvent{Object: newNamespace("kube-system")}))
+	require.False(t, p.Update(event.UpdateEvent{ObjectOld: newNamespace("default"), ObjectNew: newNamespace("default")}))
+	require.False(t, p.Delete(event.DeleteEvent{Object: newNamespace("default")}))
+}
+
+type countingTriggerer struct {
+	count int
+}
+
+func (t *countingTriggerer) Trigger() {
+	t.count++
+}
@@ -48,6 +48,8 @@ type PodReconciler struct {
 //+kubebuilder:rbac:groups="",resources=endpoints,verbs=get;list;watch
 // Required to patch webhook config with spire CA
 //+kubebuilder:rbac:groups="admissionregistration.k8s.io",resources=validatingwebhookconfigurations,verbs=get;list;patch;watch
+// Required to check that the webhook services exist
+//+kubebuilder:rbac:groups="",resources=services,verbs=get
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -0,0 +1,142 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clockskew
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	defaultThreshold = 30 * time.Second
+	defaultInterval  = 10 * time.Minute
+
+	// spireX509SVIDBackdate is how far the SPIRE server backdates the
+	// NotBefore of the X509-SVIDs it mints, to tolerate small skews.
+	spireX509SVIDBackdate = 10 * time.Second
+
+	// probeTTL is the TTL of the X509-SVIDs minted to read the SPIRE server
+	// clock. They are thrown away right away.
+	probeTTL = time.Minute
+)
+
+type Config struct {
+	// ID is the SPIFFE ID of the X509-SVIDs minted to read the SPIRE server
+	// clock.
+	ID spiffeid.ID
+
+	// Threshold is how far the clocks may drift apart before a warning is
+	// logged. Defaults to 30s.
+	Threshold time.Duration
+
+	// Interval is how often the clocks are compared. Defaults to 10m.
+	Interval time.Duration
+
+	SVIDClient spireapi.SVIDClient
+	Clock      clock.Clock
+}
+
+// Checker periodically estimates the skew between the controller clock and
+// the SPIRE server clock from the NotBefore of an X509-SVID minted by the
+// SPIRE server. The estimate is reported in the
+// spire_controller_manager_clock_skew_seconds metric, and a warning is logged when
+// it exceeds the threshold. It is a diagnostic aid only.
+type Checker struct {
+	config Config
+}
+
+func New(config Config) *Checker {
+	if config.Threshold <= 0 {
+		config.Threshold = defaultThreshold
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaultInterval
+	}
+	if config.Clock == nil {
+		config.Clock = clock.RealClock{}
+	}
+	return &Checker{
+		config: config,
+	}
+}
+
+// Start compares the clocks right away, then every interval until the
+// context is done.
+func (c *Checker) Start(ctx context.Context) error {
+	log := log.FromContext(ctx).WithName("clock-skew-checker")
+
+	timer := c.config.Clock.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		skew, err := c.Check(ctx)
+		switch {
+		case err != nil:
+			log.Error(err, "Failed to check clock skew with SPIRE server")
+		case skew.Abs() > c.config.Threshold:
+			log.Info("Clock skew with SPIRE server exceeds threshold; TTL and expiry related logic may misbehave", "skew", skew.String(), "threshold", c.config.Threshold.String())
+		default:
+			log.V(1).Info("Checked clock skew with SPIRE server", "skew", skew.String())
+		}
+		timer.Reset(c.config.Interval)
+	}
+}
+
+// Check estimates the skew between the controller clock and the SPIRE server
+// clock and reports it in the metric. The skew is positive when the SPIRE
+// server clock is ahead.
+func (c *Checker) Check(ctx context.Context) (time.Duration, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate X509-SVID private key: %w", err)
+	}
+
+	sentAt := c.config.Clock.Now()
+	svid, err := c.config.SVIDClient.MintX509SVID(ctx, spireapi.X509SVIDParams{
+		Key: key,
+		ID:  c.config.ID,
+		TTL: probeTTL,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to mint X509-SVID: %w", err)
+	}
+	receivedAt := c.config.Clock.Now()
+
+	// The SPIRE server minted the X509-SVID somewhere between the request
+	// being sent and the response being received.
+	spireNow := svid.CertChain[0].NotBefore.Add(spireX509SVIDBackdate)
+	controllerNow := sentAt.Add(receivedAt.Sub(sentAt) / 2)
+	skew := spireNow.Sub(controllerNow)
+
+	metrics.PromClockSkew.Set(skew.Seconds())
+	return skew, nil
+}
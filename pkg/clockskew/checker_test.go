@@ -0,0 +1,61 @@
+package clockskew_test
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/clockskew"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/stretchr/testify/require"
+	testclock "k8s.io/utils/clock/testing"
+)
+
+func TestCheck(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		skew time.Duration
+	}{
+		{name: "aligned"},
+		{name: "spire ahead", skew: 5 * time.Minute},
+		{name: "spire behind", skew: -5 * time.Minute},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := testclock.NewFakeClock(time.Now().Truncate(time.Second))
+			checker := clockskew.New(clockskew.Config{
+				ID:         spiffeid.RequireFromString("spiffe://example.org/spire-controller-manager"),
+				SVIDClient: fakeSVIDClient{clock: clock, skew: tt.skew},
+				Clock:      clock,
+			})
+
+			skew, err := checker.Check(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tt.skew, skew)
+			require.Equal(t, tt.skew.Seconds(), testutil.ToFloat64(metrics.PromClockSkew))
+		})
+	}
+}
+
+// fakeSVIDClient mints X509-SVIDs like a SPIRE server whose clock is skewed
+// from the given clock.
+type fakeSVIDClient struct {
+	clock *testclock.FakeClock
+	skew  time.Duration
+}
+
+func (c fakeSVIDClient) MintX509SVID(_ context.Context, params spireapi.X509SVIDParams) (*spireapi.X509SVID, error) {
+	now := c.clock.Now().Add(c.skew)
+	return &spireapi.X509SVID{
+		ID:  params.ID,
+		Key: params.Key,
+		CertChain: []*x509.Certificate{{
+			NotBefore: now.Add(-10 * time.Second),
+			NotAfter:  now.Add(params.TTL),
+		}},
+		ExpiresAt: now.Add(params.TTL),
+	}, nil
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypolicy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+
+	// maxResponseSize bounds how much of the policy service response is read.
+	maxResponseSize = 4 << 20
+)
+
+// Client validates proposed entries against an external policy service.
+type Client interface {
+	// CheckEntries returns a decision for each of the given entries, in
+	// the same order.
+	CheckEntries(ctx context.Context, entries []spireapi.Entry) ([]Decision, error)
+}
+
+// Decision is the verdict of the policy service for a single entry.
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type Config struct {
+	// URL is the policy service endpoint that proposed entries are POSTed
+	// to. The request and response bodies follow the OPA data API
+	// conventions (i.e. {"input": ...} and {"result": ...}).
+	URL string
+
+	// Timeout bounds each call to the policy service. Defaults to 5s.
+	Timeout time.Duration
+
+	// HTTPClient is the client used to reach the policy service. Defaults
+	// to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+func NewClient(config Config) Client {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return &client{config: config}
+}
+
+type client struct {
+	config Config
+}
+
+func (c *client) CheckEntries(ctx context.Context, entries []spireapi.Entry) ([]Decision, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	input := checkInput{Entries: make([]entry, 0, len(entries))}
+	for _, e := range entries {
+		input.Entries = append(input.Entries, entryFromAPI(e))
+	}
+	body, err := json.Marshal(checkRequest{Input: input})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal policy request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create policy request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach policy service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected policy service response status: %s", resp.Status)
+	}
+
+	var out checkResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode policy response: %w", err)
+	}
+	if len(out.Result) != len(entries) {
+		return nil, fmt.Errorf("policy service returned %d decisions for %d entries", len(out.Result), len(entries))
+	}
+	return out.Result, nil
+}
+
+type checkRequest struct {
+	Input checkInput `json:"input"`
+}
+
+type checkInput struct {
+	Entries []entry `json:"entries"`
+}
+
+type checkResponse struct {
+	Result []Decision `json:"result"`
+}
+
+type entry struct {
+	ID            string   `json:"id,omitempty"`
+	SPIFFEID      string   `json:"spiffeID"`
+	ParentID      string   `json:"parentID"`
+	Selectors     []string `json:"selectors"`
+	X509SVIDTTL   int64    `json:"x509SVIDTTL,omitempty"`
+	JWTSVIDTTL    int64    `json:"jwtSVIDTTL,omitempty"`
+	FederatesWith []string `json:"federatesWith,omitempty"`
+	DNSNames      []string `json:"dnsNames,omitempty"`
+	Admin         bool     `json:"admin,omitempty"`
+	Downstream    bool     `json:"downstream,omitempty"`
+	Hint          string   `json:"hint,omitempty"`
+	StoreSVID     bool     `json:"storeSVID,omitempty"`
+}
+
+func entryFromAPI(in spireapi.Entry) entry {
+	out := entry{
+		ID:          in.ID,
+		SPIFFEID:    in.SPIFFEID.String(),
+		ParentID:    in.ParentID.String(),
+		Selectors:   make([]string, 0, len(in.Selectors)),
+		X509SVIDTTL: int64(in.X509SVIDTTL / time.Second),
+		JWTSVIDTTL:  int64(in.JWTSVIDTTL / time.Second),
+		DNSNames:    in.DNSNames,
+		Admin:       in.Admin,
+		Downstream:  in.Downstream,
+		Hint:        in.Hint,
+		StoreSVID:   in.StoreSVID,
+	}
+	for _, selector := range in.Selectors {
+		out.Selectors = append(out.Selectors, selector.Type+":"+selector.Value)
+	}
+	for _, td := range in.FederatesWith {
+		out.FederatesWith = append(out.FederatesWith, td.String())
+	}
+	return out
+}
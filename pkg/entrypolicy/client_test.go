@@ -0,0 +1,118 @@
+package entrypolicy_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/entrypolicy"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckEntries(t *testing.T) {
+	entries := []spireapi.Entry{
+		{
+			SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/workload"),
+			ParentID:  spiffeid.RequireFromString("spiffe://example.org/node"),
+			Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:workload"}},
+		},
+		{
+			SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/admin"),
+			ParentID:  spiffeid.RequireFromString("spiffe://example.org/node"),
+			Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:admin"}},
+			Admin:     true,
+		},
+	}
+
+	for _, tt := range []struct {
+		desc            string
+		handler         http.HandlerFunc
+		expectDecisions []entrypolicy.Decision
+		expectErr       string
+	}{
+		{
+			desc: "approves and rejects entries",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				var req struct {
+					Input struct {
+						Entries []struct {
+							SPIFFEID  string   `json:"spiffeID"`
+							Selectors []string `json:"selectors"`
+							Admin     bool     `json:"admin"`
+						} `json:"entries"`
+					} `json:"input"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				var result []entrypolicy.Decision
+				for _, entry := range req.Input.Entries {
+					if entry.Admin {
+						result = append(result, entrypolicy.Decision{Reason: "admin not allowed for " + entry.SPIFFEID})
+					} else {
+						result = append(result, entrypolicy.Decision{Allowed: true})
+					}
+				}
+				_ = json.NewEncoder(w).Encode(map[string]any{"result": result})
+			},
+			expectDecisions: []entrypolicy.Decision{
+				{Allowed: true},
+				{Reason: "admin not allowed for spiffe://example.org/admin"},
+			},
+		},
+		{
+			desc: "non-200 status",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				http.Error(w, "oh no", http.StatusInternalServerError)
+			},
+			expectErr: "unexpected policy service response status: 500 Internal Server Error",
+		},
+		{
+			desc: "mismatched decision count",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`{"result":[{"allowed":true}]}`))
+			},
+			expectErr: "policy service returned 1 decisions for 2 entries",
+		},
+		{
+			desc: "malformed response",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`{`))
+			},
+			expectErr: "failed to decode policy response: unexpected EOF",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			t.Cleanup(server.Close)
+
+			client := entrypolicy.NewClient(entrypolicy.Config{URL: server.URL})
+			decisions, err := client.CheckEntries(context.Background(), entries)
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectDecisions, decisions)
+		})
+	}
+}
+
+func TestCheckEntriesTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-done
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(done) })
+
+	client := entrypolicy.NewClient(entrypolicy.Config{URL: server.URL, Timeout: 10 * time.Millisecond})
+	_, err := client.CheckEntries(context.Background(), nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -41,6 +41,14 @@ func ListClusterSPIFFEIDs(ctx context.Context, c client.Client) ([]spirev1alpha1
 	return list.Items, nil
 }
 
+func ListSPIFFEIDs(ctx context.Context, c client.Client) ([]spirev1alpha1.SPIFFEID, error) {
+	var list spirev1alpha1.SPIFFEIDList
+	if err := c.List(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
 func ListClusterFederatedTrustDomains(ctx context.Context, c client.Client) ([]spirev1alpha1.ClusterFederatedTrustDomain, error) {
 	var list spirev1alpha1.ClusterFederatedTrustDomainList
 	if err := c.List(ctx, &list); err != nil {
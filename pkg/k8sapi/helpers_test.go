@@ -48,6 +48,33 @@ func TestListClusterSPIFFEIDs(t *testing.T) {
 	})
 }
 
+func TestListSPIFFEIDs(t *testing.T) {
+	foo := spirev1alpha1.SPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "foo"},
+	}
+
+	t.Run("list fails", func(t *testing.T) {
+		client := FailList(k8stest.NewClientBuilder(t).Build())
+		actual, err := k8sapi.ListSPIFFEIDs(context.Background(), client)
+		assert.EqualError(t, err, errList.Error())
+		assert.Empty(t, actual)
+	})
+
+	t.Run("list empty", func(t *testing.T) {
+		client := k8stest.NewClientBuilder(t).Build()
+		actual, err := k8sapi.ListSPIFFEIDs(context.Background(), client)
+		assert.NoError(t, err)
+		assert.Empty(t, actual)
+	})
+
+	t.Run("list not empty", func(t *testing.T) {
+		client := k8stest.NewClientBuilder(t).WithRuntimeObjects(&foo).Build()
+		actual, err := k8sapi.ListSPIFFEIDs(context.Background(), client)
+		assert.NoError(t, err)
+		assert.Equal(t, []spirev1alpha1.SPIFFEID{foo}, actual)
+	})
+}
+
 func TestListClusterFederatedTrustDomains(t *testing.T) {
 	foo := spirev1alpha1.ClusterFederatedTrustDomain{
 		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
@@ -1,9 +1,54 @@
 package metrics
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
 
 const (
 	StaticEntryFailures = "cluster_static_entry_failures"
+	DuplicateEntries    = "spire_controller_manager_duplicate_entries_total"
+
+	EntryPolicyRejections = "spire_controller_manager_entry_policy_rejections_total"
+	EntryPolicyFailures   = "spire_controller_manager_entry_policy_failures_total"
+
+	TamperedEntries = "spire_controller_manager_tampered_entries_total"
+
+	DNSNameConflicts = "spire_controller_manager_dns_name_conflicts_total"
+
+	EntryFailureBackoffs = "spire_controller_manager_entry_failure_backoffs_total"
+
+	WebhookServicesMissing = "spire_controller_manager_webhook_services_missing_total"
+
+	EntriesCreated = "spire_controller_manager_entries_created_total"
+	EntriesUpdated = "spire_controller_manager_entries_updated_total"
+	EntriesDeleted = "spire_controller_manager_entries_deleted_total"
+
+	EntriesByNamespace = "spire_controller_manager_entries_by_namespace"
+
+	ManagedEntries = "spire_controller_manager_managed_entries"
+
+	ReconcilesAborted = "spire_controller_manager_reconciles_aborted_total"
+
+	SPIREWriteDuration = "spire_controller_manager_spire_write_duration_seconds"
+
+	StaticEntryRenderFailures = "spire_controller_manager_static_entry_render_failures_total"
+
+	ClockSkew = "spire_controller_manager_clock_skew_seconds"
+)
+
+// Operations of SPIREWriteDuration.
+const (
+	OperationCreateEntries                 = "create_entries"
+	OperationUpdateEntries                 = "update_entries"
+	OperationDeleteEntries                 = "delete_entries"
+	OperationCreateFederationRelationships = "create_federation_relationships"
+	OperationUpdateFederationRelationships = "update_federation_relationships"
+	OperationDeleteFederationRelationships = "delete_federation_relationships"
+	OperationSetFederatedBundles           = "set_federated_bundles"
+	OperationDeleteFederatedBundles        = "delete_federated_bundles"
 )
 
 var (
@@ -14,5 +59,157 @@ var (
 				Help: "Number of cluster static entry render failures",
 			},
 		),
+		DuplicateEntries: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: DuplicateEntries,
+				Help: "Number of duplicate SPIRE entries found when listing entries",
+			},
+		),
+		EntryPolicyRejections: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: EntryPolicyRejections,
+				Help: "Number of entries rejected by the entry policy service",
+			},
+		),
+		EntryPolicyFailures: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: EntryPolicyFailures,
+				Help: "Number of failed calls to the entry policy service",
+			},
+		),
+		TamperedEntries: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: TamperedEntries,
+				Help: "Number of SPIRE entries found modified outside of the controller",
+			},
+		),
+		DNSNameConflicts: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: DNSNameConflicts,
+				Help: "Number of DNS names found declared on entries with different SPIFFE IDs",
+			},
+		),
+		EntryFailureBackoffs: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: EntryFailureBackoffs,
+				Help: "Number of times an entry that kept failing to be created or updated was backed off",
+			},
+		),
+		WebhookServicesMissing: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: WebhookServicesMissing,
+				Help: "Number of times the webhook configuration was found referencing services that do not exist",
+			},
+		),
+		EntriesCreated: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: EntriesCreated,
+				Help: "Number of SPIRE entries created",
+			},
+		),
+		EntriesUpdated: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: EntriesUpdated,
+				Help: "Number of SPIRE entries updated",
+			},
+		),
+		EntriesDeleted: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: EntriesDeleted,
+				Help: "Number of SPIRE entries deleted",
+			},
+		),
 	}
+
+	// PromEntriesByNamespace is the number of entries declared for pods in
+	// each namespace.
+	PromEntriesByNamespace = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: EntriesByNamespace,
+			Help: "Number of SPIRE entries declared for pods in each namespace",
+		},
+		[]string{"namespace"},
+	)
+
+	// PromManagedEntries is the number of SPIRE entries managed by the
+	// controller, as of the last listing, by trust domain.
+	PromManagedEntries = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: ManagedEntries,
+			Help: "Number of SPIRE entries managed by the controller, as of the last listing",
+		},
+		[]string{"trust_domain"},
+	)
+	// PromReconcilesAborted is the number of reconciliations aborted for
+	// exceeding the maximum duration, by kind of reconciler.
+	PromReconcilesAborted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: ReconcilesAborted,
+			Help: "Number of reconciliations aborted for exceeding the maximum duration",
+		},
+		[]string{"kind"},
+	)
+
+	// PromSPIREWriteDuration is the duration of the SPIRE server RPCs
+	// writing entries and federation relationships, by operation.
+	PromSPIREWriteDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    SPIREWriteDuration,
+			Help:    "Duration of the SPIRE server calls creating, updating and deleting entries and federation relationships",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"operation"},
+	)
+
+	// PromStaticEntryRenderFailures is the number of ClusterStaticEntry
+	// render failures, by the field that failed to render.
+	PromStaticEntryRenderFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: StaticEntryRenderFailures,
+			Help: "Number of cluster static entry render failures by field",
+		},
+		[]string{"field"},
+	)
+
+	// PromClockSkew is the estimated offset of the SPIRE server clock from
+	// the controller clock. It is positive when the SPIRE server clock is
+	// ahead.
+	PromClockSkew = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: ClockSkew,
+			Help: "Estimated offset of the SPIRE server clock from the controller clock",
+		},
+	)
 )
+
+// Register registers the controller metrics with the given registerer.
+// Metrics that are already registered with it are left as is, so it is safe
+// to call more than once against the same registerer.
+func Register(reg prometheus.Registerer) error {
+	for name, counter := range PromCounters {
+		if err := reg.Register(counter); err != nil {
+			var alreadyRegistered prometheus.AlreadyRegisteredError
+			if errors.As(err, &alreadyRegistered) {
+				continue
+			}
+			return fmt.Errorf("failed to register %q metric: %w", name, err)
+		}
+	}
+	for name, collector := range map[string]prometheus.Collector{
+		EntriesByNamespace:        PromEntriesByNamespace,
+		ManagedEntries:            PromManagedEntries,
+		ReconcilesAborted:         PromReconcilesAborted,
+		SPIREWriteDuration:        PromSPIREWriteDuration,
+		StaticEntryRenderFailures: PromStaticEntryRenderFailures,
+		ClockSkew:                 PromClockSkew,
+	} {
+		if err := reg.Register(collector); err != nil {
+			var alreadyRegistered prometheus.AlreadyRegisteredError
+			if errors.As(err, &alreadyRegistered) {
+				continue
+			}
+			return fmt.Errorf("failed to register %q metric: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	require.NoError(t, metrics.Register(reg))
+
+	// Registering again against the same registerer is a no-op.
+	require.NoError(t, metrics.Register(reg))
+
+	metrics.PromCounters[metrics.DuplicateEntries].Add(2)
+
+	count, err := testutil.GatherAndCount(reg)
+	require.NoError(t, err)
+	// Vectors without children are not gathered, unlike the clock skew gauge.
+	require.Equal(t, len(metrics.PromCounters)+1, count)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP spire_controller_manager_duplicate_entries_total Number of duplicate SPIRE entries found when listing entries
+# TYPE spire_controller_manager_duplicate_entries_total counter
+spire_controller_manager_duplicate_entries_total 2
+`), metrics.DuplicateEntries))
+}
+
+func TestRegisterConflict(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: metrics.DuplicateEntries,
+		Help: "Something else entirely",
+	}))
+	require.ErrorContains(t, metrics.Register(reg), `failed to register "spire_controller_manager_duplicate_entries_total" metric`)
+}
+
+func TestMetricNames(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	require.NoError(t, metrics.Register(reg))
+	// Give the vectors a child so that they are gathered.
+	t.Cleanup(func() {
+		metrics.PromEntriesByNamespace.Reset()
+		metrics.PromManagedEntries.Reset()
+		metrics.PromReconcilesAborted.Reset()
+		metrics.PromSPIREWriteDuration.Reset()
+		metrics.PromStaticEntryRenderFailures.Reset()
+	})
+	metrics.PromEntriesByNamespace.WithLabelValues("default")
+	metrics.PromManagedEntries.WithLabelValues("example.org")
+	metrics.PromReconcilesAborted.WithLabelValues("entries")
+	metrics.PromSPIREWriteDuration.WithLabelValues(metrics.OperationCreateEntries)
+	metrics.PromStaticEntryRenderFailures.WithLabelValues("spiffeID")
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		name := family.GetName()
+		if name == metrics.StaticEntryFailures {
+			// Predates the prefix.
+			continue
+		}
+		require.True(t, strings.HasPrefix(name, "spire_controller_manager_"), "metric %q is missing the prefix", name)
+		if family.GetType().String() == "COUNTER" {
+			require.True(t, strings.HasSuffix(name, "_total"), "counter %q is missing the _total suffix", name)
+		}
+	}
+}
@@ -18,9 +18,11 @@ package reconciler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -41,6 +43,19 @@ type Config struct {
 	Reconcile  func(ctx context.Context)
 	GCInterval time.Duration
 	Clock      clock.Clock
+
+	// MaxDuration, if non-zero, is how long a single reconciliation may run
+	// before its context is canceled, so that the next one starts fresh.
+	MaxDuration time.Duration
+
+	// InitialDelay, if non-zero, is how long to wait before the first
+	// reconciliation. Otherwise it happens as soon as Run is called.
+	InitialDelay time.Duration
+
+	// CoalesceDelay, if non-zero, is how long to wait after a trigger before
+	// reconciling, so that a burst of triggers results in a single
+	// reconciliation.
+	CoalesceDelay time.Duration
 }
 
 func New(config Config) Reconciler {
@@ -48,22 +63,34 @@ func New(config Config) Reconciler {
 		config.Clock = clock.RealClock{}
 	}
 	return &reconciler{
-		kind:       config.Kind,
-		reconcile:  config.Reconcile,
-		gcInterval: config.GCInterval,
-		clock:      config.Clock,
-		triggerCh:  make(chan struct{}),
+		kind:          config.Kind,
+		reconcile:     config.Reconcile,
+		gcInterval:    config.GCInterval,
+		clock:         config.Clock,
+		maxDuration:   config.MaxDuration,
+		initialDelay:  config.InitialDelay,
+		coalesceDelay: config.CoalesceDelay,
+		// The trigger channel holds a single pending trigger. Every watcher
+		// triggering the reconciler sets the same pending trigger, so at
+		// most one reconciliation is queued no matter how many fire, and
+		// triggers that fire during a reconciliation are not lost.
+		triggerCh: make(chan struct{}, 1),
 	}
 }
 
 type reconciler struct {
-	kind       string
-	reconcile  func(ctx context.Context)
-	gcInterval time.Duration
-	clock      clock.Clock
-	triggerCh  chan struct{}
+	kind          string
+	reconcile     func(ctx context.Context)
+	gcInterval    time.Duration
+	clock         clock.Clock
+	maxDuration   time.Duration
+	initialDelay  time.Duration
+	coalesceDelay time.Duration
+	triggerCh     chan struct{}
 }
 
+// Trigger queues a reconciliation, unless one is already pending. It never
+// blocks.
 func (r *reconciler) Trigger() {
 	select {
 	case r.triggerCh <- struct{}{}:
@@ -80,10 +107,25 @@ func (r *reconciler) Run(ctx context.Context) error {
 	// is triggered before the loop is entered.
 	r.drain()
 
+	if r.initialDelay > 0 {
+		log.V(2).Info("Delaying initial reconciliation", "initialDelay", r.initialDelay.String())
+		delay := r.clock.NewTimer(r.initialDelay)
+		select {
+		case <-ctx.Done():
+			delay.Stop()
+			log.Info("Reconciliation canceled")
+			return ctx.Err()
+		case <-delay.C():
+		}
+		// Triggers during the delay are covered by the initial
+		// reconciliation.
+		r.drain()
+	}
+
 	var timer clock.Timer
 	for {
 		log.V(2).Info("Starting reconciliation")
-		r.reconcile(ctx)
+		r.reconcileOnce(ctx)
 		log.V(2).Info("Reconciliation finished")
 
 		log.V(2).Info("Waiting for next reconciliation")
@@ -102,11 +144,65 @@ func (r *reconciler) Run(ctx context.Context) error {
 		case <-timer.C():
 			log.V(2).Info("Performing periodic reconciliation")
 		case <-r.triggerCh:
+			if r.coalesceDelay > 0 {
+				// The periodic reconciliation is covered by the
+				// triggered one.
+				timer.Stop()
+				if err := r.coalesce(ctx); err != nil {
+					log.Info("Reconciliation canceled")
+					return err
+				}
+			}
 			log.V(2).Info("Performing triggered reconciliation")
 		}
 	}
 }
 
+// reconcileOnce runs a single reconciliation, canceling it if it runs for
+// longer than the maximum duration.
+func (r *reconciler) reconcileOnce(ctx context.Context) {
+	if r.maxDuration <= 0 {
+		r.reconcile(ctx)
+		return
+	}
+
+	// The deadline is driven by the configured clock rather than by
+	// context.WithTimeout, which always uses wall time. The context is
+	// canceled with context.DeadlineExceeded as the cause.
+	reconcileCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	deadline := r.clock.NewTimer(r.maxDuration)
+	defer deadline.Stop()
+	go func() {
+		select {
+		case <-deadline.C():
+			cancel(context.DeadlineExceeded)
+		case <-reconcileCtx.Done():
+		}
+	}()
+
+	r.reconcile(reconcileCtx)
+	if errors.Is(context.Cause(reconcileCtx), context.DeadlineExceeded) {
+		log.FromContext(ctx).Info("Reconciliation aborted after exceeding the maximum duration", "maxDuration", r.maxDuration.String())
+		metrics.PromReconcilesAborted.WithLabelValues(r.kind).Inc()
+	}
+}
+
+// coalesce waits out the coalesce delay. Triggers during the delay are covered
+// by the triggered reconciliation.
+func (r *reconciler) coalesce(ctx context.Context) error {
+	log.FromContext(ctx).V(2).Info("Coalescing triggers", "coalesceDelay", r.coalesceDelay.String())
+	delay := r.clock.NewTimer(r.coalesceDelay)
+	defer delay.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-delay.C():
+	}
+	r.drain()
+	return nil
+}
+
 func (r *reconciler) drain() {
 	select {
 	case <-r.triggerCh:
@@ -19,9 +19,13 @@ package reconciler_test
 import (
 	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
 	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -87,3 +91,230 @@ func TestReconciler(t *testing.T) {
 	t.Log("Wait until the trigger reconcile call")
 	require.Eventually(t, checkIfCalled, time.Minute, time.Millisecond*10)
 }
+
+func TestReconcilerCoalescesTriggers(t *testing.T) {
+	clock := new(testclock.FakeClock)
+
+	var passes atomic.Int32
+	firstPassStarted := make(chan struct{})
+	releaseFirstPass := make(chan struct{})
+	r := reconciler.New(reconciler.Config{
+		Kind: "test",
+		Reconcile: func(ctx context.Context) {
+			if passes.Add(1) == 1 {
+				close(firstPassStarted)
+				<-releaseFirstPass
+			}
+		},
+		GCInterval: time.Hour,
+		Clock:      clock,
+	})
+
+	errCh := make(chan error)
+	t.Cleanup(func() {
+		err := <-errCh
+		assert.True(t, errors.Is(err, context.Canceled), "expected canceled error; got %f", err)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		errCh <- r.Run(ctx)
+	}()
+
+	t.Log("Trigger many times concurrently while the first pass is in flight")
+	<-firstPassStarted
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				r.Trigger()
+			}
+		}()
+	}
+	wg.Wait()
+	close(releaseFirstPass)
+
+	t.Log("Wait until the coalesced pass is done and run is waiting")
+	require.Eventually(t, func() bool {
+		return passes.Load() == 2 && clock.HasWaiters()
+	}, time.Minute, time.Millisecond*10)
+	require.Never(t, func() bool {
+		return passes.Load() != 2
+	}, time.Millisecond*100, time.Millisecond*10)
+}
+
+func TestReconcilerAbortsAtMaxDuration(t *testing.T) {
+	clock := new(testclock.FakeClock)
+
+	// The first pass calls a SPIRE server that is slow to respond, while the
+	// next ones return right away.
+	var passes atomic.Int32
+	resultCh := make(chan error, 2)
+	slowSPIRE := func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-time.After(time.Minute):
+			return nil
+		}
+	}
+	r := reconciler.New(reconciler.Config{
+		Kind: "aborted",
+		Reconcile: func(ctx context.Context) {
+			if passes.Add(1) == 1 {
+				resultCh <- slowSPIRE(ctx)
+				return
+			}
+			resultCh <- context.Cause(ctx)
+		},
+		GCInterval:  time.Hour,
+		Clock:       clock,
+		MaxDuration: 50 * time.Millisecond,
+	})
+
+	errCh := make(chan error)
+	t.Cleanup(func() {
+		err := <-errCh
+		assert.True(t, errors.Is(err, context.Canceled), "expected canceled error; got %f", err)
+	})
+	aborted := metrics.PromReconcilesAborted.WithLabelValues("aborted")
+	abortedBefore := testutil.ToFloat64(aborted)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		errCh <- r.Run(ctx)
+	}()
+
+	t.Log("Wait until the slow pass is waiting on the deadline")
+	require.Eventually(t, clock.HasWaiters, time.Minute, time.Millisecond*10)
+	select {
+	case err := <-resultCh:
+		require.FailNow(t, "pass returned before the deadline", "err: %v", err)
+	default:
+	}
+
+	t.Log("Step the clock past the deadline")
+	clock.Step(50 * time.Millisecond)
+	select {
+	case err := <-resultCh:
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Minute):
+		require.FailNow(t, "timed out waiting for the pass to be aborted")
+	}
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(aborted) == abortedBefore+1
+	}, time.Minute, time.Millisecond*10)
+
+	t.Log("The next pass starts fresh")
+	require.Eventually(t, clock.HasWaiters, time.Minute, time.Millisecond*10)
+	r.Trigger()
+	require.NoError(t, <-resultCh)
+	require.Equal(t, abortedBefore+1, testutil.ToFloat64(aborted))
+}
+
+func TestReconcilerInitialDelay(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		initialDelay time.Duration
+	}{
+		{name: "immediate"},
+		{name: "delayed", initialDelay: time.Minute},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := new(testclock.FakeClock)
+
+			var passes atomic.Int32
+			r := reconciler.New(reconciler.Config{
+				Kind: "test",
+				Reconcile: func(ctx context.Context) {
+					passes.Add(1)
+				},
+				GCInterval:   time.Hour,
+				Clock:        clock,
+				InitialDelay: tt.initialDelay,
+			})
+
+			errCh := make(chan error)
+			t.Cleanup(func() {
+				err := <-errCh
+				assert.True(t, errors.Is(err, context.Canceled), "expected canceled error; got %f", err)
+			})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				errCh <- r.Run(ctx)
+			}()
+
+			if tt.initialDelay > 0 {
+				t.Log("Wait until run is waiting out the delay, triggering meanwhile")
+				require.Eventually(t, clock.HasWaiters, time.Minute, time.Millisecond*10)
+				r.Trigger()
+				require.Never(t, func() bool {
+					return passes.Load() != 0
+				}, time.Millisecond*100, time.Millisecond*10)
+				clock.Step(tt.initialDelay)
+			}
+
+			t.Log("Wait until the first pass is done without waiting for the GC interval")
+			require.Eventually(t, func() bool {
+				return passes.Load() == 1 && clock.HasWaiters()
+			}, time.Minute, time.Millisecond*10)
+			require.Never(t, func() bool {
+				return passes.Load() != 1
+			}, time.Millisecond*100, time.Millisecond*10)
+		})
+	}
+}
+
+func TestReconcilerCoalesceDelay(t *testing.T) {
+	clock := new(testclock.FakeClock)
+
+	var passes atomic.Int32
+	r := reconciler.New(reconciler.Config{
+		Kind: "test",
+		Reconcile: func(ctx context.Context) {
+			passes.Add(1)
+		},
+		GCInterval:    time.Hour,
+		Clock:         clock,
+		CoalesceDelay: time.Second,
+	})
+
+	errCh := make(chan error)
+	t.Cleanup(func() {
+		err := <-errCh
+		assert.True(t, errors.Is(err, context.Canceled), "expected canceled error; got %f", err)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		errCh <- r.Run(ctx)
+	}()
+
+	t.Log("Wait until the initial pass is done and run is waiting")
+	require.Eventually(t, func() bool {
+		return passes.Load() == 1 && clock.HasWaiters()
+	}, time.Minute, time.Millisecond*10)
+
+	t.Log("Trigger in bursts, with a pause shorter than the delay in between")
+	for i := 0; i < 10; i++ {
+		r.Trigger()
+	}
+	require.Never(t, func() bool {
+		return passes.Load() != 1
+	}, time.Millisecond*100, time.Millisecond*10)
+	for i := 0; i < 10; i++ {
+		r.Trigger()
+	}
+
+	t.Log("Wait until the delay elapses and the coalesced pass is done")
+	require.Eventually(t, func() bool {
+		clock.Step(time.Second)
+		return passes.Load() == 2
+	}, time.Minute, time.Millisecond*10)
+	require.Never(t, func() bool {
+		return passes.Load() != 2
+	}, time.Millisecond*100, time.Millisecond*10)
+}
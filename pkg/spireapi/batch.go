@@ -16,6 +16,13 @@ limitations under the License.
 
 package spireapi
 
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
 var (
 	// TODO: optimize batch/page sizes
 	// These batch sizes are vars so they can be adjusted during tests.
@@ -29,13 +36,23 @@ var (
 	federationRelationshipUpdateBatchSize = 50
 	federationRelationshipDeleteBatchSize = 200
 	federationRelationshipListPageSize    = 200
+
+	federatedBundleSetBatchSize    = 50
+	federatedBundleDeleteBatchSize = 200
+	federatedBundleListPageSize    = 200
 )
 
-func runBatch(size, batch int, fn func(start, end int) error) error {
+// runBatch calls fn for consecutive batches of up to batch items. Remaining
+// batches are abandoned if the context is canceled.
+func runBatch(ctx context.Context, size, batch int, fn func(start, end int) error) error {
 	if batch < 1 {
 		batch = size
 	}
 	for i := 0; i < size; {
+		if err := ctx.Err(); err != nil {
+			log.FromContext(ctx).Info("Abandoning remaining batches", "completed", i, "total", size)
+			return fmt.Errorf("batch aborted after %d of %d items: %w", i, size, err)
+		}
 		n := size - i
 		if n > batch {
 			n = batch
@@ -0,0 +1,38 @@
+package spireapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBatch(t *testing.T) {
+	type span struct{ start, end int }
+
+	t.Run("runs all batches", func(t *testing.T) {
+		var spans []span
+		err := runBatch(ctx, 5, 2, func(start, end int) error {
+			spans = append(spans, span{start, end})
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []span{{0, 2}, {2, 4}, {4, 5}}, spans)
+	})
+
+	t.Run("aborts remaining batches when canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var spans []span
+		err := runBatch(ctx, 5, 2, func(start, end int) error {
+			spans = append(spans, span{start, end})
+			cancel()
+			return nil
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		assert.EqualError(t, err, "batch aborted after 2 of 5 items: context canceled")
+		assert.Equal(t, []span{{0, 2}}, spans)
+	})
+}
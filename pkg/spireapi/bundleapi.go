@@ -21,7 +21,9 @@ import (
 	"fmt"
 
 	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	bundlev1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
+	apitypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
 	"google.golang.org/grpc"
 )
 
@@ -34,6 +36,19 @@ func NewBundleClient(conn grpc.ClientConnInterface) BundleClient {
 	return bundleClient{api: bundlev1.NewBundleClient(conn)}
 }
 
+// FederatedBundleClient manages the bundles of foreign trust domains that
+// are stored on the SPIRE server directly, without a federation
+// relationship to refresh them.
+type FederatedBundleClient interface {
+	ListFederatedBundles(ctx context.Context) ([]*spiffebundle.Bundle, error)
+	SetFederatedBundles(ctx context.Context, bundles []*spiffebundle.Bundle) ([]Status, error)
+	DeleteFederatedBundles(ctx context.Context, tds []spiffeid.TrustDomain) ([]Status, error)
+}
+
+func NewFederatedBundleClient(conn grpc.ClientConnInterface) FederatedBundleClient {
+	return bundleClient{api: bundlev1.NewBundleClient(conn)}
+}
+
 type bundleClient struct {
 	api bundlev1.BundleClient
 }
@@ -46,3 +61,63 @@ func (c bundleClient) GetBundle(ctx context.Context) (*spiffebundle.Bundle, erro
 
 	return bundleFromAPI(bundle)
 }
+
+func (c bundleClient) ListFederatedBundles(ctx context.Context) ([]*spiffebundle.Bundle, error) {
+	var bundles []*apitypes.Bundle
+	var pageToken string
+	for {
+		resp, err := c.api.ListFederatedBundles(ctx, &bundlev1.ListFederatedBundlesRequest{
+			PageToken: pageToken,
+			PageSize:  int32(federatedBundleListPageSize),
+		})
+		if err != nil {
+			return nil, err
+		}
+		bundles = append(bundles, resp.Bundles...)
+		pageToken = resp.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+	return bundlesFromAPI(bundles)
+}
+
+func (c bundleClient) SetFederatedBundles(ctx context.Context, bundles []*spiffebundle.Bundle) ([]Status, error) {
+	var statuses []Status
+	err := runBatch(ctx, len(bundles), federatedBundleSetBatchSize, func(start, end int) error {
+		toSet, err := bundlesToAPI(bundles[start:end])
+		if err != nil {
+			return err
+		}
+		resp, err := c.api.BatchSetFederatedBundle(ctx, &bundlev1.BatchSetFederatedBundleRequest{
+			Bundle: toSet,
+		})
+		if err == nil {
+			for _, result := range resp.Results {
+				statuses = append(statuses, statusFromAPI(result.Status))
+			}
+		}
+		return err
+	})
+	return statuses, err
+}
+
+// DeleteFederatedBundles deletes the bundles of the trust domains. SPIRE
+// refuses to delete a bundle that registration entries still federate
+// with.
+func (c bundleClient) DeleteFederatedBundles(ctx context.Context, tds []spiffeid.TrustDomain) ([]Status, error) {
+	var statuses []Status
+	err := runBatch(ctx, len(tds), federatedBundleDeleteBatchSize, func(start, end int) error {
+		resp, err := c.api.BatchDeleteFederatedBundle(ctx, &bundlev1.BatchDeleteFederatedBundleRequest{
+			TrustDomains: trustDomainsToAPI(tds[start:end]),
+			Mode:         bundlev1.BatchDeleteFederatedBundleRequest_RESTRICT,
+		})
+		if err == nil {
+			for _, result := range resp.Results {
+				statuses = append(statuses, statusFromAPI(result.Status))
+			}
+		}
+		return err
+	})
+	return statuses, err
+}
@@ -2,10 +2,13 @@ package spireapi
 
 import (
 	"context"
+	"crypto"
+	"sort"
 	"sync"
 	"testing"
 
 	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	bundlev1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
 	apitypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
 	"github.com/stretchr/testify/assert"
@@ -58,19 +61,142 @@ func TestBundleAPIGetBundle(t *testing.T) {
 	}
 }
 
+func TestBundleAPIFederatedBundles(t *testing.T) {
+	server, conn := startBundleAPIServerConn(t)
+	client := NewFederatedBundleClient(conn)
+
+	// Exercise paging of the list.
+	oldPageSize := federatedBundleListPageSize
+	federatedBundleListPageSize = 2
+	t.Cleanup(func() { federatedBundleListPageSize = oldPageSize })
+
+	jwtBundle := func(td spiffeid.TrustDomain, keyID string) *spiffebundle.Bundle {
+		return spiffebundle.FromJWTAuthorities(td, map[string]crypto.PublicKey{keyID: key.Public()})
+	}
+	bundles := []*spiffebundle.Bundle{jwtBundle(domain1, "kid1"), jwtBundle(domain2, "kid2"), jwtBundle(domain3, "kid3")}
+
+	statuses, err := client.SetFederatedBundles(ctx, bundles)
+	require.NoError(t, err)
+	require.Equal(t, []Status{{Code: codes.OK}, {Code: codes.OK}, {Code: codes.OK}}, statuses)
+
+	actual, err := client.ListFederatedBundles(ctx)
+	require.NoError(t, err)
+	require.Len(t, actual, len(bundles))
+	for i := range bundles {
+		assert.True(t, bundles[i].Equal(actual[i]), "bundle %d", i)
+	}
+
+	// Setting a bundle replaces it.
+	statuses, err = client.SetFederatedBundles(ctx, []*spiffebundle.Bundle{jwtBundle(domain1, "rotated")})
+	require.NoError(t, err)
+	require.Equal(t, []Status{{Code: codes.OK}}, statuses)
+	actual, err = client.ListFederatedBundles(ctx)
+	require.NoError(t, err)
+	assert.True(t, jwtBundle(domain1, "rotated").Equal(actual[0]))
+
+	// Bundles that entries federate with are not deleted.
+	server.setInUse(domain2)
+	statuses, err = client.DeleteFederatedBundles(ctx, []spiffeid.TrustDomain{domain1, domain2})
+	require.NoError(t, err)
+	require.Equal(t, []Status{
+		{Code: codes.OK},
+		{Code: codes.FailedPrecondition, Message: `bundle "domain2" is federated with by entries`},
+	}, statuses)
+	actual, err = client.ListFederatedBundles(ctx)
+	require.NoError(t, err)
+	require.Len(t, actual, 2)
+	assert.Equal(t, domain2, actual[0].TrustDomain())
+	assert.Equal(t, domain3, actual[1].TrustDomain())
+}
+
 func startBundleAPIServer(t *testing.T) (*bundleServer, BundleClient) {
-	api := &bundleServer{}
+	api, conn := startBundleAPIServerConn(t)
+	return api, NewBundleClient(conn)
+}
+
+func startBundleAPIServerConn(t *testing.T) (*bundleServer, grpc.ClientConnInterface) {
+	api := &bundleServer{
+		federatedBundles: make(map[string]*apitypes.Bundle),
+		inUse:            make(map[string]bool),
+	}
 	conn := startServer(t, func(s *grpc.Server) {
 		bundlev1.RegisterBundleServer(s, api)
 	})
-	return api, NewBundleClient(conn)
+	return api, conn
 }
 
 type bundleServer struct {
 	bundlev1.UnimplementedBundleServer
 
-	mtx    sync.RWMutex
-	bundle *apitypes.Bundle
+	mtx              sync.RWMutex
+	bundle           *apitypes.Bundle
+	federatedBundles map[string]*apitypes.Bundle
+	inUse            map[string]bool
+}
+
+func (s *bundleServer) ListFederatedBundles(_ context.Context, req *bundlev1.ListFederatedBundlesRequest) (*bundlev1.ListFederatedBundlesResponse, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	tds := make([]string, 0, len(s.federatedBundles))
+	for td := range s.federatedBundles {
+		tds = append(tds, td)
+	}
+	sort.Strings(tds)
+
+	start, end, more := listBounds(req.PageToken, int(req.PageSize), len(tds), func(i int) string { return tds[i] })
+	resp := &bundlev1.ListFederatedBundlesResponse{}
+	for _, td := range tds[start:end] {
+		resp.Bundles = append(resp.Bundles, s.federatedBundles[td])
+	}
+	if more {
+		resp.NextPageToken = tds[end-1]
+	}
+	return resp, nil
+}
+
+func (s *bundleServer) BatchSetFederatedBundle(_ context.Context, req *bundlev1.BatchSetFederatedBundleRequest) (*bundlev1.BatchSetFederatedBundleResponse, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	resp := &bundlev1.BatchSetFederatedBundleResponse{}
+	for _, bundle := range req.Bundle {
+		s.federatedBundles[bundle.TrustDomain] = bundle
+		resp.Results = append(resp.Results, &bundlev1.BatchSetFederatedBundleResponse_Result{
+			Status: &apitypes.Status{},
+			Bundle: bundle,
+		})
+	}
+	return resp, nil
+}
+
+func (s *bundleServer) BatchDeleteFederatedBundle(_ context.Context, req *bundlev1.BatchDeleteFederatedBundleRequest) (*bundlev1.BatchDeleteFederatedBundleResponse, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	resp := &bundlev1.BatchDeleteFederatedBundleResponse{}
+	for _, td := range req.TrustDomains {
+		st := &apitypes.Status{}
+		switch {
+		case s.inUse[td] && req.Mode == bundlev1.BatchDeleteFederatedBundleRequest_RESTRICT:
+			st = &apitypes.Status{Code: int32(codes.FailedPrecondition), Message: "bundle \"" + td + "\" is federated with by entries"}
+		case s.federatedBundles[td] == nil:
+			st = &apitypes.Status{Code: int32(codes.NotFound), Message: "bundle not found"}
+		default:
+			delete(s.federatedBundles, td)
+		}
+		resp.Results = append(resp.Results, &bundlev1.BatchDeleteFederatedBundleResponse_Result{
+			Status:      st,
+			TrustDomain: td,
+		})
+	}
+	return resp, nil
+}
+
+func (s *bundleServer) setInUse(td spiffeid.TrustDomain) {
+	s.mtx.Lock()
+	s.inUse[td.Name()] = true
+	s.mtx.Unlock()
 }
 
 func (s *bundleServer) GetBundle(context.Context, *bundlev1.GetBundleRequest) (*apitypes.Bundle, error) {
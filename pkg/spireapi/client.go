@@ -17,12 +17,18 @@ limitations under the License.
 package spireapi
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 type Client interface {
@@ -30,20 +36,49 @@ type Client interface {
 	TrustDomainClient
 	SVIDClient
 	BundleClient
+	FederatedBundleClient
 	io.Closer
 }
 
+// DialConfig configures the connection to the SPIRE Server API socket.
+type DialConfig struct {
+	// SocketPath is the path to the SPIRE Server API socket.
+	SocketPath string
+
+	// RedialAfterFailures is how many consecutive RPCs have to fail with
+	// codes.Unavailable before the socket is re-dialed. The failing RPC is
+	// retried once on the new connection if it only reads (Get*, List* and
+	// Count* RPCs); others, which the server may already have applied, are
+	// not. This shortens the window where RPCs fail on a stale connection
+	// after a SPIRE Server restart. Zero disables re-dialing.
+	RedialAfterFailures int
+
+	// ListPageTimeout, if non-zero, is how long each page of a list of
+	// entries may take. If a page exceeds it, the list is aborted. Zero
+	// means pages are only bound by the context of the list.
+	ListPageTimeout time.Duration
+
+	// MaxListEntries, if non-zero, is the most entries a list of entries may
+	// return. If the pages fetched exceed it, e.g. because the server never
+	// stops paginating, the list is aborted.
+	MaxListEntries int
+}
+
 func DialSocket(path string) (Client, error) {
-	var target string
-	if filepath.IsAbs(path) {
-		target = "unix://" + path
-	} else {
-		target = "unix:" + path
-	}
+	return Dial(DialConfig{SocketPath: path})
+}
 
-	grpcClient, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func Dial(config DialConfig) (Client, error) {
+	target := socketTarget(config.SocketPath)
+
+	grpcClient, err := dialTarget(target)
 	if err != nil {
-		return nil, fmt.Errorf("failed to dial API socket: %w", err)
+		return nil, err
+	}
+
+	var conn clientConn = grpcClient
+	if config.RedialAfterFailures > 0 {
+		conn = newRedialingConn(target, func(target string) (clientConn, error) { return dialTarget(target) }, config.RedialAfterFailures, grpcClient)
 	}
 
 	return struct {
@@ -51,12 +86,182 @@ func DialSocket(path string) (Client, error) {
 		TrustDomainClient
 		SVIDClient
 		BundleClient
+		FederatedBundleClient
 		io.Closer
 	}{
-		EntryClient:       NewEntryClient(grpcClient),
-		TrustDomainClient: NewTrustDomainClient(grpcClient),
-		SVIDClient:        NewSVIDClient(grpcClient),
-		BundleClient:      NewBundleClient(grpcClient),
-		Closer:            grpcClient,
+		EntryClient:           newEntryClient(conn, config.ListPageTimeout, config.MaxListEntries),
+		TrustDomainClient:     NewTrustDomainClient(conn),
+		SVIDClient:            NewSVIDClient(conn),
+		BundleClient:          NewBundleClient(conn),
+		FederatedBundleClient: NewFederatedBundleClient(conn),
+		Closer:                conn,
 	}, nil
 }
+
+func socketTarget(path string) string {
+	if filepath.IsAbs(path) {
+		return "unix://" + path
+	}
+	return "unix:" + path
+}
+
+func dialTarget(target string) (*grpc.ClientConn, error) {
+	grpcClient, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial API socket: %w", err)
+	}
+	return grpcClient, nil
+}
+
+type clientConn interface {
+	grpc.ClientConnInterface
+	io.Closer
+}
+
+// redialingConn replaces the underlying connection once enough consecutive
+// RPCs have failed with codes.Unavailable, as happens while a connection to
+// a restarted SPIRE Server is stale, and retries the failing RPC once on the
+// new connection if it only reads. The replaced connection is closed once
+// the RPCs in flight on it are done.
+type redialingConn struct {
+	target      string
+	dial        func(target string) (clientConn, error)
+	redialAfter int
+
+	mu       sync.Mutex
+	conn     *trackedConn
+	failures int
+}
+
+// trackedConn is a connection along with the number of RPCs in flight on
+// it, so that it is only closed once they are done after being replaced.
+type trackedConn struct {
+	clientConn
+	inFlight int
+	replaced bool
+}
+
+func newRedialingConn(target string, dial func(target string) (clientConn, error), redialAfter int, conn clientConn) *redialingConn {
+	return &redialingConn{
+		target:      target,
+		dial:        dial,
+		redialAfter: redialAfter,
+		conn:        &trackedConn{clientConn: conn},
+	}
+}
+
+func (c *redialingConn) Invoke(ctx context.Context, method string, args any, reply any, opts ...grpc.CallOption) error {
+	conn := c.acquire()
+	err := conn.Invoke(ctx, method, args, reply, opts...)
+	redial := c.recordResult(err) && ctx.Err() == nil
+	if redial {
+		redial = c.redial(conn) == nil
+	}
+	c.release(conn)
+	// Calls that write may have been applied by the server before the
+	// connection failed, so only the ones that read are retried.
+	if !redial || !isReadMethod(method) {
+		return err
+	}
+	conn = c.acquire()
+	defer c.release(conn)
+	err = conn.Invoke(ctx, method, args, reply, opts...)
+	c.recordResult(err)
+	return err
+}
+
+func (c *redialingConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	conn := c.acquire()
+	stream, err := conn.NewStream(ctx, desc, method, opts...)
+	if err != nil {
+		c.release(conn)
+		return nil, err
+	}
+	return &trackedStream{ClientStream: stream, release: sync.OnceFunc(func() { c.release(conn) })}, nil
+}
+
+func (c *redialingConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.Close()
+}
+
+// acquire returns the current connection, counting an RPC in flight on it
+// until it is released.
+func (c *redialingConn) acquire() *trackedConn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.inFlight++
+	return c.conn
+}
+
+// release counts an RPC acquired on the connection as done, closing the
+// connection if it has been replaced and was the last RPC in flight on it.
+func (c *redialingConn) release(conn *trackedConn) {
+	c.mu.Lock()
+	conn.inFlight--
+	drained := conn.replaced && conn.inFlight == 0
+	c.mu.Unlock()
+	if drained {
+		_ = conn.Close()
+	}
+}
+
+// recordResult tracks the consecutive RPC failures and returns whether the
+// connection should be re-dialed.
+func (c *redialingConn) recordResult(err error) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if status.Code(err) != codes.Unavailable {
+		c.failures = 0
+		return false
+	}
+	c.failures++
+	return c.failures >= c.redialAfter
+}
+
+// redial replaces the connection, unless a concurrent RPC already replaced
+// the stale one. The stale connection is left for the RPCs in flight on it
+// to close.
+func (c *redialingConn) redial(stale *trackedConn) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != stale {
+		return nil
+	}
+	conn, err := c.dial(c.target)
+	if err != nil {
+		return err
+	}
+	stale.replaced = true
+	c.conn = &trackedConn{clientConn: conn}
+	c.failures = 0
+	return nil
+}
+
+// trackedStream releases the connection it was opened on once it ends,
+// i.e. once receiving from it fails, including with io.EOF.
+type trackedStream struct {
+	grpc.ClientStream
+	release func()
+}
+
+func (s *trackedStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.release()
+	}
+	return err
+}
+
+// isReadMethod returns whether the full gRPC method name is for an RPC that
+// only reads from the SPIRE Server, and is therefore safe to retry.
+func isReadMethod(method string) bool {
+	name := method[strings.LastIndex(method, "/")+1:]
+	for _, prefix := range []string{"Get", "List", "Count"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
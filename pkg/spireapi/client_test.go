@@ -0,0 +1,227 @@
+package spireapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRedialingConn(t *testing.T) {
+	server := &fakeServer{}
+	dials := 0
+	c := newRedialingConn("unix:///spire-server/api.sock", func(target string) (clientConn, error) {
+		dials++
+		return server.connect(), nil
+	}, 2, server.connect())
+
+	invoke := func() error {
+		return c.Invoke(ctx, "/test/ListThings", nil, nil)
+	}
+
+	t.Log("RPCs succeed on a healthy connection")
+	require.NoError(t, invoke())
+
+	t.Log("The server restarts, leaving the existing connection stale")
+	server.restart()
+
+	t.Log("The first failure is returned without re-dialing")
+	require.Equal(t, codes.Unavailable, status.Code(invoke()))
+	require.Equal(t, 0, dials)
+
+	t.Log("The second consecutive failure re-dials and retries once")
+	require.NoError(t, invoke())
+	require.Equal(t, 1, dials)
+
+	t.Log("RPCs keep using the new connection")
+	require.NoError(t, invoke())
+	require.Equal(t, 1, dials)
+}
+
+func TestRedialingConnResetsFailuresOnOtherResults(t *testing.T) {
+	server := &fakeServer{}
+	dials := 0
+	c := newRedialingConn("", func(target string) (clientConn, error) {
+		dials++
+		return server.connect(), nil
+	}, 2, server.connect())
+
+	server.setErr(status.Error(codes.Unavailable, "unavailable"))
+	require.Error(t, c.Invoke(ctx, "/test/ListThings", nil, nil))
+	server.setErr(status.Error(codes.NotFound, "not found"))
+	require.Error(t, c.Invoke(ctx, "/test/ListThings", nil, nil))
+	server.setErr(status.Error(codes.Unavailable, "unavailable"))
+	require.Error(t, c.Invoke(ctx, "/test/ListThings", nil, nil))
+	require.Equal(t, 0, dials)
+}
+
+func TestRedialingConnRetriesOnlyOnce(t *testing.T) {
+	server := &fakeServer{}
+	server.setErr(status.Error(codes.Unavailable, "unavailable"))
+	dials := 0
+	c := newRedialingConn("", func(target string) (clientConn, error) {
+		dials++
+		return server.connect(), nil
+	}, 1, server.connect())
+
+	require.Equal(t, codes.Unavailable, status.Code(c.Invoke(ctx, "/test/ListThings", nil, nil)))
+	require.Equal(t, 1, dials)
+	require.Equal(t, 2, server.invokes())
+}
+
+func TestRedialingConnDialFailure(t *testing.T) {
+	server := &fakeServer{}
+	server.setErr(status.Error(codes.Unavailable, "unavailable"))
+	c := newRedialingConn("", func(target string) (clientConn, error) {
+		return nil, errors.New("oh no")
+	}, 1, server.connect())
+
+	require.Equal(t, codes.Unavailable, status.Code(c.Invoke(ctx, "/test/ListThings", nil, nil)))
+}
+
+func TestRedialingConnDoesNotRetryWrites(t *testing.T) {
+	server := &fakeServer{}
+	dials := 0
+	c := newRedialingConn("", func(target string) (clientConn, error) {
+		dials++
+		return server.connect(), nil
+	}, 1, server.connect())
+
+	server.restart()
+
+	t.Log("A failing write re-dials without being retried")
+	require.Equal(t, codes.Unavailable, status.Code(c.Invoke(ctx, "/test/BatchCreateThings", nil, nil)))
+	require.Equal(t, 1, dials)
+	require.Equal(t, 1, server.invokes())
+
+	t.Log("The next write uses the new connection")
+	require.NoError(t, c.Invoke(ctx, "/test/BatchCreateThings", nil, nil))
+	require.Equal(t, 1, dials)
+}
+
+func TestRedialingConnClosesReplacedConnOnceDrained(t *testing.T) {
+	server := &fakeServer{}
+	stale := server.connect().(*fakeConn)
+	c := newRedialingConn("", func(target string) (clientConn, error) {
+		return server.connect(), nil
+	}, 1, stale)
+
+	t.Log("An RPC is in flight on the connection when the server restarts")
+	unblock := make(chan struct{})
+	stale.block = unblock
+	inFlight := make(chan error, 1)
+	go func() {
+		inFlight <- c.Invoke(ctx, "/test/ListThings", nil, nil)
+	}()
+	require.Eventually(t, func() bool { return server.invokes() == 1 }, time.Second, time.Millisecond)
+	server.restart()
+	stale.block = nil
+
+	t.Log("Another RPC fails and replaces the connection")
+	require.NoError(t, c.Invoke(ctx, "/test/ListThings", nil, nil))
+	require.False(t, stale.isClosed(), "connection closed while an RPC is in flight on it")
+
+	t.Log("The replaced connection is closed once the RPC in flight is done")
+	close(unblock)
+	<-inFlight
+	require.True(t, stale.isClosed())
+}
+
+func TestIsReadMethod(t *testing.T) {
+	for method, expected := range map[string]bool{
+		"/spire.api.server.entry.v1.Entry/ListEntries":                                   true,
+		"/spire.api.server.entry.v1.Entry/CountEntries":                                  true,
+		"/spire.api.server.bundle.v1.Bundle/GetBundle":                                   true,
+		"/spire.api.server.entry.v1.Entry/BatchCreateEntry":                              false,
+		"/spire.api.server.entry.v1.Entry/BatchDeleteEntry":                              false,
+		"/spire.api.server.trustdomain.v1.TrustDomain/BatchUpdateFederationRelationship": false,
+		"/spire.api.server.svid.v1.SVID/MintX509SVID":                                    false,
+	} {
+		require.Equal(t, expected, isReadMethod(method), method)
+	}
+}
+
+// fakeServer hands out fake connections. Restarting the server drops the
+// existing connections; new connections succeed.
+type fakeServer struct {
+	mu         sync.Mutex
+	generation int
+	err        error
+	invokeN    int
+}
+
+func (s *fakeServer) connect() clientConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &fakeConn{server: s, generation: s.generation}
+}
+
+func (s *fakeServer) restart() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generation++
+}
+
+func (s *fakeServer) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *fakeServer) invokes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.invokeN
+}
+
+type fakeConn struct {
+	server     *fakeServer
+	generation int
+	closed     bool
+
+	// block, if set, is waited on by the next RPC before it completes.
+	block chan struct{}
+}
+
+func (c *fakeConn) Invoke(context.Context, string, any, any, ...grpc.CallOption) error {
+	c.server.mu.Lock()
+	c.server.invokeN++
+	block := c.block
+	c.server.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+	if c.closed {
+		return status.Error(codes.Canceled, "connection is closing")
+	}
+	if c.generation != c.server.generation {
+		return status.Error(codes.Unavailable, "connection is stale")
+	}
+	return c.server.err
+}
+
+func (c *fakeConn) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *fakeConn) Close() error {
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *fakeConn) isClosed() bool {
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+	return c.closed
+}
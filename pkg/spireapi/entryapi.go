@@ -19,12 +19,16 @@ package spireapi
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	entryv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/entry/v1"
 	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
 	apitypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -41,34 +45,93 @@ const (
 
 type Field string
 
+// ParseField parses the name of an entry field whose support varies across
+// SPIRE server versions, i.e. one of the fields the unsupported fields probe
+// detects: "hint", "jwtSVIDTTL" or "storeSVID". Other entry fields are
+// supported by every SPIRE server the controller works with.
+func ParseField(name string) (Field, error) {
+	switch field := Field(name); field {
+	case HintField, JWTSVIDTTLField, StoreSVIDField:
+		return field, nil
+	case AdminField, DNSNamesField, DownstreamField, FederatesWithField, X509SVIDTTL:
+		return "", fmt.Errorf("entry field %q is supported by every SPIRE server; expected one of %q, %q or %q", name, HintField, JWTSVIDTTLField, StoreSVIDField)
+	}
+	return "", fmt.Errorf("unknown entry field %q", name)
+}
+
 type EntryClient interface {
 	ListEntries(ctx context.Context) ([]Entry, error)
+	// CreateEntries creates the entries. The ID of each successfully created
+	// entry is set on the passed entries, since it may be assigned by the
+	// server. For entries rejected with AlreadyExists, the ID of the existing
+	// entry is set instead, or cleared if the server does not return it.
 	CreateEntries(ctx context.Context, entries []Entry) ([]Status, error)
 	UpdateEntries(ctx context.Context, entries []Entry) ([]Status, error)
 	DeleteEntries(ctx context.Context, entryIDs []string) ([]Status, error)
 	GetUnsupportedFields(ctx context.Context, td string) (map[Field]struct{}, error)
 }
 
+// unsupportedFieldsProbePath is the path of the SPIFFE ID and parent ID of
+// the dummy entries created by GetUnsupportedFields, followed by the probe ID
+// of the client.
+const unsupportedFieldsProbePath = "/spire-controller-manager/unsupported-fields-test"
+
+// IsUnsupportedFieldsProbeEntry returns whether the entry is a dummy entry
+// created by GetUnsupportedFields, e.g. one left behind by a crash.
+func IsUnsupportedFieldsProbeEntry(entry Entry) bool {
+	path := entry.SPIFFEID.Path()
+	if path != unsupportedFieldsProbePath && !strings.HasPrefix(path, unsupportedFieldsProbePath+"/") {
+		return false
+	}
+	return entry.ParentID == entry.SPIFFEID
+}
+
 func NewEntryClient(conn grpc.ClientConnInterface) EntryClient {
-	return entryClient{api: entryv1.NewEntryClient(conn)}
+	return newEntryClient(conn, 0, 0)
+}
+
+func newEntryClient(conn grpc.ClientConnInterface, listPageTimeout time.Duration, maxListEntries int) EntryClient {
+	return entryClient{
+		api:             entryv1.NewEntryClient(conn),
+		listPageTimeout: listPageTimeout,
+		maxListEntries:  maxListEntries,
+		probeID:         uuid.NewString(),
+	}
 }
 
 type entryClient struct {
 	api entryv1.EntryClient
+
+	// listPageTimeout, if non-zero, is how long each page of ListEntries
+	// may take.
+	listPageTimeout time.Duration
+
+	// maxListEntries, if non-zero, is the most entries ListEntries may
+	// return.
+	maxListEntries int
+
+	// probeID makes the dummy entry of GetUnsupportedFields unique to this
+	// client, so that replicas probing the same server do not collide.
+	probeID string
 }
 
 func (c entryClient) ListEntries(ctx context.Context) ([]Entry, error) {
 	var entries []*apitypes.Entry
 	var pageToken string
-	for {
-		resp, err := c.api.ListEntries(ctx, &entryv1.ListEntriesRequest{
-			PageToken: pageToken,
-			PageSize:  int32(entryListPageSize),
-		})
+	for page := 1; ; page++ {
+		resp, err := c.listEntriesPage(ctx, pageToken)
 		if err != nil {
+			// Entries of the pages already fetched are discarded so that
+			// callers never act on an incomplete list.
+			if c.listPageTimeout > 0 && status.Code(err) == codes.DeadlineExceeded && ctx.Err() == nil {
+				return nil, fmt.Errorf("page %d of entries exceeded the deadline of %s: %w", page, c.listPageTimeout, err)
+			}
 			return nil, err
 		}
 		entries = append(entries, resp.Entries...)
+		if c.maxListEntries > 0 && len(entries) > c.maxListEntries {
+			return nil, fmt.Errorf("entries exceeded the maximum of %d after %d pages", c.maxListEntries, page)
+		}
 		pageToken = resp.NextPageToken
 		if pageToken == "" {
 			break
@@ -77,17 +140,75 @@ func (c entryClient) ListEntries(ctx context.Context) ([]Entry, error) {
 	return entriesFromAPI(entries)
 }
 
+func (c entryClient) listEntriesPage(ctx context.Context, pageToken string) (*entryv1.ListEntriesResponse, error) {
+	if c.listPageTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.listPageTimeout)
+		defer cancel()
+	}
+	return c.api.ListEntries(ctx, &entryv1.ListEntriesRequest{
+		PageToken: pageToken,
+		PageSize:  int32(entryListPageSize),
+	})
+}
+
 func (c entryClient) GetUnsupportedFields(ctx context.Context, td string) (map[Field]struct{}, error) {
+	result, err := c.createProbeEntry(ctx, td)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Status.Code == int32(codes.AlreadyExists) && result.Entry.GetId() != "" {
+		// A dummy entry left behind by an earlier probe (e.g. one whose
+		// delete failed) is in the way. Its fields say nothing about what
+		// the server supports now, so delete it and try again.
+		if err := c.deleteProbeEntry(ctx, result.Entry.Id); err != nil {
+			return nil, fmt.Errorf("failed to delete leftover dummy entry: %w", err)
+		}
+		result, err = c.createProbeEntry(ctx, td)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if result.Status.Code != int32(codes.OK) {
+		return nil, fmt.Errorf("failed to create entry: %v", result.Status.Message)
+	}
+
+	if err := c.deleteProbeEntry(ctx, result.Entry.Id); err != nil {
+		log := log.FromContext(ctx)
+		log.Error(err, "failed to delete dummy entry", "entry_id", result.Entry.Id)
+	}
+	unsupportedFields := make(map[Field]struct{})
+	if result.Entry.JwtSvidTtl == 0 {
+		unsupportedFields[JWTSVIDTTLField] = struct{}{}
+	}
+
+	if result.Entry.Hint == "" {
+		unsupportedFields[HintField] = struct{}{}
+	}
+
+	if !result.Entry.StoreSvid {
+		unsupportedFields[StoreSVIDField] = struct{}{}
+	}
+
+	return unsupportedFields, nil
+}
+
+// createProbeEntry creates the dummy entry used to probe which fields the
+// server supports, setting every field that might be unsupported.
+func (c entryClient) createProbeEntry(ctx context.Context, td string) (*entryv1.BatchCreateEntryResponse_Result, error) {
+	path := unsupportedFieldsProbePath + "/" + c.probeID
 	resp, err := c.api.BatchCreateEntry(ctx, &entryv1.BatchCreateEntryRequest{
 		Entries: []*apitypes.Entry{
 			{
 				ParentId: &types.SPIFFEID{
 					TrustDomain: td,
-					Path:        "/spire-controller-manager/unsupported-fields-test",
+					Path:        path,
 				},
 				SpiffeId: &types.SPIFFEID{
 					TrustDomain: td,
-					Path:        "/spire-controller-manager/unsupported-fields-test",
+					Path:        path,
 				},
 				Selectors: []*types.Selector{
 					{
@@ -109,47 +230,40 @@ func (c entryClient) GetUnsupportedFields(ctx context.Context, td string) (map[F
 	if len(resp.Results) != 1 {
 		return nil, fmt.Errorf("only one response expected but got %v", len(resp.Results))
 	}
+	return resp.Results[0], nil
+}
 
-	result := resp.Results[0]
-	if result.Status.Code != int32(codes.OK) {
-		return nil, fmt.Errorf("failed to create entry: %v", result.Status.Message)
-	}
-
-	_, err = c.api.BatchDeleteEntry(ctx, &entryv1.BatchDeleteEntryRequest{
-		Ids: []string{
-			result.Entry.Id,
-		},
+func (c entryClient) deleteProbeEntry(ctx context.Context, id string) error {
+	resp, err := c.api.BatchDeleteEntry(ctx, &entryv1.BatchDeleteEntryRequest{
+		Ids: []string{id},
 	})
 	if err != nil {
-		log := log.FromContext(ctx)
-		log.Error(err, "failed to delete dummy entry", "entry_id", result.Entry.Id)
-	}
-
-	unsupportedFields := make(map[Field]struct{})
-	if result.Entry.JwtSvidTtl == 0 {
-		unsupportedFields[JWTSVIDTTLField] = struct{}{}
-	}
-
-	if result.Entry.Hint == "" {
-		unsupportedFields[HintField] = struct{}{}
+		return err
 	}
-
-	if !result.Entry.StoreSvid {
-		unsupportedFields[StoreSVIDField] = struct{}{}
+	for _, result := range resp.Results {
+		if result.Status.Code != int32(codes.OK) && result.Status.Code != int32(codes.NotFound) {
+			return status.Error(codes.Code(result.Status.Code), result.Status.Message)
+		}
 	}
-
-	return unsupportedFields, nil
+	return nil
 }
 
 func (c entryClient) CreateEntries(ctx context.Context, entries []Entry) ([]Status, error) {
 	statuses := make([]Status, 0, len(entries))
-	err := runBatch(len(entries), entryCreateBatchSize, func(start, end int) error {
+	err := runBatch(ctx, len(entries), entryCreateBatchSize, func(start, end int) error {
 		resp, err := c.api.BatchCreateEntry(ctx, &entryv1.BatchCreateEntryRequest{
 			Entries: entriesToAPI(entries[start:end]),
 		})
 		if err == nil {
-			for _, result := range resp.Results {
-				statuses = append(statuses, statusFromAPI(result.Status))
+			for i, result := range resp.Results {
+				st := statusFromAPI(result.Status)
+				switch {
+				case st.Code == codes.OK && result.Entry.GetId() != "":
+					entries[start+i].ID = result.Entry.Id
+				case st.Code == codes.AlreadyExists:
+					entries[start+i].ID = result.Entry.GetId()
+				}
+				statuses = append(statuses, st)
 			}
 		}
 		return err
@@ -159,7 +273,7 @@ func (c entryClient) CreateEntries(ctx context.Context, entries []Entry) ([]Stat
 
 func (c entryClient) UpdateEntries(ctx context.Context, entries []Entry) ([]Status, error) {
 	statuses := make([]Status, 0, len(entries))
-	err := runBatch(len(entries), entryUpdateBatchSize, func(start, end int) error {
+	err := runBatch(ctx, len(entries), entryUpdateBatchSize, func(start, end int) error {
 		resp, err := c.api.BatchUpdateEntry(ctx, &entryv1.BatchUpdateEntryRequest{
 			Entries: entriesToAPI(entries[start:end]),
 		})
@@ -175,7 +289,7 @@ func (c entryClient) UpdateEntries(ctx context.Context, entries []Entry) ([]Stat
 
 func (c entryClient) DeleteEntries(ctx context.Context, entryIDs []string) ([]Status, error) {
 	statuses := make([]Status, 0, len(entryIDs))
-	err := runBatch(len(entryIDs), entryDeleteBatchSize, func(start, end int) error {
+	err := runBatch(ctx, len(entryIDs), entryDeleteBatchSize, func(start, end int) error {
 		resp, err := c.api.BatchDeleteEntry(ctx, &entryv1.BatchDeleteEntryRequest{
 			Ids: entryIDs[start:end],
 		})
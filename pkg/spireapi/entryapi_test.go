@@ -2,6 +2,9 @@ package spireapi
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"sort"
 	"sync"
 	"testing"
@@ -15,6 +18,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 func init() {
@@ -157,6 +161,51 @@ func TestCreateEntries(t *testing.T) {
 	}
 }
 
+func TestCreateEntriesSetsAssignedIDs(t *testing.T) {
+	server, client := startEntryAPIServer(t)
+	server.setEntries(t)
+
+	entry := entry1
+	entry.ID = ""
+	entries := []Entry{entry, entry2}
+	statuses, err := client.CreateEntries(ctx, entries)
+	require.NoError(t, err)
+	require.Equal(t, []Status{{Code: codes.OK}, {Code: codes.OK}}, statuses)
+
+	entry.ID = "assigned-1"
+	assert.Equal(t, []Entry{entry, entry2}, entries)
+	assert.ElementsMatch(t, []Entry{entry, entry2}, server.getEntries(t))
+}
+
+func TestCreateEntriesSetsExistingIDs(t *testing.T) {
+	server, client := startEntryAPIServer(t)
+	server.rejectSimilarEntries = true
+	server.setEntries(t, entry1)
+
+	similar := entry1
+	similar.ID = "generated"
+	similar.Hint = "other"
+	entries := []Entry{similar}
+	statuses, err := client.CreateEntries(ctx, entries)
+	require.NoError(t, err)
+	require.Equal(t, []Status{{Code: codes.AlreadyExists, Message: "similar entry already exists"}}, statuses)
+
+	similar.ID = entry1.ID
+	assert.Equal(t, []Entry{similar}, entries)
+	assert.Equal(t, []Entry{entry1}, server.getEntries(t))
+}
+
+func TestParseField(t *testing.T) {
+	field, err := ParseField("jwtSVIDTTL")
+	require.NoError(t, err)
+	require.Equal(t, JWTSVIDTTLField, field)
+
+	_, err = ParseField("jwtSvidTtl")
+	require.EqualError(t, err, `unknown entry field "jwtSvidTtl"`)
+
+	_, err = ParseField("x509SVIDTTL")
+	require.EqualError(t, err, `entry field "x509SVIDTTL" is supported by every SPIRE server; expected one of "hint", "jwtSVIDTTL" or "storeSVID"`)
+}
 func TestGetUnsupportedFields(t *testing.T) {
 	for _, tc := range []struct {
 		desc                   string
@@ -217,6 +266,36 @@ func TestGetUnsupportedFields(t *testing.T) {
 	}
 }
 
+func TestGetUnsupportedFieldsLeftoverEntry(t *testing.T) {
+	server, client := startEntryAPIServer(t)
+	server.rejectSimilarEntries = true
+	server.clearUnsupportedFields = true
+
+	// A dummy entry from an earlier probe of this client whose delete
+	// failed. Its fields claim everything is supported.
+	path := "/spire-controller-manager/unsupported-fields-test/" + client.(entryClient).probeID
+	leftover := &apitypes.Entry{
+		Id:          "leftover",
+		ParentId:    &apitypes.SPIFFEID{TrustDomain: "domain.test", Path: path},
+		SpiffeId:    &apitypes.SPIFFEID{TrustDomain: "domain.test", Path: path},
+		Selectors:   []*apitypes.Selector{{Type: "a", Value: "1"}},
+		X509SvidTtl: 60,
+		JwtSvidTtl:  60,
+		StoreSvid:   true,
+		Hint:        "hint",
+	}
+	require.NoError(t, server.createEntry(leftover))
+
+	resp, err := client.GetUnsupportedFields(ctx, "domain.test")
+	require.NoError(t, err)
+	require.Equal(t, map[Field]struct{}{
+		HintField:       {},
+		JWTSVIDTTLField: {},
+		StoreSVIDField:  {},
+	}, resp)
+	require.Empty(t, server.getEntries(t), "leftover and new dummy entries should be deleted")
+}
+
 func TestUpdateEntries(t *testing.T) {
 	server, client := startEntryAPIServer(t)
 
@@ -355,12 +434,58 @@ func TestDeleteEntries(t *testing.T) {
 	}
 }
 
+func TestEntryAPIListEntriesPageTimeout(t *testing.T) {
+	server, client := startEntryAPIServerWithListPageTimeout(t, 100*time.Millisecond)
+	server.setEntries(t, entry1, entry2, entry3)
+	server.stallListEntriesAfterFirstPage = true
+
+	start := time.Now()
+	actualEntries, err := client.ListEntries(ctx)
+	require.Less(t, time.Since(start), 10*time.Second)
+	require.ErrorContains(t, err, "page 2 of entries exceeded the deadline of 100ms")
+	require.Equal(t, codes.DeadlineExceeded, status.Code(errors.Unwrap(err)))
+	require.Empty(t, actualEntries)
+}
+
+func TestEntryAPIListEntriesMaxEntries(t *testing.T) {
+	t.Run("within the maximum", func(t *testing.T) {
+		server, client := startEntryAPIServerWithMaxListEntries(t, 3)
+		server.setEntries(t, entry1, entry2, entry3)
+
+		actualEntries, err := client.ListEntries(ctx)
+		require.NoError(t, err)
+		require.Len(t, actualEntries, 3)
+	})
+
+	t.Run("never ending pagination", func(t *testing.T) {
+		server, client := startEntryAPIServerWithMaxListEntries(t, 10)
+		server.setEntries(t, entry1, entry2, entry3)
+		server.endlessListEntries = true
+
+		actualEntries, err := client.ListEntries(ctx)
+		require.EqualError(t, err, "entries exceeded the maximum of 10 after 4 pages")
+		require.Empty(t, actualEntries)
+	})
+}
+
 func startEntryAPIServer(t *testing.T) (*entryServer, EntryClient) {
+	return startEntryAPIServerWithListPageTimeout(t, 0)
+}
+
+func startEntryAPIServerWithListPageTimeout(t *testing.T, listPageTimeout time.Duration) (*entryServer, EntryClient) {
+	api := &entryServer{}
+	conn := startServer(t, func(s *grpc.Server) {
+		entryv1.RegisterEntryServer(s, api)
+	})
+	return api, newEntryClient(conn, listPageTimeout, 0)
+}
+
+func startEntryAPIServerWithMaxListEntries(t *testing.T, maxListEntries int) (*entryServer, EntryClient) {
 	api := &entryServer{}
 	conn := startServer(t, func(s *grpc.Server) {
 		entryv1.RegisterEntryServer(s, api)
 	})
-	return api, NewEntryClient(conn)
+	return api, newEntryClient(conn, 0, maxListEntries)
 }
 
 type entryServer struct {
@@ -371,19 +496,43 @@ type entryServer struct {
 
 	clearUnsupportedFields bool
 
+	// stallListEntriesAfterFirstPage stalls ListEntries on every page but
+	// the first until the request is canceled.
+	stallListEntriesAfterFirstPage bool
+
+	// endlessListEntries makes ListEntries return the first page of entries
+	// with a next page token on every page, i.e. never stop paginating.
+	endlessListEntries bool
+
+	// rejectSimilarEntries makes BatchCreateEntry fail with AlreadyExists,
+	// returning the existing entry, when an entry with the same SPIFFE ID,
+	// parent ID and selectors exists, like SPIRE does.
+	rejectSimilarEntries bool
+
+	// nextID is used to assign IDs to created entries without one.
+	nextID int
+
 	listEntriesErr        error
 	batchCreateEntriesErr error
 	batchUpdateEntriesErr error
 	batchDeleteEntriesErr error
 }
 
-func (s *entryServer) ListEntries(_ context.Context, req *entryv1.ListEntriesRequest) (*entryv1.ListEntriesResponse, error) {
+func (s *entryServer) ListEntries(ctx context.Context, req *entryv1.ListEntriesRequest) (*entryv1.ListEntriesResponse, error) {
+	if req.PageToken != "" && s.stallListEntriesAfterFirstPage {
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+
 	resp := new(entryv1.ListEntriesResponse)
 
 	s.mtx.RLock()
 	defer s.mtx.RUnlock()
 
 	start, end, more := listBounds(req.PageToken, int(req.PageSize), len(s.entries), func(i int) string { return s.entries[i].Id })
+	if s.endlessListEntries {
+		start, end, more = 0, len(s.entries), true
+	}
 	for _, entry := range s.entries[start:end] {
 		resp.Entries = append(resp.Entries, entry)
 		if more {
@@ -405,6 +554,17 @@ func (s *entryServer) BatchCreateEntry(_ context.Context, req *entryv1.BatchCrea
 			entry.StoreSvid = false
 		}
 
+		if existing := s.findSimilarEntry(entry); existing != nil {
+			resp.Results = append(resp.Results, &entryv1.BatchCreateEntryResponse_Result{
+				Status: &apitypes.Status{
+					Code:    int32(codes.AlreadyExists),
+					Message: "similar entry already exists",
+				},
+				Entry: existing,
+			})
+			continue
+		}
+
 		st := status.Convert(s.createEntry(entry))
 		result := &entryv1.BatchCreateEntryResponse_Result{
 			Status: &apitypes.Status{
@@ -479,10 +639,32 @@ func (s *entryServer) setEntries(t *testing.T, entries ...Entry) {
 	}
 }
 
+func (s *entryServer) findSimilarEntry(entry *apitypes.Entry) *apitypes.Entry {
+	if !s.rejectSimilarEntries {
+		return nil
+	}
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	for _, existing := range s.entries {
+		if proto.Equal(existing.SpiffeId, entry.SpiffeId) &&
+			proto.Equal(existing.ParentId, entry.ParentId) &&
+			slices.EqualFunc(existing.Selectors, entry.Selectors, func(a, b *apitypes.Selector) bool { return proto.Equal(a, b) }) {
+			return existing
+		}
+	}
+	return nil
+}
+
 func (s *entryServer) createEntry(entry *apitypes.Entry) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
+	if entry.Id == "" {
+		// Emulate the server assigning an ID.
+		s.nextID++
+		entry.Id = fmt.Sprintf("assigned-%d", s.nextID)
+	}
+
 	n := sort.Search(len(s.entries), func(i int) bool {
 		return s.entries[i].Id >= entry.Id
 	})
@@ -520,3 +702,24 @@ func (s *entryServer) deleteEntry(td string) error {
 	s.entries = s.entries[:n+copy(s.entries[n:], s.entries[n+1:])]
 	return nil
 }
+
+func TestIsUnsupportedFieldsProbeEntry(t *testing.T) {
+	newEntry := func(spiffeID, parentID string) Entry {
+		return Entry{
+			SPIFFEID: spiffeid.RequireFromString(spiffeID),
+			ParentID: spiffeid.RequireFromString(parentID),
+		}
+	}
+	assert.True(t, IsUnsupportedFieldsProbeEntry(newEntry(
+		"spiffe://domain.test/spire-controller-manager/unsupported-fields-test/id",
+		"spiffe://domain.test/spire-controller-manager/unsupported-fields-test/id")))
+	assert.True(t, IsUnsupportedFieldsProbeEntry(newEntry(
+		"spiffe://domain.test/spire-controller-manager/unsupported-fields-test",
+		"spiffe://domain.test/spire-controller-manager/unsupported-fields-test")), "entries of older releases should match")
+	assert.False(t, IsUnsupportedFieldsProbeEntry(newEntry(
+		"spiffe://domain.test/spire-controller-manager/unsupported-fields-test/id",
+		"spiffe://domain.test/parent")))
+	assert.False(t, IsUnsupportedFieldsProbeEntry(newEntry(
+		"spiffe://domain.test/spire-controller-manager/unsupported-fields-testing",
+		"spiffe://domain.test/spire-controller-manager/unsupported-fields-testing")))
+}
@@ -62,7 +62,7 @@ func (c trustDomainClient) ListFederationRelationships(ctx context.Context) ([]F
 
 func (c trustDomainClient) CreateFederationRelationships(ctx context.Context, federationRelationships []FederationRelationship) ([]Status, error) {
 	var statuses []Status
-	err := runBatch(len(federationRelationships), federationRelationshipCreateBatchSize, func(start, end int) error {
+	err := runBatch(ctx, len(federationRelationships), federationRelationshipCreateBatchSize, func(start, end int) error {
 		toCreate, err := federationRelationshipsToAPI(federationRelationships[start:end])
 		if err != nil {
 			return err
@@ -82,7 +82,7 @@ func (c trustDomainClient) CreateFederationRelationships(ctx context.Context, fe
 
 func (c trustDomainClient) UpdateFederationRelationships(ctx context.Context, federationRelationships []FederationRelationship) ([]Status, error) {
 	var statuses []Status
-	err := runBatch(len(federationRelationships), federationRelationshipUpdateBatchSize, func(start, end int) error {
+	err := runBatch(ctx, len(federationRelationships), federationRelationshipUpdateBatchSize, func(start, end int) error {
 		toUpdate, err := federationRelationshipsToAPI(federationRelationships[start:end])
 		if err != nil {
 			return err
@@ -102,7 +102,7 @@ func (c trustDomainClient) UpdateFederationRelationships(ctx context.Context, fe
 
 func (c trustDomainClient) DeleteFederationRelationships(ctx context.Context, tds []spiffeid.TrustDomain) ([]Status, error) {
 	var statuses []Status
-	err := runBatch(len(tds), federationRelationshipDeleteBatchSize, func(start, end int) error {
+	err := runBatch(ctx, len(tds), federationRelationshipDeleteBatchSize, func(start, end int) error {
 		resp, err := c.api.BatchDeleteFederationRelationship(ctx, &trustdomainv1.BatchDeleteFederationRelationshipRequest{
 			TrustDomains: trustDomainsToAPI(tds[start:end]),
 		})
@@ -2,10 +2,13 @@ package spireapi
 
 import (
 	"context"
+	"crypto"
+	"crypto/x509"
 	"sort"
 	"sync"
 	"testing"
 
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	trustdomainv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/trustdomain/v1"
 	apitypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
@@ -152,6 +155,23 @@ func TestCreateFederationRelationships(t *testing.T) {
 	}
 }
 
+func TestCreateFederationRelationshipsOIDCDiscovery(t *testing.T) {
+	server, client := startTrustDomainAPIServer(t)
+
+	// The "oidc_discovery" profile has no SPIRE equivalent that would not
+	// fail to poll the discovery document, so it is never sent to SPIRE.
+	oidcFR := FederationRelationship{
+		TrustDomain:           domain1,
+		BundleEndpointURL:     "https://domain1.test/.well-known/openid-configuration",
+		BundleEndpointProfile: OIDCDiscoveryProfile{},
+		TrustDomainBundle:     spiffebundle.FromJWTAuthorities(domain1, map[string]crypto.PublicKey{"kid": key.Public()}),
+	}
+	server.clearFederationRelationships()
+	_, err := client.CreateFederationRelationships(ctx, []FederationRelationship{oidcFR})
+	require.EqualError(t, err, "oidc_discovery profile has no SPIRE federation relationship; its bundle is set as a federated bundle instead")
+	assert.Empty(t, server.getFederationRelationships(t))
+}
+
 func TestUpdateFederationRelationships(t *testing.T) {
 	server, client := startTrustDomainAPIServer(t)
 
@@ -408,6 +428,10 @@ func (s *trustDomainServer) setFederationRelationships(t *testing.T, frs ...Fede
 }
 
 func (s *trustDomainServer) createFederationRelationship(fr *apitypes.FederationRelationship) error {
+	if err := validateFederationRelationship(fr); err != nil {
+		return err
+	}
+
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
@@ -422,6 +446,10 @@ func (s *trustDomainServer) createFederationRelationship(fr *apitypes.Federation
 }
 
 func (s *trustDomainServer) updateFederationRelationship(fr *apitypes.FederationRelationship) error {
+	if err := validateFederationRelationship(fr); err != nil {
+		return err
+	}
+
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
@@ -448,3 +476,49 @@ func (s *trustDomainServer) deleteFederationRelationship(td string) error {
 	s.frs = s.frs[:n+copy(s.frs[n:], s.frs[n+1:])]
 	return nil
 }
+
+// validateFederationRelationship rejects the federation relationships the
+// SPIRE server would reject: those without a supported bundle endpoint
+// profile, or with a trust domain bundle that is malformed or for another
+// trust domain.
+func validateFederationRelationship(fr *apitypes.FederationRelationship) error {
+	td, err := spiffeid.TrustDomainFromString(fr.TrustDomain)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to parse trust domain: %v", err)
+	}
+	if err := ValidateBundleEndpointURL(fr.BundleEndpointUrl); err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to parse bundle endpoint URL: %v", err)
+	}
+	switch profile := fr.BundleEndpointProfile.(type) {
+	case *apitypes.FederationRelationship_HttpsWeb:
+		if profile.HttpsWeb == nil {
+			return status.Error(codes.InvalidArgument, `bundle endpoint profile does not contain "HttpsWeb"`)
+		}
+	case *apitypes.FederationRelationship_HttpsSpiffe:
+		if _, err := spiffeid.FromString(profile.HttpsSpiffe.GetEndpointSpiffeId()); err != nil {
+			return status.Errorf(codes.InvalidArgument, "failed to parse endpoint SPIFFE ID: %v", err)
+		}
+	default:
+		return status.Errorf(codes.InvalidArgument, "unsupported bundle endpoint profile type: %T", profile)
+	}
+	if fr.TrustDomainBundle == nil {
+		return nil
+	}
+	if fr.TrustDomainBundle.TrustDomain != td.Name() {
+		return status.Errorf(codes.InvalidArgument, "trust domain bundle (%q) must match the trust domain of the federation relationship (%q)", fr.TrustDomainBundle.TrustDomain, td.Name())
+	}
+	for _, authority := range fr.TrustDomainBundle.X509Authorities {
+		if _, err := x509.ParseCertificate(authority.Asn1); err != nil {
+			return status.Errorf(codes.InvalidArgument, "failed to parse X.509 authority: %v", err)
+		}
+	}
+	for _, authority := range fr.TrustDomainBundle.JwtAuthorities {
+		if authority.KeyId == "" {
+			return status.Error(codes.InvalidArgument, "failed to parse JWT authority: missing key ID")
+		}
+		if _, err := x509.ParsePKIXPublicKey(authority.PublicKey); err != nil {
+			return status.Errorf(codes.InvalidArgument, "failed to parse JWT authority: %v", err)
+		}
+	}
+	return nil
+}
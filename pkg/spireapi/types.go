@@ -109,6 +109,29 @@ func (profile HTTPSSPIFFEProfile) Equal(other BundleEndpointProfile) bool {
 
 func (HTTPSSPIFFEProfile) bundleEndpointProfile() {}
 
+// OIDCDiscoveryProfile sources the JWT authorities of the trust domain from
+// the JWKS referenced by an OpenID Connect discovery document. SPIRE has no
+// equivalent profile, and would fail to poll the discovery document as a
+// bundle endpoint, so no federation relationship is created for it. The
+// caller fetches the bundle and sets it on the SPIRE server as a federated
+// bundle (see FederatedBundleClient).
+type OIDCDiscoveryProfile struct{}
+
+func (OIDCDiscoveryProfile) Name() string {
+	return "oidc_discovery"
+}
+
+func (OIDCDiscoveryProfile) Equal(other BundleEndpointProfile) bool {
+	switch other.(type) {
+	case OIDCDiscoveryProfile, *OIDCDiscoveryProfile:
+		return true
+	default:
+		return false
+	}
+}
+
+func (OIDCDiscoveryProfile) bundleEndpointProfile() {}
+
 type JWTKey struct {
 	KeyID     string
 	PublicKey crypto.PublicKey
@@ -210,6 +233,19 @@ func entryFromAPI(in *apitypes.Entry) (Entry, error) {
 	}, nil
 }
 
+// ValidateEntry checks the entry against the rules applied to the entries
+// returned by the SPIRE server, and that it has at least one selector, so
+// that malformed entries can be caught before they are sent.
+func ValidateEntry(entry Entry) error {
+	if _, err := entryFromAPI(entryToAPI(entry)); err != nil {
+		return err
+	}
+	if len(entry.Selectors) == 0 {
+		return errors.New("invalid selectors field: at least one selector is required")
+	}
+	return nil
+}
+
 func entriesFromAPI(ins []*apitypes.Entry) ([]Entry, error) {
 	var outs []Entry
 	if ins != nil {
@@ -338,6 +374,8 @@ func federationRelationshipToAPI(in FederationRelationship) (*apitypes.Federatio
 				EndpointSpiffeId: profile.EndpointSPIFFEID.String(),
 			},
 		}
+	case OIDCDiscoveryProfile:
+		return nil, errors.New("oidc_discovery profile has no SPIRE federation relationship; its bundle is set as a federated bundle instead")
 	default:
 		return nil, fmt.Errorf("unrecognized bundle endpoint profile type %T", profile)
 	}
@@ -435,6 +473,36 @@ func trustDomainsFromAPI(ins []string) ([]spiffeid.TrustDomain, error) {
 	return outs, nil
 }
 
+func bundlesToAPI(ins []*spiffebundle.Bundle) ([]*apitypes.Bundle, error) {
+	var outs []*apitypes.Bundle
+	if ins != nil {
+		outs = make([]*apitypes.Bundle, 0, len(ins))
+		for _, in := range ins {
+			out, err := bundleToAPI(in)
+			if err != nil {
+				return nil, err
+			}
+			outs = append(outs, out)
+		}
+	}
+	return outs, nil
+}
+
+func bundlesFromAPI(ins []*apitypes.Bundle) ([]*spiffebundle.Bundle, error) {
+	var outs []*spiffebundle.Bundle
+	if ins != nil {
+		outs = make([]*spiffebundle.Bundle, 0, len(ins))
+		for _, in := range ins {
+			out, err := bundleFromAPI(in)
+			if err != nil {
+				return nil, err
+			}
+			outs = append(outs, out)
+		}
+	}
+	return outs, nil
+}
+
 func bundleToAPI(in *spiffebundle.Bundle) (*apitypes.Bundle, error) {
 	trustDomain := in.TrustDomain().Name()
 	if trustDomain == "" {
@@ -110,11 +110,20 @@ func TestFederationRelationshipEqual(t *testing.T) {
 func TestProfileNames(t *testing.T) {
 	assert.Equal(t, "https_web", (HTTPSWebProfile{}).Name())
 	assert.Equal(t, "https_spiffe", (HTTPSSPIFFEProfile{}).Name())
+	assert.Equal(t, "oidc_discovery", (OIDCDiscoveryProfile{}).Name())
 }
 
 func TestHTTPSWebProfileEquality(t *testing.T) {
 	assert.True(t, (HTTPSWebProfile{}).Equal(HTTPSWebProfile{}))
 	assert.False(t, (HTTPSWebProfile{}).Equal(HTTPSSPIFFEProfile{}))
+	assert.False(t, (HTTPSWebProfile{}).Equal(OIDCDiscoveryProfile{}))
+}
+
+func TestOIDCDiscoveryProfileEquality(t *testing.T) {
+	assert.True(t, (OIDCDiscoveryProfile{}).Equal(OIDCDiscoveryProfile{}))
+	assert.True(t, (OIDCDiscoveryProfile{}).Equal(&OIDCDiscoveryProfile{}))
+	assert.False(t, (OIDCDiscoveryProfile{}).Equal(HTTPSWebProfile{}))
+	assert.False(t, (OIDCDiscoveryProfile{}).Equal(HTTPSSPIFFEProfile{}))
 }
 
 func TestHTTPSSPIFFEProfileEquality(t *testing.T) {
@@ -245,6 +254,55 @@ func TestEntryFromAPI(t *testing.T) {
 	}
 }
 
+func TestValidateEntry(t *testing.T) {
+	for _, tc := range []struct {
+		desc      string
+		modify    func(*Entry)
+		expectErr string
+	}{
+		{
+			desc:   "valid",
+			modify: func(*Entry) {},
+		},
+		{
+			desc:      "missing SPIFFE ID",
+			modify:    func(e *Entry) { e.SPIFFEID = spiffeid.ID{} },
+			expectErr: "invalid SPIFFE ID field: trust domain is missing",
+		},
+		{
+			desc:      "missing parent ID",
+			modify:    func(e *Entry) { e.ParentID = spiffeid.ID{} },
+			expectErr: "invalid parent ID field: trust domain is missing",
+		},
+		{
+			desc:      "no selectors",
+			modify:    func(e *Entry) { e.Selectors = nil },
+			expectErr: "invalid selectors field: at least one selector is required",
+		},
+		{
+			desc:      "invalid selector",
+			modify:    func(e *Entry) { e.Selectors = []Selector{{Type: "Type"}} },
+			expectErr: "invalid selectors field: selector value is empty",
+		},
+		{
+			desc:      "invalid federatesWith",
+			modify:    func(e *Entry) { e.FederatesWith = []spiffeid.TrustDomain{{}} },
+			expectErr: "invalid federatesWith field: invalid trust domain: trust domain is missing",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			e := entry
+			tc.modify(&e)
+			err := ValidateEntry(e)
+			if tc.expectErr != "" {
+				require.EqualError(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
 func TestEntriesFromAPI(t *testing.T) {
 	actual, err := entriesFromAPI(nil)
 	assert.NoError(t, err)
@@ -374,6 +432,16 @@ func TestFederationRelationshipToAPI(t *testing.T) {
 				TrustDomainBundle: apiBundle,
 			},
 		},
+		{
+			desc: "oidc_discovery has no SPIRE federation relationship",
+			fr: FederationRelationship{
+				TrustDomain:           td,
+				BundleEndpointURL:     bundleEndpointURL,
+				BundleEndpointProfile: OIDCDiscoveryProfile{},
+				TrustDomainBundle:     bundle,
+			},
+			expectErr: "oidc_discovery profile has no SPIRE federation relationship; its bundle is set as a federated bundle instead",
+		},
 		{
 			desc: "success with https_spiffe",
 			fr: FederationRelationship{
@@ -5,12 +5,15 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type byObject interface {
 	GetObjectKind() schema.ObjectKind
 
 	GetUID() types.UID
+	GetName() string
+	GetAnnotations() map[string]string
 	GetCreationTimestamp() metav1.Time
 	GetDeletionTimestamp() *metav1.Time
 
@@ -18,6 +21,8 @@ type byObject interface {
 	IncrementEntriesMasked()
 	IncrementEntrySuccess()
 	IncrementEntryFailures()
+	IncrementEntriesBackedOff()
+	SetEntryID(id string)
 }
 
 type ClusterStaticEntry struct {
@@ -39,6 +44,13 @@ func (by *ClusterStaticEntry) IncrementEntrySuccess() {
 func (by *ClusterStaticEntry) IncrementEntryFailures() {
 }
 
+func (by *ClusterStaticEntry) IncrementEntriesBackedOff() {
+}
+
+func (by *ClusterStaticEntry) SetEntryID(id string) {
+	by.NextStatus.EntryID = id
+}
+
 type ClusterSPIFFEID struct {
 	spirev1alpha1.ClusterSPIFFEID
 	NextStatus spirev1alpha1.ClusterSPIFFEIDStatus
@@ -58,3 +70,51 @@ func (by *ClusterSPIFFEID) IncrementEntrySuccess() {
 func (by *ClusterSPIFFEID) IncrementEntryFailures() {
 	by.NextStatus.Stats.EntryFailures++
 }
+
+func (by *ClusterSPIFFEID) IncrementEntriesBackedOff() {
+	by.NextStatus.Stats.EntriesBackedOff++
+}
+
+func (by *ClusterSPIFFEID) SetEntryID(id string) {
+}
+
+type SPIFFEID struct {
+	spirev1alpha1.SPIFFEID
+	NextStatus spirev1alpha1.SPIFFEIDStatus
+}
+
+func (by *SPIFFEID) IncrementEntriesToSet() {
+	by.NextStatus.Stats.EntriesToSet++
+}
+
+func (by *SPIFFEID) IncrementEntriesMasked() {
+	by.NextStatus.Stats.EntriesMasked++
+}
+
+func (by *SPIFFEID) IncrementEntrySuccess() {
+}
+
+func (by *SPIFFEID) IncrementEntryFailures() {
+	by.NextStatus.Stats.EntryFailures++
+}
+
+func (by *SPIFFEID) IncrementEntriesBackedOff() {
+	by.NextStatus.Stats.EntriesBackedOff++
+}
+
+func (by *SPIFFEID) SetEntryID(id string) {
+}
+
+// byClientObject returns the Kubernetes object wrapped by the by object, for
+// recording events on it.
+func byClientObject(by byObject) client.Object {
+	switch by := by.(type) {
+	case *ClusterStaticEntry:
+		return &by.ClusterStaticEntry
+	case *ClusterSPIFFEID:
+		return &by.ClusterSPIFFEID
+	case *SPIFFEID:
+		return &by.SPIFFEID
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// refreshCAExpiry fetches the bundle from the SPIRE server to determine when
+// the CA expires. On failure, the previously determined expiry, if any, is
+// kept.
+func (r *entryReconciler) refreshCAExpiry(ctx context.Context) {
+	log := log.FromContext(ctx)
+	bundle, err := r.config.BundleClient.GetBundle(ctx)
+	if err != nil {
+		log.Error(err, "Failed to get bundle to determine CA expiry")
+		return
+	}
+	caExpiry, err := bundleExpiry(bundle)
+	if err != nil {
+		log.Error(err, "Failed to determine CA expiry")
+		return
+	}
+	if !caExpiry.After(time.Now()) {
+		log.Error(nil, "CA has expired; X509-SVID TTLs will not be clamped", "caExpiry", caExpiry)
+	}
+	r.caExpiry = caExpiry
+}
+
+// clampX509SVIDTTL lowers the X509-SVID TTL of the entry so that SVIDs
+// minted for it do not outlive the CA. The remaining lifetime is truncated to
+// the minute so that entries are updated at most once a minute as the CA
+// nears expiry. Entries using the default TTL of the SPIRE server are left
+// alone.
+func (r *entryReconciler) clampX509SVIDTTL(log logr.Logger, entry *spireapi.Entry) {
+	if r.caExpiry.IsZero() || entry.X509SVIDTTL == 0 {
+		return
+	}
+	remaining := time.Until(r.caExpiry).Truncate(time.Minute)
+	if remaining <= 0 || entry.X509SVIDTTL <= remaining {
+		return
+	}
+	log.Info("Clamping X509-SVID TTL to the remaining CA lifetime", append(entryLogFields(*entry), "clampedX509SVIDTTL", remaining.String())...)
+	entry.X509SVIDTTL = remaining
+}
+
+// bundleExpiry returns the latest expiry of the X.509 authorities in the
+// bundle, which is that of the most recently prepared CA.
+func bundleExpiry(bundle *spiffebundle.Bundle) (time.Time, error) {
+	var expiry time.Time
+	for _, authority := range bundle.X509Authorities() {
+		if authority.NotAfter.After(expiry) {
+			expiry = authority.NotAfter
+		}
+	}
+	if expiry.IsZero() {
+		return time.Time{}, errors.New("bundle has no X.509 authorities")
+	}
+	return expiry, nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// checkDNSNameConflicts reports the DNS names declared on the entries of
+// more than one SPIFFE ID. Only the preferred declared entry of each entry
+// key is considered, since the others are masked. If DeduplicateDNSNames is
+// set, each conflicting DNS name is kept only on the entry of the most
+// preferred object (then the oldest pod, then the lowest SPIFFE ID).
+func (r *entryReconciler) checkDNSNameConflicts(ctx context.Context, state entriesState) {
+	claims := make(map[string][]*declaredEntry)
+	for _, s := range state {
+		if len(s.Declared) == 0 {
+			continue
+		}
+		sortDeclaredEntriesByPreference(s.Declared)
+		preferredEntry := &s.Declared[0]
+		for _, dnsName := range preferredEntry.Entry.DNSNames {
+			claims[dnsName] = append(claims[dnsName], preferredEntry)
+		}
+	}
+
+	log := log.FromContext(ctx)
+	for dnsName, claimants := range claims {
+		if len(claimants) < 2 {
+			continue
+		}
+		sort.Slice(claimants, func(i, j int) bool {
+			return declaredEntryCmp(claimants[i], claimants[j]) < 0
+		})
+		var spiffeIDs []string
+		for _, claimant := range claimants {
+			if spiffeID := claimant.Entry.SPIFFEID.String(); !slices.Contains(spiffeIDs, spiffeID) {
+				spiffeIDs = append(spiffeIDs, spiffeID)
+			}
+		}
+		if len(spiffeIDs) < 2 {
+			continue
+		}
+
+		r.promCounter[metrics.DNSNameConflicts].Add(1)
+		log.Info("Found DNS name declared on entries with different SPIFFE IDs", "dnsName", dnsName, "spiffeIDs", stringList(spiffeIDs), "deduplicate", r.config.DeduplicateDNSNames)
+		if !r.config.DeduplicateDNSNames {
+			continue
+		}
+		kept := claimants[0].Entry.SPIFFEID
+		for _, claimant := range claimants[1:] {
+			if claimant.Entry.SPIFFEID != kept {
+				claimant.Entry.DNSNames = slices.DeleteFunc(slices.Clone(claimant.Entry.DNSNames), func(name string) bool {
+					return name == dnsName
+				})
+			}
+		}
+	}
+}
+
+func declaredEntryCmp(a, b *declaredEntry) int {
+	if c := objectCmp(a.By, b.By); c != 0 {
+		return c
+	}
+	if a.Pod != nil && b.Pod != nil {
+		if c := a.Pod.CreationTimestamp.Compare(b.Pod.CreationTimestamp.Time); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.Entry.SPIFFEID.String(), b.Entry.SPIFFEID.String())
+}
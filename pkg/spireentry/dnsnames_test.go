@@ -0,0 +1,82 @@
+package spireentry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestDNSNameConflicts(t *testing.T) {
+	newClusterSPIFFEID := func(name string, created time.Time, podLabels map[string]string) *spirev1alpha1.ClusterSPIFFEID {
+		return &spirev1alpha1.ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(created)},
+			Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/" + name + "/{{ .PodMeta.Name }}",
+				PodSelector:      &metav1.LabelSelector{MatchLabels: podLabels},
+				DNSNameTemplates: []string{"{{ .PodMeta.Name }}.{{ .PodMeta.Namespace }}", "shared.{{ .PodMeta.Namespace }}"},
+			},
+		}
+	}
+	now := time.Now().Truncate(time.Second)
+	objects := []client.Object{
+		newClusterSPIFFEID("older", now.Add(-time.Hour), map[string]string{"app": "a"}),
+		newClusterSPIFFEID("newer", now, map[string]string{"app": "b"}),
+		newTestNamespace("default"), newTestNode("node"),
+		newTestPod("default", "a", "node", map[string]string{"app": "a"}),
+		newTestPod("default", "b", "node", map[string]string{"app": "b"}),
+	}
+
+	for _, tt := range []struct {
+		desc        string
+		deduplicate bool
+		expectDNS   map[string][]string
+	}{
+		{
+			desc: "detected",
+			expectDNS: map[string][]string{
+				"spiffe://example.org/older/a": {"a.default", "shared.default"},
+				"spiffe://example.org/newer/b": {"b.default", "shared.default"},
+			},
+		},
+		{
+			desc:        "deduplicated",
+			deduplicate: true,
+			expectDNS: map[string][]string{
+				"spiffe://example.org/older/a": {"a.default", "shared.default"},
+				"spiffe://example.org/newer/b": {"b.default"},
+			},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			entryClient := newEntryClient()
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:         entryClient,
+				DeduplicateDNSNames: tt.deduplicate,
+			}, objects...)
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			r.reconcile(ctx)
+
+			dnsNames := make(map[string][]string)
+			for _, entry := range entryClient.getEntries() {
+				dnsNames[entry.SPIFFEID.String()] = entry.DNSNames
+			}
+			require.Equal(t, tt.expectDNS, dnsNames)
+			require.Equal(t, 1.0, testutil.ToFloat64(r.promCounter[metrics.DNSNameConflicts]))
+
+			t.Log("The deduplicated entries are stable across passes")
+			entryClient.updateCalls = 0
+			r.reconcile(ctx)
+			require.Zero(t, entryClient.updateCalls)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// dryRunSummaryReason is the event reason used to summarize a dry-run
+	// pass.
+	dryRunSummaryReason = "DryRunSummary"
+
+	defaultDryRunEventInterval = 5 * time.Minute
+)
+
+// logDryRunEntries logs the entries that would be created, updated or
+// deleted, in place of writing them to the SPIRE server.
+func logDryRunEntries(ctx context.Context, toCreate, toUpdate []declaredEntry, toDelete []spireapi.Entry) {
+	log := log.FromContext(ctx)
+	for _, entry := range toDelete {
+		log.Info("Would delete entry (dry run)", entryLogFields(entry)...)
+	}
+	for _, declaredEntry := range toCreate {
+		log.Info("Would create entry (dry run)", entryLogFields(declaredEntry.Entry)...)
+	}
+	for _, declaredEntry := range toUpdate {
+		log.Info("Would update entry (dry run)", entryLogFields(declaredEntry.Entry)...)
+	}
+	log.Info("Dry run; skipped writing entries to the SPIRE server", "toCreate", len(toCreate), "toUpdate", len(toUpdate), "toDelete", len(toDelete))
+}
+
+// recordDryRunEvent records an event summarizing the dry-run pass on the
+// configured target, if any. Events are rate-limited to one per
+// DryRunEventInterval so that watching them does not turn into spam.
+func (r *entryReconciler) recordDryRunEvent(summary PassSummary) {
+	if r.config.EventRecorder == nil || r.config.DryRunEventTarget == nil {
+		return
+	}
+	now := r.config.Clock.Now()
+	if now.Before(r.nextDryRunEvent) {
+		return
+	}
+	r.nextDryRunEvent = now.Add(r.config.DryRunEventInterval)
+	r.config.EventRecorder.Eventf(r.config.DryRunEventTarget, corev1.EventTypeNormal, dryRunSummaryReason,
+		"Dry run would create %d, update %d and delete %d entries", summary.ToCreate, summary.ToUpdate, summary.ToDelete)
+}
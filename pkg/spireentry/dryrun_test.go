@@ -0,0 +1,124 @@
+package spireentry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	testclock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestDryRun(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	deletedPod := newTestPod("default", "deleted", "node", nil)
+
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"),
+		newTestPod("default", "updated", "node", nil),
+		deletedPod,
+	)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+	require.Equal(t, []string{
+		"spiffe://example.org/ns/default/pod/deleted",
+		"spiffe://example.org/ns/default/pod/updated",
+	}, entrySPIFFEIDs(entryClient.getEntries()))
+
+	// Set up entries to create, one to update and one to delete.
+	require.NoError(t, r.config.K8sClient.Create(ctx, newTestPod("default", "created", "node", nil)))
+	require.NoError(t, r.config.K8sClient.Create(ctx, newTestPod("default", "created-too", "node", nil)))
+	require.NoError(t, r.config.K8sClient.Delete(ctx, deletedPod))
+	for id, entry := range entryClient.entries {
+		if entry.SPIFFEID.Path() == "/ns/default/pod/updated" {
+			entry.X509SVIDTTL = time.Hour
+			entryClient.entries[id] = entry
+		}
+	}
+	entriesBefore := entryClient.getEntries()
+	createCalls, updateCalls, deleteCalls := entryClient.createCalls, entryClient.updateCalls, entryClient.deleteCalls
+	getUnsupportedFieldsCalls := entryClient.getUnsupportedFieldsCalls
+
+	// Make the unsupported fields probe due, since it writes an entry too.
+	clear(r.nextGetUnsupportedFields)
+
+	r.config.DryRun = true
+	r.reconcile(ctx)
+
+	require.Equal(t, createCalls, entryClient.createCalls)
+	require.Equal(t, updateCalls, entryClient.updateCalls)
+	require.Equal(t, deleteCalls, entryClient.deleteCalls)
+	require.Equal(t, getUnsupportedFieldsCalls, entryClient.getUnsupportedFieldsCalls)
+	require.Equal(t, entriesBefore, entryClient.getEntries())
+
+	// Statuses are still updated, reflecting what would be set.
+	actual := new(spirev1alpha1.ClusterSPIFFEID)
+	require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+	require.Equal(t, spirev1alpha1.ClusterSPIFFEIDStats{
+		NamespacesSelected: 1,
+		PodsSelected:       3,
+		EntriesToSet:       3,
+	}, actual.Status.Stats)
+}
+
+func TestDryRunEvent(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	target := &corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "spire-system", Name: "spire-controller-manager"}
+
+	clk := testclock.NewFakeClock(time.Now())
+	recorder := record.NewFakeRecorder(10)
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:         newEntryClient(),
+		Clock:               clk,
+		EventRecorder:       recorder,
+		DryRun:              true,
+		DryRunEventTarget:   target,
+		DryRunEventInterval: time.Minute,
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"),
+		newTestPod("default", "a", "node", nil),
+		newTestPod("default", "b", "node", nil),
+	)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	drainEvents := func() []string {
+		var events []string
+		for {
+			select {
+			case event := <-recorder.Events:
+				events = append(events, event)
+			default:
+				return events
+			}
+		}
+	}
+
+	r.reconcile(ctx)
+	require.Equal(t, []string{"Normal DryRunSummary Dry run would create 2, update 0 and delete 0 entries"}, drainEvents())
+
+	// Passes within the interval do not record another event.
+	r.reconcile(ctx)
+	require.Empty(t, drainEvents())
+
+	clk.Step(time.Minute)
+	r.reconcile(ctx)
+	require.Equal(t, []string{"Normal DryRunSummary Dry run would create 2, update 0 and delete 0 entries"}, drainEvents())
+}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"text/template"
@@ -33,24 +34,38 @@ import (
 
 var defaultParentIDTemplate = template.Must(template.New("defaultParentIDTemplate").Parse("spiffe://{{ .TrustDomain }}/spire/agent/k8s_psat/{{ .ClusterName }}/{{ .NodeMeta.UID }}"))
 
+// renderError is a render failure of a specific field.
+type renderError struct {
+	field string
+	err   error
+}
+
+func (e *renderError) Error() string {
+	return e.err.Error()
+}
+
+func (e *renderError) Unwrap() error {
+	return e.err
+}
+
 func renderStaticEntry(spec *spirev1alpha1.ClusterStaticEntrySpec) (*spireapi.Entry, error) {
 	spiffeID, err := spiffeid.FromString(spec.SPIFFEID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse SPIFFEID: %w", err)
+		return nil, &renderError{field: spiffeIDKey, err: fmt.Errorf("failed to parse SPIFFEID: %w", err)}
 	}
 	parentID, err := spiffeid.FromString(spec.ParentID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse ParentID: %w", err)
+		return nil, &renderError{field: parentIDKey, err: fmt.Errorf("failed to parse ParentID: %w", err)}
 	}
 	selectors, err := parseSelectors(spec.Selectors)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse Selectors: %w", err)
+		return nil, &renderError{field: selectorsKey, err: fmt.Errorf("failed to parse Selectors: %w", err)}
 	}
 	federatesWith := make([]spiffeid.TrustDomain, 0, len(spec.FederatesWith))
 	for _, value := range spec.FederatesWith {
 		td, err := spiffeid.TrustDomainFromString(value)
 		if err != nil {
-			return nil, fmt.Errorf("invalid federatesWith value: %w", err)
+			return nil, &renderError{field: federatesWithKey, err: fmt.Errorf("invalid federatesWith value: %w", err)}
 		}
 		federatesWith = append(federatesWith, td)
 	}
@@ -69,6 +84,40 @@ func renderStaticEntry(spec *spirev1alpha1.ClusterStaticEntrySpec) (*spireapi.En
 	}, nil
 }
 
+// renderPlaceholderEntry renders the placeholder entry of a ClusterSPIFFEID
+// that selects no pods. The SPIFFE ID template is rendered without pod or
+// node data.
+func renderPlaceholderEntry(spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, trustDomain spiffeid.TrustDomain, clusterName, clusterDomain string) (*spireapi.Entry, error) {
+	data := &templateData{
+		TrustDomain:   trustDomain.Name(),
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+	}
+	spiffeID, err := renderSPIFFEID(spec.SPIFFEIDTemplate, data, trustDomain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render SPIFFE ID: %w", err)
+	}
+	selectors, err := parseSelectors(spec.Placeholder.Selectors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Selectors: %w", err)
+	}
+	hint, err := renderHint(spec, data)
+	if err != nil {
+		return nil, err
+	}
+	return &spireapi.Entry{
+		SPIFFEID:      spiffeID,
+		ParentID:      spec.Placeholder.ParentID,
+		Selectors:     selectors,
+		X509SVIDTTL:   spec.TTL,
+		JWTSVIDTTL:    spec.JWTTTL,
+		FederatesWith: spec.FederatesWith,
+		Admin:         spec.Admin,
+		Downstream:    spec.Downstream,
+		Hint:          hint,
+	}, nil
+}
+
 func renderPodEntry(spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, node *corev1.Node, pod *corev1.Pod, endpointsList *corev1.EndpointsList, trustDomain spiffeid.TrustDomain, clusterName, clusterDomain string, parentIDTemplate *template.Template) (*spireapi.Entry, error) {
 	// We uniquely target the Pod running on the Node. The former is done
 	// via the k8s:pod-uid selector, the latter via the parent ID.
@@ -82,6 +131,7 @@ func renderPodEntry(spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, node *corev1.
 		ClusterDomain: clusterDomain,
 		NodeMeta:      &node.ObjectMeta,
 		NodeSpec:      &node.Spec,
+		NodeTopology:  nodeTopologyFromLabels(node.Labels),
 	}
 
 	if parentIDTemplate == nil {
@@ -95,6 +145,9 @@ func renderPodEntry(spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, node *corev1.
 
 	data.PodMeta = &pod.ObjectMeta
 	data.PodSpec = &pod.Spec
+	data.InitContainers = initContainersByName(&pod.Spec)
+	data.EphemeralContainers = ephemeralContainersByName(&pod.Spec)
+	data.PrimaryContainer = findContainer(&pod.Spec, spec.PrimaryContainerName)
 
 	spiffeID, err := renderSPIFFEID(spec.SPIFFEIDTemplate, data, trustDomain)
 	if err != nil {
@@ -106,30 +159,91 @@ func renderPodEntry(spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, node *corev1.
 	if err != nil {
 		return nil, err
 	}
-	dnsNames = appendIfNotExists(dnsNames, dnsNamesSet, dnsNamesFromEndpoints(endpointsList, clusterDomain)...)
+	// The rendered DNS names keep the order of their templates, since the
+	// first is used as the common name of X509-SVIDs. The auto-populated
+	// names follow in sorted order, so that the order sent to SPIRE is the
+	// same each pass regardless of how the endpoints were listed.
+	autoPopulated := dnsNamesFromEndpoints(endpointsList, clusterDomain)
+	if spec.AutoPopulatePodIP {
+		autoPopulated = append(autoPopulated, podIPs(pod)...)
+	}
+	sort.Strings(autoPopulated)
+	dnsNames = appendIfNotExists(dnsNames, dnsNamesSet, autoPopulated...)
 
+	// Duplicate selectors (e.g. a workload selector template also producing
+	// the pod-uid selector) are dropped so that they do not end up in the
+	// entry or its key.
+	selectorsSet := map[spireapi.Selector]struct{}{selectors[0]: {}}
 	for _, workloadSelectorTemplate := range spec.WorkloadSelectorTemplates {
 		selector, err := renderSelector(workloadSelectorTemplate, data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to render workload selector: %w", err)
 		}
+		if _, ok := selectorsSet[selector]; ok {
+			continue
+		}
+		selectorsSet[selector] = struct{}{}
 		selectors = append(selectors, selector)
 	}
 
+	if err := validateSelectors(selectors); err != nil {
+		return nil, err
+	}
+
+	federatesWith, err := renderFederatesWith(spec, data)
+	if err != nil {
+		return nil, err
+	}
+
+	hint, err := renderHint(spec, data)
+	if err != nil {
+		return nil, err
+	}
+
 	return &spireapi.Entry{
 		SPIFFEID:      spiffeID,
 		ParentID:      parentID,
 		Selectors:     selectors,
 		X509SVIDTTL:   spec.TTL,
 		JWTSVIDTTL:    spec.JWTTTL,
-		FederatesWith: spec.FederatesWith,
+		FederatesWith: federatesWith,
 		DNSNames:      dnsNames,
 		Admin:         spec.Admin,
 		Downstream:    spec.Downstream,
-		Hint:          spec.Hint,
+		Hint:          hint,
 	}, nil
 }
 
+// renderAliasSPIFFEIDs renders the alias SPIFFE IDs of the pod, if the spec
+// has any.
+func renderAliasSPIFFEIDs(spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, node *corev1.Node, pod *corev1.Pod, trustDomain spiffeid.TrustDomain, clusterName, clusterDomain string) ([]spiffeid.ID, error) {
+	if len(spec.AliasSPIFFEIDTemplates) == 0 {
+		return nil, nil
+	}
+	data := &templateData{
+		TrustDomain:         trustDomain.Name(),
+		ClusterName:         clusterName,
+		ClusterDomain:       clusterDomain,
+		PodMeta:             &pod.ObjectMeta,
+		PodSpec:             &pod.Spec,
+		NodeMeta:            &node.ObjectMeta,
+		NodeSpec:            &node.Spec,
+		NodeTopology:        nodeTopologyFromLabels(node.Labels),
+		InitContainers:      initContainersByName(&pod.Spec),
+		EphemeralContainers: ephemeralContainersByName(&pod.Spec),
+		PrimaryContainer:    findContainer(&pod.Spec, spec.PrimaryContainerName),
+	}
+	aliases := make([]spiffeid.ID, 0, len(spec.AliasSPIFFEIDTemplates))
+	for _, aliasSPIFFEIDTemplate := range spec.AliasSPIFFEIDTemplates {
+		alias, err := renderSPIFFEID(aliasSPIFFEIDTemplate, data, trustDomain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render alias SPIFFE ID: %w", err)
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
+
 type templateData struct {
 	TrustDomain   string
 	ClusterName   string
@@ -138,6 +252,63 @@ type templateData struct {
 	PodSpec       *corev1.PodSpec
 	NodeMeta      *metav1.ObjectMeta
 	NodeSpec      *corev1.NodeSpec
+
+	// NodeTopology holds the well-known topology labels of the node, e.g.
+	// {{ .NodeTopology.Zone }}.
+	NodeTopology nodeTopology
+
+	// InitContainers and EphemeralContainers index the containers of the
+	// pod spec by name, e.g. {{ .InitContainers.setup.Image }}.
+	InitContainers      map[string]*corev1.Container
+	EphemeralContainers map[string]*corev1.EphemeralContainer
+
+	// PrimaryContainer is the container named by the PrimaryContainerName
+	// of the spec, if any.
+	PrimaryContainer *corev1.Container
+}
+
+// nodeTopology is the zone and region of a node, empty if the node does not
+// have the corresponding label.
+type nodeTopology struct {
+	Zone   string
+	Region string
+}
+
+func nodeTopologyFromLabels(nodeLabels map[string]string) nodeTopology {
+	return nodeTopology{
+		Zone:   nodeLabels[corev1.LabelTopologyZone],
+		Region: nodeLabels[corev1.LabelTopologyRegion],
+	}
+}
+
+func initContainersByName(podSpec *corev1.PodSpec) map[string]*corev1.Container {
+	containers := make(map[string]*corev1.Container, len(podSpec.InitContainers))
+	for i := range podSpec.InitContainers {
+		containers[podSpec.InitContainers[i].Name] = &podSpec.InitContainers[i]
+	}
+	return containers
+}
+
+// findContainer returns the container of the pod spec with the given name,
+// or nil if there is none.
+func findContainer(podSpec *corev1.PodSpec, name string) *corev1.Container {
+	if name == "" {
+		return nil
+	}
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == name {
+			return &podSpec.Containers[i]
+		}
+	}
+	return nil
+}
+
+func ephemeralContainersByName(podSpec *corev1.PodSpec) map[string]*corev1.EphemeralContainer {
+	containers := make(map[string]*corev1.EphemeralContainer, len(podSpec.EphemeralContainers))
+	for i := range podSpec.EphemeralContainers {
+		containers[podSpec.EphemeralContainers[i].Name] = &podSpec.EphemeralContainers[i]
+	}
+	return containers
 }
 
 func renderSPIFFEID(tmpl *template.Template, data *templateData, expectTD spiffeid.TrustDomain) (spiffeid.ID, error) {
@@ -149,12 +320,19 @@ func renderSPIFFEID(tmpl *template.Template, data *templateData, expectTD spiffe
 	if err != nil {
 		return spiffeid.ID{}, fmt.Errorf("invalid SPIFFE ID: %w", err)
 	}
-	if id.TrustDomain() != expectTD {
-		return spiffeid.ID{}, fmt.Errorf("invalid SPIFFE ID: expected trust domain %q but got %q", expectTD, id.TrustDomain())
+	if err := checkTrustDomain(id.TrustDomain(), expectTD); err != nil {
+		return spiffeid.ID{}, fmt.Errorf("invalid SPIFFE ID: %w", err)
 	}
 	return id, nil
 }
 
+func checkTrustDomain(td, expectTD spiffeid.TrustDomain) error {
+	if td != expectTD {
+		return fmt.Errorf("expected trust domain %q but got %q", expectTD, td)
+	}
+	return nil
+}
+
 func renderDNSNames(dnsNamesSet map[string]struct{}, dnsNameTemplates []*template.Template, data *templateData) (dnsNames []string, err error) {
 	for _, dnsNameTemplate := range dnsNameTemplates {
 		dnsName, err := renderDNSName(dnsNameTemplate, data)
@@ -195,12 +373,82 @@ func dnsNamesFromEndpoints(endpointsList *corev1.EndpointsList, clusterDomain st
 	return dnsNames
 }
 
+// limitEndpoints trims the endpoints list down to at most max endpoints,
+// keeping the first ones by namespace and name so that the selection is
+// stable. It returns how many endpoints were dropped. A max of zero means no
+// limit.
+func limitEndpoints(endpointsList *corev1.EndpointsList, max int) int {
+	if max <= 0 || len(endpointsList.Items) <= max {
+		return 0
+	}
+	sort.Slice(endpointsList.Items, func(i, j int) bool {
+		a, b := endpointsList.Items[i], endpointsList.Items[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	dropped := len(endpointsList.Items) - max
+	endpointsList.Items = endpointsList.Items[:max]
+	return dropped
+}
+
+// podIPs returns the IPs assigned to the pod, if any.
+func podIPs(pod *corev1.Pod) []string {
+	var ips []string
+	if pod.Status.PodIP != "" {
+		ips = append(ips, pod.Status.PodIP)
+	}
+	for _, podIP := range pod.Status.PodIPs {
+		if podIP.IP != pod.Status.PodIP {
+			ips = append(ips, podIP.IP)
+		}
+	}
+	return ips
+}
+
+// renderFederatesWith returns the trust domains of the spec followed by those
+// rendered from its templates, without duplicates.
+func renderFederatesWith(spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, data *templateData) ([]spiffeid.TrustDomain, error) {
+	if len(spec.FederatesWithTemplates) == 0 {
+		return spec.FederatesWith, nil
+	}
+	federatesWith := append([]spiffeid.TrustDomain(nil), spec.FederatesWith...)
+	for _, federatesWithTemplate := range spec.FederatesWithTemplates {
+		rendered, err := renderTemplate(federatesWithTemplate, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render federated trust domain: %w", err)
+		}
+		td, err := spiffeid.TrustDomainFromString(rendered)
+		if err != nil {
+			return nil, fmt.Errorf("invalid federated trust domain %q: %w", rendered, err)
+		}
+		if !slices.Contains(federatesWith, td) {
+			federatesWith = append(federatesWith, td)
+		}
+	}
+	return federatesWith, nil
+}
+
+// renderHint returns the hint of the entry, rendering the hint template if
+// the spec has one.
+func renderHint(spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, data *templateData) (string, error) {
+	if spec.HintTemplate == nil {
+		return spec.Hint, nil
+	}
+	hint, err := renderTemplate(spec.HintTemplate, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render hint: %w", err)
+	}
+	return hint, nil
+}
+
 func renderSelector(tmpl *template.Template, data *templateData) (spireapi.Selector, error) {
 	rendered, err := renderTemplate(tmpl, data)
 	if err != nil {
 		return spireapi.Selector{}, err
 	}
-	selector, err := parseSelector(rendered)
+	selector, err := ParseSelector(rendered)
 	if err != nil {
 		return spireapi.Selector{}, fmt.Errorf("invalid workload selector %q: %w", rendered, err)
 	}
@@ -218,7 +466,7 @@ func renderTemplate(tmpl *template.Template, data *templateData) (string, error)
 func parseSelectors(selectors []string) ([]spireapi.Selector, error) {
 	ss := make([]spireapi.Selector, 0, len(selectors))
 	for _, selector := range selectors {
-		s, err := parseSelector(selector)
+		s, err := ParseSelector(selector)
 		if err != nil {
 			return nil, err
 		}
@@ -227,7 +475,22 @@ func parseSelectors(selectors []string) ([]spireapi.Selector, error) {
 	return ss, nil
 }
 
-func parseSelector(selector string) (spireapi.Selector, error) {
+// validateSelectors checks that every selector has a type and a value. The
+// SPIRE server would otherwise reject the whole batch the entry is sent in.
+func validateSelectors(selectors []spireapi.Selector) error {
+	for _, selector := range selectors {
+		switch {
+		case selector.Type == "":
+			return fmt.Errorf("invalid selector %q: type cannot be empty", selector.Type+":"+selector.Value)
+		case selector.Value == "":
+			return fmt.Errorf("invalid selector %q: value cannot be empty", selector.Type+":"+selector.Value)
+		}
+	}
+	return nil
+}
+
+// ParseSelector parses a selector of the form "type:value".
+func ParseSelector(selector string) (spireapi.Selector, error) {
 	parts := strings.SplitN(selector, ":", 2)
 	switch {
 	case len(parts) == 1:
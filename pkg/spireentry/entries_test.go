@@ -8,6 +8,7 @@ import (
 
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -102,6 +103,60 @@ func TestRenderPodEntry(t *testing.T) {
 	}
 }
 
+func TestRenderPodEntryDNSNameOrder(t *testing.T) {
+	spec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&spirev1alpha1.ClusterSPIFFEIDSpec{
+		SPIFFEIDTemplate:  "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		DNSNameTemplates:  []string{"{{ .PodMeta.Name }}.{{ .PodMeta.Namespace }}", "api.{{ .PodMeta.Namespace }}"},
+		AutoPopulatePodIP: true,
+	})
+	require.NoError(t, err)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{UID: "uid"}}
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	newEndpoints := func(name string) corev1.Endpoints {
+		return corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "namespace"}}
+	}
+
+	// Each pass may list the endpoints, and report the pod IPs, in a
+	// different order.
+	var passes [][]string
+	for _, tt := range []struct {
+		endpoints []corev1.Endpoints
+		podIPs    []corev1.PodIP
+	}{
+		{
+			endpoints: []corev1.Endpoints{newEndpoints("web"), newEndpoints("api")},
+			podIPs:    []corev1.PodIP{{IP: "fd00::1"}, {IP: "10.0.0.1"}},
+		},
+		{
+			endpoints: []corev1.Endpoints{newEndpoints("api"), newEndpoints("web")},
+			podIPs:    []corev1.PodIP{{IP: "10.0.0.1"}, {IP: "fd00::1"}},
+		},
+	} {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "namespace"},
+			Status:     corev1.PodStatus{PodIPs: tt.podIPs},
+		}
+		entry, err := renderPodEntry(spec, node, pod, &corev1.EndpointsList{Items: tt.endpoints}, td, clusterName, "", nil)
+		require.NoError(t, err)
+		passes = append(passes, entry.DNSNames)
+	}
+
+	// The rendered names come first, in template order, followed by the
+	// auto-populated names, sorted, less those already rendered.
+	expectDNSNames := []string{
+		"test.namespace",
+		"api.namespace",
+		"10.0.0.1",
+		"api",
+		"api.namespace.svc",
+		"fd00::1",
+		"web",
+		"web.namespace",
+		"web.namespace.svc",
+	}
+	require.Equal(t, [][]string{expectDNSNames, expectDNSNames}, passes)
+}
+
 func TestJWTTTLInRenderPodEntry(t *testing.T) {
 	spec := &spirev1alpha1.ClusterSPIFFEIDSpec{
 		SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
@@ -135,6 +190,33 @@ func TestJWTTTLInRenderPodEntry(t *testing.T) {
 	require.Equal(t, entry.JWTSVIDTTL.Nanoseconds(), spec.JWTTTL.Nanoseconds())
 }
 
+func TestHintTemplateInRenderPodEntry(t *testing.T) {
+	spec := &spirev1alpha1.ClusterSPIFFEIDSpec{
+		SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
+		HintTemplate:     `{{ index .PodMeta.Labels "app" }}`,
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{UID: "uid"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "namespace",
+			Labels:    map[string]string{"app": "orders"},
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: "test",
+		},
+	}
+
+	parsedSpec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(spec)
+	require.NoError(t, err)
+	td, err := spiffeid.TrustDomainFromString(trustDomain)
+	require.NoError(t, err)
+
+	entry, err := renderPodEntry(parsedSpec, node, pod, &corev1.EndpointsList{}, td, clusterName, clusterDomain, nil)
+	require.NoError(t, err)
+	require.Equal(t, "orders", entry.Hint)
+}
+
 func TestParentIDTemplateRenderPodEntry(t *testing.T) {
 	spec := &spirev1alpha1.ClusterSPIFFEIDSpec{
 		SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
@@ -170,3 +252,444 @@ func TestParentIDTemplateRenderPodEntry(t *testing.T) {
 
 	require.Equal(t, entry.ParentID.String(), fmt.Sprintf("spiffe://%s/spire/agent/x509pop/test.example.org", td))
 }
+
+func TestDuplicateSelectorsInRenderPodEntry(t *testing.T) {
+	spec := &spirev1alpha1.ClusterSPIFFEIDSpec{
+		SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
+		WorkloadSelectorTemplates: []string{
+			"k8s:pod-uid:{{ .PodMeta.UID }}",
+			"k8s:sa:{{ .PodSpec.ServiceAccountName }}",
+			"k8s:sa:{{ .PodSpec.ServiceAccountName }}",
+		},
+	}
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			UID: "uid",
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "namespace",
+			UID:       "pod-uid",
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: "test",
+		},
+	}
+
+	parsedSpec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(spec)
+	require.NoError(t, err)
+	td, err := spiffeid.TrustDomainFromString(trustDomain)
+	require.NoError(t, err)
+
+	entry, err := renderPodEntry(parsedSpec, node, pod, &corev1.EndpointsList{}, td, clusterName, clusterDomain, nil)
+	require.NoError(t, err)
+
+	expected := []spireapi.Selector{
+		{Type: "k8s", Value: "pod-uid:pod-uid"},
+		{Type: "k8s", Value: "sa:test"},
+	}
+	require.Equal(t, expected, entry.Selectors)
+
+	// The key is computed on the deduplicated selectors.
+	require.Equal(t, makeEntryKey(spireapi.Entry{ParentID: entry.ParentID, SPIFFEID: entry.SPIFFEID, Selectors: expected}), makeEntryKey(*entry))
+}
+
+func TestRenderStaticEntry(t *testing.T) {
+	spec := &spirev1alpha1.ClusterStaticEntrySpec{
+		SPIFFEID:      "spiffe://example.org/static",
+		ParentID:      "spiffe://example.org/parent",
+		Selectors:     []string{"aws:tag:name:static"},
+		FederatesWith: []string{"other.org"},
+		X509SVIDTTL:   metav1.Duration{Duration: time.Hour},
+		JWTSVIDTTL:    metav1.Duration{Duration: time.Minute},
+		DNSNames:      []string{"static.example.org"},
+		Hint:          "static",
+		StoreSVID:     true,
+	}
+
+	entry, err := renderStaticEntry(spec)
+	require.NoError(t, err)
+	require.Equal(t, &spireapi.Entry{
+		SPIFFEID:      spiffeid.RequireFromString("spiffe://example.org/static"),
+		ParentID:      spiffeid.RequireFromString("spiffe://example.org/parent"),
+		Selectors:     []spireapi.Selector{{Type: "aws", Value: "tag:name:static"}},
+		X509SVIDTTL:   time.Hour,
+		JWTSVIDTTL:    time.Minute,
+		FederatesWith: []spiffeid.TrustDomain{spiffeid.RequireTrustDomainFromString("other.org")},
+		DNSNames:      []string{"static.example.org"},
+		Hint:          "static",
+		StoreSVID:     true,
+	}, entry)
+}
+
+func TestValidateSelectors(t *testing.T) {
+	require.NoError(t, validateSelectors([]spireapi.Selector{{Type: "k8s", Value: "pod-uid:uid"}}))
+	require.EqualError(t, validateSelectors([]spireapi.Selector{{Type: "k8s", Value: "pod-uid:uid"}, {Type: "k8s"}}), `invalid selector "k8s:": value cannot be empty`)
+	require.EqualError(t, validateSelectors([]spireapi.Selector{{Value: "sa:workload"}}), `invalid selector ":sa:workload": type cannot be empty`)
+}
+
+func TestContainerTemplateData(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{UID: "uid", Name: "node"},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "namespace", UID: "pod-uid"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{Name: "setup", Image: "registry.example.org/setup:v1"},
+				{Name: "identity", Image: "registry.example.org/identity:v2"},
+			},
+			Containers: []corev1.Container{
+				{Name: "proxy", Image: "registry.example.org/proxy:v5"},
+				{Name: "app", Image: "registry.example.org/app:v3"},
+			},
+			EphemeralContainers: []corev1.EphemeralContainer{
+				{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "registry.example.org/debug:v4"}},
+			},
+		},
+	}
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+
+	for _, tt := range []struct {
+		name              string
+		spec              spirev1alpha1.ClusterSPIFFEIDSpec
+		expectSPIFFEID    string
+		expectSelectors   []spireapi.Selector
+		expectDNSNames    []string
+		expectErrContains string
+	}{
+		{
+			name: "init container in SPIFFE ID",
+			spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/init/{{ .InitContainers.identity.Name }}",
+			},
+			expectSPIFFEID:  "spiffe://example.org/init/identity",
+			expectSelectors: []spireapi.Selector{{Type: "k8s", Value: "pod-uid:pod-uid"}},
+		},
+		{
+			name: "init container image in workload selector",
+			spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate:          "spiffe://{{ .TrustDomain }}/workload",
+				WorkloadSelectorTemplates: []string{"k8s:container-image:{{ (index .InitContainers \"setup\").Image }}"},
+			},
+			expectSPIFFEID: "spiffe://example.org/workload",
+			expectSelectors: []spireapi.Selector{
+				{Type: "k8s", Value: "pod-uid:pod-uid"},
+				{Type: "k8s", Value: "container-image:registry.example.org/setup:v1"},
+			},
+		},
+		{
+			name: "ephemeral container in DNS name",
+			spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/workload",
+				DNSNameTemplates: []string{"{{ .EphemeralContainers.debugger.Name }}.{{ .PodMeta.Namespace }}"},
+			},
+			expectSPIFFEID:  "spiffe://example.org/workload",
+			expectSelectors: []spireapi.Selector{{Type: "k8s", Value: "pod-uid:pod-uid"}},
+			expectDNSNames:  []string{"debugger.namespace"},
+		},
+		{
+			name: "primary container image in workload selector",
+			spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate:          "spiffe://{{ .TrustDomain }}/{{ .PrimaryContainer.Name }}",
+				WorkloadSelectorTemplates: []string{"k8s:container-image:{{ .PrimaryContainer.Image }}"},
+				PrimaryContainerName:      "app",
+			},
+			expectSPIFFEID: "spiffe://example.org/app",
+			expectSelectors: []spireapi.Selector{
+				{Type: "k8s", Value: "pod-uid:pod-uid"},
+				{Type: "k8s", Value: "container-image:registry.example.org/app:v3"},
+			},
+		},
+		{
+			name: "primary container not set",
+			spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate:          "spiffe://{{ .TrustDomain }}/workload",
+				WorkloadSelectorTemplates: []string{"k8s:container-image:{{ .PrimaryContainer.Image }}"},
+			},
+			expectErrContains: "failed to render workload selector",
+		},
+		{
+			name: "missing init container",
+			spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/init/{{ .InitContainers.missing.Image }}",
+			},
+			expectErrContains: "invalid SPIFFE ID",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			parsedSpec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&tt.spec)
+			require.NoError(t, err)
+
+			entry, err := renderPodEntry(parsedSpec, node, pod, &corev1.EndpointsList{}, td, clusterName, clusterDomain, nil)
+			if tt.expectErrContains != "" {
+				require.ErrorContains(t, err, tt.expectErrContains)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectSPIFFEID, entry.SPIFFEID.String())
+			require.Equal(t, tt.expectSelectors, entry.Selectors)
+			require.Equal(t, tt.expectDNSNames, entry.DNSNames)
+		})
+	}
+}
+func TestNodeTopologyTemplateData(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "namespace", UID: "pod-uid"},
+	}
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+
+	for _, tt := range []struct {
+		name           string
+		nodeLabels     map[string]string
+		spec           spirev1alpha1.ClusterSPIFFEIDSpec
+		expectSPIFFEID string
+		expectDNSNames []string
+	}{
+		{
+			name: "zone and region in SPIFFE ID",
+			nodeLabels: map[string]string{
+				corev1.LabelTopologyZone:   "us-east-1a",
+				corev1.LabelTopologyRegion: "us-east-1",
+			},
+			spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/region/{{ .NodeTopology.Region }}/zone/{{ .NodeTopology.Zone }}",
+			},
+			expectSPIFFEID: "spiffe://example.org/region/us-east-1/zone/us-east-1a",
+		},
+		{
+			name: "zone in DNS name",
+			nodeLabels: map[string]string{
+				corev1.LabelTopologyZone: "eu-west-1b",
+			},
+			spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/workload",
+				DNSNameTemplates: []string{"{{ .PodMeta.Name }}.{{ .NodeTopology.Zone }}.example.org"},
+			},
+			expectSPIFFEID: "spiffe://example.org/workload",
+			expectDNSNames: []string{"test.eu-west-1b.example.org"},
+		},
+		{
+			name: "unlabeled node",
+			spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/workload{{ with .NodeTopology.Zone }}/zone/{{ . }}{{ end }}",
+			},
+			expectSPIFFEID: "spiffe://example.org/workload",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{UID: "uid", Name: "node", Labels: tt.nodeLabels},
+			}
+			parsedSpec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&tt.spec)
+			require.NoError(t, err)
+
+			entry, err := renderPodEntry(parsedSpec, node, pod, &corev1.EndpointsList{}, td, clusterName, clusterDomain, nil)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectSPIFFEID, entry.SPIFFEID.String())
+			require.Equal(t, tt.expectDNSNames, entry.DNSNames)
+		})
+	}
+
+	t.Run("zone in parent ID", func(t *testing.T) {
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{UID: "uid", Name: "node", Labels: map[string]string{corev1.LabelTopologyZone: "us-east-1a"}},
+		}
+		parsedSpec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/workload",
+		})
+		require.NoError(t, err)
+		parentIDTemplate := template.Must(template.New("parentIDTemplate").Parse("spiffe://{{ .TrustDomain }}/agent/{{ .NodeTopology.Zone }}/{{ .NodeMeta.Name }}"))
+
+		entry, err := renderPodEntry(parsedSpec, node, pod, &corev1.EndpointsList{}, td, clusterName, clusterDomain, parentIDTemplate)
+		require.NoError(t, err)
+		require.Equal(t, "spiffe://example.org/agent/us-east-1a/node", entry.ParentID.String())
+	})
+}
+
+func TestRenderAliasSPIFFEIDs(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{UID: "uid", Name: "node"},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "namespace", UID: "pod-uid"},
+		Spec:       corev1.PodSpec{ServiceAccountName: "sa"},
+	}
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+
+	for _, tt := range []struct {
+		name              string
+		templates         []string
+		expectAliases     []string
+		expectErrContains string
+	}{
+		{
+			name: "none",
+		},
+		{
+			name: "several",
+			templates: []string{
+				"spiffe://{{ .TrustDomain }}/legacy/{{ .PodSpec.ServiceAccountName }}",
+				"spiffe://{{ .TrustDomain }}/cluster/{{ .ClusterName }}/{{ .PodMeta.Name }}",
+			},
+			expectAliases: []string{
+				"spiffe://example.org/legacy/sa",
+				"spiffe://example.org/cluster/test/test",
+			},
+		},
+		{
+			name:              "other trust domain",
+			templates:         []string{"spiffe://other.org/legacy"},
+			expectErrContains: "failed to render alias SPIFFE ID",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			parsedSpec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate:       "spiffe://{{ .TrustDomain }}/workload",
+				AliasSPIFFEIDTemplates: tt.templates,
+			})
+			require.NoError(t, err)
+
+			aliases, err := renderAliasSPIFFEIDs(parsedSpec, node, pod, td, clusterName, clusterDomain)
+			if tt.expectErrContains != "" {
+				require.ErrorContains(t, err, tt.expectErrContains)
+				return
+			}
+			require.NoError(t, err)
+			var actual []string
+			for _, alias := range aliases {
+				actual = append(actual, alias.String())
+			}
+			require.Equal(t, tt.expectAliases, actual)
+		})
+	}
+}
+
+func TestRenderFederatesWith(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{UID: "uid", Name: "node", Labels: map[string]string{corev1.LabelTopologyRegion: "region1"}},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "namespace", UID: "pod-uid", Labels: map[string]string{"downstream": "cluster1"}},
+		Spec:       corev1.PodSpec{ServiceAccountName: "sa"},
+	}
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+
+	for _, tt := range []struct {
+		name                string
+		federatesWith       []string
+		templates           []string
+		expectFederatesWith []string
+		expectErrContains   string
+	}{
+		{
+			name:                "no templates",
+			federatesWith:       []string{"partner.org"},
+			expectFederatesWith: []string{"partner.org"},
+		},
+		{
+			name:          "templates",
+			federatesWith: []string{"partner.org"},
+			templates: []string{
+				"{{ index .PodMeta.Labels \"downstream\" }}.{{ .NodeTopology.Region }}.example.org",
+				"partner.org",
+			},
+			expectFederatesWith: []string{"partner.org", "cluster1.region1.example.org"},
+		},
+		{
+			name:              "invalid trust domain",
+			templates:         []string{"{{ .PodMeta.Name }}/{{ .PodMeta.Namespace }}"},
+			expectErrContains: `invalid federated trust domain "test/namespace"`,
+		},
+		{
+			name:              "template failure",
+			templates:         []string{"{{ .PodMeta.Missing }}"},
+			expectErrContains: "failed to render federated trust domain",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			parsedSpec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate:       "spiffe://{{ .TrustDomain }}/workload",
+				FederatesWith:          tt.federatesWith,
+				FederatesWithTemplates: tt.templates,
+			})
+			require.NoError(t, err)
+
+			entry, err := renderPodEntry(parsedSpec, node, pod, &corev1.EndpointsList{}, td, clusterName, clusterDomain, nil)
+			if tt.expectErrContains != "" {
+				require.ErrorContains(t, err, tt.expectErrContains)
+				return
+			}
+			require.NoError(t, err)
+			var actual []string
+			for _, federatedTD := range entry.FederatesWith {
+				actual = append(actual, federatedTD.Name())
+			}
+			require.Equal(t, tt.expectFederatesWith, actual)
+		})
+	}
+}
+
+func TestLimitEndpoints(t *testing.T) {
+	newEndpointsList := func() *corev1.EndpointsList {
+		endpointsList := &corev1.EndpointsList{}
+		// Add the endpoints in reverse order to exercise the sorting
+		for i := 99; i >= 0; i-- {
+			endpointsList.Items = append(endpointsList.Items, corev1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("endpoint-%02d", i),
+					Namespace: "namespace",
+				},
+			})
+		}
+		return endpointsList
+	}
+
+	t.Run("no limit", func(t *testing.T) {
+		endpointsList := newEndpointsList()
+		require.Zero(t, limitEndpoints(endpointsList, 0))
+		require.Len(t, endpointsList.Items, 100)
+	})
+
+	t.Run("under limit", func(t *testing.T) {
+		endpointsList := newEndpointsList()
+		require.Zero(t, limitEndpoints(endpointsList, 100))
+		require.Len(t, endpointsList.Items, 100)
+	})
+
+	t.Run("over limit", func(t *testing.T) {
+		endpointsList := newEndpointsList()
+		require.Equal(t, 98, limitEndpoints(endpointsList, 2))
+		require.Equal(t, []string{"endpoint-00", "endpoint-01"}, []string{endpointsList.Items[0].Name, endpointsList.Items[1].Name})
+
+		parsedSpec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
+		})
+		require.NoError(t, err)
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "namespace"}, Spec: corev1.PodSpec{ServiceAccountName: "test"}}
+		entry, err := renderPodEntry(parsedSpec, &corev1.Node{ObjectMeta: metav1.ObjectMeta{UID: "uid"}}, pod, endpointsList, spiffeid.RequireTrustDomainFromString(trustDomain), clusterName, clusterDomain, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"endpoint-00",
+			"endpoint-00.namespace",
+			"endpoint-00.namespace.svc",
+			"endpoint-00.namespace.svc.cluster.local",
+			"endpoint-01",
+			"endpoint-01.namespace",
+			"endpoint-01.namespace.svc",
+			"endpoint-01.namespace.svc.cluster.local",
+		}, entry.DNSNames)
+	})
+
+	t.Run("selection is deterministic", func(t *testing.T) {
+		a, b := newEndpointsList(), newEndpointsList()
+		// Shuffle one of the lists
+		b.Items[0], b.Items[50] = b.Items[50], b.Items[0]
+		limitEndpoints(a, 10)
+		limitEndpoints(b, 10)
+		require.Equal(t, a.Items, b.Items)
+	})
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	defaultEntryFailureBackoff = 30 * time.Second
+	maxEntryFailureBackoff     = 10 * time.Minute
+)
+
+// entryFailureState tracks the consecutive attempts to create or update an
+// entry that failed, and the resulting backoff.
+type entryFailureState struct {
+	failures int
+	backoff  time.Duration
+	retryAt  time.Time
+}
+
+// dropBackedOffEntries returns the entries that are not backed off for
+// failing repeatedly. Entries that are dropped count as failures, and as
+// backed off, so that the owning objects reflect that they are not set.
+func (r *entryReconciler) dropBackedOffEntries(ctx context.Context, declaredEntries []declaredEntry, now time.Time) []declaredEntry {
+	if len(r.entryFailures) == 0 {
+		return declaredEntries
+	}
+	log := log.FromContext(ctx)
+	out := make([]declaredEntry, 0, len(declaredEntries))
+	for _, declaredEntry := range declaredEntries {
+		s, ok := r.entryFailures[makeEntryKey(declaredEntry.Entry)]
+		if ok && now.Before(s.retryAt) {
+			declaredEntry.By.IncrementEntryFailures()
+			declaredEntry.By.IncrementEntriesBackedOff()
+			log.V(1).Info("Not writing entry; backed off after repeated failures", append(entryLogFields(declaredEntry.Entry), "retryAt", s.retryAt)...)
+			continue
+		}
+		out = append(out, declaredEntry)
+	}
+	return out
+}
+
+// recordEntryFailure tracks a failed attempt to create or update the entry.
+// Once the entry has failed EntryFailureBackoffAfter consecutive times, it is
+// backed off, doubling the backoff on each further failing attempt up to a
+// maximum, so that a few entries the SPIRE server keeps rejecting do not
+// weigh on every pass.
+func (r *entryReconciler) recordEntryFailure(ctx context.Context, declaredEntry declaredEntry, now time.Time) {
+	if r.config.EntryFailureBackoffAfter <= 0 {
+		return
+	}
+	key := makeEntryKey(declaredEntry.Entry)
+	s, ok := r.entryFailures[key]
+	if !ok {
+		s = &entryFailureState{}
+		r.entryFailures[key] = s
+	}
+	s.failures++
+	if s.failures < r.config.EntryFailureBackoffAfter {
+		return
+	}
+	if s.backoff == 0 {
+		s.backoff = defaultEntryFailureBackoff
+	} else {
+		s.backoff = min(2*s.backoff, maxEntryFailureBackoff)
+	}
+	s.retryAt = now.Add(s.backoff)
+	r.promCounter[metrics.EntryFailureBackoffs].Add(1)
+	log.FromContext(ctx).Info("Entry keeps failing; backing off", append(entryLogFields(declaredEntry.Entry), "failures", s.failures, "backoff", s.backoff.String())...)
+}
+
+// clearEntryFailures resets the tracking of the entry once it is written.
+func (r *entryReconciler) clearEntryFailures(entry spireapi.Entry) {
+	delete(r.entryFailures, makeEntryKey(entry))
+}
+
+// pruneEntryFailures forgets the failures of entries that are no longer
+// declared.
+func (r *entryReconciler) pruneEntryFailures(state entriesState) {
+	for key := range r.entryFailures {
+		if s, ok := state[key]; !ok || len(s.Declared) == 0 {
+			delete(r.entryFailures, key)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package spireentry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestEntryFailureBackoff(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	const poisonID = "spiffe://example.org/ns/default/pod/poison"
+
+	entryClient := newEntryClient()
+	entryClient.createStatus = map[string]spireapi.Status{
+		poisonID: {Code: codes.Internal, Message: "datastore error"},
+	}
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:              entryClient,
+		EntryFailureBackoffAfter: 2,
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"),
+		newTestPod("default", "healthy", "node", nil),
+		newTestPod("default", "poison", "node", nil),
+	)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	getStats := func() spirev1alpha1.ClusterSPIFFEIDStats {
+		actual := new(spirev1alpha1.ClusterSPIFFEID)
+		require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+		return actual.Status.Stats
+	}
+	poisonKey := func() entryKey {
+		require.Len(t, r.entryFailures, 1)
+		for key := range r.entryFailures {
+			return key
+		}
+		return ""
+	}
+
+	t.Log("The failing entry is retried on the next pass while under the threshold")
+	r.reconcile(ctx)
+	r.reconcile(ctx)
+	require.Len(t, entryClient.createBatches, 2)
+	require.ElementsMatch(t, []string{"spiffe://example.org/ns/default/pod/healthy", poisonID}, entryClient.createBatches[0])
+	require.Equal(t, []string{poisonID}, entryClient.createBatches[1])
+	require.Equal(t, []string{"spiffe://example.org/ns/default/pod/healthy"}, entrySPIFFEIDs(entryClient.getEntries()))
+	require.Equal(t, 30*time.Second, r.entryFailures[poisonKey()].backoff)
+	require.Equal(t, 1.0, testutil.ToFloat64(r.promCounter[metrics.EntryFailureBackoffs]))
+
+	t.Log("Once backed off, the failing entry is not retried")
+	r.reconcile(ctx)
+	require.Equal(t, 2, entryClient.createCalls)
+	stats := getStats()
+	require.Equal(t, 1, stats.EntryFailures)
+	require.Equal(t, 1, stats.EntriesBackedOff)
+
+	t.Log("Failing again once the backoff expires doubles it")
+	r.entryFailures[poisonKey()].retryAt = time.Now().Add(-time.Second)
+	r.reconcile(ctx)
+	require.Equal(t, 3, entryClient.createCalls)
+	require.Equal(t, 60*time.Second, r.entryFailures[poisonKey()].backoff)
+	require.Equal(t, 2.0, testutil.ToFloat64(r.promCounter[metrics.EntryFailureBackoffs]))
+
+	t.Log("Succeeding once the backoff expires resets it")
+	r.entryFailures[poisonKey()].retryAt = time.Now().Add(-time.Second)
+	entryClient.createStatus = nil
+	r.reconcile(ctx)
+	require.Equal(t, 4, entryClient.createCalls)
+	require.Len(t, entryClient.getEntries(), 2)
+	require.Empty(t, r.entryFailures)
+	stats = getStats()
+	require.Zero(t, stats.EntryFailures)
+	require.Zero(t, stats.EntriesBackedOff)
+}
+
+func TestEntryFailureBackoffPrunesUndeclaredEntries(t *testing.T) {
+	r := newEntryReconciler(ReconcilerConfig{})
+	declared := spireapi.Entry{SPIFFEID: spiffeid.RequireFromString("spiffe://example.org/declared")}
+	undeclared := spireapi.Entry{SPIFFEID: spiffeid.RequireFromString("spiffe://example.org/undeclared")}
+	r.entryFailures[makeEntryKey(declared)] = &entryFailureState{failures: 1}
+	r.entryFailures[makeEntryKey(undeclared)] = &entryFailureState{failures: 1}
+
+	state := make(entriesState)
+	state.AddDeclared(declared, &ClusterSPIFFEID{}, nil)
+	r.pruneEntryFailures(state)
+	require.Equal(t, map[entryKey]*entryFailureState{makeEntryKey(declared): {failures: 1}}, r.entryFailures)
+}
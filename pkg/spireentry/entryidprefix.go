@@ -0,0 +1,85 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// entryIDPrefixRE matches a legal entry ID prefix, including the trailing
+// dot separating it from the generated part of the ID.
+var entryIDPrefixRE = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*\.$`)
+
+// EntryIDPrefixPolicy lets ClusterSPIFFEIDs and ClusterStaticEntries pick the
+// prefix of the IDs of their entries from a set of approved prefixes by naming
+// the prefix in an annotation. Entries with any of the approved prefixes are
+// managed alongside those with the global prefix, so they are still cleaned
+// up after the object picking the prefix is gone.
+type EntryIDPrefixPolicy struct {
+	// Annotation is the annotation holding the prefix.
+	Annotation string
+
+	// Prefixes are the approved prefixes, each ending with a dot.
+	Prefixes []string
+}
+
+// Validate checks that the annotation is set and the prefixes are legal.
+func (p *EntryIDPrefixPolicy) Validate() error {
+	if p.Annotation == "" {
+		return fmt.Errorf("annotation is required")
+	}
+	for _, prefix := range p.Prefixes {
+		if !entryIDPrefixRE.MatchString(prefix) {
+			return fmt.Errorf("prefix %q must only contain alphanumerics, '-', '_' and '.' separators", prefix)
+		}
+	}
+	return nil
+}
+
+// prefixFor returns the prefix named by the annotation on the object, if any.
+// An error is returned if the prefix is not approved.
+func (p *EntryIDPrefixPolicy) prefixFor(by byObject) (string, bool, error) {
+	if _, ok := by.(*SPIFFEID); ok {
+		// Namespaced SPIFFEIDs are managed by tenants, who don't get to
+		// pick the prefix.
+		return "", false, nil
+	}
+	prefix, ok := by.GetAnnotations()[p.Annotation]
+	if !ok {
+		return "", false, nil
+	}
+	if !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+	if !slices.Contains(p.Prefixes, prefix) {
+		return "", false, fmt.Errorf("entry ID prefix %q is not approved", prefix)
+	}
+	return prefix, true, nil
+}
+
+// manages returns whether the entry ID has one of the approved prefixes.
+func (p *EntryIDPrefixPolicy) manages(id string) bool {
+	for _, prefix := range p.Prefixes {
+		if strings.HasPrefix(id, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package spireentry
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestEntryIDPrefixPolicyValidate(t *testing.T) {
+	for _, tt := range []struct {
+		desc      string
+		policy    EntryIDPrefixPolicy
+		expectErr string
+	}{
+		{
+			desc:   "valid",
+			policy: EntryIDPrefixPolicy{Annotation: "example.org/prefix", Prefixes: []string{"team-a.", "org.team_b."}},
+		},
+		{
+			desc:      "missing annotation",
+			policy:    EntryIDPrefixPolicy{Prefixes: []string{"team-a."}},
+			expectErr: "annotation is required",
+		},
+		{
+			desc:      "illegal character",
+			policy:    EntryIDPrefixPolicy{Annotation: "example.org/prefix", Prefixes: []string{"team/a."}},
+			expectErr: `prefix "team/a." must only contain alphanumerics, '-', '_' and '.' separators`,
+		},
+		{
+			desc:      "empty segment",
+			policy:    EntryIDPrefixPolicy{Annotation: "example.org/prefix", Prefixes: []string{"team..a."}},
+			expectErr: `prefix "team..a." must only contain alphanumerics, '-', '_' and '.' separators`,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestEntryIDPrefixPolicy(t *testing.T) {
+	const annotation = "example.org/entry-id-prefix"
+	newStaticEntry := func(name, prefix string) *spirev1alpha1.ClusterStaticEntry {
+		staticEntry := &spirev1alpha1.ClusterStaticEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: spirev1alpha1.ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://example.org/" + name,
+				ParentID:  "spiffe://example.org/parent",
+				Selectors: []string{"k8s:ns:" + name},
+			},
+		}
+		if prefix != "" {
+			staticEntry.Annotations = map[string]string{annotation: prefix}
+		}
+		return staticEntry
+	}
+	newEntry := func(id string) spireapi.Entry {
+		return spireapi.Entry{
+			ID:        id,
+			SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/" + id),
+			ParentID:  spiffeid.RequireFromString("spiffe://example.org/parent"),
+			Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:" + id}},
+		}
+	}
+
+	// The entries of a deleted object with an approved prefix are still
+	// managed; those with an unknown prefix are left alone.
+	unmanaged := newEntry("other.unmanaged")
+	entryClient := newEntryClient(newEntry("team-b.stale"), unmanaged)
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:   entryClient,
+		EntryIDPrefix: "cluster.",
+		EntryIDPrefixPolicy: &EntryIDPrefixPolicy{
+			Annotation: annotation,
+			Prefixes:   []string{"team-a.", "team-b."},
+		},
+	},
+		newStaticEntry("a", "team-a"),
+		newStaticEntry("b", "team-b."),
+		newStaticEntry("unapproved", "team-c"),
+		newStaticEntry("default", ""),
+	)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+
+	prefixes := make(map[string]string)
+	for _, entry := range entryClient.getEntries() {
+		if entry.ID == unmanaged.ID {
+			continue
+		}
+		prefix, _, _ := strings.Cut(entry.ID, ".")
+		prefixes[entry.SPIFFEID.Path()] = prefix
+	}
+	require.Equal(t, map[string]string{
+		"/a":          "team-a",
+		"/b":          "team-b",
+		"/unapproved": "cluster",
+		"/default":    "cluster",
+	}, prefixes)
+	require.Contains(t, entryClient.getEntries(), unmanaged)
+
+	t.Log("Entries with overridden prefixes are recognized on the next pass")
+	entryClient.createCalls = 0
+	r.reconcile(ctx)
+	require.Zero(t, entryClient.createCalls)
+	require.Len(t, entryClient.getEntries(), 5)
+}
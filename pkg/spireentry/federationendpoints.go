@@ -0,0 +1,94 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/k8sapi"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// federationEndpointCollisionReason is the event reason used when an
+	// entry has the SPIFFE ID of a federation bundle endpoint.
+	federationEndpointCollisionReason = "FederationEndpointCollision"
+
+	clusterFederatedTrustDomainLogKey = "clusterFederatedTrustDomain"
+)
+
+// checkFederationEndpointCollisions warns about declared entries with the
+// SPIFFE ID of the bundle endpoint of a ClusterFederatedTrustDomain managed by
+// the controller. Workloads are not expected to serve federation bundles, so
+// this is almost always a misconfiguration.
+func (r *entryReconciler) checkFederationEndpointCollisions(ctx context.Context, state entriesState) {
+	if !r.config.Reconcile.ClusterFederatedTrustDomains {
+		return
+	}
+	log := log.FromContext(ctx)
+
+	endpoints, err := r.listFederationEndpoints(ctx)
+	if err != nil {
+		log.Error(err, "Failed to list ClusterFederatedTrustDomains; skipping federation endpoint collision check")
+		return
+	}
+	if len(endpoints) == 0 {
+		return
+	}
+
+	for _, s := range state {
+		for _, declared := range s.Declared {
+			name, ok := endpoints[declared.Entry.SPIFFEID]
+			if !ok {
+				continue
+			}
+			log.Info("Found entry with the SPIFFE ID of a federation bundle endpoint", append(entryLogFields(declared.Entry), clusterFederatedTrustDomainLogKey, name)...)
+			if obj := byClientObject(declared.By); obj != nil {
+				r.recordWarning(obj, federationEndpointCollisionReason,
+					"Entry %q has the SPIFFE ID of the bundle endpoint of ClusterFederatedTrustDomain %q", declared.Entry.SPIFFEID, name)
+			}
+		}
+	}
+}
+
+// listFederationEndpoints returns the names of the ClusterFederatedTrustDomains
+// using the https_spiffe profile, by bundle endpoint SPIFFE ID.
+func (r *entryReconciler) listFederationEndpoints(ctx context.Context) (map[spiffeid.ID]string, error) {
+	clusterFederatedTrustDomains, err := k8sapi.ListClusterFederatedTrustDomains(ctx, r.config.K8sClient)
+	if err != nil {
+		return nil, err
+	}
+	endpoints := make(map[spiffeid.ID]string)
+	for i := range clusterFederatedTrustDomains {
+		if !r.reconcileClass(clusterFederatedTrustDomains[i].Spec.ClassName) {
+			continue
+		}
+		// Invalid objects are reported by the federation relationship
+		// reconciler.
+		federationRelationship, err := spirev1alpha1.ParseClusterFederatedTrustDomainSpec(&clusterFederatedTrustDomains[i].Spec)
+		if err != nil {
+			continue
+		}
+		if profile, ok := federationRelationship.BundleEndpointProfile.(spireapi.HTTPSSPIFFEProfile); ok {
+			endpoints[profile.EndpointSPIFFEID] = clusterFederatedTrustDomains[i].Name
+		}
+	}
+	return endpoints, nil
+}
@@ -0,0 +1,84 @@
+package spireentry
+
+import (
+	"context"
+	"testing"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestFederationEndpointCollisions(t *testing.T) {
+	newStaticEntry := func(name string) *spirev1alpha1.ClusterStaticEntry {
+		return &spirev1alpha1.ClusterStaticEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: spirev1alpha1.ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://example.org/" + name,
+				ParentID:  "spiffe://example.org/parent",
+				Selectors: []string{"k8s:ns:" + name},
+			},
+		}
+	}
+	newClusterFederatedTrustDomain := func(name string, profile spirev1alpha1.BundleEndpointProfile) *spirev1alpha1.ClusterFederatedTrustDomain {
+		return &spirev1alpha1.ClusterFederatedTrustDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+				TrustDomain:           name + ".test",
+				BundleEndpointURL:     "https://" + name + ".test/bundle",
+				BundleEndpointProfile: profile,
+			},
+		}
+	}
+	objects := []client.Object{
+		newStaticEntry("bundle-endpoint"),
+		newStaticEntry("workload"),
+		newClusterFederatedTrustDomain("spiffe", spirev1alpha1.BundleEndpointProfile{Type: "https_spiffe", EndpointSPIFFEID: "spiffe://example.org/bundle-endpoint"}),
+		newClusterFederatedTrustDomain("web", spirev1alpha1.BundleEndpointProfile{Type: "https_web"}),
+	}
+
+	for _, tt := range []struct {
+		desc         string
+		reconcile    spirev1alpha1.ReconcileConfig
+		expectEvents []string
+	}{
+		{
+			desc:      "federation relationships managed",
+			reconcile: spirev1alpha1.ReconcileConfig{ClusterStaticEntries: true, ClusterFederatedTrustDomains: true},
+			expectEvents: []string{
+				`Warning FederationEndpointCollision Entry "spiffe://example.org/bundle-endpoint" has the SPIFFE ID of the bundle endpoint of ClusterFederatedTrustDomain "spiffe"`,
+			},
+		},
+		{
+			desc:      "federation relationships not managed",
+			reconcile: spirev1alpha1.ReconcileConfig{ClusterStaticEntries: true},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			entryClient := newEntryClient()
+			recorder := record.NewFakeRecorder(10)
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:   entryClient,
+				Reconcile:     tt.reconcile,
+				EventRecorder: recorder,
+			}, objects...)
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			r.reconcile(ctx)
+
+			// The colliding entry is still created.
+			require.Len(t, entryClient.getEntries(), 2)
+
+			var events []string
+			close(recorder.Events)
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			require.Equal(t, tt.expectEvents, events)
+		})
+	}
+}
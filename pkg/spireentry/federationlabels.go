@@ -0,0 +1,80 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+)
+
+// FederationLabelPolicy lets pods federate with additional trust domains by
+// naming a federation group in a label.
+type FederationLabelPolicy struct {
+	// Label is the pod label holding the name of the federation group.
+	Label string
+
+	// Groups maps group names to trust domains.
+	Groups map[string][]spiffeid.TrustDomain
+
+	// WarnOnUnknownGroup, if set, causes pods naming an unknown group to be
+	// logged. Unknown groups are ignored either way.
+	WarnOnUnknownGroup bool
+}
+
+// Validate checks that the policy is usable.
+func (p *FederationLabelPolicy) Validate() error {
+	if p.Label == "" {
+		return fmt.Errorf("label is required")
+	}
+	for name, trustDomains := range p.Groups {
+		for _, td := range trustDomains {
+			if td.IsZero() {
+				return fmt.Errorf("group %q: trust domain is required", name)
+			}
+		}
+	}
+	return nil
+}
+
+// apply merges the trust domains of the group named by the pod label, if
+// any, into those the entry federates with. An error is returned if the
+// group is unknown, in which case the entry is left as is.
+func (p *FederationLabelPolicy) apply(entry *spireapi.Entry, pod *corev1.Pod) error {
+	name, ok := pod.Labels[p.Label]
+	if !ok {
+		return nil
+	}
+	trustDomains, ok := p.Groups[name]
+	if !ok {
+		return fmt.Errorf("unknown federation group %q", name)
+	}
+	// The declared trust domains may be shared with other entries; never
+	// append to them in place.
+	federatesWith := append([]spiffeid.TrustDomain(nil), entry.FederatesWith...)
+	for _, td := range trustDomains {
+		if !slices.Contains(federatesWith, td) {
+			federatesWith = append(federatesWith, td)
+		}
+	}
+	entry.FederatesWith = federatesWith
+	return nil
+}
@@ -0,0 +1,53 @@
+package spireentry
+
+import (
+	"context"
+	"testing"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestFederationLabelPolicy(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			FederatesWith:    []string{"partner.test"},
+		},
+	}
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+		FederationLabelPolicy: &FederationLabelPolicy{
+			Label: "example.org/federation",
+			Groups: map[string][]spiffeid.TrustDomain{
+				"payments": {spiffeid.RequireTrustDomainFromString("bank.test"), spiffeid.RequireTrustDomainFromString("partner.test")},
+			},
+			WarnOnUnknownGroup: true,
+		},
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"),
+		newTestPod("default", "none", "node", nil),
+		newTestPod("default", "payments", "node", map[string]string{"example.org/federation": "payments"}),
+		newTestPod("default", "unknown", "node", map[string]string{"example.org/federation": "unknown"}),
+	)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+
+	actual := make(map[string][]string)
+	for _, entry := range entryClient.getEntries() {
+		for _, td := range entry.FederatesWith {
+			actual[entry.SPIFFEID.Path()] = append(actual[entry.SPIFFEID.Path()], td.Name())
+		}
+	}
+	require.Equal(t, map[string][]string{
+		"/ns/default/pod/none":     {"partner.test"},
+		"/ns/default/pod/payments": {"partner.test", "bank.test"},
+		"/ns/default/pod/unknown":  {"partner.test"},
+	}, actual)
+}
@@ -29,6 +29,7 @@ import (
 const (
 	clusterStaticEntryLogKey = "clusterStaticEntry"
 	clusterSPIFFEIDLogKey    = "clusterSPIFFEID"
+	spiffeIDObjectLogKey     = "namespacedSPIFFEID"
 	namespaceLogKey          = "namespace"
 	podLogKey                = "pod"
 	idKey                    = "id"
@@ -43,6 +44,9 @@ const (
 	downstreamKey            = "downstream"
 	hintKey                  = "hint"
 	storeSVIDKey             = "storeSVID"
+	duplicateIDsKey          = "duplicateIDs"
+	policyReasonKey          = "policyReason"
+	trustDomainKey           = "trustDomain"
 )
 
 func objectName(o metav1.Object) string {
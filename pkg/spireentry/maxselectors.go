@@ -0,0 +1,79 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+)
+
+// MaxSelectorsAction is what to do with entries with more selectors than the
+// maximum.
+type MaxSelectorsAction string
+
+const (
+	// MaxSelectorsActionReject fails to render entries with more selectors
+	// than the maximum.
+	MaxSelectorsActionReject MaxSelectorsAction = "reject"
+
+	// MaxSelectorsActionTruncate drops the selectors past the maximum,
+	// keeping them in the order they were rendered in. The pod-uid selector
+	// comes first and is always kept.
+	MaxSelectorsActionTruncate MaxSelectorsAction = "truncate"
+)
+
+// MaxSelectorsPolicy guards against pod entries with more selectors than the
+// SPIRE server accepts, e.g. from a workload selector template emitting one
+// selector per pod label.
+type MaxSelectorsPolicy struct {
+	// MaxSelectors is the maximum number of selectors of an entry.
+	MaxSelectors int
+
+	// Action is what to do with entries with more selectors than the
+	// maximum. Defaults to MaxSelectorsActionReject.
+	Action MaxSelectorsAction
+}
+
+// Validate checks that the maximum and action are valid.
+func (p *MaxSelectorsPolicy) Validate() error {
+	if p.MaxSelectors <= 0 {
+		return fmt.Errorf("maximum selectors must be positive")
+	}
+	switch p.Action {
+	case "", MaxSelectorsActionReject, MaxSelectorsActionTruncate:
+		return nil
+	default:
+		return fmt.Errorf("unknown action %q; expected %q or %q", p.Action, MaxSelectorsActionReject, MaxSelectorsActionTruncate)
+	}
+}
+
+// apply truncates the selectors of the entry to the maximum or, if the action
+// is to reject, returns an error if there are more than the maximum.
+func (p *MaxSelectorsPolicy) apply(log logr.Logger, entry *spireapi.Entry) error {
+	count := len(entry.Selectors)
+	if count <= p.MaxSelectors {
+		return nil
+	}
+	if p.Action != MaxSelectorsActionTruncate {
+		return &renderError{field: selectorsKey, err: fmt.Errorf("%d selectors exceed the maximum of %d", count, p.MaxSelectors)}
+	}
+	log.Info("Truncating selectors to the maximum", append(entryLogFields(*entry), "count", count, "maxSelectors", p.MaxSelectors)...)
+	entry.Selectors = entry.Selectors[:p.MaxSelectors:p.MaxSelectors]
+	return nil
+}
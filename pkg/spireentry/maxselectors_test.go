@@ -0,0 +1,90 @@
+package spireentry
+
+import (
+	"context"
+	"testing"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+)
+
+func TestMaxSelectorsPolicyValidate(t *testing.T) {
+	require.NoError(t, (&MaxSelectorsPolicy{MaxSelectors: 1}).Validate())
+	require.NoError(t, (&MaxSelectorsPolicy{MaxSelectors: 1, Action: MaxSelectorsActionTruncate}).Validate())
+	require.EqualError(t, (&MaxSelectorsPolicy{}).Validate(), "maximum selectors must be positive")
+	require.EqualError(t, (&MaxSelectorsPolicy{MaxSelectors: 1, Action: "drop"}).Validate(), `unknown action "drop"; expected "reject" or "truncate"`)
+}
+
+func TestMaxSelectorsPolicy(t *testing.T) {
+	newClusterSPIFFEID := func(name string, workloadSelectorTemplates ...string) *spirev1alpha1.ClusterSPIFFEID {
+		return &spirev1alpha1.ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate:          "spiffe://{{ .TrustDomain }}/" + name + "/{{ .PodMeta.Name }}",
+				WorkloadSelectorTemplates: workloadSelectorTemplates,
+			},
+		}
+	}
+	within := newClusterSPIFFEID("within", "k8s:ns:default")
+	exceeding := newClusterSPIFFEID("exceeding", "k8s:ns:default", "k8s:sa:default", "k8s:pod-name:pod")
+
+	for _, tt := range []struct {
+		desc            string
+		action          MaxSelectorsAction
+		expectSelectors map[string][]spireapi.Selector
+		expectFailures  int
+	}{
+		{
+			desc: "reject",
+			expectSelectors: map[string][]spireapi.Selector{
+				"/within/pod": {
+					{Type: "k8s", Value: "pod-uid:pod-uid"},
+					{Type: "k8s", Value: "ns:default"},
+				},
+			},
+			expectFailures: 1,
+		},
+		{
+			desc:   "truncate",
+			action: MaxSelectorsActionTruncate,
+			expectSelectors: map[string][]spireapi.Selector{
+				"/within/pod": {
+					{Type: "k8s", Value: "pod-uid:pod-uid"},
+					{Type: "k8s", Value: "ns:default"},
+				},
+				"/exceeding/pod": {
+					{Type: "k8s", Value: "pod-uid:pod-uid"},
+					{Type: "k8s", Value: "ns:default"},
+					{Type: "k8s", Value: "sa:default"},
+				},
+			},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			entryClient := newEntryClient()
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:        entryClient,
+				MaxSelectorsPolicy: &MaxSelectorsPolicy{MaxSelectors: 3, Action: tt.action},
+			}, within, exceeding, newTestNamespace("default"), newTestNode("node"), newTestPod("default", "pod", "node", nil))
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			r.reconcile(ctx)
+
+			selectors := make(map[string][]spireapi.Selector)
+			for _, entry := range entryClient.getEntries() {
+				selectors[entry.SPIFFEID.Path()] = entry.Selectors
+			}
+			require.Equal(t, tt.expectSelectors, selectors)
+
+			actual := new(spirev1alpha1.ClusterSPIFFEID)
+			require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(exceeding), actual))
+			require.Equal(t, tt.expectFailures, actual.Status.Stats.PodEntryRenderFailures)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"slices"
+)
+
+// mergeMaskedEntries unions the federated trust domains and DNS names of the
+// masked entries into the preferred entry, i.e. the first one, if so
+// configured. The declared entries share the same key, so only these
+// non-key fields can differ. The slices are copied since they may be shared
+// with the parsed spec of the declaring object.
+func (r *entryReconciler) mergeMaskedEntries(declared []declaredEntry) {
+	if !r.config.MergeMaskedEntries || len(declared) < 2 {
+		return
+	}
+	preferred := &declared[0].Entry
+	federatesWith := slices.Clone(preferred.FederatesWith)
+	var mergedDNSNames []string
+	for _, masked := range declared[1:] {
+		for _, td := range masked.Entry.FederatesWith {
+			if !slices.Contains(federatesWith, td) {
+				federatesWith = append(federatesWith, td)
+			}
+		}
+		for _, dnsName := range masked.Entry.DNSNames {
+			if !slices.Contains(preferred.DNSNames, dnsName) && !slices.Contains(mergedDNSNames, dnsName) {
+				mergedDNSNames = append(mergedDNSNames, dnsName)
+			}
+		}
+	}
+	// The DNS names of the preferred entry keep their order, since the first
+	// is used as the common name of X509-SVIDs. The merged names follow in
+	// sorted order.
+	slices.Sort(mergedDNSNames)
+	preferred.FederatesWith = federatesWith
+	preferred.DNSNames = append(slices.Clone(preferred.DNSNames), mergedDNSNames...)
+}
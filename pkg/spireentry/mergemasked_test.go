@@ -0,0 +1,123 @@
+package spireentry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestMergeMaskedEntries(t *testing.T) {
+	newClusterSPIFFEID := func(name string, created time.Time, federatesWith []string, dnsName string) *spirev1alpha1.ClusterSPIFFEID {
+		return &spirev1alpha1.ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(created)},
+			Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+				FederatesWith:    federatesWith,
+				DNSNameTemplates: []string{dnsName},
+			},
+		}
+	}
+	now := time.Now().Truncate(time.Second)
+	older := newClusterSPIFFEID("older", now.Add(-time.Hour), []string{"a.org", "shared.org"}, "older.default")
+	newer := newClusterSPIFFEID("newer", now, []string{"b.org", "shared.org"}, "newer.default")
+	objects := []client.Object{
+		older, newer,
+		newTestNamespace("default"), newTestNode("node"),
+		newTestPod("default", "pod", "node", nil),
+	}
+
+	for _, tt := range []struct {
+		desc                string
+		merge               bool
+		expectFederatesWith []string
+		expectDNSNames      []string
+		expectMasked        int
+	}{
+		{
+			desc:                "masked",
+			expectFederatesWith: []string{"a.org", "shared.org"},
+			expectDNSNames:      []string{"older.default"},
+			expectMasked:        1,
+		},
+		{
+			desc:                "merged",
+			merge:               true,
+			expectFederatesWith: []string{"a.org", "shared.org", "b.org"},
+			expectDNSNames:      []string{"older.default", "newer.default"},
+			expectMasked:        1,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			entryClient := newEntryClient()
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:        entryClient,
+				MergeMaskedEntries: tt.merge,
+			}, objects...)
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			r.reconcile(ctx)
+			entries := entryClient.getEntries()
+			require.Len(t, entries, 1)
+			var federatesWith []string
+			for _, td := range entries[0].FederatesWith {
+				federatesWith = append(federatesWith, td.Name())
+			}
+			require.Equal(t, tt.expectFederatesWith, federatesWith)
+			require.Equal(t, tt.expectDNSNames, entries[0].DNSNames)
+
+			actual := new(spirev1alpha1.ClusterSPIFFEID)
+			require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(newer), actual))
+			require.Equal(t, tt.expectMasked, actual.Status.Stats.EntriesMasked)
+
+			t.Log("The entry is stable on the next pass")
+			r.reconcile(ctx)
+			require.Equal(t, 1, entryClient.createCalls)
+			require.Zero(t, entryClient.updateCalls)
+		})
+	}
+}
+
+func TestMergeMaskedEntriesDoesNotModifySharedSlices(t *testing.T) {
+	// The federated trust domains of the parsed spec have spare capacity
+	// an append would write into.
+	federatesWith := make([]spiffeid.TrustDomain, 1, 2)
+	federatesWith[0] = spiffeid.RequireTrustDomainFromString("a.org")
+	declared := []declaredEntry{
+		{Entry: spireapi.Entry{FederatesWith: federatesWith}},
+		{Entry: spireapi.Entry{FederatesWith: []spiffeid.TrustDomain{spiffeid.RequireTrustDomainFromString("b.org")}}},
+	}
+	r := newEntryReconciler(ReconcilerConfig{MergeMaskedEntries: true})
+	r.mergeMaskedEntries(declared)
+	require.Len(t, declared[0].Entry.FederatesWith, 2)
+	require.True(t, federatesWith[:2][1].IsZero())
+}
+
+func TestMergeMaskedEntriesDNSNameOrder(t *testing.T) {
+	newDeclared := func(masked ...[]string) []declaredEntry {
+		declared := []declaredEntry{{Entry: spireapi.Entry{DNSNames: []string{"preferred", "b"}}}}
+		for _, dnsNames := range masked {
+			declared = append(declared, declaredEntry{Entry: spireapi.Entry{DNSNames: dnsNames}})
+		}
+		return declared
+	}
+	r := newEntryReconciler(ReconcilerConfig{MergeMaskedEntries: true})
+
+	// The preferred entry keeps the order of its DNS names, followed by the
+	// merged names in sorted order, however the masked entries order them.
+	for _, declared := range [][]declaredEntry{
+		newDeclared([]string{"d", "a"}, []string{"c", "b"}),
+		newDeclared([]string{"c"}, []string{"a", "d", "preferred"}),
+	} {
+		r.mergeMaskedEntries(declared)
+		require.Equal(t, []string{"preferred", "b", "a", "c", "d"}, declared[0].Entry.DNSNames)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+)
+
+// MinTTLAction is what to do with entries whose SVID TTLs are below the
+// minimum.
+type MinTTLAction string
+
+const (
+	// MinTTLActionBump raises TTLs below the minimum to the minimum.
+	MinTTLActionBump MinTTLAction = "bump"
+
+	// MinTTLActionReject fails to render entries with TTLs below the
+	// minimum.
+	MinTTLActionReject MinTTLAction = "reject"
+)
+
+// MinTTLPolicy sets a floor on the SVID TTLs of declared entries, so that
+// agents have time to renew SVIDs before they expire. Entries using the
+// default TTLs of the SPIRE server are not affected.
+type MinTTLPolicy struct {
+	// MinTTL is the minimum X509-SVID and JWT-SVID TTL.
+	MinTTL time.Duration
+
+	// Action is what to do with entries with TTLs below the minimum.
+	// Defaults to MinTTLActionBump.
+	Action MinTTLAction
+}
+
+// Validate checks that the minimum TTL and action are valid.
+func (p *MinTTLPolicy) Validate() error {
+	if p.MinTTL <= 0 {
+		return fmt.Errorf("minimum TTL must be positive")
+	}
+	switch p.Action {
+	case "", MinTTLActionBump, MinTTLActionReject:
+		return nil
+	default:
+		return fmt.Errorf("unknown action %q; expected %q or %q", p.Action, MinTTLActionBump, MinTTLActionReject)
+	}
+}
+
+// apply raises the TTLs of the entry that are below the minimum to the
+// minimum or, if the action is to reject, returns an error naming the field
+// of the first such TTL.
+func (p *MinTTLPolicy) apply(log logr.Logger, entry *spireapi.Entry) error {
+	for _, ttl := range []struct {
+		field string
+		value *time.Duration
+	}{
+		{field: x509SVIDTTLKey, value: &entry.X509SVIDTTL},
+		{field: jwtSVIDTTLKey, value: &entry.JWTSVIDTTL},
+	} {
+		if *ttl.value == 0 || *ttl.value >= p.MinTTL {
+			continue
+		}
+		if p.Action == MinTTLActionReject {
+			return &renderError{field: ttl.field, err: fmt.Errorf("%s %s is below the minimum of %s", ttl.field, *ttl.value, p.MinTTL)}
+		}
+		log.V(1).Info("Raising TTL to the minimum", append(entryLogFields(*entry), "field", ttl.field, "minTTL", p.MinTTL.String())...)
+		*ttl.value = p.MinTTL
+	}
+	return nil
+}
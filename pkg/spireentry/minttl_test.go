@@ -0,0 +1,80 @@
+package spireentry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestMinTTLPolicy(t *testing.T) {
+	newStaticEntry := func(name string, x509SVIDTTL, jwtSVIDTTL time.Duration) *spirev1alpha1.ClusterStaticEntry {
+		return &spirev1alpha1.ClusterStaticEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: spirev1alpha1.ClusterStaticEntrySpec{
+				SPIFFEID:    "spiffe://example.org/" + name,
+				ParentID:    "spiffe://example.org/parent",
+				Selectors:   []string{"k8s:ns:" + name},
+				X509SVIDTTL: metav1.Duration{Duration: x509SVIDTTL},
+				JWTSVIDTTL:  metav1.Duration{Duration: jwtSVIDTTL},
+			},
+		}
+	}
+	objects := []client.Object{
+		newStaticEntry("below", time.Minute, 0),
+		newStaticEntry("jwt-below", time.Hour, time.Minute),
+		newStaticEntry("above", time.Hour, time.Hour),
+		newStaticEntry("default", 0, 0),
+	}
+
+	type ttls struct {
+		X509SVIDTTL time.Duration
+		JWTSVIDTTL  time.Duration
+	}
+	for _, tt := range []struct {
+		desc          string
+		action        MinTTLAction
+		expectEntries map[string]ttls
+	}{
+		{
+			desc: "bump",
+			expectEntries: map[string]ttls{
+				"/below":     {X509SVIDTTL: 5 * time.Minute},
+				"/jwt-below": {X509SVIDTTL: time.Hour, JWTSVIDTTL: 5 * time.Minute},
+				"/above":     {X509SVIDTTL: time.Hour, JWTSVIDTTL: time.Hour},
+				"/default":   {},
+			},
+		},
+		{
+			desc:   "reject",
+			action: MinTTLActionReject,
+			expectEntries: map[string]ttls{
+				"/above":   {X509SVIDTTL: time.Hour, JWTSVIDTTL: time.Hour},
+				"/default": {},
+			},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			entryClient := newEntryClient()
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:  entryClient,
+				MinTTLPolicy: &MinTTLPolicy{MinTTL: 5 * time.Minute, Action: tt.action},
+			}, objects...)
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			r.reconcile(ctx)
+
+			entries := make(map[string]ttls)
+			for _, entry := range entryClient.getEntries() {
+				entries[entry.SPIFFEID.Path()] = ttls{X509SVIDTTL: entry.X509SVIDTTL, JWTSVIDTTL: entry.JWTSVIDTTL}
+			}
+			require.Equal(t, tt.expectEntries, entries)
+		})
+	}
+}
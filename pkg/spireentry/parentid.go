@@ -0,0 +1,43 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ParentIDTemplateRule is the parent ID template used for pods on the nodes
+// matching a selector, e.g. for node pools attested differently.
+type ParentIDTemplateRule struct {
+	NodeSelector     labels.Selector
+	ParentIDTemplate *template.Template
+}
+
+// parentIDTemplateFor returns the parent ID template of the first rule
+// matching the node, or the configured parent ID template if none matches.
+// A nil template means the default parent ID template.
+func (r *entryReconciler) parentIDTemplateFor(node *corev1.Node) *template.Template {
+	for _, rule := range r.config.ParentIDTemplateRules {
+		if rule.NodeSelector.Matches(labels.Set(node.Labels)) {
+			return rule.ParentIDTemplate
+		}
+	}
+	return r.config.ParentIDTemplate
+}
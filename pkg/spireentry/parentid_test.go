@@ -0,0 +1,80 @@
+package spireentry
+
+import (
+	"context"
+	"testing"
+	"text/template"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestParentIDTemplateRules(t *testing.T) {
+	newNode := func(name, pool string) *corev1.Node {
+		node := newTestNode(name)
+		if pool != "" {
+			node.Labels = map[string]string{"pool": pool}
+		}
+		return node
+	}
+	newRule := func(pool, parentIDTemplate string) ParentIDTemplateRule {
+		return ParentIDTemplateRule{
+			NodeSelector:     labels.SelectorFromSet(labels.Set{"pool": pool}),
+			ParentIDTemplate: template.Must(template.New(pool).Parse(parentIDTemplate)),
+		}
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+		ParentIDTemplateRules: []ParentIDTemplateRule{
+			newRule("a", "spiffe://{{ .TrustDomain }}/spire/agent/x509pop/{{ .NodeMeta.Name }}"),
+			newRule("b", "spiffe://{{ .TrustDomain }}/spire/agent/k8s_psat/{{ .ClusterName }}/{{ .NodeMeta.UID }}"),
+		},
+	}, clusterSPIFFEID, newTestNamespace("default"),
+		newNode("node-a", "a"), newNode("node-b", "b"), newNode("node-c", "c"),
+		newTestPod("default", "pod-a", "node-a", nil),
+		newTestPod("default", "pod-b", "node-b", nil),
+		newTestPod("default", "pod-c", "node-c", nil),
+	)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+
+	parentIDs := make(map[string]string)
+	for _, entry := range entryClient.getEntries() {
+		parentIDs[entry.SPIFFEID.Path()] = entry.ParentID.String()
+	}
+	require.Equal(t, map[string]string{
+		"/ns/default/pod/pod-a": "spiffe://example.org/spire/agent/x509pop/node-a",
+		"/ns/default/pod/pod-b": "spiffe://example.org/spire/agent/k8s_psat/" + clusterName + "/node-b-uid",
+		// Nodes matching no rule fall back to the default template.
+		"/ns/default/pod/pod-c": "spiffe://example.org/spire/agent/k8s_psat/" + clusterName + "/node-c-uid",
+	}, parentIDs)
+
+	t.Run("first matching rule wins", func(t *testing.T) {
+		r := &entryReconciler{config: ReconcilerConfig{
+			ParentIDTemplate: template.Must(template.New("fallback").Parse("spiffe://{{ .TrustDomain }}/fallback")),
+			ParentIDTemplateRules: []ParentIDTemplateRule{
+				newRule("a", "spiffe://{{ .TrustDomain }}/first"),
+				{NodeSelector: labels.Everything(), ParentIDTemplate: template.Must(template.New("second").Parse("spiffe://{{ .TrustDomain }}/second"))},
+			},
+		}}
+		require.Equal(t, "a", r.parentIDTemplateFor(newNode("node-a", "a")).Name())
+		require.Equal(t, "second", r.parentIDTemplateFor(newNode("node-b", "b")).Name())
+
+		r.config.ParentIDTemplateRules = nil
+		require.Equal(t, "fallback", r.parentIDTemplateFor(newNode("node-a", "a")).Name())
+	})
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"slices"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// inParentIDScope returns true if entries with the parent ID are managed by
+// the controller, per the parent ID scope.
+func (r *entryReconciler) inParentIDScope(parentID spiffeid.ID) bool {
+	return r.config.ParentIDScope == nil || r.config.ParentIDScope.MatchString(parentID.String())
+}
+
+// dropOutOfScopeEntries removes the declared entries with a parent ID outside
+// of the parent ID scope from the state, since the controller does not manage
+// them.
+func (r *entryReconciler) dropOutOfScopeEntries(ctx context.Context, state entriesState) {
+	if r.config.ParentIDScope == nil {
+		return
+	}
+	log := log.FromContext(ctx)
+	for key, s := range state {
+		s.Declared = slices.DeleteFunc(s.Declared, func(declared declaredEntry) bool {
+			if r.inParentIDScope(declared.Entry.ParentID) {
+				return false
+			}
+			log.V(1).Info("Ignoring declared entry outside of the parent ID scope", entryLogFields(declared.Entry)...)
+			return true
+		})
+		if len(s.Declared) == 0 && len(s.Current) == 0 {
+			delete(state, key)
+		}
+	}
+}
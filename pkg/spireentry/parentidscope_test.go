@@ -0,0 +1,58 @@
+package spireentry
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestParentIDScope(t *testing.T) {
+	newStaticEntry := func(name, server string) *spirev1alpha1.ClusterStaticEntry {
+		return &spirev1alpha1.ClusterStaticEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: spirev1alpha1.ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://example.org/" + name,
+				ParentID:  "spiffe://example.org/spire/agent/" + server + "/node",
+				Selectors: []string{"k8s:ns:" + name},
+			},
+		}
+	}
+	newEntry := func(id, server string) spireapi.Entry {
+		return spireapi.Entry{
+			ID:        id,
+			SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/" + id),
+			ParentID:  spiffeid.RequireFromString("spiffe://example.org/spire/agent/" + server + "/node"),
+			Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:" + id}},
+		}
+	}
+
+	// Stale entries are only deleted within the scope.
+	otherServerEntry := newEntry("other-stale", "server-b")
+	entryClient := newEntryClient(newEntry("stale", "server-a"), otherServerEntry)
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:   entryClient,
+		ParentIDScope: regexp.MustCompile(`^spiffe://example\.org/spire/agent/server-a/`),
+	},
+		newStaticEntry("in-scope", "server-a"),
+		newStaticEntry("out-of-scope", "server-b"),
+	)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+
+	require.Equal(t, []string{"spiffe://example.org/in-scope", "spiffe://example.org/other-stale"}, entrySPIFFEIDs(entryClient.getEntries()))
+	require.Contains(t, entryClient.getEntries(), otherServerEntry)
+
+	t.Log("Entries outside of the scope are not recreated on the next pass")
+	entryClient.createCalls = 0
+	r.reconcile(ctx)
+	require.Zero(t, entryClient.createCalls)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"google.golang.org/grpc/codes"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// parentEntryLimitReason is the event reason used when an entry could
+	// not be created because its parent has reached the entry limit.
+	parentEntryLimitReason = "ParentEntryLimitReached"
+
+	defaultParentEntryLimitBackoff = 30 * time.Second
+	maxParentEntryLimitBackoff     = 10 * time.Minute
+)
+
+// parentBackoff tracks how long creating entries for a parent is backed off
+// for after its entry limit was reached.
+type parentBackoff struct {
+	until time.Time
+	delay time.Duration
+}
+
+// isParentEntryLimitStatus returns whether the status indicates the entry
+// was rejected because its parent has reached the entry limit.
+func isParentEntryLimitStatus(status spireapi.Status) bool {
+	return status.Code == codes.ResourceExhausted
+}
+
+// dropBackedOffParentEntries returns the entries whose parent is not backed
+// off. Entries that are dropped count as failures so that the owning objects
+// reflect that they are not set.
+func (r *entryReconciler) dropBackedOffParentEntries(ctx context.Context, declaredEntries []declaredEntry, now time.Time) []declaredEntry {
+	if len(r.parentBackoffs) == 0 {
+		return declaredEntries
+	}
+	log := log.FromContext(ctx)
+	out := make([]declaredEntry, 0, len(declaredEntries))
+	for _, declaredEntry := range declaredEntries {
+		backoff, ok := r.parentBackoffs[declaredEntry.Entry.ParentID]
+		if ok && now.Before(backoff.until) {
+			declaredEntry.By.IncrementEntryFailures()
+			log.V(1).Info("Not creating entry; parent entry limit reached", append(entryLogFields(declaredEntry.Entry), "retryAt", backoff.until)...)
+			continue
+		}
+		out = append(out, declaredEntry)
+	}
+	return out
+}
+
+// backOffParent backs off creating entries for the parent, doubling the
+// previous backoff, if any, up to a maximum. Whether the parent is still
+// backed off by an earlier status of the same batch is returned, in which
+// case the backoff is left as is.
+func (r *entryReconciler) backOffParent(parentID spiffeid.ID, now time.Time) (time.Duration, bool) {
+	backoff, ok := r.parentBackoffs[parentID]
+	switch {
+	case !ok:
+		backoff.delay = r.config.ParentEntryLimitBackoff
+		if backoff.delay <= 0 {
+			backoff.delay = defaultParentEntryLimitBackoff
+		}
+	case now.Before(backoff.until):
+		return backoff.delay, true
+	default:
+		backoff.delay = min(2*backoff.delay, max(maxParentEntryLimitBackoff, r.config.ParentEntryLimitBackoff))
+	}
+	backoff.until = now.Add(backoff.delay)
+	r.parentBackoffs[parentID] = backoff
+	return backoff.delay, false
+}
+
+// clearParentBackoff resets the backoff of the parent once one of its
+// entries is created.
+func (r *entryReconciler) clearParentBackoff(parentID spiffeid.ID) {
+	delete(r.parentBackoffs, parentID)
+}
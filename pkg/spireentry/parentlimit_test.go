@@ -0,0 +1,115 @@
+package spireentry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestParentEntryLimit(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	limitedParentID := spiffeid.RequireFromString("spiffe://example.org/spire/agent/k8s_psat/" + clusterName + "/node-a-uid")
+
+	entryClient := newEntryClient()
+	entryClient.parentEntryLimits = map[string]int{limitedParentID.String(): 1}
+	recorder := record.NewFakeRecorder(10)
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:   entryClient,
+		EventRecorder: recorder,
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node-a"), newTestNode("node-b"),
+		newTestPod("default", "a1", "node-a", nil),
+		newTestPod("default", "a2", "node-a", nil),
+		newTestPod("default", "a3", "node-a", nil),
+		newTestPod("default", "b1", "node-b", nil),
+	)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	getEntryFailures := func() int {
+		actual := new(spirev1alpha1.ClusterSPIFFEID)
+		require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+		return actual.Status.Stats.EntryFailures
+	}
+	drainEvents := func() []string {
+		var events []string
+		for {
+			select {
+			case event := <-recorder.Events:
+				events = append(events, event)
+			default:
+				return events
+			}
+		}
+	}
+
+	t.Log("The entries over the limit fail and the parent is backed off")
+	r.reconcile(ctx)
+	require.Equal(t, 1, entryClient.createCalls)
+	require.Len(t, entryClient.getEntries(), 2)
+	require.Equal(t, 2, getEntryFailures())
+	events := drainEvents()
+	require.Len(t, events, 1)
+	require.Contains(t, events[0], "Warning ParentEntryLimitReached Parent \""+limitedParentID.String()+"\" has reached the SPIRE entry limit")
+	require.Contains(t, events[0], "will not be created for 30s")
+
+	t.Log("Creating the entries of the backed off parent is not retried")
+	r.reconcile(ctx)
+	require.Equal(t, 1, entryClient.createCalls)
+	require.Len(t, entryClient.getEntries(), 2)
+	require.Equal(t, 2, getEntryFailures())
+	require.Empty(t, drainEvents())
+
+	t.Log("Once the backoff expires, creating the entries is retried")
+	backoff := r.parentBackoffs[limitedParentID]
+	backoff.until = time.Now().Add(-time.Second)
+	r.parentBackoffs[limitedParentID] = backoff
+	entryClient.parentEntryLimits = nil
+	r.reconcile(ctx)
+	require.Equal(t, 2, entryClient.createCalls)
+	require.Len(t, entryClient.getEntries(), 4)
+	require.Equal(t, 0, getEntryFailures())
+	require.Empty(t, r.parentBackoffs)
+	require.Empty(t, drainEvents())
+}
+
+func TestBackOffParent(t *testing.T) {
+	parentID := spiffeid.RequireFromString("spiffe://example.org/node")
+	now := time.Now()
+	r := newEntryReconciler(ReconcilerConfig{ParentEntryLimitBackoff: 4 * time.Minute})
+
+	delay, alreadyBackedOff := r.backOffParent(parentID, now)
+	require.Equal(t, 4*time.Minute, delay)
+	require.False(t, alreadyBackedOff)
+
+	t.Log("Hitting the limit again while backed off keeps the backoff")
+	delay, alreadyBackedOff = r.backOffParent(parentID, now.Add(time.Minute))
+	require.Equal(t, 4*time.Minute, delay)
+	require.True(t, alreadyBackedOff)
+
+	t.Log("Hitting the limit again after the backoff doubles it up to the maximum")
+	now = now.Add(4 * time.Minute)
+	delay, alreadyBackedOff = r.backOffParent(parentID, now)
+	require.Equal(t, 8*time.Minute, delay)
+	require.False(t, alreadyBackedOff)
+	now = now.Add(8 * time.Minute)
+	delay, _ = r.backOffParent(parentID, now)
+	require.Equal(t, maxParentEntryLimitBackoff, delay)
+
+	r.clearParentBackoff(parentID)
+	delay, alreadyBackedOff = r.backOffParent(parentID, now)
+	require.Equal(t, 4*time.Minute, delay)
+	require.False(t, alreadyBackedOff)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+)
+
+// confirmDeletes implements the two-stage delete: entries about to be
+// deleted are first marked, and only returned for deletion once they have
+// been marked for the confirmation delay. Marked entries that are no longer
+// about to be deleted (e.g. because they are declared again) are unmarked.
+func (r *entryReconciler) confirmDeletes(ctx context.Context, toDelete []spireapi.Entry, now time.Time) []spireapi.Entry {
+	log := log.FromContext(ctx)
+	var confirmed []spireapi.Entry
+	marked := make(map[string]time.Time, len(toDelete))
+	for _, entry := range toDelete {
+		markedAt, ok := r.pendingDeletes[entry.ID]
+		switch {
+		case !ok:
+			log.Info("Marked entry for deletion", append(entryLogFields(entry), "confirmationDelay", r.config.DeleteConfirmationDelay.String())...)
+			markedAt = now
+		case now.Sub(markedAt) >= r.config.DeleteConfirmationDelay:
+			confirmed = append(confirmed, entry)
+			continue
+		}
+		marked[entry.ID] = markedAt
+	}
+	for id := range r.pendingDeletes {
+		if _, ok := marked[id]; !ok {
+			log.V(1).Info("Unmarked entry for deletion", idKey, id)
+		}
+	}
+	r.pendingDeletes = marked
+	return confirmed
+}
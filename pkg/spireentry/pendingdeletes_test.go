@@ -0,0 +1,91 @@
+package spireentry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+)
+
+func TestDeleteConfirmationDelay(t *testing.T) {
+	const delay = time.Minute
+	orphan := spireapi.Entry{
+		ID:        "orphan",
+		SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/orphan"),
+		ParentID:  spiffeid.RequireFromString("spiffe://example.org/parent"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:orphan"}},
+	}
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	t.Run("deleted after the delay", func(t *testing.T) {
+		clk := testclock.NewFakeClock(time.Now())
+		entryClient := newEntryClient(orphan)
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient:             entryClient,
+			Clock:                   clk,
+			DeleteConfirmationDelay: delay,
+		})
+
+		// The first pass only marks the entry.
+		r.reconcile(ctx)
+		require.Equal(t, []spireapi.Entry{orphan}, entryClient.getEntries())
+
+		clk.Step(delay - time.Second)
+		r.reconcile(ctx)
+		require.Equal(t, []spireapi.Entry{orphan}, entryClient.getEntries())
+		require.Zero(t, entryClient.deleteCalls)
+
+		clk.Step(time.Second)
+		r.reconcile(ctx)
+		require.Empty(t, entryClient.getEntries())
+		require.Empty(t, r.pendingDeletes)
+	})
+
+	t.Run("unmarked when declared again", func(t *testing.T) {
+		clk := testclock.NewFakeClock(time.Now())
+		entryClient := newEntryClient(orphan)
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient:             entryClient,
+			Clock:                   clk,
+			DeleteConfirmationDelay: delay,
+		})
+
+		r.reconcile(ctx)
+		require.Contains(t, r.pendingDeletes, orphan.ID)
+
+		// The entry is declared again before the delay elapses.
+		staticEntry := &spirev1alpha1.ClusterStaticEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: "orphan"},
+			Spec: spirev1alpha1.ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://example.org/orphan",
+				ParentID:  "spiffe://example.org/parent",
+				Selectors: []string{"k8s:ns:orphan"},
+			},
+		}
+		require.NoError(t, r.config.K8sClient.Create(ctx, staticEntry))
+		clk.Step(delay / 2)
+		r.reconcile(ctx)
+		require.Empty(t, r.pendingDeletes)
+
+		// Orphaning it again restarts the delay.
+		require.NoError(t, r.config.K8sClient.Delete(ctx, staticEntry))
+		clk.Step(delay / 2)
+		r.reconcile(ctx)
+		clk.Step(delay / 2)
+		r.reconcile(ctx)
+		require.Equal(t, []spireapi.Entry{orphan}, entryClient.getEntries())
+
+		clk.Step(delay / 2)
+		r.reconcile(ctx)
+		require.Empty(t, entryClient.getEntries())
+	})
+}
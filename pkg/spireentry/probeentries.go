@@ -0,0 +1,65 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+)
+
+// cleanupProbeEntries deletes the dummy entries of the unsupported fields
+// probe found in the listing, e.g. left behind by a run that crashed
+// mid-probe or by a probe whose own cleanup failed. The probe runs after the
+// listing, so the listed entries never belong to a probe in progress.
+func (r *entryReconciler) cleanupProbeEntries(ctx context.Context, probeEntries []spireapi.Entry) {
+	if len(probeEntries) == 0 {
+		return
+	}
+	log := log.FromContext(ctx)
+	if r.config.DryRun {
+		for _, entry := range probeEntries {
+			log.Info("Would delete leftover unsupported fields probe entry (dry run)", entryLogFields(entry)...)
+		}
+		return
+	}
+
+	ids := make([]string, 0, len(probeEntries))
+	for _, entry := range probeEntries {
+		ids = append(ids, entry.ID)
+	}
+	statuses, err := r.config.EntryClient.DeleteEntries(ctx, ids)
+	if err != nil {
+		log.Error(err, "Failed to delete leftover unsupported fields probe entries; will retry")
+		return
+	}
+	deleted := 0
+	for i, st := range statuses {
+		switch st.Code {
+		case codes.OK, codes.NotFound:
+			deleted++
+		default:
+			log.Error(st.Err(), "Failed to delete leftover unsupported fields probe entry; will retry", idKey, ids[i])
+		}
+	}
+	if deleted > 0 {
+		log.Info("Deleted leftover unsupported fields probe entries", "count", deleted)
+	}
+}
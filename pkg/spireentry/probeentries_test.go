@@ -0,0 +1,76 @@
+package spireentry
+
+import (
+	"context"
+	"testing"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+)
+
+func TestCleanupProbeEntries(t *testing.T) {
+	probeID := spiffeid.RequireFromString("spiffe://example.org/spire-controller-manager/unsupported-fields-test/crashed")
+	probeEntry := spireapi.Entry{
+		ID:        "probe",
+		SPIFFEID:  probeID,
+		ParentID:  probeID,
+		Selectors: []spireapi.Selector{{Type: "a", Value: "1"}},
+	}
+	unmanagedEntry := spireapi.Entry{
+		ID:        "unmanaged",
+		SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/unmanaged"),
+		ParentID:  spiffeid.RequireFromString("spiffe://example.org/parent"),
+		Selectors: []spireapi.Selector{{Type: "a", Value: "1"}},
+	}
+
+	for _, tt := range []struct {
+		desc          string
+		dryRun        bool
+		expectEntries []string
+	}{
+		{
+			desc:          "deleted",
+			expectEntries: []string{"unmanaged"},
+		},
+		{
+			desc:          "kept in dry run",
+			dryRun:        true,
+			expectEntries: []string{"probe", "unmanaged"},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			entryClient := newEntryClient()
+			entryClient.entries[probeEntry.ID] = probeEntry
+			entryClient.entries[unmanagedEntry.ID] = unmanagedEntry
+			// With an entry ID prefix, entries without it are left alone,
+			// so the probe entry would otherwise never be deleted.
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:   entryClient,
+				EntryIDPrefix: "scm.",
+				DryRun:        tt.dryRun,
+			})
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			r.reconcile(ctx)
+			require.ElementsMatch(t, tt.expectEntries, entryIDs(entryClient))
+
+			// Probe entries showing up later, e.g. left behind by a
+			// probe whose cleanup failed, are deleted too.
+			entryClient.entries[probeEntry.ID] = probeEntry
+			r.reconcile(ctx)
+			require.ElementsMatch(t, tt.expectEntries, entryIDs(entryClient))
+		})
+	}
+}
+
+func entryIDs(entryClient *entryClient) []string {
+	var ids []string
+	for _, entry := range entryClient.getEntries() {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
@@ -20,12 +20,15 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"regexp"
 	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -34,14 +37,19 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/entrypolicy"
 	"github.com/spiffe/spire-controller-manager/pkg/k8sapi"
 	"github.com/spiffe/spire-controller-manager/pkg/metrics"
 	"github.com/spiffe/spire-controller-manager/pkg/namespace"
@@ -50,6 +58,21 @@ import (
 )
 
 const (
+	// allNamespacesIgnoredReason is the event reason used when all of the
+	// namespaces selected by a ClusterSPIFFEID are ignored.
+	allNamespacesIgnoredReason = "AllNamespacesIgnored"
+
+	// staticEntryRenderFailedReason is the event reason used when a
+	// ClusterStaticEntry fails to render.
+	staticEntryRenderFailedReason = "RenderFailed"
+
+	defaultUnsupportedFieldsProbeInterval     = 10 * time.Minute
+	defaultUnsupportedFieldsProbeRetryBackoff = time.Second
+
+	// cacheSyncTimeout is how long a reconcile waits for the cache to sync
+	// before it is skipped.
+	cacheSyncTimeout = time.Second
+
 	// joinTokenSpiffePrefix is the prefix that is the part of the parent SPIFFE ID for join token entries.
 	// Ref: https://github.com/spiffe/spire/blob/v1.8.7/pkg/server/api/agent/v1/service.go#L714
 	// nolint: gosec // not a credential
@@ -72,48 +95,411 @@ type ReconcilerConfig struct {
 	ClassName            string
 	WatchClassless       bool
 	ParentIDTemplate     *template.Template
+
+	// ParentIDTemplateRules, if set, pick the parent ID template by the
+	// labels of the node. The first matching rule wins; nodes matching no
+	// rule use ParentIDTemplate.
+	ParentIDTemplateRules []ParentIDTemplateRule
+
 	Reconcile            spirev1alpha1.ReconcileConfig
 	EntryIDPrefix        string
 	EntryIDPrefixCleanup *string
 
+	// EntryIDPrefixPolicy, if set, lets ClusterSPIFFEIDs and
+	// ClusterStaticEntries override EntryIDPrefix for their entries.
+	EntryIDPrefixPolicy *EntryIDPrefixPolicy
+
+	// GlobalPodExclusionSelector, if set, excludes matching pods from all
+	// ClusterSPIFFEIDs.
+	GlobalPodExclusionSelector labels.Selector
+
+	// ClassScopedEntryIDs, if set, appends the ClassName to the
+	// EntryIDPrefix so that entries owned by each class are managed
+	// independently.
+	ClassScopedEntryIDs bool
+
+	// BootstrapPasses is the number of initial reconcile passes that only
+	// create entries, without listing, updating, or deleting SPIRE entries.
+	BootstrapPasses int
+
+	// PreserveDuplicates, if set, leaves current entries that duplicate the
+	// entry being reused for a declared entry in place instead of deleting
+	// them.
+	PreserveDuplicates bool
+
+	// AdminSelector, if set, is appended to the selectors of every admin
+	// entry.
+	AdminSelector *spireapi.Selector
+
+	// EntryPolicy, if set, is consulted before entries are created or
+	// updated. Entries rejected by the policy are dropped.
+	EntryPolicy entrypolicy.Client
+
+	// EntryPolicyFailOpen determines whether entries are created or updated
+	// when the policy service cannot be reached.
+	EntryPolicyFailOpen bool
+
+	// SPIFFEIDPathPrefix, if set, is prepended to the path of every
+	// rendered SPIFFE ID (e.g. "/cluster-a").
+	SPIFFEIDPathPrefix string
+
+	// CreateDownstreamEntriesFirst, if set, creates downstream entries in a
+	// batch ahead of all other entries so that the entries for downstream
+	// SPIRE servers exist before the workloads they attest.
+	CreateDownstreamEntriesFirst bool
+
+	// CreateEntriesByPodPriority, if set, creates the entries of higher
+	// priority pods ahead of those of lower priority pods. Entries not
+	// rendered for a pod count as priority zero.
+	CreateEntriesByPodPriority bool
+
+	// TTLTolerance is how much the X509-SVID and JWT-SVID TTLs of a current
+	// entry can differ from the declared ones before the entry is updated.
+	// Zero means the TTLs must match exactly.
+	TTLTolerance time.Duration
+
+	// TTLTierPolicy, if set, lets pods pick the TTLs of their SVIDs from a
+	// set of approved tiers.
+	TTLTierPolicy *TTLTierPolicy
+
+	// FederationLabelPolicy, if set, lets pods federate with additional
+	// trust domains by naming a federation group in a label.
+	FederationLabelPolicy *FederationLabelPolicy
+
+	// MinTTLPolicy, if set, raises or rejects SVID TTLs below a minimum.
+	MinTTLPolicy *MinTTLPolicy
+
+	// MaxSelectorsPolicy, if set, truncates or rejects pod entries with more
+	// selectors than a maximum.
+	MaxSelectorsPolicy *MaxSelectorsPolicy
+
+	// ParentIDScope, if set, restricts the entries managed by the controller
+	// to those with a parent ID matching it, e.g. the agents attested by one
+	// of several SPIRE servers sharing a datastore. Other entries are left
+	// alone, and declared entries outside of the scope are not created.
+	ParentIDScope *regexp.Regexp
+
+	// SummaryWriter, if set, receives a line of JSON summarizing each
+	// complete reconcile pass (see PassSummary).
+	SummaryWriter io.Writer
+
+	// MaxDNSNameEndpoints, if non-zero, limits how many endpoints contribute
+	// DNS names to a pod entry.
+	MaxDNSNameEndpoints int
+
+	// SkipUnsupportedFieldsProbe, if set, assumes the SPIRE server supports
+	// every entry field instead of probing it.
+	SkipUnsupportedFieldsProbe bool
+
+	// UnsupportedFieldsProbeInterval is how long the probe result is cached
+	// for. Defaults to 10 minutes.
+	UnsupportedFieldsProbeInterval time.Duration
+
+	// Clock is used to schedule the unsupported fields probe and the
+	// reconcile at pod maturity, and to time other per-pass bookkeeping.
+	// Defaults to the real clock.
+	Clock clock.WithDelayedExecution
+
+	// UnsupportedFieldsProbeRetries is how many times a probe failing with a
+	// transient error is retried.
+	UnsupportedFieldsProbeRetries int
+
+	// FieldSupportOverrides maps entry fields to whether they are
+	// supported, overriding the probe result.
+	FieldSupportOverrides map[spireapi.Field]bool
+
+	// ClampX509SVIDTTLToCA, if set, lowers X509-SVID TTLs that exceed the
+	// remaining lifetime of the CA. Requires BundleClient.
+	ClampX509SVIDTTLToCA bool
+
+	// BundleClient is used to fetch the bundle to determine the CA expiry.
+	BundleClient spireapi.BundleClient
+
+	// DeclaredEntryGracePeriod, if non-zero, is how long the current entries
+	// for a declared entry that is no longer declared are retained. This
+	// smooths over objects briefly missing from the cache (e.g. during an
+	// informer re-list) that would otherwise cause entries to be deleted and
+	// recreated.
+	DeclaredEntryGracePeriod time.Duration
+
+	// DeleteConfirmationDelay, if non-zero, is how long entries about to be
+	// deleted are marked before being deleted, giving a window to notice
+	// entries orphaned by mistake. Marked entries are unmarked if they stop
+	// being about to be deleted.
+	DeleteConfirmationDelay time.Duration
+
+	// RevalidatePodsBeforeCreate, if set, checks that the pods of entries
+	// about to be created still exist, dropping the entries of pods deleted
+	// since they were listed.
+	RevalidatePodsBeforeCreate bool
+
+	// ValidateEntriesBeforeSend, if set, validates the entries about to be
+	// created or updated, dropping invalid entries as failures instead of
+	// sending them to the SPIRE server.
+	ValidateEntriesBeforeSend bool
+
+	// EntryRevisions, if set, records the entries last written by the
+	// controller, so that entries modified outside of the controller can be
+	// detected when listed. The revisions are kept in memory, so entries
+	// modified before the reconciler started are not detected.
+	EntryRevisions bool
+
+	// DefaultX509SVIDTTL and DefaultJWTSVIDTTL, if non-zero, are the
+	// X509-SVID and JWT-SVID TTLs of entries that do not set one.
+	DefaultX509SVIDTTL time.Duration
+	DefaultJWTSVIDTTL  time.Duration
+
+	// MinPodAgeForEntry, if non-zero, is how long ago a pod must have been
+	// started before an entry is rendered for it.
+	MinPodAgeForEntry time.Duration
+
+	// SkipTerminatingPods, if set, skips the pods that are being deleted or
+	// that have terminated, so that no entries are rendered for them.
+	SkipTerminatingPods bool
+
+	// NamespaceConcurrency is how many of the namespaces selected by a
+	// ClusterSPIFFEID have their pods listed and entries rendered
+	// concurrently. Values below 2 process namespaces serially.
+	NamespaceConcurrency int
+
+	// ParentEntryLimitBackoff is how long creating the entries of a parent
+	// is backed off for once the SPIRE server reports the parent has
+	// reached the entry limit. The backoff doubles while the limit keeps
+	// being hit. Defaults to 30 seconds.
+	ParentEntryLimitBackoff time.Duration
+
+	// DeduplicateDNSNames, if set, keeps a DNS name declared on the entries
+	// of more than one SPIFFE ID only on the entry of the most preferred
+	// object. Such DNS names are reported either way.
+	DeduplicateDNSNames bool
+
+	// RenderFailureBackoffAfter, if non-zero, is how many consecutive passes
+	// every entry of an object has to fail to render before the object is
+	// only re-attempted with a backoff.
+	RenderFailureBackoffAfter int
+
+	// EntryFailureBackoffAfter, if non-zero, is how many consecutive times
+	// creating or updating an entry has to fail before it is only
+	// re-attempted with a backoff.
+	EntryFailureBackoffAfter int
+
+	// MergeMaskedEntries, if set, unions the federated trust domains and DNS
+	// names of masked entries into the entry that masks them.
+	MergeMaskedEntries bool
+
+	// DryRun, if set, logs the entries that would be created, updated or
+	// deleted instead of writing them to the SPIRE server. The unsupported
+	// fields probe, which creates and deletes an entry, is skipped. Statuses
+	// are still updated so that their stats reflect what would be set.
+	DryRun bool
+
+	// DryRunEventTarget, if set, is the object on which an event summarizing
+	// how many entries a dry-run pass would create, update and delete is
+	// recorded with the EventRecorder.
+	DryRunEventTarget *corev1.ObjectReference
+
+	// DryRunEventInterval is the minimum time between two dry-run summary
+	// events. Defaults to 5 minutes.
+	DryRunEventInterval time.Duration
+
+	// Cache, if set, is checked to have synced before each reconcile. Until
+	// it has, reconciles are skipped so that entries are not deleted based
+	// on a partial view of the cluster.
+	Cache CacheSyncer
+
+	// EventRecorder, if set, is used to surface misconfigurations as events
+	// on the offending objects.
+	EventRecorder record.EventRecorder
+
 	// GCInterval how long to sit idle (i.e. untriggered) before doing
 	// another reconcile.
 	GCInterval time.Duration
+
+	// MaxReconcileDuration, if non-zero, is how long a reconcile may run
+	// before it is aborted.
+	MaxReconcileDuration time.Duration
+
+	// InitialReconcileDelay, if non-zero, is how long to wait before the
+	// first reconcile.
+	InitialReconcileDelay time.Duration
 }
 
 func Reconciler(config ReconcilerConfig) reconciler.Reconciler {
+	return newEntryReconciler(config).newReconciler()
+}
+
+// newReconciler returns the reconciler running the reconcile passes.
+func (r *entryReconciler) newReconciler() reconciler.Reconciler {
+	rec := reconciler.New(reconciler.Config{
+		Kind:         "entry",
+		Reconcile:    r.reconcile,
+		GCInterval:   r.config.GCInterval,
+		MaxDuration:  r.config.MaxReconcileDuration,
+		InitialDelay: r.config.InitialReconcileDelay,
+		Clock:        r.config.Clock,
+	})
+	r.triggerer = rec
+	return rec
+}
+
+func newEntryReconciler(config ReconcilerConfig) *entryReconciler {
+	if config.ClassScopedEntryIDs && config.ClassName != "" {
+		config.EntryIDPrefix += config.ClassName + "."
+	}
+	if config.DryRunEventInterval <= 0 {
+		config.DryRunEventInterval = defaultDryRunEventInterval
+	}
+	if config.UnsupportedFieldsProbeInterval <= 0 {
+		config.UnsupportedFieldsProbeInterval = defaultUnsupportedFieldsProbeInterval
+	}
+	if config.Clock == nil {
+		config.Clock = clock.RealClock{}
+	}
 	r := &entryReconciler{
-		config:      config,
-		promCounter: metrics.PromCounters,
+		config:                        config,
+		probeRetryBackoff:             defaultUnsupportedFieldsProbeRetryBackoff,
+		promCounter:                   metrics.PromCounters,
+		promEntriesByNamespace:        metrics.PromEntriesByNamespace,
+		promManagedEntries:            metrics.PromManagedEntries,
+		promStaticEntryRenderFailures: metrics.PromStaticEntryRenderFailures,
+		unsupportedFields:             make(map[spiffeid.TrustDomain]map[spireapi.Field]struct{}),
+		nextGetUnsupportedFields:      make(map[spiffeid.TrustDomain]time.Time),
+		lastDeclared:                  make(map[entryKey]time.Time),
+		parentBackoffs:                make(map[spiffeid.ID]parentBackoff),
+		renderFailures:                make(map[types.UID]*renderFailureState),
+		entryFailures:                 make(map[entryKey]*entryFailureState),
+		entryRevisions:                make(map[string]spireapi.Entry),
 	}
-	return reconciler.New(reconciler.Config{
-		Kind:       "entry",
-		Reconcile:  r.reconcile,
-		GCInterval: config.GCInterval,
-	})
+	if config.SkipUnsupportedFieldsProbe && len(config.FieldSupportOverrides) > 0 {
+		// Without a probe, the overrides are all there is. Other trust
+		// domains fall back to those of the configured trust domain.
+		r.unsupportedFields[config.TrustDomain] = r.applyFieldSupportOverrides(nil)
+	}
+	return r
+}
+
+// CacheSyncer reports whether a cache has synced. It is implemented by the
+// controller-runtime cache.
+type CacheSyncer interface {
+	WaitForCacheSync(ctx context.Context) bool
 }
 
 type entryReconciler struct {
 	config ReconcilerConfig
 
-	unsupportedFields        map[spireapi.Field]struct{}
+	// cacheSynced is set once the cache has been observed to have synced.
+	cacheSynced bool
+
+	// unsupportedFields and nextGetUnsupportedFields are tracked per trust
+	// domain since a SPIRE server may host more than one.
+	// unsupportedFieldsMu guards the writes to unsupportedFields, which
+	// traces read concurrently with the reconcile.
+	unsupportedFieldsMu      sync.RWMutex
+	unsupportedFields        map[spiffeid.TrustDomain]map[spireapi.Field]struct{}
 	promCounter              map[string]prometheus.Counter
-	nextGetUnsupportedFields time.Time
+	nextGetUnsupportedFields map[spiffeid.TrustDomain]time.Time
+	bootstrapPassesDone      int
+
+	// promEntriesByNamespace is set from the declared entries at the end of
+	// each reconcile. reportedNamespaces tracks the namespaces it was last
+	// set for so that the series for namespaces that no longer have entries
+	// can be pruned.
+	promEntriesByNamespace *prometheus.GaugeVec
+	reportedNamespaces     map[string]struct{}
+
+	// promManagedEntries is set from the current entries after each
+	// listing. reportedTrustDomains tracks the trust domains it was last set
+	// for, so that the series of trust domains without entries are pruned.
+	promManagedEntries   *prometheus.GaugeVec
+	reportedTrustDomains map[spiffeid.TrustDomain]struct{}
+
+	// promStaticEntryRenderFailures counts the ClusterStaticEntry render
+	// failures by field.
+	promStaticEntryRenderFailures *prometheus.CounterVec
+
+	// lastDeclared tracks when each entry was last declared, for the
+	// declared entry grace period.
+	lastDeclared map[entryKey]time.Time
+
+	// pendingDeletes tracks when each entry about to be deleted was marked
+	// for deletion, by ID, for the delete confirmation delay.
+	pendingDeletes map[string]time.Time
+
+	// entryRevisions holds the entries last written, or found up to date,
+	// by ID, for detecting entries modified outside of the controller.
+	entryRevisions map[string]spireapi.Entry
+
+	// parentBackoffs tracks the parents whose entry limit was reached, so
+	// that creating their entries is not retried on every pass.
+	parentBackoffs map[spiffeid.ID]parentBackoff
+
+	// renderFailures tracks the objects whose entries keep failing to
+	// render, by UID.
+	renderFailures map[types.UID]*renderFailureState
+
+	// entryFailures tracks the entries that keep failing to be created or
+	// updated, by key.
+	entryFailures map[entryKey]*entryFailureState
+
+	// trace, if set, collects the trace of a ClusterSPIFFEID while its
+	// entries state is added. Only set on reconcilers dedicated to a trace.
+	trace *clusterSPIFFEIDTrace
+
+	// triggerer triggers the reconciler the entry reconciler runs in, to
+	// pick up pods once they are old enough. nextPodMaturity is when the
+	// next pod matures, as of the current pass, and podMaturityTimer is the
+	// pending trigger, if any. podMaturityMu guards nextPodMaturity, which
+	// is recorded while namespaces are processed concurrently.
+	triggerer        reconciler.Triggerer
+	podMaturityMu    sync.Mutex
+	nextPodMaturity  time.Time
+	podMaturityTimer clock.Timer
+
+	// caExpiry is when the CA expires, as of the last bundle fetch.
+	caExpiry time.Time
+
+	// probeRetryBackoff is how long to wait before the first retry of a
+	// failed probe. It doubles on each subsequent retry.
+	probeRetryBackoff time.Duration
+
+	// reprobeUnsupportedFields is set when an entry write fails in a way
+	// that suggests the SPIRE server no longer supports a field (e.g. after
+	// a downgrade).
+	reprobeUnsupportedFields bool
+
+	// nextDryRunEvent is when the next dry-run summary event may be
+	// recorded.
+	nextDryRunEvent time.Time
 }
 
 func (r *entryReconciler) reconcile(ctx context.Context) {
 	log := log.FromContext(ctx)
 
-	if time.Now().After(r.nextGetUnsupportedFields) {
-		r.recalculateUnsupportFields(ctx, log)
+	if !r.hasCacheSynced(ctx) {
+		log.Info("Skipping reconcile; Kubernetes cache has not synced")
+		return
 	}
-	unsupportedFields := r.unsupportedFields
 
-	// Load current entries from SPIRE server.
-	currentEntries, deleteOnlyEntries, err := r.listEntries(ctx)
-	if err != nil {
-		log.Error(err, "Failed to list SPIRE entries")
-		return
+	// Load current entries from SPIRE server. While bootstrapping, the
+	// listing is skipped and every declared entry is created, relying on
+	// SPIRE to reject the ones that already exist.
+	bootstrapping := r.bootstrapping()
+	var currentEntries, deleteOnlyEntries []spireapi.Entry
+	var err error
+	if bootstrapping {
+		log.Info("Bootstrapping; skipping SPIRE entry listing", "pass", r.bootstrapPassesDone+1, "bootstrapPasses", r.config.BootstrapPasses)
+	} else {
+		var probeEntries []spireapi.Entry
+		currentEntries, deleteOnlyEntries, probeEntries, err = r.listEntries(ctx)
+		if err != nil {
+			log.Error(err, "Failed to list SPIRE entries")
+			return
+		}
+		r.cleanupProbeEntries(ctx, probeEntries)
+	}
+
+	if r.config.ClampX509SVIDTTLToCA {
+		r.refreshCAExpiry(ctx)
 	}
 
 	// Populate the existing state
@@ -121,6 +507,7 @@ func (r *entryReconciler) reconcile(ctx context.Context) {
 	for _, entry := range currentEntries {
 		state.AddCurrent(entry)
 	}
+	r.reportDuplicateEntries(ctx, state)
 
 	clusterStaticEntries := []*ClusterStaticEntry{}
 	if r.config.Reconcile.ClusterStaticEntries {
@@ -133,6 +520,7 @@ func (r *entryReconciler) reconcile(ctx context.Context) {
 		r.addClusterStaticEntryEntriesState(ctx, state, clusterStaticEntries)
 	}
 
+	r.nextPodMaturity = time.Time{}
 	clusterSPIFFEIDs := []*ClusterSPIFFEID{}
 	if r.config.Reconcile.ClusterSPIFFEIDs {
 		// Load and add entry state for ClusterSPIFFEIDs
@@ -144,16 +532,35 @@ func (r *entryReconciler) reconcile(ctx context.Context) {
 		r.addClusterSPIFFEIDEntriesState(ctx, state, clusterSPIFFEIDs)
 	}
 
+	spiffeIDs := []*SPIFFEID{}
+	if r.config.Reconcile.SPIFFEIDs {
+		// Load and add entry state for SPIFFEIDs
+		spiffeIDs, err = r.listSPIFFEIDs(ctx)
+		if err != nil {
+			log.Error(err, "Failed to list SPIFFEIDs")
+			return
+		}
+		r.addSPIFFEIDEntriesState(ctx, state, spiffeIDs)
+	}
+	r.triggerAtPodMaturity()
+	r.pruneRenderFailures(objectUIDs(clusterSPIFFEIDs, spiffeIDs))
+	r.prepareDeclaredEntries(ctx, state)
+	r.checkFederationEndpointCollisions(ctx, state)
+
+	// Determine which fields each trust domain being written to supports.
+	trustDomains := declaredTrustDomains(state, r.config.TrustDomain)
+	r.refreshUnsupportedFields(ctx, log, trustDomains, false)
+	r.detectTamperedEntries(ctx, currentEntries)
+
 	var toDelete []spireapi.Entry
 	var toCreate []declaredEntry
 	var toUpdate []declaredEntry
+	var unchanged []declaredEntry
+	now := r.config.Clock.Now()
 
-	for _, s := range state {
-		// Sort declared entries.
-		sortDeclaredEntriesByPreference(s.Declared)
+	for key, s := range state {
 		if len(s.Declared) > 0 {
-			// Grab the first to set.
-			preferredEntry := s.Declared[0]
+			preferredEntry, currentEntry, outdatedFields := r.matchEntryState(s)
 			preferredEntry.By.IncrementEntriesToSet()
 
 			// Record the remaining as masked.
@@ -164,37 +571,104 @@ func (r *entryReconciler) reconcile(ctx context.Context) {
 			// Borrow the current entry ID if available, for the update. Then
 			// drop the current entry from the list so it isn't added to the
 			// "to delete" list.
-			if len(s.Current) == 0 {
-				if preferredEntry.Entry.ID == "" && r.config.EntryIDPrefix != "" {
-					preferredEntry.Entry.ID = fmt.Sprintf("%s%s", r.config.EntryIDPrefix, uuid.New())
+			if currentEntry == nil {
+				if prefix := r.entryIDPrefixFor(ctx, preferredEntry.By); preferredEntry.Entry.ID == "" && prefix != "" {
+					preferredEntry.Entry.ID = fmt.Sprintf("%s%s", prefix, uuid.New())
 				}
 				toCreate = append(toCreate, preferredEntry)
 			} else {
-				preferredEntry.Entry.ID = s.Current[0].ID
-				if outdatedFields := getOutdatedEntryFields(preferredEntry.Entry, s.Current[0], unsupportedFields); len(outdatedFields) != 0 {
+				preferredEntry.Entry.ID = currentEntry.ID
+				preferredEntry.By.SetEntryID(currentEntry.ID)
+				if len(outdatedFields) != 0 {
 					// Current field does not match. Nothing to do.
 					toUpdate = append(toUpdate, preferredEntry)
+				} else {
+					r.recordEntryRevision(preferredEntry.Entry)
+					unchanged = append(unchanged, preferredEntry)
 				}
 				s.Current = s.Current[1:]
 			}
 		}
 
+		if r.withinGracePeriod(key, len(s.Declared) > 0, now) {
+			log.V(1).Info("Retaining entries no longer declared during the grace period", "count", len(s.Current))
+			continue
+		}
+
+		// Duplicates of the reused entry are kept around if so configured.
+		if r.config.PreserveDuplicates && len(s.Declared) > 0 {
+			continue
+		}
+
 		// Any remaining current entries that are not associated with join tokens
 		// should be removed as they aren't going to be reused for the entry update.
 		toDelete = append(toDelete, filterJoinTokenEntries(s.Current)...)
 	}
 
+	if !bootstrapping {
+		r.reportManagedEntries(currentEntries)
+	}
+	r.pruneLastDeclared(now)
+	r.pruneEntryFailures(state)
+
+	if r.config.EntryPolicy != nil {
+		toCreate, toUpdate = r.applyEntryPolicy(ctx, toCreate, toUpdate)
+	}
+
+	if r.config.ValidateEntriesBeforeSend {
+		toCreate = r.dropInvalidEntries(ctx, toCreate)
+		toUpdate = r.dropInvalidEntries(ctx, toUpdate)
+	}
+
+	if r.config.RevalidatePodsBeforeCreate {
+		toCreate = r.dropEntriesForDeletedPods(ctx, toCreate)
+	}
+
+	toCreate = r.dropBackedOffEntries(ctx, toCreate, now)
+	toUpdate = r.dropBackedOffEntries(ctx, toUpdate, now)
+
+	// Entries are counted once the drops above are done, so that the ones
+	// that won't be set are not counted.
+	r.reportEntriesByNamespace(unchanged, toCreate, toUpdate)
+
 	toDelete = append(toDelete, deleteOnlyEntries...)
-	if len(toDelete) > 0 {
-		r.deleteEntries(ctx, toDelete)
+	if r.config.DeleteConfirmationDelay > 0 {
+		toDelete = r.confirmDeletes(ctx, toDelete, now)
 	}
-	if len(toCreate) > 0 {
-		r.createEntries(ctx, toCreate)
+	summary := PassSummary{ToCreate: len(toCreate), ToUpdate: len(toUpdate), ToDelete: len(toDelete)}
+	if r.config.DryRun {
+		logDryRunEntries(ctx, toCreate, toUpdate, toDelete)
+		r.recordDryRunEvent(summary)
+	} else {
+		r.writeEntries(ctx, toCreate, toUpdate, toDelete)
 	}
-	if len(toUpdate) > 0 {
-		r.updateEntries(ctx, toUpdate)
+	if ctx.Err() != nil {
+		log.Info("Reconcile canceled; remaining changes will be applied on the next pass")
+		return
+	}
+	if r.reprobeUnsupportedFields {
+		// Don't wait for the next scheduled probe so that the next pass
+		// stops sending fields the server no longer supports.
+		log.Info("Entry writes failed with invalid arguments; re-probing unsupported fields")
+		r.reprobeUnsupportedFields = false
+		r.refreshUnsupportedFields(ctx, log, trustDomains, true)
+	}
+	if bootstrapping {
+		r.bootstrapPassesDone++
 	}
 
+	// Statuses are updated in dry-run mode too, so that their stats reflect
+	// what would be set.
+	r.updateStatuses(ctx, clusterStaticEntries, clusterSPIFFEIDs, spiffeIDs)
+
+	r.writeSummary(ctx, summary, clusterStaticEntries, clusterSPIFFEIDs, spiffeIDs)
+}
+
+// updateStatuses writes the next statuses of the objects declaring entries
+// that have changed.
+func (r *entryReconciler) updateStatuses(ctx context.Context, clusterStaticEntries []*ClusterStaticEntry, clusterSPIFFEIDs []*ClusterSPIFFEID, spiffeIDs []*SPIFFEID) {
+	log := log.FromContext(ctx)
+
 	// Update the ClusterStaticEntry statuses
 	for _, clusterStaticEntry := range clusterStaticEntries {
 		log := log.WithValues(clusterStaticEntryLogKey, objectName(clusterStaticEntry))
@@ -224,23 +698,186 @@ func (r *entryReconciler) reconcile(ctx context.Context) {
 			log.Error(err, "Failed to update status")
 		}
 	}
+
+	// Update the SPIFFEID statuses
+	for _, spiffeID := range spiffeIDs {
+		log := log.WithValues(spiffeIDObjectLogKey, objectName(spiffeID))
+
+		if spiffeID.Status == spiffeID.NextStatus {
+			continue
+		}
+		spiffeID.Status = spiffeID.NextStatus
+		if err := r.config.K8sClient.Status().Update(ctx, &spiffeID.SPIFFEID); err == nil {
+			log.Info("Updated status")
+		} else {
+			log.Error(err, "Failed to update status")
+		}
+	}
+}
+
+// prepareDeclaredEntries drops or adjusts the declared entries before they
+// are matched against the current entries.
+func (r *entryReconciler) prepareDeclaredEntries(ctx context.Context, state entriesState) {
+	r.dropOutOfScopeEntries(ctx, state)
+	r.checkDNSNameConflicts(ctx, state)
+}
+
+// matchEntryState picks the declared entry to set for the entry state,
+// merging the entries it masks into it, and matches it against the current
+// entry it would be written over, if any. The fields of that current entry
+// that are outdated are returned with it. The state must have declared
+// entries.
+func (r *entryReconciler) matchEntryState(s *entryState) (declaredEntry, *spireapi.Entry, []spireapi.Field) {
+	sortDeclaredEntriesByPreference(s.Declared)
+	r.mergeMaskedEntries(s.Declared)
+	preferredEntry := s.Declared[0]
+	if len(s.Current) == 0 {
+		return preferredEntry, nil, nil
+	}
+	currentEntry := &s.Current[0]
+	return preferredEntry, currentEntry, getOutdatedEntryFields(preferredEntry.Entry, *currentEntry, r.unsupportedFieldsFor(preferredEntry.Entry.SPIFFEID.TrustDomain()), r.config.TTLTolerance)
+}
+
+// writeEntries deletes, creates and updates the given entries on the SPIRE
+// server, stopping early if the context is canceled.
+func (r *entryReconciler) writeEntries(ctx context.Context, toCreate, toUpdate []declaredEntry, toDelete []spireapi.Entry) {
+	if len(toDelete) > 0 && ctx.Err() == nil {
+		r.deleteEntries(ctx, toDelete)
+	}
+	if r.config.CreateEntriesByPodPriority {
+		sortByPodPriority(toCreate)
+	}
+	if r.config.CreateDownstreamEntriesFirst {
+		var downstream []declaredEntry
+		downstream, toCreate = partitionDownstreamEntries(toCreate)
+		if len(downstream) > 0 && ctx.Err() == nil {
+			r.createEntries(ctx, downstream)
+		}
+	}
+	if len(toCreate) > 0 && ctx.Err() == nil {
+		r.createEntries(ctx, toCreate)
+	}
+	if len(toUpdate) > 0 && ctx.Err() == nil {
+		r.updateEntries(ctx, toUpdate)
+	}
+}
+
+// applyEntryPolicy drops the entries to create or update that are rejected by
+// the entry policy.
+func (r *entryReconciler) applyEntryPolicy(ctx context.Context, toCreate, toUpdate []declaredEntry) ([]declaredEntry, []declaredEntry) {
+	log := log.FromContext(ctx)
+	if len(toCreate) == 0 && len(toUpdate) == 0 {
+		return toCreate, toUpdate
+	}
+
+	proposed := append(append([]declaredEntry(nil), toCreate...), toUpdate...)
+	decisions, err := r.config.EntryPolicy.CheckEntries(ctx, entriesFromDeclaredEntries(proposed))
+	if err != nil {
+		r.promCounter[metrics.EntryPolicyFailures].Add(1)
+		if r.config.EntryPolicyFailOpen {
+			log.Error(err, "Failed to check entries against policy; allowing all entries")
+			return toCreate, toUpdate
+		}
+		log.Error(err, "Failed to check entries against policy; rejecting all entries")
+		for _, declaredEntry := range proposed {
+			declaredEntry.By.IncrementEntryFailures()
+		}
+		return nil, nil
+	}
+
+	filter := func(declaredEntries []declaredEntry, decisions []entrypolicy.Decision) []declaredEntry {
+		var allowed []declaredEntry
+		for i, declaredEntry := range declaredEntries {
+			if decisions[i].Allowed {
+				allowed = append(allowed, declaredEntry)
+				continue
+			}
+			r.promCounter[metrics.EntryPolicyRejections].Add(1)
+			declaredEntry.By.IncrementEntryFailures()
+			log.Info("Entry rejected by policy", append(entryLogFields(declaredEntry.Entry), policyReasonKey, decisions[i].Reason)...)
+		}
+		return allowed
+	}
+	return filter(toCreate, decisions[:len(toCreate)]), filter(toUpdate, decisions[len(toCreate):])
+}
+
+func (r *entryReconciler) reportDuplicateEntries(ctx context.Context, state entriesState) {
+	log := log.FromContext(ctx)
+	for _, s := range state {
+		current := filterJoinTokenEntries(s.Current)
+		if len(current) < 2 {
+			continue
+		}
+		r.promCounter[metrics.DuplicateEntries].Add(float64(len(current) - 1))
+		log.Info("Found duplicate SPIRE entries", append(entryLogFields(current[0]), duplicateIDsKey, idsFromEntries(current[1:]))...)
+	}
+}
+
+func (r *entryReconciler) bootstrapping() bool {
+	return r.bootstrapPassesDone < r.config.BootstrapPasses
+}
+
+// hasCacheSynced returns whether the cache, if configured, has synced. It
+// waits only briefly so a pass isn't held up by a cache that is slow to sync.
+func (r *entryReconciler) hasCacheSynced(ctx context.Context) bool {
+	if r.config.Cache == nil || r.cacheSynced {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(ctx, cacheSyncTimeout)
+	defer cancel()
+	r.cacheSynced = r.config.Cache.WaitForCacheSync(ctx)
+	return r.cacheSynced
 }
 
 func (r *entryReconciler) reconcileClass(className string) bool {
 	return (className == "" && r.config.WatchClassless) || className == r.config.ClassName
 }
 
-func (r *entryReconciler) recalculateUnsupportFields(ctx context.Context, log logr.Logger) {
-	unsupportedFields, err := r.getUnsupportedFields(ctx)
+// refreshUnsupportedFields probes the unsupported fields for the given trust
+// domains, if due or if forced.
+func (r *entryReconciler) refreshUnsupportedFields(ctx context.Context, log logr.Logger, trustDomains []spiffeid.TrustDomain, force bool) {
+	// The probe creates and deletes an entry, so it is skipped in dry-run
+	// mode, relying on the unsupported fields found so far.
+	if r.config.SkipUnsupportedFieldsProbe || r.config.DryRun {
+		return
+	}
+	now := r.config.Clock.Now()
+	for _, td := range trustDomains {
+		if force || now.After(r.nextGetUnsupportedFields[td]) {
+			r.recalculateUnsupportFields(ctx, log, td)
+		}
+	}
+}
+
+// unsupportedFieldsFor returns the unsupported fields for the trust domain,
+// falling back to those of the configured trust domain if the trust domain
+// could not be probed.
+func (r *entryReconciler) unsupportedFieldsFor(td spiffeid.TrustDomain) map[spireapi.Field]struct{} {
+	if unsupportedFields, ok := r.unsupportedFields[td]; ok {
+		return unsupportedFields
+	}
+	return r.unsupportedFields[r.config.TrustDomain]
+}
+
+func (r *entryReconciler) recalculateUnsupportFields(ctx context.Context, log logr.Logger, td spiffeid.TrustDomain) {
+	log = log.WithValues(trustDomainKey, td.Name())
+	unsupportedFields, err := r.getUnsupportedFields(ctx, log, td)
 	if err != nil {
 		log.Error(err, "failed to get unsupported fields")
+		if td != r.config.TrustDomain {
+			// Don't probe other trust domains on every pass if the server
+			// does not host them. The configured trust domain is used as a
+			// fallback in the meantime.
+			r.nextGetUnsupportedFields[td] = r.config.Clock.Now().Add(r.config.UnsupportedFieldsProbeInterval)
+		}
 		return
 	}
+	unsupportedFields = r.applyFieldSupportOverrides(unsupportedFields)
 
 	// Get the list of new fields that are marked as unsupported
 	var newUnsupportedFields []string
 	for key := range unsupportedFields {
-		if _, ok := r.unsupportedFields[key]; !ok {
+		if _, ok := r.unsupportedFields[td][key]; !ok {
 			newUnsupportedFields = append(newUnsupportedFields, string(key))
 		}
 	}
@@ -250,7 +887,7 @@ func (r *entryReconciler) recalculateUnsupportFields(ctx context.Context, log lo
 
 	// Get the list of fields that used to be unsupported but now are supported
 	var supportedFields []string
-	for key := range r.unsupportedFields {
+	for key := range r.unsupportedFields[td] {
 		if _, ok := unsupportedFields[key]; !ok {
 			supportedFields = append(supportedFields, string(key))
 		}
@@ -259,8 +896,88 @@ func (r *entryReconciler) recalculateUnsupportFields(ctx context.Context, log lo
 		log.Info("Fields previously unsupported are now supported on SPIRE server", "fields", strings.Join(supportedFields, ","))
 	}
 
-	r.unsupportedFields = unsupportedFields
-	r.nextGetUnsupportedFields = time.Now().Add(10 * time.Minute)
+	r.unsupportedFieldsMu.Lock()
+	r.unsupportedFields[td] = unsupportedFields
+	r.unsupportedFieldsMu.Unlock()
+	r.nextGetUnsupportedFields[td] = r.config.Clock.Now().Add(r.config.UnsupportedFieldsProbeInterval)
+}
+
+// knownUnsupportedFields returns a copy of the unsupported fields found so
+// far, by trust domain. It is safe to call concurrently with the reconcile.
+func (r *entryReconciler) knownUnsupportedFields() map[spiffeid.TrustDomain]map[spireapi.Field]struct{} {
+	r.unsupportedFieldsMu.RLock()
+	defer r.unsupportedFieldsMu.RUnlock()
+	return maps.Clone(r.unsupportedFields)
+}
+
+// applyFieldSupportOverrides returns the unsupported fields with the
+// configured overrides applied. The given set is not modified.
+func (r *entryReconciler) applyFieldSupportOverrides(unsupportedFields map[spireapi.Field]struct{}) map[spireapi.Field]struct{} {
+	if len(r.config.FieldSupportOverrides) == 0 {
+		return unsupportedFields
+	}
+	out := make(map[spireapi.Field]struct{}, len(unsupportedFields))
+	for field := range unsupportedFields {
+		out[field] = struct{}{}
+	}
+	for field, supported := range r.config.FieldSupportOverrides {
+		if supported {
+			delete(out, field)
+		} else {
+			out[field] = struct{}{}
+		}
+	}
+	return out
+}
+
+// getUnsupportedFields probes the unsupported fields for the trust domain,
+// retrying the probe on transient failures.
+func (r *entryReconciler) getUnsupportedFields(ctx context.Context, log logr.Logger, td spiffeid.TrustDomain) (map[spireapi.Field]struct{}, error) {
+	backoff := r.probeRetryBackoff
+	for attempt := 0; ; attempt++ {
+		unsupportedFields, err := r.config.EntryClient.GetUnsupportedFields(ctx, td.Name())
+		if err == nil || attempt >= r.config.UnsupportedFieldsProbeRetries || !isTransientError(err) {
+			return unsupportedFields, err
+		}
+		log.V(1).Info("Retrying unsupported fields probe", "attempt", attempt+1, "backoff", backoff, "reason", err.Error())
+		select {
+		case <-r.config.Clock.After(backoff):
+		case <-ctx.Done():
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+// isTransientError returns true if the gRPC error is one a retry might
+// resolve.
+func isTransientError(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// entryIDPrefixFor returns the prefix for the IDs of new entries declared by
+// the object.
+func (r *entryReconciler) entryIDPrefixFor(ctx context.Context, by byObject) string {
+	if r.config.EntryIDPrefixPolicy == nil {
+		return r.config.EntryIDPrefix
+	}
+	prefix, ok, err := r.config.EntryIDPrefixPolicy.prefixFor(by)
+	if err != nil {
+		logKey := clusterSPIFFEIDLogKey
+		if _, ok := by.(*ClusterStaticEntry); ok {
+			logKey = clusterStaticEntryLogKey
+		}
+		log.FromContext(ctx).Error(err, "Ignoring entry ID prefix annotation; falling back to the default prefix", logKey, by.GetName())
+	}
+	if !ok {
+		return r.config.EntryIDPrefix
+	}
+	return prefix
 }
 
 func (r *entryReconciler) shouldProcessOrDeleteEntryID(entry spireapi.Entry) (bool, bool) {
@@ -270,6 +987,9 @@ func (r *entryReconciler) shouldProcessOrDeleteEntryID(entry spireapi.Entry) (bo
 	if strings.HasPrefix(entry.ID, r.config.EntryIDPrefix) {
 		return true, false
 	}
+	if r.config.EntryIDPrefixPolicy != nil && r.config.EntryIDPrefixPolicy.manages(entry.ID) {
+		return true, false
+	}
 	if r.config.EntryIDPrefixCleanup != nil {
 		cleanupPrefix := *r.config.EntryIDPrefixCleanup
 		if cleanupPrefix == "" {
@@ -282,15 +1002,26 @@ func (r *entryReconciler) shouldProcessOrDeleteEntryID(entry spireapi.Entry) (bo
 	return false, false
 }
 
-func (r *entryReconciler) listEntries(ctx context.Context) ([]spireapi.Entry, []spireapi.Entry, error) {
+// listEntries lists the entries on the SPIRE server. The entries left
+// behind by the unsupported fields probe are returned separately, for the
+// caller to clean up; listing has no side effects.
+func (r *entryReconciler) listEntries(ctx context.Context) ([]spireapi.Entry, []spireapi.Entry, []spireapi.Entry, error) {
 	// TODO: cache?
 	var deleteOnlyEntries []spireapi.Entry
 	var currentEntries []spireapi.Entry
+	var probeEntries []spireapi.Entry
 	tmpvals, err := r.config.EntryClient.ListEntries(ctx)
 	if err != nil {
-		return currentEntries, deleteOnlyEntries, err
+		return currentEntries, deleteOnlyEntries, probeEntries, err
 	}
 	for _, value := range tmpvals {
+		if spireapi.IsUnsupportedFieldsProbeEntry(value) {
+			probeEntries = append(probeEntries, value)
+			continue
+		}
+		if !r.inParentIDScope(value.ParentID) {
+			continue
+		}
 		proc, del := r.shouldProcessOrDeleteEntryID(value)
 		if proc {
 			currentEntries = append(currentEntries, value)
@@ -299,11 +1030,7 @@ func (r *entryReconciler) listEntries(ctx context.Context) ([]spireapi.Entry, []
 			deleteOnlyEntries = append(deleteOnlyEntries, value)
 		}
 	}
-	return currentEntries, deleteOnlyEntries, nil
-}
-
-func (r *entryReconciler) getUnsupportedFields(ctx context.Context) (map[spireapi.Field]struct{}, error) {
-	return r.config.EntryClient.GetUnsupportedFields(ctx, r.config.TrustDomain.Name())
+	return currentEntries, deleteOnlyEntries, probeEntries, nil
 }
 
 func (r *entryReconciler) listClusterStaticEntries(ctx context.Context) ([]*ClusterStaticEntry, error) {
@@ -338,12 +1065,45 @@ func (r *entryReconciler) listClusterSPIFFEIDs(ctx context.Context) ([]*ClusterS
 	return out, nil
 }
 
-func (r *entryReconciler) listNamespaces(ctx context.Context, namespaceSelector labels.Selector) ([]corev1.Namespace, error) {
-	return k8sapi.ListNamespaces(ctx, r.config.K8sClient, namespaceSelector)
+func (r *entryReconciler) listSPIFFEIDs(ctx context.Context) ([]*SPIFFEID, error) {
+	spiffeIDs, err := k8sapi.ListSPIFFEIDs(ctx, r.config.K8sClient)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*SPIFFEID, 0, len(spiffeIDs))
+	for _, spiffeID := range spiffeIDs {
+		if r.reconcileClass(spiffeID.Spec.ClassName) {
+			out = append(out, &SPIFFEID{
+				SPIFFEID: spiffeID,
+			})
+		}
+	}
+	return out, nil
+}
+
+// listNamespaces lists the namespaces matching the label selector and, if
+// set, the field selector. Field selectors are matched here rather than by
+// the client since the cache only supports them on indexed fields.
+func (r *entryReconciler) listNamespaces(ctx context.Context, namespaceSelector labels.Selector, namespaceFieldSelector fields.Selector) ([]corev1.Namespace, error) {
+	namespaces, err := k8sapi.ListNamespaces(ctx, r.config.K8sClient, namespaceSelector)
+	if err != nil || namespaceFieldSelector == nil {
+		return namespaces, err
+	}
+	return slices.DeleteFunc(namespaces, func(ns corev1.Namespace) bool {
+		return !namespaceFieldSelector.Matches(spirev1alpha1.NamespaceFields(&ns))
+	}), nil
 }
 
-func (r *entryReconciler) listNamespacePods(ctx context.Context, namespace string, podSelector labels.Selector) ([]corev1.Pod, error) {
-	return k8sapi.ListNamespacePods(ctx, r.config.K8sClient, namespace, podSelector)
+// listNamespacePods lists the pods in the namespace matching the label
+// selector and, if set, the field selector.
+func (r *entryReconciler) listNamespacePods(ctx context.Context, namespace string, podSelector labels.Selector, podFieldSelector fields.Selector) ([]corev1.Pod, error) {
+	pods, err := k8sapi.ListNamespacePods(ctx, r.config.K8sClient, namespace, podSelector)
+	if err != nil || podFieldSelector == nil {
+		return pods, err
+	}
+	return slices.DeleteFunc(pods, func(pod corev1.Pod) bool {
+		return !podFieldSelector.Matches(spirev1alpha1.PodFields(&pod))
+	}), nil
 }
 
 func (r *entryReconciler) addClusterStaticEntryEntriesState(ctx context.Context, state entriesState, clusterStaticEntries []*ClusterStaticEntry) {
@@ -352,14 +1112,41 @@ func (r *entryReconciler) addClusterStaticEntryEntriesState(ctx context.Context,
 		log := log.WithValues(clusterSPIFFEIDLogKey, objectName(clusterStaticEntry))
 		entry, err := renderStaticEntry(&clusterStaticEntry.Spec)
 		if err != nil {
-			log.Error(err, "Failed to render ClusterStaticEntry")
-			clusterStaticEntry.NextStatus.Rendered = false
-			r.promCounter[metrics.StaticEntryFailures].Add(1)
+			r.reportStaticEntryRenderFailure(log, clusterStaticEntry, err)
 			continue
 		}
+		if err := r.prefixSPIFFEID(entry); err != nil {
+			r.reportStaticEntryRenderFailure(log, clusterStaticEntry, &renderError{field: spiffeIDKey, err: err})
+			continue
+		}
+		r.applyDefaultSVIDTTLs(entry)
+		if r.config.MinTTLPolicy != nil {
+			if err := r.config.MinTTLPolicy.apply(log, entry); err != nil {
+				r.reportStaticEntryRenderFailure(log, clusterStaticEntry, err)
+				continue
+			}
+		}
 		clusterStaticEntry.NextStatus.Rendered = true
-		state.AddDeclared(*entry, clusterStaticEntry)
+		r.clampX509SVIDTTL(log, entry)
+		r.restrictAdminEntry(entry)
+		state.AddDeclared(*entry, clusterStaticEntry, nil)
+	}
+}
+
+// reportStaticEntryRenderFailure reports a ClusterStaticEntry that failed to
+// render in its status, the logs, the metrics and an event carrying the
+// error.
+func (r *entryReconciler) reportStaticEntryRenderFailure(log logr.Logger, clusterStaticEntry *ClusterStaticEntry, err error) {
+	field := "unknown"
+	var renderErr *renderError
+	if errors.As(err, &renderErr) {
+		field = renderErr.field
 	}
+	log.Error(err, "Failed to render ClusterStaticEntry", "field", field)
+	clusterStaticEntry.NextStatus.Rendered = false
+	r.promCounter[metrics.StaticEntryFailures].Add(1)
+	r.promStaticEntryRenderFailures.WithLabelValues(field).Inc()
+	r.recordWarning(&clusterStaticEntry.ClusterStaticEntry, staticEntryRenderFailedReason, "Failed to render entry: %v", err)
 }
 
 func (r *entryReconciler) addClusterSPIFFEIDEntriesState(ctx context.Context, state entriesState, clusterSPIFFEIDs []*ClusterSPIFFEID) {
@@ -375,19 +1162,28 @@ func (r *entryReconciler) addClusterSPIFFEIDEntriesState(ctx context.Context, st
 		}
 		return -1
 	})
+	now := r.config.Clock.Now()
 	for _, clusterSPIFFEID := range clusterSPIFFEIDs {
 		log := log.WithValues(clusterSPIFFEIDLogKey, objectName(clusterSPIFFEID))
 
+		if r.inRenderFailureBackoff(clusterSPIFFEID, now) {
+			// Keep the stats of the last attempt.
+			log.V(1).Info("Skipping ClusterSPIFFEID in render failure backoff")
+			clusterSPIFFEID.NextStatus = clusterSPIFFEID.Status
+			continue
+		}
+
 		spec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&clusterSPIFFEID.Spec)
 		if err != nil {
 			// TODO: should this be prevented via admission webhook? should
 			// we dump this failure into the status?
 			log.Error(err, "Failed to parse ClusterSPIFFEID spec")
+			r.trace.parseError(clusterSPIFFEID, err)
 			continue
 		}
 
 		// List namespaces applicable to the ClusterSPIFFEID
-		namespaces, err := r.listNamespaces(ctx, spec.NamespaceSelector)
+		namespaces, err := r.listNamespaces(ctx, spec.NamespaceSelector, spec.NamespaceFieldSelector)
 		if err != nil {
 			log.Error(err, "Failed to list namespaces")
 			continue
@@ -395,50 +1191,453 @@ func (r *entryReconciler) addClusterSPIFFEIDEntriesState(ctx context.Context, st
 
 		clusterSPIFFEID.NextStatus.Stats.NamespacesSelected += len(namespaces)
 
+		var namespacesIgnored int
 		for i := range namespaces {
-			if namespace.IsIgnored(r.config.IgnoreNamespaces, namespaces[i].Name) {
-				clusterSPIFFEID.NextStatus.Stats.NamespacesIgnored++
-				continue
+			ignored := namespace.IsIgnored(r.config.IgnoreNamespaces, namespaces[i].Name)
+			if ignored {
+				namespacesIgnored++
+			}
+			r.trace.namespace(clusterSPIFFEID, &namespaces[i], ignored)
+		}
+		clusterSPIFFEID.NextStatus.Stats.NamespacesIgnored += namespacesIgnored
+		if namespacesIgnored > 0 && namespacesIgnored == len(namespaces) {
+			log.Info("All namespaces selected by ClusterSPIFFEID are ignored; no entries will be produced")
+			r.recordWarning(&clusterSPIFFEID.ClusterSPIFFEID, allNamespacesIgnoredReason,
+				"All %d namespaces selected are ignored by the controller; no entries will be produced", namespacesIgnored)
+			continue
+		}
+
+		// Pods are listed and entries rendered concurrently across
+		// namespaces. The results are then added to the state and stats
+		// serially, in namespace order, so that the outcome does not depend
+		// on the concurrency.
+		results := r.renderNamespacePods(ctx, log, spec, clusterSPIFFEID.Spec.Fallback, namespaces, podsWithNonFallbackApplied, now)
+		rendered := 0
+		for _, result := range results {
+			clusterSPIFFEID.NextStatus.Stats.PodsSelected += len(result.pods)
+			for i := range result.pods {
+				pod := &result.pods[i]
+				podResult := result.podResults[i]
+				countPodOutcome(&clusterSPIFFEID.NextStatus.Stats, podResult.outcome)
+				switch podResult.outcome {
+				case TracePodRendered:
+					rendered++
+					for _, entry := range podResult.entries {
+						state.AddDeclared(entry, clusterSPIFFEID, pod)
+					}
+					if !clusterSPIFFEID.Spec.Fallback {
+						podsWithNonFallbackApplied[pod.UID] = struct{}{}
+					}
+				case "":
+					// renderPodEntries returns no entries if requisite k8s
+					// objects disappeared from underneath.
+					continue
+				}
+				r.trace.pod(clusterSPIFFEID, pod, podResult.outcome, podResult.err, podResult.entry())
+			}
+		}
+		if spec.Placeholder != nil && clusterSPIFFEID.NextStatus.Stats.PodsSelected == 0 {
+			entry, err := renderPlaceholderEntry(spec, r.config.TrustDomain, r.config.ClusterName, r.config.ClusterDomain)
+			if err != nil {
+				log.Error(err, "Failed to render placeholder entry")
+			} else {
+				rendered++
+				state.AddDeclared(*entry, clusterSPIFFEID, nil)
 			}
+		}
+		r.recordRenderOutcome(log, clusterSPIFFEID, rendered, clusterSPIFFEID.NextStatus.Stats.PodEntryRenderFailures, now)
+	}
+}
 
-			log := log.WithValues(namespaceLogKey, objectName(&namespaces[i]))
+// countPodOutcome counts the pods that did not get an entry, by outcome.
+func countPodOutcome(stats *spirev1alpha1.ClusterSPIFFEIDStats, outcome string) {
+	switch outcome {
+	case TracePodExcluded:
+		stats.PodsExcluded++
+	case TracePodSkipped:
+		stats.PodsSkipped++
+	case TracePodAwaitingIP:
+		stats.PodsAwaitingIP++
+	case TracePodTooYoung:
+		stats.PodsTooYoung++
+	case TracePodRenderFailed:
+		stats.PodEntryRenderFailures++
+	}
+}
 
-			pods, err := r.listNamespacePods(ctx, namespaces[i].Name, spec.PodSelector)
-			switch {
-			case err == nil:
-			case apierrors.IsNotFound(err):
-				continue
-			default:
-				log.Error(err, "Failed to list namespace pods")
-				continue
+// namespacePods holds the pods selected in a namespace and the outcome of
+// rendering an entry for each of them.
+type namespacePods struct {
+	pods       []corev1.Pod
+	podResults []podResult
+}
+
+type podResult struct {
+	// outcome is the trace outcome of the pod, or empty if the entry could
+	// not be rendered because requisite k8s objects disappeared.
+	outcome string
+	// entries holds the entry of the pod, followed by those for its alias
+	// SPIFFE IDs.
+	entries []spireapi.Entry
+	err     error
+}
+
+// entry returns the entry of the pod, or nil if none was rendered.
+func (p podResult) entry() *spireapi.Entry {
+	if len(p.entries) == 0 {
+		return nil
+	}
+	return &p.entries[0]
+}
+
+// renderNamespacePods lists the pods selected in each non-ignored namespace
+// and renders their entries, processing up to NamespaceConcurrency
+// namespaces at once. The results are returned in namespace order. Nothing
+// shared is modified other than the next pod maturity, which is guarded.
+func (r *entryReconciler) renderNamespacePods(ctx context.Context, log logr.Logger, spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, fallback bool, namespaces []corev1.Namespace, podsWithNonFallbackApplied map[types.UID]struct{}, now time.Time) []namespacePods {
+	results := make([]namespacePods, len(namespaces))
+	render := func(i int) {
+		if namespace.IsIgnored(r.config.IgnoreNamespaces, namespaces[i].Name) {
+			return
+		}
+
+		log := log.WithValues(namespaceLogKey, objectName(&namespaces[i]))
+
+		pods, err := r.listNamespacePods(ctx, namespaces[i].Name, spec.PodSelector, spec.PodFieldSelector)
+		switch {
+		case err == nil:
+		case apierrors.IsNotFound(err):
+			return
+		default:
+			log.Error(err, "Failed to list namespace pods")
+			return
+		}
+
+		podResults := make([]podResult, len(pods))
+		for j := range pods {
+			podResults[j] = r.renderPodResult(ctx, log, spec, fallback, &pods[j], podsWithNonFallbackApplied, now)
+		}
+		results[i] = namespacePods{pods: pods, podResults: podResults}
+	}
+
+	concurrency := min(r.config.NamespaceConcurrency, len(namespaces))
+	if concurrency < 2 {
+		for i := range namespaces {
+			render(i)
+		}
+		return results
+	}
+
+	indices := make(chan int)
+	wg := new(sync.WaitGroup)
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				render(i)
 			}
+		}()
+	}
+	for i := range namespaces {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	return results
+}
 
-			clusterSPIFFEID.NextStatus.Stats.PodsSelected += len(pods)
-			for i := range pods {
-				log := log.WithValues(podLogKey, objectName(&pods[i]))
-				if _, ok := podsWithNonFallbackApplied[pods[i].UID]; ok && clusterSPIFFEID.Spec.Fallback {
-					continue
-				}
+func (r *entryReconciler) renderPodResult(ctx context.Context, log logr.Logger, spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, fallback bool, pod *corev1.Pod, podsWithNonFallbackApplied map[types.UID]struct{}, now time.Time) podResult {
+	log = log.WithValues(podLogKey, objectName(pod))
+	if r.isPodExcluded(pod) {
+		return podResult{outcome: TracePodExcluded}
+	}
+	if r.isPodSkipped(pod) {
+		return podResult{outcome: TracePodSkipped}
+	}
+	if spec.AutoPopulatePodIP && len(podIPs(pod)) == 0 {
+		// The pod will be picked up again once it is updated with an IP.
+		return podResult{outcome: TracePodAwaitingIP}
+	}
+	if !r.isPodMature(pod, now) {
+		// The pod will be picked up again once it matures, or once it is
+		// updated if it has not started yet.
+		return podResult{outcome: TracePodTooYoung}
+	}
+	if _, ok := podsWithNonFallbackApplied[pod.UID]; ok && fallback {
+		return podResult{outcome: TracePodFallbackSkipped}
+	}
 
-				entry, err := r.renderPodEntry(ctx, spec, &pods[i])
-				switch {
-				case err != nil:
-					log.Error(err, "Failed to render entry")
-					clusterSPIFFEID.NextStatus.Stats.PodEntryRenderFailures++
-				case entry != nil:
-					// renderPodEntry will return a nil entry if requisite k8s
-					// objects disappeared from underneath.
-					state.AddDeclared(*entry, clusterSPIFFEID)
-					if !clusterSPIFFEID.Spec.Fallback {
-						podsWithNonFallbackApplied[pods[i].UID] = struct{}{}
+	entries, err := r.renderPodEntries(ctx, spec, pod)
+	switch {
+	case err != nil:
+		log.Error(err, "Failed to render entry")
+		return podResult{outcome: TracePodRenderFailed, err: err}
+	case len(entries) > 0:
+		return podResult{outcome: TracePodRendered, entries: entries}
+	}
+	return podResult{}
+}
+
+// isPodMature returns whether the pod was started at least
+// MinPodAgeForEntry ago. If not, and the pod has started, when the pod
+// matures is recorded so that a reconcile can be triggered then.
+func (r *entryReconciler) isPodMature(pod *corev1.Pod, now time.Time) bool {
+	if r.config.MinPodAgeForEntry <= 0 {
+		return true
+	}
+	// The age is that of the pod, not of its containers, so that container
+	// restarts do not take the entry of a long-running pod away.
+	if pod.Status.StartTime == nil {
+		// The pod will be picked up again once it is started.
+		return false
+	}
+	maturesAt := pod.Status.StartTime.Add(r.config.MinPodAgeForEntry)
+	if !now.Before(maturesAt) {
+		return true
+	}
+	r.podMaturityMu.Lock()
+	defer r.podMaturityMu.Unlock()
+	if r.nextPodMaturity.IsZero() || maturesAt.Before(r.nextPodMaturity) {
+		r.nextPodMaturity = maturesAt
+	}
+	return false
+}
+
+// triggerAtPodMaturity schedules a reconcile for when the next pod matures,
+// replacing the previously scheduled one.
+func (r *entryReconciler) triggerAtPodMaturity() {
+	if r.triggerer == nil {
+		return
+	}
+	if r.podMaturityTimer != nil {
+		r.podMaturityTimer.Stop()
+		r.podMaturityTimer = nil
+	}
+	if !r.nextPodMaturity.IsZero() {
+		r.podMaturityTimer = r.config.Clock.AfterFunc(r.nextPodMaturity.Sub(r.config.Clock.Now()), r.triggerer.Trigger)
+	}
+}
+
+// addSPIFFEIDEntriesState adds the entries declared by the namespaced
+// SPIFFEIDs. Unlike ClusterSPIFFEIDs, they only select pods in their own
+// namespace and the SPIFFE IDs they render must be in that namespace too,
+// since they can be managed by namespace owners.
+func (r *entryReconciler) addSPIFFEIDEntriesState(ctx context.Context, state entriesState, spiffeIDs []*SPIFFEID) {
+	log := log.FromContext(ctx)
+	now := r.config.Clock.Now()
+	for _, spiffeID := range spiffeIDs {
+		log := log.WithValues(spiffeIDObjectLogKey, objectName(spiffeID))
+
+		if namespace.IsIgnored(r.config.IgnoreNamespaces, spiffeID.Namespace) {
+			log.V(1).Info("Skipping SPIFFEID in ignored namespace")
+			continue
+		}
+		if r.inRenderFailureBackoff(spiffeID, now) {
+			log.V(1).Info("Skipping SPIFFEID in render failure backoff")
+			spiffeID.NextStatus = spiffeID.Status
+			continue
+		}
+
+		spec, err := spirev1alpha1.ParseSPIFFEIDSpec(&spiffeID.Spec)
+		if err != nil {
+			log.Error(err, "Failed to parse SPIFFEID spec")
+			continue
+		}
+
+		pods, err := r.listNamespacePods(ctx, spiffeID.Namespace, spec.PodSelector, nil)
+		if err != nil {
+			log.Error(err, "Failed to list namespace pods")
+			continue
+		}
+
+		spiffeID.NextStatus.Stats.PodsSelected += len(pods)
+		rendered := 0
+		for i := range pods {
+			result := r.renderPodResult(ctx, log, spec, false, &pods[i], nil, now)
+			if result.outcome == TracePodRendered {
+				for _, entry := range result.entries {
+					if err := r.checkSPIFFEIDInNamespace(entry.SPIFFEID, spiffeID.Namespace); err != nil {
+						log.Error(err, "Failed to render entry", podLogKey, objectName(&pods[i]))
+						result = podResult{outcome: TracePodRenderFailed, err: err}
+						break
 					}
 				}
 			}
+			countPodOutcome(&spiffeID.NextStatus.Stats, result.outcome)
+			if result.outcome == TracePodRendered {
+				rendered++
+				for _, entry := range result.entries {
+					state.AddDeclared(entry, spiffeID, &pods[i])
+				}
+			}
+		}
+		r.recordRenderOutcome(log, spiffeID, rendered, spiffeID.NextStatus.Stats.PodEntryRenderFailures, now)
+	}
+}
+
+// checkSPIFFEIDInNamespace checks that the path of the SPIFFE ID, after the
+// configured path prefix, is in the namespace, i.e. /ns/<namespace>.
+func (r *entryReconciler) checkSPIFFEIDInNamespace(id spiffeid.ID, namespace string) error {
+	nsPath := r.config.SPIFFEIDPathPrefix + "/ns/" + namespace
+	if path := id.Path(); path != nsPath && !strings.HasPrefix(path, nsPath+"/") {
+		return fmt.Errorf("SPIFFE ID %q is not in namespace %q; its path must start with %q", id, namespace, nsPath)
+	}
+	return nil
+}
+
+// dropInvalidEntries drops the entries that would be rejected as malformed,
+// counting them as failures of the object they were declared by.
+func (r *entryReconciler) dropInvalidEntries(ctx context.Context, declaredEntries []declaredEntry) []declaredEntry {
+	log := log.FromContext(ctx)
+	var kept []declaredEntry
+	for _, declaredEntry := range declaredEntries {
+		if err := spireapi.ValidateEntry(declaredEntry.Entry); err != nil {
+			log.Error(err, "Dropping invalid entry", entryLogFields(declaredEntry.Entry)...)
+			declaredEntry.By.IncrementEntryFailures()
+			continue
+		}
+		kept = append(kept, declaredEntry)
+	}
+	return kept
+}
+
+// dropEntriesForDeletedPods drops the entries rendered for pods that have
+// been deleted, or replaced by a pod with the same name, since they were
+// listed. The pods are fetched from the cache.
+func (r *entryReconciler) dropEntriesForDeletedPods(ctx context.Context, declaredEntries []declaredEntry) []declaredEntry {
+	log := log.FromContext(ctx)
+	var kept []declaredEntry
+	for _, declaredEntry := range declaredEntries {
+		if declaredEntry.Pod == nil {
+			kept = append(kept, declaredEntry)
+			continue
+		}
+		pod := new(corev1.Pod)
+		err := r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(declaredEntry.Pod), pod)
+		switch {
+		case err == nil && pod.UID == declaredEntry.Pod.UID:
+			kept = append(kept, declaredEntry)
+			continue
+		case err != nil && !apierrors.IsNotFound(err):
+			// Not knowing any better, assume the pod is still around.
+			log.Error(err, "Failed to revalidate pod", podLogKey, objectName(declaredEntry.Pod))
+			kept = append(kept, declaredEntry)
+			continue
+		}
+		log.V(1).Info("Dropping entry for deleted pod", append(entryLogFields(declaredEntry.Entry), podLogKey, objectName(declaredEntry.Pod))...)
+		switch by := declaredEntry.By.(type) {
+		case *ClusterSPIFFEID:
+			by.NextStatus.Stats.EntriesToSet--
+			by.NextStatus.Stats.PodsDeleted++
+		case *SPIFFEID:
+			by.NextStatus.Stats.EntriesToSet--
+			by.NextStatus.Stats.PodsDeleted++
 		}
 	}
+	return kept
 }
 
-func (r *entryReconciler) renderPodEntry(ctx context.Context, spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, pod *corev1.Pod) (*spireapi.Entry, error) {
+// withinGracePeriod records when the entry was last declared and returns
+// true if the current entries for an entry that is no longer declared should
+// be retained because it was declared within the grace period.
+func (r *entryReconciler) withinGracePeriod(key entryKey, declared bool, now time.Time) bool {
+	if r.config.DeclaredEntryGracePeriod <= 0 {
+		return false
+	}
+	if declared {
+		r.lastDeclared[key] = now
+		return false
+	}
+	lastDeclared, ok := r.lastDeclared[key]
+	return ok && now.Sub(lastDeclared) < r.config.DeclaredEntryGracePeriod
+}
+
+// pruneLastDeclared forgets entries that were last declared before the grace
+// period.
+func (r *entryReconciler) pruneLastDeclared(now time.Time) {
+	for key, lastDeclared := range r.lastDeclared {
+		if now.Sub(lastDeclared) >= r.config.DeclaredEntryGracePeriod {
+			delete(r.lastDeclared, key)
+		}
+	}
+}
+
+// reportEntriesByNamespace sets the per-namespace entry gauge from the
+// entries declared for pods, removing the series for namespaces that no
+// longer have any entries.
+func (r *entryReconciler) reportEntriesByNamespace(declaredEntries ...[]declaredEntry) {
+	entriesByNamespace := make(map[string]int)
+	for _, entries := range declaredEntries {
+		for _, declaredEntry := range entries {
+			if declaredEntry.Pod != nil {
+				entriesByNamespace[declaredEntry.Pod.Namespace]++
+			}
+		}
+	}
+	for namespace := range r.reportedNamespaces {
+		if _, ok := entriesByNamespace[namespace]; !ok {
+			r.promEntriesByNamespace.DeleteLabelValues(namespace)
+		}
+	}
+	r.reportedNamespaces = make(map[string]struct{}, len(entriesByNamespace))
+	for namespace, count := range entriesByNamespace {
+		r.promEntriesByNamespace.WithLabelValues(namespace).Set(float64(count))
+		r.reportedNamespaces[namespace] = struct{}{}
+	}
+}
+
+func (r *entryReconciler) reportManagedEntries(currentEntries []spireapi.Entry) {
+	entriesByTrustDomain := make(map[spiffeid.TrustDomain]int)
+	for _, entry := range currentEntries {
+		entriesByTrustDomain[entry.SPIFFEID.TrustDomain()]++
+	}
+	for td := range r.reportedTrustDomains {
+		if _, ok := entriesByTrustDomain[td]; !ok {
+			r.promManagedEntries.DeleteLabelValues(td.Name())
+		}
+	}
+	r.reportedTrustDomains = make(map[spiffeid.TrustDomain]struct{}, len(entriesByTrustDomain))
+	for td, count := range entriesByTrustDomain {
+		r.promManagedEntries.WithLabelValues(td.Name()).Set(float64(count))
+		r.reportedTrustDomains[td] = struct{}{}
+	}
+}
+
+func (r *entryReconciler) recordWarning(obj client.Object, reason, messageFmt string, args ...any) {
+	if r.config.EventRecorder != nil {
+		r.config.EventRecorder.Eventf(obj, corev1.EventTypeWarning, reason, messageFmt, args...)
+	}
+}
+
+func (r *entryReconciler) isPodExcluded(pod *corev1.Pod) bool {
+	return r.config.GlobalPodExclusionSelector != nil && r.config.GlobalPodExclusionSelector.Matches(labels.Set(pod.Labels))
+}
+
+// isPodSkipped returns true if the pod is being deleted or has terminated
+// and such pods are skipped.
+func (r *entryReconciler) isPodSkipped(pod *corev1.Pod) bool {
+	if !r.config.SkipTerminatingPods {
+		return false
+	}
+	switch {
+	case pod.DeletionTimestamp != nil:
+		return true
+	case pod.Status.Phase == corev1.PodSucceeded, pod.Status.Phase == corev1.PodFailed:
+		return true
+	}
+	return false
+}
+
+// renderPodEntries renders the entry of the pod, followed by those for its
+// alias SPIFFE IDs, if any. No entries are returned if the pod is skipped.
+func (r *entryReconciler) renderPodEntries(ctx context.Context, spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, pod *corev1.Pod) ([]spireapi.Entry, error) {
+	// Pods without the primary container, e.g. other workloads selected by
+	// the same selectors, are skipped rather than failing to render.
+	if spec.PrimaryContainerName != "" && findContainer(&pod.Spec, spec.PrimaryContainerName) == nil {
+		log.FromContext(ctx).Info("Skipping pod without the primary container", podLogKey, objectName(pod), "container", spec.PrimaryContainerName)
+		return nil, nil
+	}
 	// TODO: should we be caching this? probably not since it grabs from the
 	// controller client, which is cached already.
 	node := new(corev1.Node)
@@ -450,13 +1649,112 @@ func (r *entryReconciler) renderPodEntry(ctx context.Context, spec *spirev1alpha
 		if err := r.config.K8sClient.List(ctx, endpointsList, client.InNamespace(pod.Namespace), client.MatchingFields{reconciler.EndpointUID: string(pod.UID)}); err != nil && !apierrors.IsNotFound(err) {
 			return nil, err
 		}
+		if dropped := limitEndpoints(endpointsList, r.config.MaxDNSNameEndpoints); dropped > 0 {
+			log.FromContext(ctx).Info("Dropped DNS names for endpoints over the limit", podLogKey, objectName(pod), "dropped", dropped, "limit", r.config.MaxDNSNameEndpoints)
+		}
+	}
+	entry, err := renderPodEntry(spec, node, pod, endpointsList, r.config.TrustDomain, r.config.ClusterName, r.config.ClusterDomain, r.parentIDTemplateFor(node))
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := renderAliasSPIFFEIDs(spec, node, pod, r.config.TrustDomain, r.config.ClusterName, r.config.ClusterDomain)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.prefixSPIFFEID(entry); err != nil {
+		return nil, err
+	}
+	if r.config.TTLTierPolicy != nil {
+		if err := r.config.TTLTierPolicy.apply(entry, pod); err != nil {
+			log.FromContext(ctx).Error(err, "Ignoring TTL tier; falling back to the default TTLs", podLogKey, objectName(pod))
+		}
+	}
+	if r.config.FederationLabelPolicy != nil {
+		if err := r.config.FederationLabelPolicy.apply(entry, pod); err != nil && r.config.FederationLabelPolicy.WarnOnUnknownGroup {
+			log.FromContext(ctx).Info("Ignoring federation group", podLogKey, objectName(pod), "reason", err.Error())
+		}
+	}
+	r.applyDefaultSVIDTTLs(entry)
+	if r.config.MinTTLPolicy != nil {
+		if err := r.config.MinTTLPolicy.apply(log.FromContext(ctx).WithValues(podLogKey, objectName(pod)), entry); err != nil {
+			return nil, err
+		}
+	}
+	if r.config.MaxSelectorsPolicy != nil {
+		if err := r.config.MaxSelectorsPolicy.apply(log.FromContext(ctx).WithValues(podLogKey, objectName(pod)), entry); err != nil {
+			return nil, err
+		}
+	}
+	r.clampX509SVIDTTL(log.FromContext(ctx).WithValues(podLogKey, objectName(pod)), entry)
+	r.restrictAdminEntry(entry)
+
+	// The alias entries only differ from the entry by SPIFFE ID.
+	entries := []spireapi.Entry{*entry}
+	for _, alias := range aliases {
+		aliasEntry := *entry
+		aliasEntry.SPIFFEID = alias
+		aliasEntry.Selectors = slices.Clone(entry.Selectors)
+		aliasEntry.DNSNames = slices.Clone(entry.DNSNames)
+		if err := r.prefixSPIFFEID(&aliasEntry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, aliasEntry)
+	}
+	return entries, nil
+}
+
+// prefixSPIFFEID prepends the configured path prefix, if any, to the SPIFFE
+// ID of the entry. Since the SPIFFE ID is part of the entry key, this must be
+// applied before the entry is added to the state.
+func (r *entryReconciler) prefixSPIFFEID(entry *spireapi.Entry) error {
+	if r.config.SPIFFEIDPathPrefix == "" {
+		return nil
+	}
+	spiffeID, err := spiffeid.FromPath(entry.SPIFFEID.TrustDomain(), r.config.SPIFFEIDPathPrefix+entry.SPIFFEID.Path())
+	if err != nil {
+		return fmt.Errorf("failed to prefix SPIFFE ID %q: %w", entry.SPIFFEID, err)
+	}
+	entry.SPIFFEID = spiffeID
+	return nil
+}
+
+// applyDefaultSVIDTTLs sets the configured default X509-SVID and JWT-SVID
+// TTLs, if any, on entries that do not set one.
+func (r *entryReconciler) applyDefaultSVIDTTLs(entry *spireapi.Entry) {
+	if entry.X509SVIDTTL == 0 {
+		entry.X509SVIDTTL = r.config.DefaultX509SVIDTTL
+	}
+	if entry.JWTSVIDTTL == 0 {
+		entry.JWTSVIDTTL = r.config.DefaultJWTSVIDTTL
+	}
+}
+
+// restrictAdminEntry appends the mandatory admin selector, if configured, to
+// admin entries. Since selectors are part of the entry key, this must be
+// applied before the entry is added to the state.
+func (r *entryReconciler) restrictAdminEntry(entry *spireapi.Entry) {
+	if r.config.AdminSelector == nil || !entry.Admin {
+		return
+	}
+	for _, selector := range entry.Selectors {
+		if selector == *r.config.AdminSelector {
+			return
+		}
 	}
-	return renderPodEntry(spec, node, pod, endpointsList, r.config.TrustDomain, r.config.ClusterName, r.config.ClusterDomain, r.config.ParentIDTemplate)
+	entry.Selectors = append(entry.Selectors, *r.config.AdminSelector)
 }
 
 func (r *entryReconciler) createEntries(ctx context.Context, declaredEntries []declaredEntry) {
 	log := log.FromContext(ctx)
-	statuses, err := r.config.EntryClient.CreateEntries(ctx, entriesFromDeclaredEntries(declaredEntries))
+	now := r.config.Clock.Now()
+	declaredEntries = r.dropBackedOffParentEntries(ctx, declaredEntries, now)
+	if len(declaredEntries) == 0 {
+		return
+	}
+	timer := prometheus.NewTimer(metrics.PromSPIREWriteDuration.WithLabelValues(metrics.OperationCreateEntries))
+	entries := entriesFromDeclaredEntries(declaredEntries)
+	statuses, err := r.config.EntryClient.CreateEntries(ctx, entries)
+	timer.ObserveDuration()
 	if err != nil {
 		for _, declaredEntry := range declaredEntries {
 			declaredEntry.By.IncrementEntryFailures()
@@ -465,20 +1763,57 @@ func (r *entryReconciler) createEntries(ctx context.Context, declaredEntries []d
 		return
 	}
 	for i, status := range statuses {
-		switch status.Code {
-		case codes.OK:
-			log.Info("Created entry", entryLogFields(declaredEntries[i].Entry)...)
+		switch {
+		case status.Code == codes.OK:
+			log.Info("Created entry", entryLogFields(entries[i])...)
+			r.promCounter[metrics.EntriesCreated].Inc()
+			declaredEntries[i].By.IncrementEntrySuccess()
+			declaredEntries[i].By.SetEntryID(entries[i].ID)
+			r.recordEntryRevision(entries[i])
+			r.clearParentBackoff(declaredEntries[i].Entry.ParentID)
+			r.clearEntryFailures(declaredEntries[i].Entry)
+		case status.Code == codes.AlreadyExists:
+			if !r.bootstrapping() {
+				declaredEntries[i].By.IncrementEntryFailures()
+				log.Error(status.Err(), "Failed to create entry", entryLogFields(declaredEntries[i].Entry)...)
+				continue
+			}
+			// Expected while bootstrapping since current entries were not
+			// listed beforehand. The existing entry may differ in fields
+			// other than the ones making it similar, so its revision is not
+			// recorded. If the server did not return its ID, it is set on
+			// the first pass that lists entries.
+			log.V(1).Info("Entry already exists", entryLogFields(declaredEntries[i].Entry)...)
 			declaredEntries[i].By.IncrementEntrySuccess()
+			if entries[i].ID != "" {
+				declaredEntries[i].By.SetEntryID(entries[i].ID)
+			}
+		case isParentEntryLimitStatus(status):
+			declaredEntries[i].By.IncrementEntryFailures()
+			delay, alreadyBackedOff := r.backOffParent(declaredEntries[i].Entry.ParentID, now)
+			if alreadyBackedOff {
+				continue
+			}
+			log.Error(status.Err(), "Failed to create entry; parent entry limit reached; backing off", append(entryLogFields(declaredEntries[i].Entry), "backoff", delay.String())...)
+			if obj := byClientObject(declaredEntries[i].By); obj != nil {
+				r.recordWarning(obj, parentEntryLimitReason,
+					"Parent %q has reached the SPIRE entry limit; entry %q will not be created for %s", declaredEntries[i].Entry.ParentID, declaredEntries[i].Entry.SPIFFEID, delay)
+			}
 		default:
 			declaredEntries[i].By.IncrementEntryFailures()
+			r.checkUnsupportedFieldStatus(status)
 			log.Error(status.Err(), "Failed to create entry", entryLogFields(declaredEntries[i].Entry)...)
+			r.recordEntryFailure(ctx, declaredEntries[i], now)
 		}
 	}
 }
 
 func (r *entryReconciler) updateEntries(ctx context.Context, declaredEntries []declaredEntry) {
 	log := log.FromContext(ctx)
+	now := r.config.Clock.Now()
+	timer := prometheus.NewTimer(metrics.PromSPIREWriteDuration.WithLabelValues(metrics.OperationUpdateEntries))
 	statuses, err := r.config.EntryClient.UpdateEntries(ctx, entriesFromDeclaredEntries(declaredEntries))
+	timer.ObserveDuration()
 	if err != nil {
 		for _, declaredEntry := range declaredEntries {
 			declaredEntry.By.IncrementEntryFailures()
@@ -490,16 +1825,32 @@ func (r *entryReconciler) updateEntries(ctx context.Context, declaredEntries []d
 		switch status.Code {
 		case codes.OK:
 			log.Info("Updated entry", entryLogFields(declaredEntries[i].Entry)...)
+			r.promCounter[metrics.EntriesUpdated].Inc()
+			r.recordEntryRevision(declaredEntries[i].Entry)
+			r.clearEntryFailures(declaredEntries[i].Entry)
 		default:
 			declaredEntries[i].By.IncrementEntryFailures()
+			r.checkUnsupportedFieldStatus(status)
 			log.Error(status.Err(), "Failed to update entry", entryLogFields(declaredEntries[i].Entry)...)
+			r.recordEntryFailure(ctx, declaredEntries[i], now)
 		}
 	}
 }
 
+// checkUnsupportedFieldStatus flags the unsupported fields for re-probing if
+// the status indicates the entry was rejected as invalid, which is how the
+// SPIRE server reports fields it does not understand.
+func (r *entryReconciler) checkUnsupportedFieldStatus(status spireapi.Status) {
+	if status.Code == codes.InvalidArgument {
+		r.reprobeUnsupportedFields = true
+	}
+}
+
 func (r *entryReconciler) deleteEntries(ctx context.Context, entries []spireapi.Entry) {
 	log := log.FromContext(ctx)
+	timer := prometheus.NewTimer(metrics.PromSPIREWriteDuration.WithLabelValues(metrics.OperationDeleteEntries))
 	statuses, err := r.config.EntryClient.DeleteEntries(ctx, idsFromEntries(entries))
+	timer.ObserveDuration()
 	if err != nil {
 		log.Error(err, "Failed to delete entries")
 		return
@@ -508,6 +1859,7 @@ func (r *entryReconciler) deleteEntries(ctx context.Context, entries []spireapi.
 		switch status.Code {
 		case codes.OK:
 			log.Info("Deleted entry", entryLogFields(entries[i])...)
+			r.promCounter[metrics.EntriesDeleted].Inc()
 		default:
 			log.Error(status.Err(), "Failed to delete entry", entryLogFields(entries[i])...)
 		}
@@ -521,11 +1873,12 @@ func (es entriesState) AddCurrent(entry spireapi.Entry) {
 	s.Current = append(s.Current, entry)
 }
 
-func (es entriesState) AddDeclared(entry spireapi.Entry, by byObject) {
+func (es entriesState) AddDeclared(entry spireapi.Entry, by byObject, pod *corev1.Pod) {
 	s := es.stateFor(entry)
 	s.Declared = append(s.Declared, declaredEntry{
 		Entry: entry,
 		By:    by,
+		Pod:   pod,
 	})
 }
 
@@ -547,10 +1900,30 @@ type entryState struct {
 type declaredEntry struct {
 	Entry spireapi.Entry
 	By    byObject
+
+	// Pod is the pod the entry was rendered for. It is nil for entries not
+	// rendered for a pod (e.g. static entries).
+	Pod *corev1.Pod
+}
+
+// podPriority returns the priority of the pod the entry was rendered for.
+// It is zero for entries not rendered for a pod and for pods whose priority
+// has not been resolved.
+func (e declaredEntry) podPriority() int32 {
+	if e.Pod == nil || e.Pod.Spec.Priority == nil {
+		return 0
+	}
+	return *e.Pod.Spec.Priority
 }
 
 type entryKey string
 
+// makeEntryKey returns the key used to tell declared and current entries
+// apart. It mirrors SPIRE's uniqueness rule: SPIRE rejects an entry with the
+// same SPIFFE ID, parent ID and selectors as an existing one with
+// AlreadyExists, whatever its hint. Entries differing only by hint therefore
+// share a key and mask each other, since only one of them could ever be
+// created.
 func makeEntryKey(entry spireapi.Entry) entryKey {
 	h := sha256.New()
 	_, _ = io.WriteString(h, entry.SPIFFEID.String())
@@ -627,15 +2000,17 @@ func objectCmp(a, b byObject) int {
 	}
 }
 
-func getOutdatedEntryFields(newEntry, oldEntry spireapi.Entry, unsupportedFields map[spireapi.Field]struct{}) []spireapi.Field {
+func getOutdatedEntryFields(newEntry, oldEntry spireapi.Entry, unsupportedFields map[spireapi.Field]struct{}, ttlTolerance time.Duration) []spireapi.Field {
 	// We don't need to bother with the parent ID, the SPIFFE ID, or the
 	// selectors since they are part of the uniqueness check that resulted in
-	// the AlreadyExists error code.
+	// the AlreadyExists error code. They are also part of the entry key, so
+	// changing a workload selector template results in a new entry rather
+	// than an update.
 	var outdated []spireapi.Field
-	if oldEntry.X509SVIDTTL != newEntry.X509SVIDTTL {
+	if !ttlsMatch(oldEntry.X509SVIDTTL, newEntry.X509SVIDTTL, ttlTolerance) {
 		outdated = append(outdated, spireapi.X509SVIDTTL)
 	}
-	if oldEntry.JWTSVIDTTL != newEntry.JWTSVIDTTL {
+	if !ttlsMatch(oldEntry.JWTSVIDTTL, newEntry.JWTSVIDTTL, ttlTolerance) {
 		if _, ok := unsupportedFields[spireapi.JWTSVIDTTLField]; !ok {
 			outdated = append(outdated, spireapi.JWTSVIDTTLField)
 		}
@@ -666,6 +2041,15 @@ func getOutdatedEntryFields(newEntry, oldEntry spireapi.Entry, unsupportedFields
 	return outdated
 }
 
+// ttlsMatch returns true if the TTLs differ by no more than the tolerance.
+func ttlsMatch(a, b, tolerance time.Duration) bool {
+	diff := a - b
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= tolerance
+}
+
 func trustDomainsMatch(as, bs []spiffeid.TrustDomain) bool {
 	if len(as) != len(bs) {
 		return false
@@ -704,6 +2088,47 @@ func stringsMatch(as, bs []string) bool {
 	return true
 }
 
+// declaredTrustDomains returns the trust domains of the declared entries,
+// starting with the given default trust domain.
+func declaredTrustDomains(state entriesState, defaultTD spiffeid.TrustDomain) []spiffeid.TrustDomain {
+	trustDomains := []spiffeid.TrustDomain{defaultTD}
+	seen := map[spiffeid.TrustDomain]struct{}{defaultTD: {}}
+	for _, s := range state {
+		for _, declared := range s.Declared {
+			td := declared.Entry.SPIFFEID.TrustDomain()
+			if _, ok := seen[td]; !ok {
+				seen[td] = struct{}{}
+				trustDomains = append(trustDomains, td)
+			}
+		}
+	}
+	sort.Slice(trustDomains[1:], func(i, j int) bool {
+		return trustDomains[i+1].Compare(trustDomains[j+1]) < 0
+	})
+	return trustDomains
+}
+
+// partitionDownstreamEntries splits the declared entries into downstream and
+// non-downstream entries, preserving their relative order.
+func partitionDownstreamEntries(declaredEntries []declaredEntry) (downstream, other []declaredEntry) {
+	for _, declaredEntry := range declaredEntries {
+		if declaredEntry.Entry.Downstream {
+			downstream = append(downstream, declaredEntry)
+		} else {
+			other = append(other, declaredEntry)
+		}
+	}
+	return downstream, other
+}
+
+// sortByPodPriority sorts the declared entries by descending pod priority,
+// preserving the relative order of entries of the same priority.
+func sortByPodPriority(declaredEntries []declaredEntry) {
+	sort.SliceStable(declaredEntries, func(i, j int) bool {
+		return declaredEntries[i].podPriority() > declaredEntries[j].podPriority()
+	})
+}
+
 func entriesFromDeclaredEntries(declaredEntries []declaredEntry) []spireapi.Entry {
 	entries := make([]spireapi.Entry, 0, len(declaredEntries))
 	for _, declaredEntry := range declaredEntries {
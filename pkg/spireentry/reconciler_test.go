@@ -1,11 +1,45 @@
 package spireentry
 
 import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/entrypolicy"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
 	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	testclock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 func TestMakeEntryKey(t *testing.T) {
@@ -123,3 +157,2792 @@ func TestFilterJoinTokenEntries(t *testing.T) {
 		})
 	}
 }
+
+func TestBootstrapPasses(t *testing.T) {
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:static"},
+		},
+	}
+	existing := spireapi.Entry{
+		ID:        "existing",
+		SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/static"),
+		ParentID:  spiffeid.RequireFromString("spiffe://example.org/parent"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:static"}},
+	}
+	stale := spireapi.Entry{
+		ID:        "stale",
+		SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/stale"),
+		ParentID:  spiffeid.RequireFromString("spiffe://example.org/parent"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:stale"}},
+	}
+
+	entryClient := newEntryClient(existing, stale)
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:     entryClient,
+		BootstrapPasses: 2,
+	}, staticEntry)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	for pass := 1; pass <= 2; pass++ {
+		r.reconcile(ctx)
+		require.Equal(t, 0, entryClient.listCalls, "pass %d should not list entries", pass)
+		require.Equal(t, []spireapi.Entry{existing, stale}, entryClient.getEntries(), "pass %d should not delete entries", pass)
+
+		actual := new(spirev1alpha1.ClusterStaticEntry)
+		require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(staticEntry), actual))
+		require.True(t, actual.Status.Set, "pass %d should treat the existing entry as set", pass)
+	}
+
+	// Steady state lists entries and removes the stale one.
+	r.reconcile(ctx)
+	require.Equal(t, 1, entryClient.listCalls)
+	require.Equal(t, []spireapi.Entry{existing}, entryClient.getEntries())
+}
+
+func TestBootstrapPassesCreatesMissingEntries(t *testing.T) {
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:static"},
+		},
+	}
+
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:     entryClient,
+		BootstrapPasses: 1,
+	}, staticEntry)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+	require.Equal(t, 0, entryClient.listCalls)
+	entries := entryClient.getEntries()
+	require.Len(t, entries, 1)
+	require.Equal(t, "spiffe://example.org/static", entries[0].SPIFFEID.String())
+
+	// The steady state pass finds the entry and has nothing left to do.
+	r.reconcile(ctx)
+	require.Equal(t, 1, entryClient.listCalls)
+	require.Equal(t, 1, entryClient.createCalls)
+	require.Equal(t, entries, entryClient.getEntries())
+}
+
+func TestSkipReconcileUntilCacheSynced(t *testing.T) {
+	stale := spireapi.Entry{
+		ID:        "stale",
+		SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/stale"),
+		ParentID:  spiffeid.RequireFromString("spiffe://example.org/parent"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:stale"}},
+	}
+
+	cache := &fakeCache{}
+	entryClient := newEntryClient(stale)
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+		Cache:       cache,
+	})
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	// The unsynced cache appears empty but the pass is skipped instead of
+	// deleting every entry.
+	r.reconcile(ctx)
+	require.Equal(t, 0, entryClient.listCalls)
+	require.Equal(t, []spireapi.Entry{stale}, entryClient.getEntries())
+
+	cache.synced = true
+	r.reconcile(ctx)
+	require.Equal(t, 1, entryClient.listCalls)
+	require.Empty(t, entryClient.getEntries())
+}
+
+func TestDuplicateEntries(t *testing.T) {
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:static"},
+		},
+	}
+	dup := func(id string) spireapi.Entry {
+		return spireapi.Entry{
+			ID:        id,
+			SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/static"),
+			ParentID:  spiffeid.RequireFromString("spiffe://example.org/parent"),
+			Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:static"}},
+		}
+	}
+
+	for _, tt := range []struct {
+		desc          string
+		preserve      bool
+		expectEntries []spireapi.Entry
+	}{
+		{
+			desc:          "cleans duplicates by default",
+			expectEntries: []spireapi.Entry{dup("1")},
+		},
+		{
+			desc:          "preserves duplicates",
+			preserve:      true,
+			expectEntries: []spireapi.Entry{dup("1"), dup("2"), dup("3")},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			// The fake client enforces uniqueness on create only, so seed
+			// the duplicates directly.
+			entryClient := newEntryClient(dup("1"), dup("2"), dup("3"))
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:        entryClient,
+				PreserveDuplicates: tt.preserve,
+			}, staticEntry)
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			r.reconcile(ctx)
+			require.Equal(t, tt.expectEntries, entryClient.getEntries())
+			require.Equal(t, float64(2), testutil.ToFloat64(r.promCounter[metrics.DuplicateEntries]))
+		})
+	}
+}
+
+func TestAdminSelector(t *testing.T) {
+	adminEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/admin",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:admin"},
+			Admin:     true,
+		},
+	}
+	regularEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/regular",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:regular"},
+		},
+	}
+	adminSelector := spireapi.Selector{Type: "k8s", Value: "node-name:control-plane"}
+
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:   entryClient,
+		AdminSelector: &adminSelector,
+	}, adminEntry, regularEntry)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+	selectorsBySPIFFEID := make(map[string][]spireapi.Selector)
+	for _, entry := range entryClient.getEntries() {
+		selectorsBySPIFFEID[entry.SPIFFEID.String()] = entry.Selectors
+	}
+	require.Equal(t, map[string][]spireapi.Selector{
+		"spiffe://example.org/admin":   {{Type: "k8s", Value: "ns:admin"}, adminSelector},
+		"spiffe://example.org/regular": {{Type: "k8s", Value: "ns:regular"}},
+	}, selectorsBySPIFFEID)
+
+	// The restricted admin entry matches on subsequent passes.
+	r.reconcile(ctx)
+	require.Equal(t, 1, entryClient.createCalls)
+	require.Len(t, entryClient.getEntries(), 2)
+}
+
+func TestEntryPolicy(t *testing.T) {
+	allowedEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "allowed"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/allowed",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:allowed"},
+		},
+	}
+	rejectedEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "rejected"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/rejected",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:rejected"},
+			Admin:     true,
+		},
+	}
+
+	// policyHandler rejects admin entries.
+	policyHandler := func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input struct {
+				Entries []struct {
+					Admin bool `json:"admin"`
+				} `json:"entries"`
+			} `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result := make([]entrypolicy.Decision, 0, len(req.Input.Entries))
+		for _, entry := range req.Input.Entries {
+			result = append(result, entrypolicy.Decision{Allowed: !entry.Admin, Reason: "no admins"})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}
+	unavailableHandler := func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}
+
+	for _, tt := range []struct {
+		desc             string
+		handler          http.HandlerFunc
+		failOpen         bool
+		expectSPIFFEIDs  []string
+		expectRejections float64
+		expectFailures   float64
+	}{
+		{
+			desc:             "drops rejected entries",
+			handler:          policyHandler,
+			expectSPIFFEIDs:  []string{"spiffe://example.org/allowed"},
+			expectRejections: 1,
+		},
+		{
+			desc:           "fails closed",
+			handler:        unavailableHandler,
+			expectFailures: 1,
+		},
+		{
+			desc:            "fails open",
+			handler:         unavailableHandler,
+			failOpen:        true,
+			expectSPIFFEIDs: []string{"spiffe://example.org/allowed", "spiffe://example.org/rejected"},
+			expectFailures:  1,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			t.Cleanup(server.Close)
+
+			entryClient := newEntryClient()
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:         entryClient,
+				EntryPolicy:         entrypolicy.NewClient(entrypolicy.Config{URL: server.URL}),
+				EntryPolicyFailOpen: tt.failOpen,
+			}, allowedEntry, rejectedEntry)
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			r.reconcile(ctx)
+			var spiffeIDs []string
+			for _, entry := range entryClient.getEntries() {
+				spiffeIDs = append(spiffeIDs, entry.SPIFFEID.String())
+			}
+			sort.Strings(spiffeIDs)
+			require.Equal(t, tt.expectSPIFFEIDs, spiffeIDs)
+			require.Equal(t, tt.expectRejections, testutil.ToFloat64(r.promCounter[metrics.EntryPolicyRejections]))
+			require.Equal(t, tt.expectFailures, testutil.ToFloat64(r.promCounter[metrics.EntryPolicyFailures]))
+		})
+	}
+}
+
+func TestReprobeUnsupportedFieldsOnInvalidArgument(t *testing.T) {
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:static"},
+			Hint:      "new",
+		},
+	}
+	existing := spireapi.Entry{
+		ID:        "existing",
+		SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/static"),
+		ParentID:  spiffeid.RequireFromString("spiffe://example.org/parent"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:static"}},
+		Hint:      "old",
+	}
+
+	entryClient := newEntryClient(existing)
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, staticEntry)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+	require.Equal(t, 1, entryClient.getUnsupportedFieldsCalls)
+	require.Equal(t, 1, entryClient.updateCalls)
+
+	// Simulate a server downgrade where the hint is no longer supported.
+	entryClient.entries["existing"] = existing
+	entryClient.updateStatus["existing"] = spireapi.Status{Code: codes.InvalidArgument, Message: "unknown field"}
+	entryClient.unsupportedFields[spireapi.HintField] = struct{}{}
+
+	// The failed update triggers an immediate re-probe.
+	r.reconcile(ctx)
+	require.Equal(t, 2, entryClient.updateCalls)
+	require.Equal(t, 2, entryClient.getUnsupportedFieldsCalls)
+	require.Contains(t, r.unsupportedFields[r.config.TrustDomain], spireapi.HintField)
+
+	// The hint is now ignored, so the entry is no longer updated and no
+	// further probes happen before the interval elapses.
+	r.reconcile(ctx)
+	require.Equal(t, 2, entryClient.updateCalls)
+	require.Equal(t, 2, entryClient.getUnsupportedFieldsCalls)
+}
+
+func TestUnsupportedFieldsPerTrustDomain(t *testing.T) {
+	newStaticEntry := func(td string) *spirev1alpha1.ClusterStaticEntry {
+		return &spirev1alpha1.ClusterStaticEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: td},
+			Spec: spirev1alpha1.ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://" + td + "/workload",
+				ParentID:  "spiffe://" + td + "/parent",
+				Selectors: []string{"k8s:ns:workload"},
+				Hint:      "new",
+			},
+		}
+	}
+	newEntry := func(td string) spireapi.Entry {
+		return spireapi.Entry{
+			ID:        td,
+			SPIFFEID:  spiffeid.RequireFromString("spiffe://" + td + "/workload"),
+			ParentID:  spiffeid.RequireFromString("spiffe://" + td + "/parent"),
+			Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:workload"}},
+			Hint:      "old",
+		}
+	}
+
+	// other.org does not support hints while example.org does.
+	entryClient := newEntryClient(newEntry("example.org"), newEntry("other.org"))
+	entryClient.unsupportedFieldsByTD = map[string]map[spireapi.Field]struct{}{
+		"other.org": {spireapi.HintField: {}},
+	}
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, newStaticEntry("example.org"), newStaticEntry("other.org"))
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+	require.Equal(t, []string{"example.org", "other.org"}, entryClient.probedTrustDomains)
+	require.Empty(t, r.unsupportedFields[spiffeid.RequireTrustDomainFromString("example.org")])
+	require.Contains(t, r.unsupportedFields[spiffeid.RequireTrustDomainFromString("other.org")], spireapi.HintField)
+
+	// Only the entry in the trust domain supporting hints is updated.
+	hints := make(map[string]string)
+	for _, entry := range entryClient.getEntries() {
+		hints[entry.ID] = entry.Hint
+	}
+	require.Equal(t, map[string]string{"example.org": "new", "other.org": "old"}, hints)
+	require.Equal(t, 1, entryClient.updateCalls)
+}
+
+func TestUnsupportedFieldsProbe(t *testing.T) {
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:static"},
+		},
+	}
+	transientErr := status.Error(codes.Unavailable, "oh no")
+
+	for _, tt := range []struct {
+		desc         string
+		config       ReconcilerConfig
+		probeErrs    []error
+		passes       int
+		expectProbes int
+		expectFields map[spireapi.Field]struct{}
+	}{
+		{
+			desc:         "skipped",
+			config:       ReconcilerConfig{SkipUnsupportedFieldsProbe: true},
+			passes:       2,
+			expectProbes: 0,
+		},
+		{
+			desc:         "cached between passes",
+			passes:       3,
+			expectProbes: 1,
+			expectFields: map[spireapi.Field]struct{}{spireapi.HintField: {}},
+		},
+		{
+			desc:         "probed every pass once expired",
+			config:       ReconcilerConfig{UnsupportedFieldsProbeInterval: time.Nanosecond},
+			passes:       3,
+			expectProbes: 3,
+			expectFields: map[spireapi.Field]struct{}{spireapi.HintField: {}},
+		},
+		{
+			desc:         "retried on transient failure",
+			config:       ReconcilerConfig{UnsupportedFieldsProbeRetries: 2},
+			probeErrs:    []error{transientErr, transientErr},
+			passes:       1,
+			expectProbes: 3,
+			expectFields: map[spireapi.Field]struct{}{spireapi.HintField: {}},
+		},
+		{
+			desc:         "gives up after retries",
+			config:       ReconcilerConfig{UnsupportedFieldsProbeRetries: 1},
+			probeErrs:    []error{transientErr, transientErr},
+			passes:       1,
+			expectProbes: 2,
+		},
+		{
+			desc:         "not retried on permanent failure",
+			config:       ReconcilerConfig{UnsupportedFieldsProbeRetries: 2},
+			probeErrs:    []error{errors.New("oh no")},
+			passes:       1,
+			expectProbes: 1,
+		},
+		{
+			desc: "overrides take precedence over the probe",
+			config: ReconcilerConfig{FieldSupportOverrides: map[spireapi.Field]bool{
+				spireapi.HintField:       true,
+				spireapi.JWTSVIDTTLField: false,
+			}},
+			passes:       1,
+			expectProbes: 1,
+			expectFields: map[spireapi.Field]struct{}{spireapi.JWTSVIDTTLField: {}},
+		},
+		{
+			desc: "overrides apply when skipped",
+			config: ReconcilerConfig{
+				SkipUnsupportedFieldsProbe: true,
+				FieldSupportOverrides:      map[spireapi.Field]bool{spireapi.StoreSVIDField: false},
+			},
+			passes:       1,
+			expectProbes: 0,
+			expectFields: map[spireapi.Field]struct{}{spireapi.StoreSVIDField: {}},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			entryClient := newEntryClient()
+			entryClient.unsupportedFields[spireapi.HintField] = struct{}{}
+			entryClient.getUnsupportedFieldsErrs = tt.probeErrs
+			tt.config.EntryClient = entryClient
+			r := newTestEntryReconciler(t, tt.config, staticEntry)
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			for i := 0; i < tt.passes; i++ {
+				r.reconcile(ctx)
+			}
+			require.Equal(t, tt.expectProbes, entryClient.getUnsupportedFieldsCalls)
+			require.Equal(t, map[spireapi.Field]struct{}{spireapi.HintField: {}}, entryClient.unsupportedFields, "probe result must not be modified")
+			if tt.expectFields == nil {
+				require.Empty(t, r.unsupportedFieldsFor(r.config.TrustDomain))
+			} else {
+				require.Equal(t, tt.expectFields, r.unsupportedFieldsFor(r.config.TrustDomain))
+			}
+		})
+	}
+}
+
+func TestUnsupportedFieldsProbeRetryBackoff(t *testing.T) {
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:static"},
+		},
+	}
+	transientErr := status.Error(codes.Unavailable, "unavailable")
+	clk := testclock.NewFakeClock(time.Now())
+	entryClient := newEntryClient()
+	entryClient.unsupportedFields[spireapi.HintField] = struct{}{}
+	entryClient.getUnsupportedFieldsErrs = []error{transientErr, transientErr}
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:                   entryClient,
+		Clock:                         clk,
+		UnsupportedFieldsProbeRetries: 2,
+	}, staticEntry)
+	r.probeRetryBackoff = time.Second
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.reconcile(ctx)
+	}()
+
+	t.Log("The first retry waits for the backoff on the configured clock")
+	require.Eventually(t, clk.HasWaiters, time.Second, time.Millisecond)
+	clk.Step(time.Second)
+
+	t.Log("The second retry waits for twice the backoff")
+	require.Eventually(t, clk.HasWaiters, time.Second, time.Millisecond)
+	clk.Step(time.Second)
+	require.True(t, clk.HasWaiters())
+	clk.Step(time.Second)
+
+	<-done
+	require.Equal(t, 3, entryClient.getUnsupportedFieldsCalls)
+	require.Equal(t, map[spireapi.Field]struct{}{spireapi.HintField: {}}, r.unsupportedFieldsFor(r.config.TrustDomain))
+}
+
+func TestUnsupportedFieldsProbeInterval(t *testing.T) {
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:static"},
+		},
+	}
+	clk := testclock.NewFakeClock(time.Now())
+	entryClient := newEntryClient()
+	entryClient.unsupportedFields[spireapi.HintField] = struct{}{}
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:                    entryClient,
+		Clock:                          clk,
+		UnsupportedFieldsProbeInterval: time.Minute,
+	}, staticEntry)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+	require.Equal(t, 1, entryClient.getUnsupportedFieldsCalls)
+
+	// The probe result is cached until the interval has passed.
+	clk.Step(59 * time.Second)
+	r.reconcile(ctx)
+	require.Equal(t, 1, entryClient.getUnsupportedFieldsCalls)
+
+	// Once it has, the next pass probes again and notices the field became
+	// supported.
+	delete(entryClient.unsupportedFields, spireapi.HintField)
+	clk.Step(2 * time.Second)
+	r.reconcile(ctx)
+	require.Equal(t, 2, entryClient.getUnsupportedFieldsCalls)
+	require.Empty(t, r.unsupportedFieldsFor(r.config.TrustDomain))
+
+	clk.Step(time.Minute + time.Second)
+	r.reconcile(ctx)
+	require.Equal(t, 3, entryClient.getUnsupportedFieldsCalls)
+}
+
+func TestClassScopedEntryIDs(t *testing.T) {
+	newStaticEntry := func(className string) *spirev1alpha1.ClusterStaticEntry {
+		return &spirev1alpha1.ClusterStaticEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: className},
+			Spec: spirev1alpha1.ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://example.org/" + className,
+				ParentID:  "spiffe://example.org/parent",
+				Selectors: []string{"k8s:ns:" + className},
+				ClassName: className,
+			},
+		}
+	}
+	staticEntryA := newStaticEntry("a")
+	staticEntryB := newStaticEntry("b")
+
+	// Both classes share the same SPIRE server and Kubernetes cluster.
+	entryClient := newEntryClient()
+	newReconciler := func(className string) *entryReconciler {
+		return newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient:         entryClient,
+			ClassName:           className,
+			EntryIDPrefix:       "prefix.",
+			ClassScopedEntryIDs: true,
+		}, staticEntryA, staticEntryB)
+	}
+	reconcilerA := newReconciler("a")
+	reconcilerB := newReconciler("b")
+	require.Equal(t, "prefix.a.", reconcilerA.config.EntryIDPrefix)
+	require.Equal(t, "prefix.b.", reconcilerB.config.EntryIDPrefix)
+
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	for i := 0; i < 2; i++ {
+		reconcilerA.reconcile(ctx)
+		reconcilerB.reconcile(ctx)
+	}
+
+	entries := entryClient.getEntries()
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		className := strings.TrimPrefix(entry.SPIFFEID.Path(), "/")
+		require.True(t, strings.HasPrefix(entry.ID, "prefix."+className+"."), "entry %q not scoped to class %q", entry.ID, className)
+	}
+	require.Equal(t, 2, entryClient.createCalls)
+}
+
+func TestGlobalPodExclusionSelector(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	objects := []client.Object{
+		clusterSPIFFEID,
+		newTestNamespace("default"),
+		newTestNode("node"),
+		newTestPod("default", "app", "node", nil),
+		newTestPod("default", "infra", "node", map[string]string{"infra": "true"}),
+	}
+
+	exclusion, err := labels.Parse("infra=true")
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		desc            string
+		exclusion       labels.Selector
+		expectSPIFFEIDs []string
+		expectExcluded  int
+	}{
+		{
+			desc:            "no exclusion",
+			expectSPIFFEIDs: []string{"spiffe://example.org/ns/default/pod/app", "spiffe://example.org/ns/default/pod/infra"},
+		},
+		{
+			desc:            "excludes matching pods",
+			exclusion:       exclusion,
+			expectSPIFFEIDs: []string{"spiffe://example.org/ns/default/pod/app"},
+			expectExcluded:  1,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			entryClient := newEntryClient()
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:                entryClient,
+				GlobalPodExclusionSelector: tt.exclusion,
+			}, objects...)
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			r.reconcile(ctx)
+			require.Equal(t, tt.expectSPIFFEIDs, entrySPIFFEIDs(entryClient.getEntries()))
+
+			actual := new(spirev1alpha1.ClusterSPIFFEID)
+			require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+			require.Equal(t, 2, actual.Status.Stats.PodsSelected)
+			require.Equal(t, tt.expectExcluded, actual.Status.Stats.PodsExcluded)
+		})
+	}
+}
+
+func TestNamespaceConcurrency(t *testing.T) {
+	newClusterSPIFFEID := func(name string, fallback bool) *spirev1alpha1.ClusterSPIFFEID {
+		return &spirev1alpha1.ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/" + name + "/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+				Fallback:         fallback,
+			},
+		}
+	}
+	primary := newClusterSPIFFEID("primary", false)
+	primary.Spec.PodSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "primary"}}
+	fallback := newClusterSPIFFEID("fallback", true)
+	broken := newClusterSPIFFEID("broken", false)
+	broken.Spec.SPIFFEIDTemplate = "spiffe://{{ .TrustDomain }}/{{ .PodMeta.Namespace | printf \"%s/../x\" }}"
+
+	objects := []client.Object{primary, fallback, broken, newTestNode("node"), newTestNamespace("kube-system")}
+	for i := range 20 {
+		ns := fmt.Sprintf("ns-%02d", i)
+		objects = append(objects,
+			newTestNamespace(ns),
+			newTestPod(ns, "primary", "node", map[string]string{"app": "primary"}),
+			newTestPod(ns, "other", "node", nil),
+			newTestPod(ns, "infra", "node", map[string]string{"infra": "true"}),
+		)
+	}
+	exclusion, err := labels.Parse("infra=true")
+	require.NoError(t, err)
+
+	reconcile := func(concurrency int) ([]string, map[string]spirev1alpha1.ClusterSPIFFEIDStats) {
+		entryClient := newEntryClient()
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient:                entryClient,
+			IgnoreNamespaces:           []*regexp.Regexp{regexp.MustCompile("^kube-system$")},
+			GlobalPodExclusionSelector: exclusion,
+			NamespaceConcurrency:       concurrency,
+		}, objects...)
+		ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+		r.reconcile(ctx)
+
+		stats := make(map[string]spirev1alpha1.ClusterSPIFFEIDStats)
+		for _, clusterSPIFFEID := range []*spirev1alpha1.ClusterSPIFFEID{primary, fallback, broken} {
+			actual := new(spirev1alpha1.ClusterSPIFFEID)
+			require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+			stats[clusterSPIFFEID.Name] = actual.Status.Stats
+		}
+		return entrySPIFFEIDs(entryClient.getEntries()), stats
+	}
+
+	expectSPIFFEIDs, expectStats := reconcile(1)
+	require.Len(t, expectSPIFFEIDs, 40)
+	require.Equal(t, spirev1alpha1.ClusterSPIFFEIDStats{NamespacesSelected: 21, NamespacesIgnored: 1, PodsSelected: 20, EntriesToSet: 20}, expectStats["primary"])
+	require.Equal(t, spirev1alpha1.ClusterSPIFFEIDStats{NamespacesSelected: 21, NamespacesIgnored: 1, PodsSelected: 60, PodsExcluded: 20, EntriesToSet: 20}, expectStats["fallback"])
+	require.Equal(t, spirev1alpha1.ClusterSPIFFEIDStats{NamespacesSelected: 21, NamespacesIgnored: 1, PodsSelected: 60, PodsExcluded: 20, PodEntryRenderFailures: 40}, expectStats["broken"])
+
+	for _, concurrency := range []int{2, 8, 64} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			actualSPIFFEIDs, actualStats := reconcile(concurrency)
+			require.Equal(t, expectSPIFFEIDs, actualSPIFFEIDs)
+			require.Equal(t, expectStats, actualStats)
+		})
+	}
+}
+
+func BenchmarkNamespaceConcurrency(b *testing.B) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	objects := []client.Object{clusterSPIFFEID, newTestNode("node")}
+	for i := range 100 {
+		ns := fmt.Sprintf("ns-%03d", i)
+		objects = append(objects, newTestNamespace(ns))
+		for j := range 10 {
+			objects = append(objects, newTestPod(ns, fmt.Sprintf("pod-%d", j), "node", nil))
+		}
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(b, clientgoscheme.AddToScheme(scheme))
+	require.NoError(b, spirev1alpha1.AddToScheme(scheme))
+	// Each list takes a millisecond, as a round trip to the API server
+	// would.
+	k8sClient := interceptor.NewClient(fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(), interceptor.Funcs{
+		List: func(ctx context.Context, client client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			time.Sleep(time.Millisecond)
+			return client.List(ctx, list, opts...)
+		},
+	})
+
+	for _, concurrency := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("concurrency %d", concurrency), func(b *testing.B) {
+			r := newTestEntryReconciler(b, ReconcilerConfig{
+				K8sClient:            k8sClient,
+				NamespaceConcurrency: concurrency,
+			})
+			ctx := context.Background()
+			clusterSPIFFEIDs, err := r.listClusterSPIFFEIDs(ctx)
+			require.NoError(b, err)
+
+			b.ResetTimer()
+			for range b.N {
+				r.addClusterSPIFFEIDEntriesState(ctx, make(entriesState), clusterSPIFFEIDs)
+			}
+		})
+	}
+}
+
+func TestPrimaryContainer(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate:          "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			WorkloadSelectorTemplates: []string{"k8s:container-image:{{ .PrimaryContainer.Image }}"},
+			PrimaryContainerName:      "app",
+		},
+	}
+	withApp := newTestPod("default", "with-app", "node", nil)
+	withApp.Spec.Containers = []corev1.Container{
+		{Name: "proxy", Image: "registry.example.org/proxy:v1"},
+		{Name: "app", Image: "registry.example.org/app:v2"},
+	}
+	withoutApp := newTestPod("default", "without-app", "node", nil)
+	withoutApp.Spec.Containers = []corev1.Container{
+		{Name: "proxy", Image: "registry.example.org/proxy:v1"},
+		{Name: "worker", Image: "registry.example.org/worker:v3"},
+	}
+
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"), withApp, withoutApp)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+	entries := entryClient.getEntries()
+	require.Equal(t, []string{"spiffe://example.org/ns/default/pod/with-app"}, entrySPIFFEIDs(entries))
+	require.Contains(t, entries[0].Selectors, spireapi.Selector{Type: "k8s", Value: "container-image:registry.example.org/app:v2"})
+
+	// The pod without the primary container is skipped, not failed.
+	actual := new(spirev1alpha1.ClusterSPIFFEID)
+	require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+	require.Equal(t, 2, actual.Status.Stats.PodsSelected)
+	require.Zero(t, actual.Status.Stats.PodEntryRenderFailures)
+}
+
+func TestAliasSPIFFEIDs(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate:       "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
+			AliasSPIFFEIDTemplates: []string{"spiffe://{{ .TrustDomain }}/legacy/{{ .PodMeta.Name }}"},
+		},
+	}
+	pod := newTestPod("default", "workload", "node", nil)
+	pod.Spec.ServiceAccountName = "app"
+
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"), pod)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+	entries := entryClient.getEntries()
+	require.Equal(t, []string{
+		"spiffe://example.org/legacy/workload",
+		"spiffe://example.org/ns/default/sa/app",
+	}, entrySPIFFEIDs(entries))
+	require.Equal(t, entries[0].ParentID, entries[1].ParentID)
+	require.Equal(t, entries[0].Selectors, entries[1].Selectors)
+
+	actual := new(spirev1alpha1.ClusterSPIFFEID)
+	require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+	require.Equal(t, 2, actual.Status.Stats.EntriesToSet)
+	require.Zero(t, actual.Status.Stats.EntriesMasked)
+
+	// Once the migration is over, the alias entry is deleted.
+	actual.Spec.AliasSPIFFEIDTemplates = nil
+	require.NoError(t, r.config.K8sClient.Update(ctx, actual))
+	r.reconcile(ctx)
+	require.Equal(t, []string{"spiffe://example.org/ns/default/sa/app"}, entrySPIFFEIDs(entryClient.getEntries()))
+	require.Equal(t, 1, entryClient.createCalls)
+	require.Zero(t, entryClient.updateCalls)
+}
+
+func TestAutoPopulatePodIP(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate:  "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			AutoPopulatePodIP: true,
+		},
+	}
+	assigned := newTestPod("default", "assigned", "node", nil)
+	assigned.Status.PodIP = "10.0.0.1"
+	assigned.Status.PodIPs = []corev1.PodIP{{IP: "10.0.0.1"}, {IP: "fd00::1"}}
+	unassigned := newTestPod("default", "unassigned", "node", nil)
+
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"), assigned, unassigned)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+	entries := entryClient.getEntries()
+	require.Equal(t, []string{"spiffe://example.org/ns/default/pod/assigned"}, entrySPIFFEIDs(entries))
+	require.Equal(t, []string{"10.0.0.1", "fd00::1"}, entries[0].DNSNames)
+
+	actual := new(spirev1alpha1.ClusterSPIFFEID)
+	require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+	require.Equal(t, 2, actual.Status.Stats.PodsSelected)
+	require.Equal(t, 1, actual.Status.Stats.PodsAwaitingIP)
+
+	// Once the IP is assigned (or changes), the entry is created (or updated).
+	unassigned.Status.PodIP = "10.0.0.2"
+	assigned.Status.PodIP = "10.0.0.3"
+	assigned.Status.PodIPs = []corev1.PodIP{{IP: "10.0.0.3"}}
+	r = newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"), assigned, unassigned)
+
+	r.reconcile(ctx)
+	entries = entryClient.getEntries()
+	require.Equal(t, []string{"spiffe://example.org/ns/default/pod/assigned", "spiffe://example.org/ns/default/pod/unassigned"}, entrySPIFFEIDs(entries))
+	dnsNames := map[string][]string{}
+	for _, entry := range entries {
+		dnsNames[entry.SPIFFEID.String()] = entry.DNSNames
+	}
+	require.Equal(t, []string{"10.0.0.3"}, dnsNames["spiffe://example.org/ns/default/pod/assigned"])
+	require.Equal(t, []string{"10.0.0.2"}, dnsNames["spiffe://example.org/ns/default/pod/unassigned"])
+}
+
+func TestFieldSelectors(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate:       "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			NamespaceFieldSelector: "status.phase!=Terminating",
+			PodFieldSelector:       "status.phase=Running",
+		},
+	}
+	running := newTestPod("default", "running", "node", nil)
+	running.Status.Phase = corev1.PodRunning
+	pending := newTestPod("default", "pending", "node", nil)
+	pending.Status.Phase = corev1.PodPending
+	terminatingNamespace := newTestNamespace("terminating")
+	terminatingNamespace.Status.Phase = corev1.NamespaceTerminating
+	inTerminatingNamespace := newTestPod("terminating", "running", "node", nil)
+	inTerminatingNamespace.Status.Phase = corev1.PodRunning
+
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, clusterSPIFFEID, newTestNamespace("default"), terminatingNamespace, newTestNode("node"), running, pending, inTerminatingNamespace)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+	require.Equal(t, []string{"spiffe://example.org/ns/default/pod/running"}, entrySPIFFEIDs(entryClient.getEntries()))
+
+	actual := new(spirev1alpha1.ClusterSPIFFEID)
+	require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+	require.Equal(t, 1, actual.Status.Stats.NamespacesSelected)
+	require.Equal(t, 1, actual.Status.Stats.PodsSelected)
+}
+
+func TestPlaceholderEntry(t *testing.T) {
+	placeholderParentID := "spiffe://example.org/placeholder"
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/cluster/{{ .ClusterName }}/workload",
+			PodSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "workload"},
+			},
+			Placeholder: &spirev1alpha1.ClusterSPIFFEIDPlaceholder{
+				ParentID:  placeholderParentID,
+				Selectors: []string{"k8s:ns:default", "k8s:sa:workload"},
+			},
+		},
+	}
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	t.Log("The placeholder entry is created while no pods are selected")
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"))
+	r.reconcile(ctx)
+	entries := entryClient.getEntries()
+	require.Len(t, entries, 1)
+	require.Equal(t, "spiffe://example.org/cluster/test/workload", entries[0].SPIFFEID.String())
+	require.Equal(t, placeholderParentID, entries[0].ParentID.String())
+	require.Equal(t, []spireapi.Selector{{Type: "k8s", Value: "ns:default"}, {Type: "k8s", Value: "sa:workload"}}, entries[0].Selectors)
+
+	actual := new(spirev1alpha1.ClusterSPIFFEID)
+	require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+	require.Equal(t, 0, actual.Status.Stats.PodsSelected)
+	require.Equal(t, 1, actual.Status.Stats.EntriesToSet)
+
+	t.Log("The placeholder entry is replaced once a pod is selected")
+	r = newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"),
+		newTestPod("default", "workload", "node", map[string]string{"app": "workload"}))
+	r.reconcile(ctx)
+	entries = entryClient.getEntries()
+	require.Len(t, entries, 1)
+	require.Equal(t, "spiffe://example.org/cluster/test/workload", entries[0].SPIFFEID.String())
+	require.Equal(t, "spiffe://example.org/spire/agent/k8s_psat/test/node-uid", entries[0].ParentID.String())
+	require.Equal(t, []spireapi.Selector{{Type: "k8s", Value: "pod-uid:workload-uid"}}, entries[0].Selectors)
+
+	t.Log("No placeholder entry is created for templates that need pod data")
+	clusterSPIFFEID.Spec.SPIFFEIDTemplate = "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}"
+	entryClient = newEntryClient()
+	r = newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"))
+	r.reconcile(ctx)
+	require.Empty(t, entryClient.getEntries())
+}
+
+func TestWorkloadSelectorTemplateChanges(t *testing.T) {
+	newClusterSPIFFEID := func(workloadSelectorTemplates ...string) *spirev1alpha1.ClusterSPIFFEID {
+		return &spirev1alpha1.ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+			Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate:          "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+				WorkloadSelectorTemplates: workloadSelectorTemplates,
+			},
+		}
+	}
+	entryClient := newEntryClient()
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	reconcileWith := func(clusterSPIFFEID *spirev1alpha1.ClusterSPIFFEID) spirev1alpha1.ClusterSPIFFEIDStatus {
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient: entryClient,
+		}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"), newTestPod("default", "app", "node", nil))
+		r.reconcile(ctx)
+
+		actual := new(spirev1alpha1.ClusterSPIFFEID)
+		require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+		return actual.Status
+	}
+
+	entrySelectors := func() []spireapi.Selector {
+		entries := entryClient.getEntries()
+		require.Len(t, entries, 1)
+		return entries[0].Selectors
+	}
+
+	reconcileWith(newClusterSPIFFEID("k8s:ns:{{ .PodMeta.Namespace }}", "k8s:pod-name:{{ .PodMeta.Name }}"))
+	require.Equal(t, []spireapi.Selector{
+		{Type: "k8s", Value: "pod-uid:app-uid"},
+		{Type: "k8s", Value: "ns:default"},
+		{Type: "k8s", Value: "pod-name:app"},
+	}, entrySelectors())
+	originalID := entryClient.getEntries()[0].ID
+
+	// Reordering the templates yields the same entry.
+	status := reconcileWith(newClusterSPIFFEID("k8s:pod-name:{{ .PodMeta.Name }}", "k8s:ns:{{ .PodMeta.Namespace }}"))
+	require.Equal(t, originalID, entryClient.getEntries()[0].ID)
+	require.Zero(t, entryClient.updateCalls)
+	require.Zero(t, status.Stats.EntriesMasked)
+
+	// Adding a template yields a new entry that replaces the old one.
+	status = reconcileWith(newClusterSPIFFEID("k8s:pod-name:{{ .PodMeta.Name }}", "k8s:ns:{{ .PodMeta.Namespace }}", "k8s:node-name:{{ .PodSpec.NodeName }}"))
+	require.NotEqual(t, originalID, entryClient.getEntries()[0].ID)
+	require.Equal(t, []spireapi.Selector{
+		{Type: "k8s", Value: "pod-uid:app-uid"},
+		{Type: "k8s", Value: "pod-name:app"},
+		{Type: "k8s", Value: "ns:default"},
+		{Type: "k8s", Value: "node-name:node"},
+	}, entrySelectors())
+	require.Zero(t, entryClient.updateCalls)
+	require.Zero(t, status.Stats.EntriesMasked)
+}
+
+func TestEmptySelectors(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate:          "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			WorkloadSelectorTemplates: []string{`k8s:{{ index .PodMeta.Labels "selector" }}`},
+		},
+	}
+	clusterStaticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:"},
+		},
+	}
+
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, clusterSPIFFEID, clusterStaticEntry, newTestNamespace("default"), newTestNode("node"),
+		newTestPod("default", "labeled", "node", map[string]string{"selector": "sa:workload"}),
+		newTestPod("default", "unlabeled", "node", nil))
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+
+	// Only the entry with well formed selectors is sent to SPIRE.
+	require.Equal(t, []string{"spiffe://example.org/ns/default/pod/labeled"}, entrySPIFFEIDs(entryClient.getEntries()))
+	require.Equal(t, 1, entryClient.createCalls)
+
+	actual := new(spirev1alpha1.ClusterSPIFFEID)
+	require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+	require.Equal(t, 1, actual.Status.Stats.PodEntryRenderFailures)
+	require.Equal(t, 1, actual.Status.Stats.EntriesToSet)
+
+	actualStatic := new(spirev1alpha1.ClusterStaticEntry)
+	require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterStaticEntry), actualStatic))
+	require.False(t, actualStatic.Status.Rendered)
+	require.Equal(t, float64(1), testutil.ToFloat64(r.promCounter[metrics.StaticEntryFailures]))
+}
+
+func TestStaticEntryStatusEntryID(t *testing.T) {
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:static"},
+		},
+	}
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	t.Run("created", func(t *testing.T) {
+		entryClient := newEntryClient()
+		r := newTestEntryReconciler(t, ReconcilerConfig{EntryClient: entryClient})
+		by := &ClusterStaticEntry{ClusterStaticEntry: *staticEntry}
+		entry, err := renderStaticEntry(&staticEntry.Spec)
+		require.NoError(t, err)
+
+		r.createEntries(ctx, []declaredEntry{{Entry: *entry, By: by}})
+		require.Len(t, entryClient.getEntries(), 1)
+		require.True(t, by.NextStatus.Set)
+		require.Equal(t, entryClient.getEntries()[0].ID, by.NextStatus.EntryID)
+	})
+
+	t.Run("matched", func(t *testing.T) {
+		existing := spireapi.Entry{
+			ID:        "existing",
+			SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/static"),
+			ParentID:  spiffeid.RequireFromString("spiffe://example.org/parent"),
+			Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:static"}},
+		}
+		entryClient := newEntryClient(existing)
+		r := newTestEntryReconciler(t, ReconcilerConfig{EntryClient: entryClient}, staticEntry.DeepCopy())
+
+		r.reconcile(ctx)
+		require.Zero(t, entryClient.createCalls)
+
+		actual := new(spirev1alpha1.ClusterStaticEntry)
+		require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(staticEntry), actual))
+		require.Equal(t, "existing", actual.Status.EntryID)
+	})
+
+	t.Run("bootstrapped", func(t *testing.T) {
+		existing := spireapi.Entry{
+			ID:        "existing",
+			SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/static"),
+			ParentID:  spiffeid.RequireFromString("spiffe://example.org/parent"),
+			Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:static"}},
+		}
+		entryClient := newEntryClient(existing)
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient:     entryClient,
+			BootstrapPasses: 1,
+		}, staticEntry.DeepCopy())
+
+		// The bootstrap pass creates the entry without listing, so SPIRE
+		// rejects it as already existing.
+		r.reconcile(ctx)
+		require.Zero(t, entryClient.listCalls)
+		require.Equal(t, 1, entryClient.createCalls)
+		require.Equal(t, []spireapi.Entry{existing}, entryClient.getEntries())
+
+		actual := new(spirev1alpha1.ClusterStaticEntry)
+		require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(staticEntry), actual))
+		require.Equal(t, "existing", actual.Status.EntryID)
+	})
+}
+
+func TestValidateEntriesBeforeSend(t *testing.T) {
+	valid := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "valid"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/valid",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:valid"},
+		},
+	}
+	// Renders fine but is rejected by the SPIRE server.
+	invalid := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "invalid"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID: "spiffe://example.org/invalid",
+			ParentID: "spiffe://example.org/parent",
+		},
+	}
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	for _, tt := range []struct {
+		desc          string
+		validate      bool
+		expectCreated []string
+	}{
+		{
+			desc:          "disabled",
+			expectCreated: []string{"spiffe://example.org/invalid", "spiffe://example.org/valid"},
+		},
+		{
+			desc:          "enabled",
+			validate:      true,
+			expectCreated: []string{"spiffe://example.org/valid"},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			entryClient := newEntryClient()
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:               entryClient,
+				ValidateEntriesBeforeSend: tt.validate,
+			}, valid.DeepCopy(), invalid.DeepCopy())
+
+			r.reconcile(ctx)
+			var created []string
+			for _, entry := range entryClient.getEntries() {
+				created = append(created, entry.SPIFFEID.String())
+			}
+			require.ElementsMatch(t, tt.expectCreated, created)
+
+			actual := new(spirev1alpha1.ClusterStaticEntry)
+			require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(valid), actual))
+			require.True(t, actual.Status.Set)
+		})
+	}
+
+	t.Run("failures are counted", func(t *testing.T) {
+		r := newTestEntryReconciler(t, ReconcilerConfig{EntryClient: newEntryClient()})
+		by := &ClusterSPIFFEID{}
+		validEntry := spireapi.Entry{
+			SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/valid"),
+			ParentID:  spiffeid.RequireFromString("spiffe://example.org/parent"),
+			Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:valid"}},
+		}
+		invalidEntry := validEntry
+		invalidEntry.Selectors = []spireapi.Selector{{Type: "k8s"}}
+		noSelectorsEntry := validEntry
+		noSelectorsEntry.Selectors = nil
+
+		kept := r.dropInvalidEntries(ctx, []declaredEntry{
+			{Entry: invalidEntry, By: by},
+			{Entry: validEntry, By: by},
+			{Entry: noSelectorsEntry, By: by},
+		})
+		require.Equal(t, []declaredEntry{{Entry: validEntry, By: by}}, kept)
+		require.Equal(t, 2, by.NextStatus.Stats.EntryFailures)
+	})
+}
+
+func TestStaticEntryRenderFailures(t *testing.T) {
+	for _, tt := range []struct {
+		desc        string
+		modify      func(spec *spirev1alpha1.ClusterStaticEntrySpec)
+		expectField string
+		expectEvent string
+	}{
+		{
+			desc:        "SPIFFE ID",
+			modify:      func(spec *spirev1alpha1.ClusterStaticEntrySpec) { spec.SPIFFEID = "example.org/static" },
+			expectField: "spiffeID",
+			expectEvent: "Warning RenderFailed Failed to render entry: failed to parse SPIFFEID: scheme is missing or invalid",
+		},
+		{
+			desc:        "parent ID",
+			modify:      func(spec *spirev1alpha1.ClusterStaticEntrySpec) { spec.ParentID = "spiffe://example.org/parent/.." },
+			expectField: "parentID",
+			expectEvent: "Warning RenderFailed Failed to render entry: failed to parse ParentID: path cannot contain dot segments",
+		},
+		{
+			desc:        "selectors",
+			modify:      func(spec *spirev1alpha1.ClusterStaticEntrySpec) { spec.Selectors = []string{"k8s"} },
+			expectField: "selectors",
+			expectEvent: "Warning RenderFailed Failed to render entry: failed to parse Selectors: expected at least one colon separate the type from the value",
+		},
+		{
+			desc:        "federatesWith",
+			modify:      func(spec *spirev1alpha1.ClusterStaticEntrySpec) { spec.FederatesWith = []string{"Example.org"} },
+			expectField: "federatesWith",
+			expectEvent: "Warning RenderFailed Failed to render entry: invalid federatesWith value: trust domain characters are limited to lowercase letters, numbers, dots, dashes, and underscores",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			staticEntry := &spirev1alpha1.ClusterStaticEntry{
+				ObjectMeta: metav1.ObjectMeta{Name: "static"},
+				Spec: spirev1alpha1.ClusterStaticEntrySpec{
+					SPIFFEID:  "spiffe://example.org/static",
+					ParentID:  "spiffe://example.org/parent",
+					Selectors: []string{"k8s:ns:static"},
+				},
+			}
+			tt.modify(&staticEntry.Spec)
+			failures := metrics.PromStaticEntryRenderFailures.WithLabelValues(tt.expectField)
+			before := testutil.ToFloat64(failures)
+
+			entryClient := newEntryClient()
+			recorder := record.NewFakeRecorder(10)
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:   entryClient,
+				EventRecorder: recorder,
+			}, staticEntry)
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			r.reconcile(ctx)
+			require.Empty(t, entryClient.getEntries())
+			require.Equal(t, before+1, testutil.ToFloat64(failures))
+
+			var events []string
+			close(recorder.Events)
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			require.Equal(t, []string{tt.expectEvent}, events)
+		})
+	}
+}
+
+func TestAllSelectedNamespacesIgnored(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"system": "true"},
+			},
+		},
+	}
+	systemNamespace := newTestNamespace("kube-system")
+	systemNamespace.Labels = map[string]string{"system": "true"}
+
+	for _, tt := range []struct {
+		desc             string
+		ignoreNamespaces []*regexp.Regexp
+		expectEntries    int
+		expectEvent      string
+	}{
+		{
+			desc:          "selected namespace is not ignored",
+			expectEntries: 1,
+		},
+		{
+			desc:             "all selected namespaces are ignored",
+			ignoreNamespaces: []*regexp.Regexp{regexp.MustCompile("^kube-system$")},
+			expectEvent:      "Warning AllNamespacesIgnored All 1 namespaces selected are ignored by the controller; no entries will be produced",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			entryClient := newEntryClient()
+			recorder := record.NewFakeRecorder(10)
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:      entryClient,
+				IgnoreNamespaces: tt.ignoreNamespaces,
+				EventRecorder:    recorder,
+			}, clusterSPIFFEID, systemNamespace, newTestNamespace("default"), newTestNode("node"),
+				newTestPod("kube-system", "app", "node", nil))
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			r.reconcile(ctx)
+			require.Len(t, entryClient.getEntries(), tt.expectEntries)
+
+			actual := new(spirev1alpha1.ClusterSPIFFEID)
+			require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+			require.Equal(t, 1, actual.Status.Stats.NamespacesSelected)
+
+			var events []string
+			close(recorder.Events)
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			if tt.expectEvent == "" {
+				require.Empty(t, events)
+				return
+			}
+			require.Equal(t, 1, actual.Status.Stats.NamespacesIgnored)
+			require.Equal(t, []string{tt.expectEvent}, events)
+		})
+	}
+}
+
+func TestTTLTolerance(t *testing.T) {
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:    "spiffe://example.org/static",
+			ParentID:    "spiffe://example.org/parent",
+			Selectors:   []string{"k8s:ns:static"},
+			X509SVIDTTL: metav1.Duration{Duration: time.Hour},
+			JWTSVIDTTL:  metav1.Duration{Duration: 5 * time.Minute},
+		},
+	}
+	current := func(x509SVIDTTL, jwtSVIDTTL time.Duration) spireapi.Entry {
+		return spireapi.Entry{
+			ID:          "1",
+			SPIFFEID:    spiffeid.RequireFromString("spiffe://example.org/static"),
+			ParentID:    spiffeid.RequireFromString("spiffe://example.org/parent"),
+			Selectors:   []spireapi.Selector{{Type: "k8s", Value: "ns:static"}},
+			X509SVIDTTL: x509SVIDTTL,
+			JWTSVIDTTL:  jwtSVIDTTL,
+		}
+	}
+
+	for _, tt := range []struct {
+		desc         string
+		tolerance    time.Duration
+		current      spireapi.Entry
+		expectUpdate bool
+	}{
+		{
+			desc:    "exact match without tolerance",
+			current: current(time.Hour, 5*time.Minute),
+		},
+		{
+			desc:         "any difference without tolerance",
+			current:      current(time.Hour+time.Second, 5*time.Minute),
+			expectUpdate: true,
+		},
+		{
+			desc:      "X509-SVID TTL difference within tolerance",
+			tolerance: 10 * time.Second,
+			current:   current(time.Hour-10*time.Second, 5*time.Minute),
+		},
+		{
+			desc:      "JWT-SVID TTL difference within tolerance",
+			tolerance: 10 * time.Second,
+			current:   current(time.Hour, 5*time.Minute+5*time.Second),
+		},
+		{
+			desc:         "X509-SVID TTL difference outside tolerance",
+			tolerance:    10 * time.Second,
+			current:      current(time.Hour+11*time.Second, 5*time.Minute),
+			expectUpdate: true,
+		},
+		{
+			desc:         "JWT-SVID TTL difference outside tolerance",
+			tolerance:    10 * time.Second,
+			current:      current(time.Hour, 5*time.Minute-11*time.Second),
+			expectUpdate: true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			entryClient := newEntryClient(tt.current)
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:  entryClient,
+				TTLTolerance: tt.tolerance,
+			}, staticEntry)
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			r.reconcile(ctx)
+			if tt.expectUpdate {
+				require.Equal(t, 1, entryClient.updateCalls)
+				entries := entryClient.getEntries()
+				require.Len(t, entries, 1)
+				require.Equal(t, time.Hour, entries[0].X509SVIDTTL)
+				require.Equal(t, 5*time.Minute, entries[0].JWTSVIDTTL)
+			} else {
+				require.Zero(t, entryClient.updateCalls)
+				require.Equal(t, []spireapi.Entry{tt.current}, entryClient.getEntries())
+			}
+		})
+	}
+}
+
+func TestCreateDownstreamEntriesFirst(t *testing.T) {
+	downstreamEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "downstream"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:   "spiffe://example.org/downstream",
+			ParentID:   "spiffe://example.org/parent",
+			Selectors:  []string{"k8s:ns:downstream"},
+			Downstream: true,
+		},
+	}
+	workloadEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/workload",
+			ParentID:  "spiffe://example.org/downstream",
+			Selectors: []string{"k8s:ns:workload"},
+		},
+	}
+
+	for _, tt := range []struct {
+		desc          string
+		enabled       bool
+		expectBatches [][]string
+	}{
+		{
+			desc:          "disabled",
+			expectBatches: [][]string{{"spiffe://example.org/downstream", "spiffe://example.org/workload"}},
+		},
+		{
+			desc:          "enabled",
+			enabled:       true,
+			expectBatches: [][]string{{"spiffe://example.org/downstream"}, {"spiffe://example.org/workload"}},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			entryClient := newEntryClient()
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:                  entryClient,
+				CreateDownstreamEntriesFirst: tt.enabled,
+			}, downstreamEntry, workloadEntry)
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			r.reconcile(ctx)
+			for _, batch := range entryClient.createBatches {
+				sort.Strings(batch)
+			}
+			require.Equal(t, tt.expectBatches, entryClient.createBatches)
+		})
+	}
+}
+
+func TestCreateEntriesByPodPriority(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	newPriorityPod := func(name string, priority *int32) *corev1.Pod {
+		pod := newTestPod("default", name, "node", nil)
+		pod.Spec.Priority = priority
+		return pod
+	}
+	high, medium := int32(1000), int32(100)
+
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:                entryClient,
+		CreateEntriesByPodPriority: true,
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"),
+		newPriorityPod("low-1", nil),
+		newPriorityPod("high", &high),
+		newPriorityPod("low-2", nil),
+		newPriorityPod("medium", &medium),
+	)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+	require.Len(t, entryClient.createBatches, 1)
+	batch := entryClient.createBatches[0]
+	require.Len(t, batch, 4)
+	require.Equal(t, []string{
+		"spiffe://example.org/ns/default/pod/high",
+		"spiffe://example.org/ns/default/pod/medium",
+	}, batch[:2])
+	require.ElementsMatch(t, []string{
+		"spiffe://example.org/ns/default/pod/low-1",
+		"spiffe://example.org/ns/default/pod/low-2",
+	}, batch[2:])
+}
+
+func TestSPIFFEIDPathPrefix(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:static"},
+		},
+	}
+
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:        entryClient,
+		SPIFFEIDPathPrefix: "/test",
+	}, clusterSPIFFEID, staticEntry, newTestNamespace("default"), newTestNode("node"), newTestPod("default", "app", "node", nil))
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+	require.Equal(t, []string{
+		"spiffe://example.org/test/ns/default/pod/app",
+		"spiffe://example.org/test/static",
+	}, entrySPIFFEIDs(entryClient.getEntries()))
+
+	// Reconciling again is a no-op since the prefix is part of the entry key.
+	r.reconcile(ctx)
+	require.Equal(t, 1, entryClient.createCalls)
+	require.Zero(t, entryClient.updateCalls)
+	require.Len(t, entryClient.getEntries(), 2)
+}
+
+func TestEntriesByNamespace(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:static"},
+		},
+	}
+	lonePod := newTestPod("b", "app3", "node", nil)
+
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: newEntryClient(),
+	}, clusterSPIFFEID, staticEntry, newTestNamespace("a"), newTestNamespace("b"), newTestNode("node"),
+		newTestPod("a", "app1", "node", nil), newTestPod("a", "app2", "node", nil), lonePod)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+	// Static entries are not attributed to any namespace.
+	require.Equal(t, 2, testutil.CollectAndCount(r.promEntriesByNamespace))
+	require.Equal(t, float64(2), testutil.ToFloat64(r.promEntriesByNamespace.WithLabelValues("a")))
+	require.Equal(t, float64(1), testutil.ToFloat64(r.promEntriesByNamespace.WithLabelValues("b")))
+
+	// The series for a namespace without entries is pruned.
+	require.NoError(t, r.config.K8sClient.Delete(ctx, lonePod))
+	r.reconcile(ctx)
+	require.Equal(t, 1, testutil.CollectAndCount(r.promEntriesByNamespace))
+	require.Equal(t, float64(2), testutil.ToFloat64(r.promEntriesByNamespace.WithLabelValues("a")))
+}
+
+func TestManagedEntries(t *testing.T) {
+	newEntry := func(id, spiffeID string) spireapi.Entry {
+		return spireapi.Entry{
+			ID:        id,
+			SPIFFEID:  spiffeid.RequireFromString(spiffeID),
+			ParentID:  spiffeid.RequireFromString("spiffe://example.org/parent"),
+			Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:" + id}},
+		}
+	}
+	entryClient := newEntryClient(
+		newEntry("k8s.a", "spiffe://example.org/a"),
+		newEntry("k8s.b", "spiffe://example.org/b"),
+		newEntry("k8s.c", "spiffe://other.org/c"),
+		newEntry("unmanaged", "spiffe://example.org/unmanaged"),
+	)
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:   entryClient,
+		EntryIDPrefix: "k8s.",
+	})
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	// Entries without the prefix are not managed by the controller.
+	r.reconcile(ctx)
+	require.Equal(t, 2, testutil.CollectAndCount(r.promManagedEntries))
+	require.Equal(t, float64(2), testutil.ToFloat64(r.promManagedEntries.WithLabelValues("example.org")))
+	require.Equal(t, float64(1), testutil.ToFloat64(r.promManagedEntries.WithLabelValues("other.org")))
+
+	// The managed entries were not declared and have been deleted, so the
+	// series are pruned.
+	r.reconcile(ctx)
+	require.Equal(t, []string{"unmanaged"}, idsFromEntries(entryClient.getEntries()))
+	require.Zero(t, testutil.CollectAndCount(r.promManagedEntries))
+}
+
+func TestWriteDurations(t *testing.T) {
+	operations := []string{
+		metrics.OperationCreateEntries,
+		metrics.OperationUpdateEntries,
+		metrics.OperationDeleteEntries,
+	}
+	writeDurationSamples := func(operation string) uint64 {
+		m := new(dto.Metric)
+		require.NoError(t, metrics.PromSPIREWriteDuration.WithLabelValues(operation).(prometheus.Histogram).Write(m))
+		return m.GetHistogram().GetSampleCount()
+	}
+	before := make(map[string]uint64)
+	for _, operation := range operations {
+		before[operation] = writeDurationSamples(operation)
+	}
+
+	newStaticEntry := func(name string) *spirev1alpha1.ClusterStaticEntry {
+		return &spirev1alpha1.ClusterStaticEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: spirev1alpha1.ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://example.org/" + name,
+				ParentID:  "spiffe://example.org/parent",
+				Selectors: []string{"k8s:ns:" + name},
+				Hint:      name,
+			},
+		}
+	}
+	newEntry := func(name string) spireapi.Entry {
+		return spireapi.Entry{
+			ID:        name,
+			SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/" + name),
+			ParentID:  spiffeid.RequireFromString("spiffe://example.org/parent"),
+			Selectors: []spireapi.Selector{{Type: "k8s", Value: "ns:" + name}},
+		}
+	}
+
+	entryClient := newEntryClient(newEntry("updated"), newEntry("deleted"))
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, newStaticEntry("created"), newStaticEntry("updated"))
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+
+	for _, operation := range operations {
+		require.Equal(t, before[operation]+1, writeDurationSamples(operation), operation)
+	}
+}
+
+func TestClampX509SVIDTTLToCA(t *testing.T) {
+	newStaticEntry := func(name string, ttl time.Duration) *spirev1alpha1.ClusterStaticEntry {
+		return &spirev1alpha1.ClusterStaticEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: spirev1alpha1.ClusterStaticEntrySpec{
+				SPIFFEID:    "spiffe://example.org/" + name,
+				ParentID:    "spiffe://example.org/parent",
+				Selectors:   []string{"k8s:ns:" + name},
+				X509SVIDTTL: metav1.Duration{Duration: ttl},
+			},
+		}
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			TTL:              metav1.Duration{Duration: time.Hour},
+		},
+	}
+	objects := []client.Object{
+		newStaticEntry("long", time.Hour),
+		newStaticEntry("short", 10*time.Minute),
+		newStaticEntry("default", 0),
+		clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"), newTestPod("default", "app", "node", nil),
+	}
+
+	// The CA expires in a little over 30 minutes.
+	bundle := spiffebundle.New(spiffeid.RequireTrustDomainFromString(trustDomain))
+	bundle.AddX509Authority(&x509.Certificate{NotAfter: time.Now().Add(30*time.Minute + 30*time.Second)})
+	bundle.AddX509Authority(&x509.Certificate{NotAfter: time.Now().Add(5 * time.Minute)})
+
+	for _, tt := range []struct {
+		desc       string
+		enabled    bool
+		bundleErr  error
+		expectTTLs map[string]time.Duration
+	}{
+		{
+			desc:    "disabled",
+			enabled: false,
+			expectTTLs: map[string]time.Duration{
+				"spiffe://example.org/long":               time.Hour,
+				"spiffe://example.org/short":              10 * time.Minute,
+				"spiffe://example.org/default":            0,
+				"spiffe://example.org/ns/default/pod/app": time.Hour,
+			},
+		},
+		{
+			desc:    "enabled",
+			enabled: true,
+			expectTTLs: map[string]time.Duration{
+				"spiffe://example.org/long":               30 * time.Minute,
+				"spiffe://example.org/short":              10 * time.Minute,
+				"spiffe://example.org/default":            0,
+				"spiffe://example.org/ns/default/pod/app": 30 * time.Minute,
+			},
+		},
+		{
+			desc:      "bundle unavailable",
+			enabled:   true,
+			bundleErr: errors.New("oh no"),
+			expectTTLs: map[string]time.Duration{
+				"spiffe://example.org/long":               time.Hour,
+				"spiffe://example.org/short":              10 * time.Minute,
+				"spiffe://example.org/default":            0,
+				"spiffe://example.org/ns/default/pod/app": time.Hour,
+			},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			entryClient := newEntryClient()
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:          entryClient,
+				ClampX509SVIDTTLToCA: tt.enabled,
+				BundleClient:         bundleClient{bundle: bundle, err: tt.bundleErr},
+			}, objects...)
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			r.reconcile(ctx)
+			ttls := make(map[string]time.Duration)
+			for _, entry := range entryClient.getEntries() {
+				ttls[entry.SPIFFEID.String()] = entry.X509SVIDTTL
+			}
+			require.Equal(t, tt.expectTTLs, ttls)
+		})
+	}
+}
+
+type bundleClient struct {
+	bundle *spiffebundle.Bundle
+	err    error
+}
+
+func (c bundleClient) GetBundle(context.Context) (*spiffebundle.Bundle, error) {
+	return c.bundle, c.err
+}
+
+func TestDeclaredEntryGracePeriod(t *testing.T) {
+	newStaticEntry := func() *spirev1alpha1.ClusterStaticEntry {
+		return &spirev1alpha1.ClusterStaticEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: "static"},
+			Spec: spirev1alpha1.ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://example.org/static",
+				ParentID:  "spiffe://example.org/parent",
+				Selectors: []string{"k8s:ns:static"},
+			},
+		}
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		entryClient := newEntryClient()
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient: entryClient,
+		}, newStaticEntry())
+		ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+		r.reconcile(ctx)
+		require.Len(t, entryClient.getEntries(), 1)
+
+		require.NoError(t, r.config.K8sClient.Delete(ctx, newStaticEntry()))
+		r.reconcile(ctx)
+		require.Empty(t, entryClient.getEntries())
+	})
+
+	t.Run("transient disappearance", func(t *testing.T) {
+		entryClient := newEntryClient()
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient:              entryClient,
+			DeclaredEntryGracePeriod: time.Minute,
+		}, newStaticEntry())
+		ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+		r.reconcile(ctx)
+		require.Len(t, entryClient.getEntries(), 1)
+
+		// The entry is retained while the object is missing...
+		require.NoError(t, r.config.K8sClient.Delete(ctx, newStaticEntry()))
+		r.reconcile(ctx)
+		require.Len(t, entryClient.getEntries(), 1)
+
+		// ...and reused without being recreated when it reappears.
+		require.NoError(t, r.config.K8sClient.Create(ctx, newStaticEntry()))
+		r.reconcile(ctx)
+		require.Len(t, entryClient.getEntries(), 1)
+		require.Equal(t, 1, entryClient.createCalls)
+	})
+
+	t.Run("grace period elapsed", func(t *testing.T) {
+		entryClient := newEntryClient()
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient:              entryClient,
+			DeclaredEntryGracePeriod: time.Minute,
+		}, newStaticEntry())
+		ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+		r.reconcile(ctx)
+		require.Len(t, entryClient.getEntries(), 1)
+
+		require.NoError(t, r.config.K8sClient.Delete(ctx, newStaticEntry()))
+		r.reconcile(ctx)
+		require.Len(t, entryClient.getEntries(), 1)
+
+		// Pretend the entry was last declared before the grace period.
+		for key := range r.lastDeclared {
+			r.lastDeclared[key] = time.Now().Add(-2 * time.Minute)
+		}
+		r.reconcile(ctx)
+		require.Empty(t, entryClient.getEntries())
+		require.Empty(t, r.lastDeclared)
+	})
+}
+
+func TestRevalidatePodsBeforeCreate(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+
+	for _, tt := range []struct {
+		desc            string
+		enabled         bool
+		expectSPIFFEIDs []string
+		expectStats     spirev1alpha1.ClusterSPIFFEIDStats
+		// expectEntries is the number of entries counted for the namespace.
+		expectEntries float64
+	}{
+		{
+			desc: "disabled",
+			expectSPIFFEIDs: []string{
+				"spiffe://example.org/ns/default/pod/doomed",
+				"spiffe://example.org/ns/default/pod/stable",
+			},
+			expectStats: spirev1alpha1.ClusterSPIFFEIDStats{
+				NamespacesSelected: 1,
+				PodsSelected:       2,
+				EntriesToSet:       2,
+			},
+			expectEntries: 2,
+		},
+		{
+			desc:    "enabled",
+			enabled: true,
+			expectSPIFFEIDs: []string{
+				"spiffe://example.org/ns/default/pod/stable",
+			},
+			expectStats: spirev1alpha1.ClusterSPIFFEIDStats{
+				NamespacesSelected: 1,
+				PodsSelected:       2,
+				PodsDeleted:        1,
+				EntriesToSet:       1,
+			},
+			expectEntries: 1,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			doomedPod := newTestPod("default", "doomed", "node", nil)
+			scheme := runtime.NewScheme()
+			require.NoError(t, clientgoscheme.AddToScheme(scheme))
+			require.NoError(t, spirev1alpha1.AddToScheme(scheme))
+			var deleted bool
+			k8sClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(clusterSPIFFEID.DeepCopy(), newTestNamespace("default"), newTestNode("node"), doomedPod, newTestPod("default", "stable", "node", nil)).
+				WithStatusSubresource(&spirev1alpha1.ClusterSPIFFEID{}).
+				WithInterceptorFuncs(interceptor.Funcs{
+					// Delete the pod once the pods have been listed, i.e. when
+					// the node is fetched to render the first entry.
+					Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+						if _, ok := obj.(*corev1.Node); ok && !deleted {
+							deleted = true
+							require.NoError(t, c.Delete(ctx, doomedPod))
+						}
+						return c.Get(ctx, key, obj, opts...)
+					},
+				}).
+				Build()
+
+			entryClient := newEntryClient()
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:                entryClient,
+				K8sClient:                  k8sClient,
+				RevalidatePodsBeforeCreate: tt.enabled,
+			})
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			r.reconcile(ctx)
+			require.True(t, deleted)
+			require.Equal(t, tt.expectSPIFFEIDs, entrySPIFFEIDs(entryClient.getEntries()))
+
+			updated := new(spirev1alpha1.ClusterSPIFFEID)
+			require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), updated))
+			require.Equal(t, tt.expectStats, updated.Status.Stats)
+			require.Equal(t, tt.expectEntries, testutil.ToFloat64(r.promEntriesByNamespace.WithLabelValues("default")))
+		})
+	}
+}
+
+func TestEntryRevisions(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			TTL:              metav1.Duration{Duration: time.Hour},
+		},
+	}
+	hintedEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:static"},
+			Hint:      "static",
+		},
+	}
+	objects := []client.Object{clusterSPIFFEID, hintedEntry, newTestNamespace("default"), newTestNode("node"), newTestPod("default", "app", "node", nil)}
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	podEntry := func(entryClient *entryClient) spireapi.Entry {
+		for _, entry := range entryClient.getEntries() {
+			if entry.SPIFFEID.String() == "spiffe://example.org/ns/default/pod/app" {
+				return entry
+			}
+		}
+		require.FailNow(t, "pod entry not found")
+		return spireapi.Entry{}
+	}
+
+	t.Run("detects and restores tampered entries", func(t *testing.T) {
+		entryClient := newEntryClient()
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient:    entryClient,
+			EntryRevisions: true,
+		}, objects...)
+
+		r.reconcile(ctx)
+		created := podEntry(entryClient)
+		require.Empty(t, created.Hint, "revisions are not written to the entries")
+		require.Contains(t, r.entryRevisions, created.ID)
+		for _, entry := range entryClient.getEntries() {
+			if entry.SPIFFEID.String() == "spiffe://example.org/static" {
+				require.Equal(t, "static", entry.Hint, "existing hints are left alone")
+			}
+		}
+
+		t.Log("Reconciling again is a no-op")
+		r.reconcile(ctx)
+		require.Zero(t, entryClient.updateCalls)
+		require.Zero(t, testutil.ToFloat64(r.promCounter[metrics.TamperedEntries]))
+
+		t.Log("Modify the entry outside of the controller")
+		tampered := created
+		tampered.X509SVIDTTL = 24 * time.Hour
+		entryClient.entries[tampered.ID] = tampered
+
+		r.reconcile(ctx)
+		require.Equal(t, float64(1), testutil.ToFloat64(r.promCounter[metrics.TamperedEntries]))
+		require.Equal(t, 1, entryClient.updateCalls)
+		require.Equal(t, created, podEntry(entryClient))
+
+		t.Log("The restored entry is no longer reported")
+		r.reconcile(ctx)
+		require.Equal(t, float64(1), testutil.ToFloat64(r.promCounter[metrics.TamperedEntries]))
+
+		t.Log("The revisions of deleted entries are dropped")
+		delete(entryClient.entries, created.ID)
+		r.reconcile(ctx)
+		require.NotContains(t, r.entryRevisions, created.ID)
+	})
+
+	t.Run("TTL differences within the tolerance are not reported", func(t *testing.T) {
+		entryClient := newEntryClient()
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient:    entryClient,
+			EntryRevisions: true,
+			TTLTolerance:   time.Minute,
+		}, objects...)
+
+		r.reconcile(ctx)
+		drifted := podEntry(entryClient)
+		drifted.X509SVIDTTL += 30 * time.Second
+		entryClient.entries[drifted.ID] = drifted
+
+		r.reconcile(ctx)
+		require.Zero(t, testutil.ToFloat64(r.promCounter[metrics.TamperedEntries]))
+		require.Zero(t, entryClient.updateCalls)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		entryClient := newEntryClient()
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient: entryClient,
+		}, objects...)
+
+		r.reconcile(ctx)
+		require.Empty(t, r.entryRevisions)
+	})
+}
+
+func TestDefaultJWTSVIDTTL(t *testing.T) {
+	withoutJWTTTL := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/default/{{ .PodMeta.Name }}",
+			TTL:              metav1.Duration{Duration: time.Hour},
+		},
+	}
+	withJWTTTL := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "explicit"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/explicit/{{ .PodMeta.Name }}",
+			JWTTTL:           metav1.Duration{Duration: 10 * time.Minute},
+		},
+	}
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:static"},
+		},
+	}
+	objects := []client.Object{withoutJWTTTL, withJWTTTL, staticEntry, newTestNamespace("default"), newTestNode("node"), newTestPod("default", "app", "node", nil)}
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	ttlsBySPIFFEID := func(entries []spireapi.Entry) map[string][2]time.Duration {
+		out := make(map[string][2]time.Duration)
+		for _, entry := range entries {
+			out[entry.SPIFFEID.String()] = [2]time.Duration{entry.X509SVIDTTL, entry.JWTSVIDTTL}
+		}
+		return out
+	}
+
+	t.Run("applied to entries without a JWT-SVID TTL", func(t *testing.T) {
+		entryClient := newEntryClient()
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient:       entryClient,
+			DefaultJWTSVIDTTL: 5 * time.Minute,
+		}, objects...)
+
+		r.reconcile(ctx)
+		require.Equal(t, map[string][2]time.Duration{
+			"spiffe://example.org/default/app":  {time.Hour, 5 * time.Minute},
+			"spiffe://example.org/explicit/app": {0, 10 * time.Minute},
+			"spiffe://example.org/static":       {0, 5 * time.Minute},
+		}, ttlsBySPIFFEID(entryClient.getEntries()))
+	})
+
+	t.Run("no churn when the server does not support the JWT-SVID TTL", func(t *testing.T) {
+		entryClient := newEntryClient()
+		entryClient.unsupportedFields[spireapi.JWTSVIDTTLField] = struct{}{}
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient:       entryClient,
+			DefaultJWTSVIDTTL: 5 * time.Minute,
+		}, objects...)
+
+		r.reconcile(ctx)
+
+		// The server ignores the JWT-SVID TTL.
+		for id, entry := range entryClient.entries {
+			entry.JWTSVIDTTL = 0
+			entryClient.entries[id] = entry
+		}
+
+		r.reconcile(ctx)
+		require.Zero(t, entryClient.updateCalls)
+	})
+}
+
+func TestEntryWriteCounters(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	deletedPod := newTestPod("default", "deleted", "node", nil)
+
+	entryClient := newEntryClient()
+	entryClient.createStatus = map[string]spireapi.Status{
+		"spiffe://example.org/ns/default/pod/failing": {Code: codes.Internal, Message: "datastore error"},
+	}
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"),
+		newTestPod("default", "updated", "node", nil),
+		newTestPod("default", "failing", "node", nil),
+		deletedPod,
+	)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	counts := func() [3]float64 {
+		return [3]float64{
+			testutil.ToFloat64(r.promCounter[metrics.EntriesCreated]),
+			testutil.ToFloat64(r.promCounter[metrics.EntriesUpdated]),
+			testutil.ToFloat64(r.promCounter[metrics.EntriesDeleted]),
+		}
+	}
+
+	// Only the entries created successfully are counted.
+	r.reconcile(ctx)
+	require.Equal(t, [3]float64{2, 0, 0}, counts())
+
+	require.NoError(t, r.config.K8sClient.Delete(ctx, deletedPod))
+	for id, entry := range entryClient.entries {
+		if entry.SPIFFEID.Path() == "/ns/default/pod/updated" {
+			entry.X509SVIDTTL = time.Hour
+			entryClient.entries[id] = entry
+		}
+	}
+	r.reconcile(ctx)
+	require.Equal(t, [3]float64{2, 1, 1}, counts())
+
+	// Nothing is counted once the entries are up to date.
+	r.reconcile(ctx)
+	require.Equal(t, [3]float64{2, 1, 1}, counts())
+}
+
+func TestDefaultX509SVIDTTL(t *testing.T) {
+	withoutTTL := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/default/{{ .PodMeta.Name }}",
+		},
+	}
+	withTTL := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "explicit"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/explicit/{{ .PodMeta.Name }}",
+			TTL:              metav1.Duration{Duration: 10 * time.Minute},
+		},
+	}
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:static"},
+		},
+	}
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:        entryClient,
+		DefaultX509SVIDTTL: 2 * time.Hour,
+		DefaultJWTSVIDTTL:  5 * time.Minute,
+	}, withoutTTL, withTTL, staticEntry, newTestNamespace("default"), newTestNode("node"), newTestPod("default", "app", "node", nil))
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+	ttls := make(map[string][2]time.Duration)
+	for _, entry := range entryClient.getEntries() {
+		ttls[entry.SPIFFEID.String()] = [2]time.Duration{entry.X509SVIDTTL, entry.JWTSVIDTTL}
+	}
+	// The TTLs set on the objects win over the defaults.
+	require.Equal(t, map[string][2]time.Duration{
+		"spiffe://example.org/default/app":  {2 * time.Hour, 5 * time.Minute},
+		"spiffe://example.org/explicit/app": {10 * time.Minute, 5 * time.Minute},
+		"spiffe://example.org/static":       {2 * time.Hour, 5 * time.Minute},
+	}, ttls)
+
+	t.Log("The defaulted entries are stable across passes")
+	r.reconcile(ctx)
+	require.Zero(t, entryClient.updateCalls)
+}
+
+func TestMinPodAgeForEntry(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	startedPod := func(name string, startTime time.Time) *corev1.Pod {
+		pod := newTestPod("default", name, "node", nil)
+		pod.Status.Phase = corev1.PodRunning
+		pod.Status.StartTime = &metav1.Time{Time: startTime}
+		return pod
+	}
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	t.Run("young pods are skipped", func(t *testing.T) {
+		clk := testclock.NewFakeClock(time.Now())
+		// A container restart does not reset the age of the pod.
+		restarted := startedPod("restarted", clk.Now().Add(-time.Hour))
+		restarted.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			RestartCount: 3,
+			State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(clk.Now().Add(-time.Second))}},
+		}}
+		entryClient := newEntryClient()
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient:       entryClient,
+			MinPodAgeForEntry: time.Minute,
+			Clock:             clk,
+		}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"),
+			startedPod("mature", clk.Now().Add(-time.Hour)),
+			startedPod("young", clk.Now().Add(-time.Second)),
+			restarted,
+			newTestPod("default", "pending", "node", nil),
+		)
+
+		r.reconcile(ctx)
+		require.Equal(t, []string{
+			"spiffe://example.org/ns/default/pod/mature",
+			"spiffe://example.org/ns/default/pod/restarted",
+		}, entrySPIFFEIDs(entryClient.getEntries()))
+
+		actual := &spirev1alpha1.ClusterSPIFFEID{}
+		require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+		require.Equal(t, 2, actual.Status.Stats.PodsTooYoung)
+		require.Equal(t, 2, actual.Status.Stats.EntriesToSet)
+	})
+
+	t.Run("reconcile is triggered once young pods mature", func(t *testing.T) {
+		clk := testclock.NewFakeClock(time.Now())
+		triggered := 0
+		entryClient := newEntryClient()
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient:       entryClient,
+			MinPodAgeForEntry: time.Minute,
+			Clock:             clk,
+		}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"),
+			startedPod("young", clk.Now().Add(-time.Second)),
+		)
+		r.triggerer = triggererFunc(func() { triggered++ })
+
+		r.reconcile(ctx)
+		require.Empty(t, entryClient.getEntries())
+
+		clk.Step(time.Minute - 2*time.Second)
+		require.Zero(t, triggered, "reconcile was triggered before the pod matured")
+
+		clk.Step(time.Second)
+		require.Equal(t, 1, triggered, "reconcile was not triggered once the pod matured")
+
+		r.reconcile(ctx)
+		require.Equal(t, []string{"spiffe://example.org/ns/default/pod/young"}, entrySPIFFEIDs(entryClient.getEntries()))
+		require.Nil(t, r.podMaturityTimer, "no trigger is scheduled once all pods are mature")
+	})
+}
+
+func TestEntriesDifferingOnlyByHint(t *testing.T) {
+	// Creating both hinted entries is not possible: SPIRE rejects an entry
+	// with the same SPIFFE ID, parent ID and selectors as an existing one
+	// with AlreadyExists, whatever its hint (see makeEntryKey), as the fake
+	// entry client does. Entries differing only by hint mask each other
+	// instead of the second one failing on every pass.
+	const spiffeIDTemplate = "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/default"
+	now := time.Now()
+	older := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "payments", CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: spiffeIDTemplate,
+			Hint:             "payments",
+		},
+	}
+	newer := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", CreationTimestamp: metav1.NewTime(now)},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: spiffeIDTemplate,
+			HintTemplate:     `{{ index .PodMeta.Labels "app" }}`,
+		},
+	}
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, older, newer, newTestNamespace("default"), newTestNode("node"),
+		newTestPod("default", "workload", "node", map[string]string{"app": "orders"}),
+	)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	getStats := func(obj client.Object) spirev1alpha1.ClusterSPIFFEIDStats {
+		actual := new(spirev1alpha1.ClusterSPIFFEID)
+		require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(obj), actual))
+		return actual.Status.Stats
+	}
+
+	// Both passes converge on the entry of the preferred ClusterSPIFFEID.
+	for pass := 1; pass <= 2; pass++ {
+		r.reconcile(ctx)
+		entries := entryClient.getEntries()
+		require.Len(t, entries, 1)
+		require.Equal(t, "payments", entries[0].Hint)
+		require.Equal(t, 1, getStats(older).EntriesToSet)
+		require.Equal(t, 1, getStats(newer).EntriesMasked)
+		require.Zero(t, getStats(older).EntryFailures)
+	}
+	require.Equal(t, 1, entryClient.createCalls)
+	require.Zero(t, entryClient.updateCalls)
+}
+
+func TestSkipTerminatingPods(t *testing.T) {
+	const spiffeIDTemplate = "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}"
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec:       spirev1alpha1.ClusterSPIFFEIDSpec{SPIFFEIDTemplate: spiffeIDTemplate},
+	}
+	spiffeID := &spirev1alpha1.SPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "workload"},
+		Spec:       spirev1alpha1.SPIFFEIDSpec{SPIFFEIDTemplate: spiffeIDTemplate},
+	}
+	newPod := func(name string, phase corev1.PodPhase) *corev1.Pod {
+		pod := newTestPod("default", name, "node", nil)
+		pod.Status.Phase = phase
+		return pod
+	}
+	deleting := newPod("deleting", corev1.PodRunning)
+	deleting.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	// The fake client refuses objects being deleted without finalizers.
+	deleting.Finalizers = []string{"example.org/finalizer"}
+	newObjects := func() []client.Object {
+		return []client.Object{
+			newTestNamespace("default"), newTestNode("node"),
+			newPod("running", corev1.PodRunning),
+			newPod("pending", corev1.PodPending),
+			newPod("succeeded", corev1.PodSucceeded),
+			newPod("failed", corev1.PodFailed),
+			deleting.DeepCopy(),
+		}
+	}
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	for _, tt := range []struct {
+		desc          string
+		skip          bool
+		expectPods    []string
+		expectSkipped int
+	}{
+		{
+			desc:       "disabled",
+			expectPods: []string{"deleting", "failed", "pending", "running", "succeeded"},
+		},
+		{
+			desc:          "enabled",
+			skip:          true,
+			expectPods:    []string{"pending", "running"},
+			expectSkipped: 3,
+		},
+	} {
+		var expectSPIFFEIDs []string
+		for _, pod := range tt.expectPods {
+			expectSPIFFEIDs = append(expectSPIFFEIDs, "spiffe://example.org/ns/default/pod/"+pod)
+		}
+
+		t.Run(tt.desc+"/ClusterSPIFFEID", func(t *testing.T) {
+			entryClient := newEntryClient()
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:         entryClient,
+				SkipTerminatingPods: tt.skip,
+			}, append(newObjects(), clusterSPIFFEID.DeepCopy())...)
+
+			r.reconcile(ctx)
+			require.Equal(t, expectSPIFFEIDs, entrySPIFFEIDs(entryClient.getEntries()))
+
+			actual := &spirev1alpha1.ClusterSPIFFEID{}
+			require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+			require.Equal(t, 5, actual.Status.Stats.PodsSelected)
+			require.Equal(t, tt.expectSkipped, actual.Status.Stats.PodsSkipped)
+			require.Equal(t, len(tt.expectPods), actual.Status.Stats.EntriesToSet)
+		})
+
+		t.Run(tt.desc+"/SPIFFEID", func(t *testing.T) {
+			entryClient := newEntryClient()
+			r := newTestEntryReconciler(t, ReconcilerConfig{
+				EntryClient:         entryClient,
+				Reconcile:           spirev1alpha1.ReconcileConfig{SPIFFEIDs: true},
+				SkipTerminatingPods: tt.skip,
+			}, append(newObjects(), spiffeID.DeepCopy())...)
+
+			r.reconcile(ctx)
+			require.Equal(t, expectSPIFFEIDs, entrySPIFFEIDs(entryClient.getEntries()))
+
+			actual := &spirev1alpha1.SPIFFEID{}
+			require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(spiffeID), actual))
+			require.Equal(t, 5, actual.Status.Stats.PodsSelected)
+			require.Equal(t, tt.expectSkipped, actual.Status.Stats.PodsSkipped)
+		})
+	}
+
+	t.Run("each condition", func(t *testing.T) {
+		r := &entryReconciler{config: ReconcilerConfig{SkipTerminatingPods: true}}
+		require.True(t, r.isPodSkipped(deleting))
+		require.True(t, r.isPodSkipped(newPod("succeeded", corev1.PodSucceeded)))
+		require.True(t, r.isPodSkipped(newPod("failed", corev1.PodFailed)))
+		require.False(t, r.isPodSkipped(newPod("running", corev1.PodRunning)))
+		require.False(t, r.isPodSkipped(newPod("pending", corev1.PodPending)))
+		require.False(t, r.isPodSkipped(newPod("unknown", corev1.PodUnknown)))
+
+		r.config.SkipTerminatingPods = false
+		require.False(t, r.isPodSkipped(deleting))
+		require.False(t, r.isPodSkipped(newPod("failed", corev1.PodFailed)))
+	})
+}
+
+func TestSPIFFEIDs(t *testing.T) {
+	newSPIFFEID := func(namespace, name, spiffeIDTemplate string, podLabels map[string]string) *spirev1alpha1.SPIFFEID {
+		spiffeID := &spirev1alpha1.SPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec: spirev1alpha1.SPIFFEIDSpec{
+				SPIFFEIDTemplate: spiffeIDTemplate,
+			},
+		}
+		if podLabels != nil {
+			spiffeID.Spec.PodSelector = &metav1.LabelSelector{MatchLabels: podLabels}
+		}
+		return spiffeID
+	}
+	const nsTemplate = "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}"
+	frontend := newSPIFFEID("a", "frontend", nsTemplate, map[string]string{"app": "frontend"})
+	// Claims the identity of a workload in another namespace.
+	impersonating := newSPIFFEID("a", "impersonating", "spiffe://{{ .TrustDomain }}/ns/b/pod/{{ .PodMeta.Name }}", map[string]string{"app": "backend"})
+	ignored := newSPIFFEID("kube-system", "ignored", nsTemplate, nil)
+
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:      entryClient,
+		IgnoreNamespaces: []*regexp.Regexp{regexp.MustCompile("^kube-system$")},
+		Reconcile:        spirev1alpha1.ReconcileConfig{SPIFFEIDs: true},
+	}, frontend, impersonating, ignored,
+		newTestNamespace("a"), newTestNamespace("b"), newTestNamespace("kube-system"), newTestNode("node"),
+		newTestPod("a", "frontend", "node", map[string]string{"app": "frontend"}),
+		newTestPod("a", "backend", "node", map[string]string{"app": "backend"}),
+		newTestPod("b", "other-frontend", "node", map[string]string{"app": "frontend"}),
+		newTestPod("kube-system", "system", "node", nil),
+	)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+
+	// Only the pods in the namespace of the SPIFFEID are selected, and only
+	// SPIFFE IDs in that namespace are registered.
+	require.Equal(t, []string{"spiffe://example.org/ns/a/pod/frontend"}, entrySPIFFEIDs(entryClient.getEntries()))
+
+	getStats := func(spiffeID *spirev1alpha1.SPIFFEID) spirev1alpha1.ClusterSPIFFEIDStats {
+		actual := &spirev1alpha1.SPIFFEID{}
+		require.NoError(t, r.config.K8sClient.Get(ctx, client.ObjectKeyFromObject(spiffeID), actual))
+		return actual.Status.Stats
+	}
+	require.Equal(t, spirev1alpha1.ClusterSPIFFEIDStats{PodsSelected: 1, EntriesToSet: 1}, getStats(frontend))
+	require.Equal(t, spirev1alpha1.ClusterSPIFFEIDStats{PodsSelected: 1, PodEntryRenderFailures: 1}, getStats(impersonating))
+	require.Equal(t, spirev1alpha1.ClusterSPIFFEIDStats{}, getStats(ignored))
+
+	t.Run("not reconciled unless enabled", func(t *testing.T) {
+		entryClient := newEntryClient()
+		r := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient: entryClient,
+		}, frontend, newTestNamespace("a"), newTestNode("node"),
+			newTestPod("a", "frontend", "node", map[string]string{"app": "frontend"}),
+		)
+
+		r.reconcile(ctx)
+		require.Empty(t, entryClient.getEntries())
+	})
+}
+
+func TestCheckSPIFFEIDInNamespace(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		id        string
+		prefix    string
+		expectErr string
+	}{
+		{name: "namespace", id: "spiffe://example.org/ns/a"},
+		{name: "in namespace", id: "spiffe://example.org/ns/a/sa/default"},
+		{name: "in namespace with prefix", id: "spiffe://example.org/cluster/ns/a/sa/default", prefix: "/cluster"},
+		{name: "other namespace", id: "spiffe://example.org/ns/b/sa/default", expectErr: `SPIFFE ID "spiffe://example.org/ns/b/sa/default" is not in namespace "a"; its path must start with "/ns/a"`},
+		{name: "namespace name prefix", id: "spiffe://example.org/ns/ab/sa/default", expectErr: `its path must start with "/ns/a"`},
+		{name: "outside of namespaces", id: "spiffe://example.org/admin", expectErr: `its path must start with "/ns/a"`},
+		{name: "missing prefix", id: "spiffe://example.org/ns/a/sa/default", prefix: "/cluster", expectErr: `its path must start with "/cluster/ns/a"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &entryReconciler{config: ReconcilerConfig{SPIFFEIDPathPrefix: tt.prefix}}
+			err := r.checkSPIFFEIDInNamespace(spiffeid.RequireFromString(tt.id), "a")
+			if tt.expectErr != "" {
+				require.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+type triggererFunc func()
+
+func (fn triggererFunc) Trigger() {
+	fn()
+}
+
+func newTestNamespace(name string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}
+}
+
+func newTestNode(name string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name + "-uid")},
+	}
+}
+
+func newTestPod(namespace, name, nodeName string, podLabels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			UID:       types.UID(name + "-uid"),
+			Labels:    podLabels,
+		},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+		},
+	}
+}
+
+func entrySPIFFEIDs(entries []spireapi.Entry) []string {
+	var out []string
+	for _, entry := range entries {
+		out = append(out, entry.SPIFFEID.String())
+	}
+	sort.Strings(out)
+	return out
+}
+
+func newTestEntryReconciler(t testing.TB, config ReconcilerConfig, objects ...client.Object) *entryReconciler {
+	if config.TrustDomain.IsZero() {
+		config.TrustDomain = spiffeid.RequireTrustDomainFromString(trustDomain)
+	}
+	if config.ClusterName == "" {
+		config.ClusterName = clusterName
+	}
+	if config.ClusterDomain == "" {
+		config.ClusterDomain = clusterDomain
+	}
+	if config.K8sClient == nil {
+		scheme := runtime.NewScheme()
+		require.NoError(t, clientgoscheme.AddToScheme(scheme))
+		require.NoError(t, spirev1alpha1.AddToScheme(scheme))
+		config.K8sClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(objects...).
+			WithStatusSubresource(&spirev1alpha1.ClusterStaticEntry{}, &spirev1alpha1.ClusterSPIFFEID{}, &spirev1alpha1.SPIFFEID{}).
+			Build()
+	}
+	if !config.Reconcile.ClusterSPIFFEIDs && !config.Reconcile.ClusterStaticEntries {
+		config.Reconcile.ClusterSPIFFEIDs = true
+		config.Reconcile.ClusterStaticEntries = true
+	}
+	promCounter := make(map[string]prometheus.Counter, len(metrics.PromCounters))
+	for name := range metrics.PromCounters {
+		promCounter[name] = prometheus.NewCounter(prometheus.CounterOpts{Name: name})
+	}
+	r := newEntryReconciler(config)
+	r.promCounter = promCounter
+	r.probeRetryBackoff = time.Millisecond
+	r.promEntriesByNamespace = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: metrics.EntriesByNamespace}, []string{"namespace"})
+	r.promManagedEntries = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: metrics.ManagedEntries}, []string{"trust_domain"})
+	return r
+}
+
+type entryClient struct {
+	entries                   map[string]spireapi.Entry
+	nextID                    int
+	listCalls                 int
+	createCalls               int
+	createBatches             [][]string
+	updateCalls               int
+	deleteCalls               int
+	getUnsupportedFieldsCalls int
+	unsupportedFields         map[spireapi.Field]struct{}
+	unsupportedFieldsByTD     map[string]map[spireapi.Field]struct{}
+	probedTrustDomains        []string
+	getUnsupportedFieldsErrs  []error
+	listError                 error
+	createError               error
+	updateError               error
+	deleteError               error
+	updateStatus              map[string]spireapi.Status
+	createStatus              map[string]spireapi.Status
+	parentEntryLimits         map[string]int
+}
+
+type fakeCache struct {
+	synced bool
+}
+
+func (c *fakeCache) WaitForCacheSync(context.Context) bool {
+	return c.synced
+}
+
+func newEntryClient(entries ...spireapi.Entry) *entryClient {
+	c := &entryClient{
+		entries:           make(map[string]spireapi.Entry),
+		unsupportedFields: make(map[spireapi.Field]struct{}),
+		updateStatus:      make(map[string]spireapi.Status),
+	}
+	for _, entry := range entries {
+		c.entries[entry.ID] = entry
+	}
+	return c
+}
+
+func (c *entryClient) ListEntries(context.Context) ([]spireapi.Entry, error) {
+	c.listCalls++
+	if c.listError != nil {
+		return nil, c.listError
+	}
+	return c.getEntries(), nil
+}
+
+func (c *entryClient) GetUnsupportedFields(_ context.Context, td string) (map[spireapi.Field]struct{}, error) {
+	c.getUnsupportedFieldsCalls++
+	c.probedTrustDomains = append(c.probedTrustDomains, td)
+	if len(c.getUnsupportedFieldsErrs) > 0 {
+		err := c.getUnsupportedFieldsErrs[0]
+		c.getUnsupportedFieldsErrs = c.getUnsupportedFieldsErrs[1:]
+		return nil, err
+	}
+	unsupportedFields, ok := c.unsupportedFieldsByTD[td]
+	if !ok {
+		unsupportedFields = c.unsupportedFields
+	}
+	out := make(map[spireapi.Field]struct{}, len(unsupportedFields))
+	for field := range unsupportedFields {
+		out[field] = struct{}{}
+	}
+	return out, nil
+}
+
+func (c *entryClient) CreateEntries(_ context.Context, entries []spireapi.Entry) ([]spireapi.Status, error) {
+	c.createCalls++
+	if c.createError != nil {
+		return nil, c.createError
+	}
+	var batch []string
+	for _, entry := range entries {
+		batch = append(batch, entry.SPIFFEID.String())
+	}
+	c.createBatches = append(c.createBatches, batch)
+	out := make([]spireapi.Status, 0, len(entries))
+	for i, entry := range entries {
+		if existing, ok := c.findSimilar(entry); ok {
+			entries[i].ID = existing.ID
+			out = append(out, spireapi.Status{Code: codes.AlreadyExists, Message: "similar entry already exists"})
+			continue
+		}
+		if st, ok := c.createStatus[entry.SPIFFEID.String()]; ok {
+			out = append(out, st)
+			continue
+		}
+		if limit, ok := c.parentEntryLimits[entry.ParentID.String()]; ok && c.countByParent(entry.ParentID) >= limit {
+			out = append(out, spireapi.Status{Code: codes.ResourceExhausted, Message: "parent entry limit reached"})
+			continue
+		}
+		if entry.ID == "" {
+			c.nextID++
+			entry.ID = fmt.Sprintf("id-%d", c.nextID)
+			entries[i].ID = entry.ID
+		}
+		c.entries[entry.ID] = entry
+		out = append(out, spireapi.Status{})
+	}
+	return out, nil
+}
+
+func (c *entryClient) UpdateEntries(_ context.Context, entries []spireapi.Entry) ([]spireapi.Status, error) {
+	c.updateCalls++
+	if c.updateError != nil {
+		return nil, c.updateError
+	}
+	out := make([]spireapi.Status, 0, len(entries))
+	for _, entry := range entries {
+		if _, ok := c.entries[entry.ID]; !ok {
+			out = append(out, spireapi.Status{Code: codes.NotFound})
+			continue
+		}
+		if st, ok := c.updateStatus[entry.ID]; ok {
+			out = append(out, st)
+			continue
+		}
+		c.entries[entry.ID] = entry
+		out = append(out, spireapi.Status{})
+	}
+	return out, nil
+}
+
+func (c *entryClient) DeleteEntries(_ context.Context, ids []string) ([]spireapi.Status, error) {
+	c.deleteCalls++
+	if c.deleteError != nil {
+		return nil, c.deleteError
+	}
+	out := make([]spireapi.Status, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := c.entries[id]; !ok {
+			out = append(out, spireapi.Status{Code: codes.NotFound})
+			continue
+		}
+		delete(c.entries, id)
+		out = append(out, spireapi.Status{})
+	}
+	return out, nil
+}
+
+// findSimilar returns whether an entry SPIRE considers similar to the given
+// one exists, i.e. one with the same SPIFFE ID, parent ID and selectors. As
+// in SPIRE, the hint and other fields are not compared.
+func (c *entryClient) findSimilar(entry spireapi.Entry) (spireapi.Entry, bool) {
+	selectors := sortSelectors(entry.Selectors)
+	for _, existing := range c.entries {
+		if existing.SPIFFEID == entry.SPIFFEID && existing.ParentID == entry.ParentID && slices.Equal(sortSelectors(existing.Selectors), selectors) {
+			return existing, true
+		}
+	}
+	return spireapi.Entry{}, false
+}
+
+func (c *entryClient) countByParent(parentID spiffeid.ID) int {
+	n := 0
+	for _, entry := range c.entries {
+		if entry.ParentID == parentID {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *entryClient) getEntries() []spireapi.Entry {
+	out := make([]spireapi.Entry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(a, b int) bool {
+		return out[a].ID < out[b].ID
+	})
+	return out
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	defaultRenderFailureBackoff = 30 * time.Second
+	maxRenderFailureBackoff     = 10 * time.Minute
+)
+
+// renderFailureState tracks the consecutive passes in which every entry of
+// an object failed to render, and the resulting backoff.
+type renderFailureState struct {
+	// generation is the generation of the object the failures are for. A
+	// new generation (e.g. a fixed template) resets the tracking.
+	generation int64
+	passes     int
+	backoff    time.Duration
+	retryAt    time.Time
+}
+
+// inRenderFailureBackoff returns whether rendering the entries of the object
+// is skipped for this pass because they have kept failing to render.
+func (r *entryReconciler) inRenderFailureBackoff(obj metav1.Object, now time.Time) bool {
+	s, ok := r.renderFailures[obj.GetUID()]
+	switch {
+	case !ok:
+		return false
+	case s.generation != obj.GetGeneration():
+		delete(r.renderFailures, obj.GetUID())
+		return false
+	}
+	return now.Before(s.retryAt)
+}
+
+// recordRenderOutcome tracks whether every entry of the object failed to
+// render in this pass. Once they have for RenderFailureBackoffAfter
+// consecutive passes, the object is backed off, doubling the backoff on each
+// further failing attempt up to a maximum. A rendered entry resets the
+// tracking.
+func (r *entryReconciler) recordRenderOutcome(log logr.Logger, obj metav1.Object, rendered, failed int, now time.Time) {
+	if r.config.RenderFailureBackoffAfter <= 0 {
+		return
+	}
+	if rendered > 0 || failed == 0 {
+		delete(r.renderFailures, obj.GetUID())
+		return
+	}
+
+	s, ok := r.renderFailures[obj.GetUID()]
+	if !ok || s.generation != obj.GetGeneration() {
+		s = &renderFailureState{generation: obj.GetGeneration()}
+		r.renderFailures[obj.GetUID()] = s
+	}
+	s.passes++
+	if s.passes < r.config.RenderFailureBackoffAfter {
+		return
+	}
+	if s.backoff == 0 {
+		s.backoff = defaultRenderFailureBackoff
+	} else {
+		s.backoff = min(2*s.backoff, maxRenderFailureBackoff)
+	}
+	s.retryAt = now.Add(s.backoff)
+	log.Info("All entries failed to render; backing off", "passes", s.passes, "backoff", s.backoff.String())
+}
+
+// pruneRenderFailures forgets the render failures of objects that no longer
+// exist.
+func (r *entryReconciler) pruneRenderFailures(objects map[types.UID]struct{}) {
+	for uid := range r.renderFailures {
+		if _, ok := objects[uid]; !ok {
+			delete(r.renderFailures, uid)
+		}
+	}
+}
+
+func objectUIDs(clusterSPIFFEIDs []*ClusterSPIFFEID, spiffeIDs []*SPIFFEID) map[types.UID]struct{} {
+	uids := make(map[types.UID]struct{}, len(clusterSPIFFEIDs)+len(spiffeIDs))
+	for _, clusterSPIFFEID := range clusterSPIFFEIDs {
+		uids[clusterSPIFFEID.UID] = struct{}{}
+	}
+	for _, spiffeID := range spiffeIDs {
+		uids[spiffeID.UID] = struct{}{}
+	}
+	return uids
+}
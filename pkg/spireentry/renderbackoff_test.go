@@ -0,0 +1,127 @@
+package spireentry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestRenderFailureBackoff(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "broken", UID: "broken-uid", Generation: 1},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "not-a-spiffe-id",
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, spirev1alpha1.AddToScheme(scheme))
+	// Pods are listed once per pass that attempts to render the
+	// ClusterSPIFFEID.
+	attempts := 0
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"), newTestPod("default", "pod", "node", nil)).
+		WithStatusSubresource(&spirev1alpha1.ClusterSPIFFEID{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				if _, ok := list.(*corev1.PodList); ok {
+					attempts++
+				}
+				return c.List(ctx, list, opts...)
+			},
+		}).
+		Build()
+
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		K8sClient:                 k8sClient,
+		EntryClient:               newEntryClient(),
+		RenderFailureBackoffAfter: 2,
+	})
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	getRenderFailures := func() int {
+		actual := new(spirev1alpha1.ClusterSPIFFEID)
+		require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), actual))
+		return actual.Status.Stats.PodEntryRenderFailures
+	}
+
+	t.Log("Rendering is attempted every pass until the threshold is reached")
+	r.reconcile(ctx)
+	require.Equal(t, 1, attempts)
+	require.Equal(t, 1, getRenderFailures())
+	r.reconcile(ctx)
+	require.Equal(t, 2, attempts)
+	require.Contains(t, r.renderFailures, clusterSPIFFEID.UID)
+	require.Equal(t, defaultRenderFailureBackoff, r.renderFailures[clusterSPIFFEID.UID].backoff)
+
+	t.Log("Rendering is not attempted while backed off and the status is kept")
+	r.reconcile(ctx)
+	r.reconcile(ctx)
+	require.Equal(t, 2, attempts)
+	require.Equal(t, 1, getRenderFailures())
+
+	t.Log("Once the backoff expires, rendering is attempted again and the backoff doubles")
+	r.renderFailures[clusterSPIFFEID.UID].retryAt = time.Now().Add(-time.Second)
+	r.reconcile(ctx)
+	require.Equal(t, 3, attempts)
+	require.Equal(t, 2*defaultRenderFailureBackoff, r.renderFailures[clusterSPIFFEID.UID].backoff)
+	r.reconcile(ctx)
+	require.Equal(t, 3, attempts)
+
+	t.Log("A rendered entry resets the backoff")
+	r.renderFailures[clusterSPIFFEID.UID].retryAt = time.Now().Add(-time.Second)
+	fixed := new(spirev1alpha1.ClusterSPIFFEID)
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), fixed))
+	fixed.Spec.SPIFFEIDTemplate = "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}"
+	require.NoError(t, k8sClient.Update(ctx, fixed))
+	r.reconcile(ctx)
+	require.Equal(t, 4, attempts)
+	require.Equal(t, 0, getRenderFailures())
+	require.Empty(t, r.renderFailures)
+}
+
+func TestInRenderFailureBackoff(t *testing.T) {
+	now := time.Now()
+	obj := &metav1.ObjectMeta{UID: "uid", Generation: 1}
+	r := newEntryReconciler(ReconcilerConfig{RenderFailureBackoffAfter: 1})
+	logger := logrtesting.NewTestLogger(t)
+
+	require.False(t, r.inRenderFailureBackoff(obj, now))
+	r.recordRenderOutcome(logger, obj, 0, 3, now)
+	require.True(t, r.inRenderFailureBackoff(obj, now))
+	require.False(t, r.inRenderFailureBackoff(obj, now.Add(defaultRenderFailureBackoff)))
+
+	t.Log("The backoff is capped")
+	for i := 0; i < 10; i++ {
+		r.recordRenderOutcome(logger, obj, 0, 3, now)
+	}
+	require.Equal(t, maxRenderFailureBackoff, r.renderFailures[obj.UID].backoff)
+
+	t.Log("A new generation resets the backoff")
+	obj.Generation = 2
+	require.False(t, r.inRenderFailureBackoff(obj, now))
+	require.Empty(t, r.renderFailures)
+
+	t.Log("Objects without failures are not tracked")
+	r.recordRenderOutcome(logger, obj, 0, 0, now)
+	require.Empty(t, r.renderFailures)
+
+	t.Log("Tracking is disabled by default")
+	r = newEntryReconciler(ReconcilerConfig{})
+	r.recordRenderOutcome(logger, obj, 0, 3, now)
+	require.False(t, r.inRenderFailureBackoff(obj, now))
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// recordEntryRevision records the entry as the revision last written, or
+// found up to date, by the controller, keyed by its ID. Nothing is written to
+// the SPIRE server.
+func (r *entryReconciler) recordEntryRevision(entry spireapi.Entry) {
+	if !r.config.EntryRevisions || entry.ID == "" {
+		return
+	}
+	r.entryRevisions[entry.ID] = entry
+}
+
+// detectTamperedEntries logs and counts the current entries that differ from
+// their recorded revision, i.e. entries modified outside of the controller
+// since it last wrote them. They are compared like declared entries are, so
+// that fields the server does not support and TTL differences within the TTL
+// tolerance are not reported. Such entries are restored by the regular
+// update, which records them again. The revisions of entries that no longer
+// exist are dropped.
+func (r *entryReconciler) detectTamperedEntries(ctx context.Context, currentEntries []spireapi.Entry) {
+	if !r.config.EntryRevisions {
+		return
+	}
+	log := log.FromContext(ctx)
+	revisions := make(map[string]spireapi.Entry, len(r.entryRevisions))
+	for _, entry := range currentEntries {
+		revision, ok := r.entryRevisions[entry.ID]
+		if !ok {
+			continue
+		}
+		if makeEntryKey(revision) != makeEntryKey(entry) ||
+			len(getOutdatedEntryFields(revision, entry, r.unsupportedFieldsFor(entry.SPIFFEID.TrustDomain()), r.config.TTLTolerance)) > 0 {
+			log.Info("Detected SPIRE entry modified outside of the controller", entryLogFields(entry)...)
+			r.promCounter[metrics.TamperedEntries].Add(1)
+			continue
+		}
+		revisions[entry.ID] = revision
+	}
+	r.entryRevisions = revisions
+}
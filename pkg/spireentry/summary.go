@@ -0,0 +1,88 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// PassSummary is the summary of a reconcile pass written, as a line of JSON,
+// to the summary writer.
+type PassSummary struct {
+	// Time is when the pass finished.
+	Time time.Time `json:"time"`
+
+	// ToCreate, ToUpdate and ToDelete are how many entries the pass set out
+	// to create, update and delete.
+	ToCreate int `json:"toCreate"`
+	ToUpdate int `json:"toUpdate"`
+	ToDelete int `json:"toDelete"`
+
+	// Objects are the outcomes of the objects declaring entries.
+	Objects []ObjectSummary `json:"objects"`
+}
+
+// ObjectSummary is the outcome of a reconcile pass for an object declaring
+// entries.
+type ObjectSummary struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+
+	// Status is the status of the object as of the pass.
+	Status any `json:"status"`
+}
+
+// writeSummary writes the summary of the pass to the summary writer, if
+// configured.
+func (r *entryReconciler) writeSummary(ctx context.Context, summary PassSummary, clusterStaticEntries []*ClusterStaticEntry, clusterSPIFFEIDs []*ClusterSPIFFEID, spiffeIDs []*SPIFFEID) {
+	if r.config.SummaryWriter == nil {
+		return
+	}
+	summary.Time = time.Now()
+	summary.Objects = make([]ObjectSummary, 0, len(clusterStaticEntries)+len(clusterSPIFFEIDs)+len(spiffeIDs))
+	for _, clusterStaticEntry := range clusterStaticEntries {
+		summary.Objects = append(summary.Objects, ObjectSummary{
+			Kind:   "ClusterStaticEntry",
+			Name:   clusterStaticEntry.Name,
+			Status: clusterStaticEntry.NextStatus,
+		})
+	}
+	for _, clusterSPIFFEID := range clusterSPIFFEIDs {
+		summary.Objects = append(summary.Objects, ObjectSummary{
+			Kind:   "ClusterSPIFFEID",
+			Name:   clusterSPIFFEID.Name,
+			Status: clusterSPIFFEID.NextStatus,
+		})
+	}
+	for _, spiffeID := range spiffeIDs {
+		summary.Objects = append(summary.Objects, ObjectSummary{
+			Kind:      "SPIFFEID",
+			Namespace: spiffeID.Namespace,
+			Name:      spiffeID.Name,
+			Status:    spiffeID.NextStatus,
+		})
+	}
+	// The encoder terminates each summary with a newline.
+	if err := json.NewEncoder(r.config.SummaryWriter).Encode(summary); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to write reconcile summary")
+	}
+}
@@ -0,0 +1,76 @@
+package spireentry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestReconcileSummary(t *testing.T) {
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s:ns:static"},
+		},
+	}
+	invalidStaticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "invalid"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/invalid",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s"},
+		},
+	}
+
+	summaries := new(bytes.Buffer)
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:   newEntryClient(),
+		SummaryWriter: summaries,
+	}, staticEntry, invalidStaticEntry)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+	r.reconcile(ctx)
+
+	// Each pass is summarized on a line of its own.
+	var lines []map[string]any
+	scanner := bufio.NewScanner(summaries)
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		require.NotEmpty(t, line["time"])
+		delete(line, "time")
+		lines = append(lines, line)
+	}
+	require.NoError(t, scanner.Err())
+
+	newObjects := func(set bool) []any {
+		return []any{
+			map[string]any{
+				"kind":   "ClusterStaticEntry",
+				"name":   "invalid",
+				"status": map[string]any{"rendered": false, "masked": false, "set": false},
+			},
+			map[string]any{
+				"kind":   "ClusterStaticEntry",
+				"name":   "static",
+				"status": map[string]any{"rendered": true, "masked": false, "set": set, "entryID": "id-1"},
+			},
+		}
+	}
+	// The entry is only set on the first pass; the second has nothing to do.
+	require.Equal(t, []map[string]any{
+		{"toCreate": float64(1), "toUpdate": float64(0), "toDelete": float64(0), "objects": newObjects(true)},
+		{"toCreate": float64(0), "toUpdate": float64(0), "toDelete": float64(0), "objects": newObjects(false)},
+	}, lines)
+}
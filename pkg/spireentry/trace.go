@@ -0,0 +1,343 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Outcomes of the pods selected by a traced ClusterSPIFFEID.
+const (
+	TracePodExcluded        = "Excluded"
+	TracePodSkipped         = "Skipped"
+	TracePodAwaitingIP      = "AwaitingIP"
+	TracePodTooYoung        = "TooYoung"
+	TracePodFallbackSkipped = "FallbackSkipped"
+	TracePodRenderFailed    = "RenderFailed"
+	TracePodRendered        = "Rendered"
+)
+
+// Actions that would be taken against SPIRE for the entries of a traced
+// ClusterSPIFFEID.
+const (
+	TraceEntryCreate   = "Create"
+	TraceEntryUpdate   = "Update"
+	TraceEntryNone     = "None"
+	TraceEntryMasked   = "Masked"
+	TraceEntryRejected = "Rejected"
+)
+
+// errTraceObjectNotFound is returned when the traced ClusterSPIFFEID does not
+// exist or is not handled by this controller.
+var errTraceObjectNotFound = errors.New("ClusterSPIFFEID not found")
+
+// TraceReport describes what a reconcile does for a single ClusterSPIFFEID.
+type TraceReport struct {
+	ClusterSPIFFEID string                             `json:"clusterSPIFFEID"`
+	ParseError      string                             `json:"parseError,omitempty"`
+	Namespaces      []TraceNamespace                   `json:"namespaces,omitempty"`
+	Pods            []TracePod                         `json:"pods,omitempty"`
+	Stats           spirev1alpha1.ClusterSPIFFEIDStats `json:"stats"`
+}
+
+// TraceNamespace is a namespace selected by the traced ClusterSPIFFEID.
+type TraceNamespace struct {
+	Name    string `json:"name"`
+	Ignored bool   `json:"ignored,omitempty"`
+}
+
+// TracePod is a pod selected by the traced ClusterSPIFFEID.
+type TracePod struct {
+	Namespace string      `json:"namespace"`
+	Name      string      `json:"name"`
+	Outcome   string      `json:"outcome"`
+	Error     string      `json:"error,omitempty"`
+	Entry     *TraceEntry `json:"entry,omitempty"`
+}
+
+// TraceEntry is an entry rendered for a pod and the action that would be
+// taken for it against SPIRE.
+type TraceEntry struct {
+	ID             string   `json:"id,omitempty"`
+	SPIFFEID       string   `json:"spiffeID"`
+	ParentID       string   `json:"parentID"`
+	Selectors      []string `json:"selectors"`
+	DNSNames       []string `json:"dnsNames,omitempty"`
+	Action         string   `json:"action"`
+	OutdatedFields []string `json:"outdatedFields,omitempty"`
+}
+
+// clusterSPIFFEIDTrace collects the trace of a single ClusterSPIFFEID while
+// its entries state is added. Its methods are no-ops on a nil trace or for
+// other ClusterSPIFFEIDs.
+type clusterSPIFFEIDTrace struct {
+	report TraceReport
+
+	// entries holds the entries rendered for the traced pods, by index in
+	// the report. Their action is resolved once the state is complete.
+	entries map[int]spireapi.Entry
+}
+
+func (t *clusterSPIFFEIDTrace) traces(clusterSPIFFEID *ClusterSPIFFEID) bool {
+	return t != nil && clusterSPIFFEID.Name == t.report.ClusterSPIFFEID
+}
+
+func (t *clusterSPIFFEIDTrace) parseError(clusterSPIFFEID *ClusterSPIFFEID, err error) {
+	if t.traces(clusterSPIFFEID) {
+		t.report.ParseError = err.Error()
+	}
+}
+
+func (t *clusterSPIFFEIDTrace) namespace(clusterSPIFFEID *ClusterSPIFFEID, namespace *corev1.Namespace, ignored bool) {
+	if t.traces(clusterSPIFFEID) {
+		t.report.Namespaces = append(t.report.Namespaces, TraceNamespace{Name: namespace.Name, Ignored: ignored})
+	}
+}
+
+func (t *clusterSPIFFEIDTrace) pod(clusterSPIFFEID *ClusterSPIFFEID, pod *corev1.Pod, outcome string, err error, entry *spireapi.Entry) {
+	if !t.traces(clusterSPIFFEID) {
+		return
+	}
+	tracePod := TracePod{
+		Namespace: pod.Namespace,
+		Name:      pod.Name,
+		Outcome:   outcome,
+	}
+	if err != nil {
+		tracePod.Error = err.Error()
+	}
+	if entry != nil {
+		t.entries[len(t.report.Pods)] = *entry
+	}
+	t.report.Pods = append(t.report.Pods, tracePod)
+}
+
+// ReconcilerWithTraceHandler returns the entry reconciler, as Reconciler
+// does, along with a handler that traces its reconcile of the
+// ClusterSPIFFEID named by the "name" query parameter and responds with a
+// JSON TraceReport. The trace does not modify SPIRE or Kubernetes state.
+// Entries are matched as the reconciler matches them, including with the
+// entry fields it found the SPIRE server not to support, and are checked
+// against the entry policy, if any.
+func ReconcilerWithTraceHandler(config ReconcilerConfig) (reconciler.Reconciler, http.Handler) {
+	r := newEntryReconciler(config)
+	return r.newReconciler(), traceHandler(config, r.knownUnsupportedFields)
+}
+
+func traceHandler(config ReconcilerConfig, knownUnsupportedFields func() map[spiffeid.TrustDomain]map[spireapi.Field]struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		name := req.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "name query parameter is required", http.StatusBadRequest)
+			return
+		}
+		report, err := traceClusterSPIFFEID(req.Context(), config, knownUnsupportedFields(), name)
+		switch {
+		case errors.Is(err, errTraceObjectNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case err != nil:
+			log.FromContext(req.Context()).Error(err, "Failed to trace ClusterSPIFFEID", clusterSPIFFEIDLogKey, name)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// traceClusterSPIFFEID runs a dry reconcile with a dedicated reconciler,
+// tracing the named ClusterSPIFFEID. Every ClusterSPIFFEID and
+// ClusterStaticEntry is rendered since they can mask or take precedence over
+// the entries of the traced one. Probing the SPIRE server for unsupported
+// fields is skipped since it creates an entry; the unsupported fields known
+// to the running reconciler, if any, are used instead.
+func traceClusterSPIFFEID(ctx context.Context, config ReconcilerConfig, unsupportedFields map[spiffeid.TrustDomain]map[spireapi.Field]struct{}, name string) (*TraceReport, error) {
+	// Events must not be recorded by a trace.
+	config.EventRecorder = nil
+	r := newEntryReconciler(config)
+	for td, fields := range unsupportedFields {
+		r.unsupportedFields[td] = fields
+	}
+	// Nor must the metrics be updated; the trace counts into throwaway ones.
+	r.promCounter = make(map[string]prometheus.Counter, len(metrics.PromCounters))
+	for name := range metrics.PromCounters {
+		r.promCounter[name] = prometheus.NewCounter(prometheus.CounterOpts{Name: name})
+	}
+	r.promStaticEntryRenderFailures = prometheus.NewCounterVec(prometheus.CounterOpts{Name: metrics.StaticEntryRenderFailures}, []string{"field"})
+	r.trace = &clusterSPIFFEIDTrace{
+		report:  TraceReport{ClusterSPIFFEID: name},
+		entries: make(map[int]spireapi.Entry),
+	}
+
+	clusterSPIFFEIDs, err := r.listClusterSPIFFEIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ClusterSPIFFEIDs: %w", err)
+	}
+	var traced *ClusterSPIFFEID
+	for _, clusterSPIFFEID := range clusterSPIFFEIDs {
+		if clusterSPIFFEID.Name == name {
+			traced = clusterSPIFFEID
+		}
+	}
+	if traced == nil {
+		return nil, errTraceObjectNotFound
+	}
+
+	currentEntries, _, _, err := r.listEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SPIRE entries: %w", err)
+	}
+	state := make(entriesState)
+	for _, entry := range currentEntries {
+		state.AddCurrent(entry)
+	}
+	if r.config.Reconcile.ClusterStaticEntries {
+		clusterStaticEntries, err := r.listClusterStaticEntries(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list ClusterStaticEntries: %w", err)
+		}
+		r.addClusterStaticEntryEntriesState(ctx, state, clusterStaticEntries)
+	}
+	r.addClusterSPIFFEIDEntriesState(ctx, state, clusterSPIFFEIDs)
+	if r.config.Reconcile.SPIFFEIDs {
+		spiffeIDs, err := r.listSPIFFEIDs(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list SPIFFEIDs: %w", err)
+		}
+		r.addSPIFFEIDEntriesState(ctx, state, spiffeIDs)
+	}
+	r.prepareDeclaredEntries(ctx, state)
+
+	// Match the entries of the traced pods as the reconcile does, once per
+	// entry state.
+	type entryMatch struct {
+		preferredEntry declaredEntry
+		currentEntry   *spireapi.Entry
+		outdatedFields []spireapi.Field
+	}
+	matches := make(map[entryKey]entryMatch)
+	traceEntries := make(map[int]*TraceEntry, len(r.trace.entries))
+	traceKeys := make(map[int]entryKey, len(r.trace.entries))
+	var toCreate, toUpdate []declaredEntry
+	for i, entry := range r.trace.entries {
+		key := makeEntryKey(entry)
+		s := state[key]
+		if len(s.Declared) == 0 {
+			// Dropped for being out of the parent ID scope.
+			continue
+		}
+		match, ok := matches[key]
+		if !ok {
+			match.preferredEntry, match.currentEntry, match.outdatedFields = r.matchEntryState(s)
+			matches[key] = match
+		}
+		if match.preferredEntry.By == traced {
+			// The DNS names may have been deduplicated, and the DNS names
+			// and federated trust domains merged from masked entries.
+			entry = match.preferredEntry.Entry
+		}
+		traceEntry := &TraceEntry{
+			SPIFFEID:  entry.SPIFFEID.String(),
+			ParentID:  entry.ParentID.String(),
+			Selectors: selectorStrings(entry.Selectors),
+			DNSNames:  entry.DNSNames,
+		}
+		switch {
+		case match.preferredEntry.By != traced:
+			traceEntry.Action = TraceEntryMasked
+		case match.currentEntry == nil:
+			traceEntry.Action = TraceEntryCreate
+			if !ok {
+				toCreate = append(toCreate, match.preferredEntry)
+			}
+		default:
+			traceEntry.ID = match.currentEntry.ID
+			traceEntry.Action = TraceEntryNone
+			if len(match.outdatedFields) > 0 {
+				traceEntry.Action = TraceEntryUpdate
+				for _, field := range match.outdatedFields {
+					traceEntry.OutdatedFields = append(traceEntry.OutdatedFields, string(field))
+				}
+				if !ok {
+					toUpdate = append(toUpdate, match.preferredEntry)
+				}
+			}
+		}
+		traceEntries[i] = traceEntry
+		traceKeys[i] = key
+	}
+
+	// Entries rejected by the entry policy are not set, as in the
+	// reconcile. The rejections are counted into the throwaway metrics.
+	if r.config.EntryPolicy != nil {
+		toCreate, toUpdate = r.applyEntryPolicy(ctx, toCreate, toUpdate)
+		allowed := make(map[entryKey]struct{}, len(toCreate)+len(toUpdate))
+		for _, declaredEntry := range append(toCreate, toUpdate...) {
+			allowed[makeEntryKey(declaredEntry.Entry)] = struct{}{}
+		}
+		for i, traceEntry := range traceEntries {
+			if traceEntry.Action != TraceEntryCreate && traceEntry.Action != TraceEntryUpdate {
+				continue
+			}
+			if _, ok := allowed[traceKeys[i]]; !ok {
+				traceEntry.Action = TraceEntryRejected
+			}
+		}
+	}
+
+	r.trace.report.Stats = traced.NextStatus.Stats
+	for i, traceEntry := range traceEntries {
+		if traceEntry.Action == TraceEntryMasked {
+			r.trace.report.Stats.EntriesMasked++
+		} else {
+			r.trace.report.Stats.EntriesToSet++
+		}
+		r.trace.report.Pods[i].Entry = traceEntry
+	}
+
+	sort.Slice(r.trace.report.Pods, func(i, j int) bool {
+		a, b := r.trace.report.Pods[i], r.trace.report.Pods[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return &r.trace.report, nil
+}
+
+func selectorStrings(selectors []spireapi.Selector) []string {
+	out := make([]string, 0, len(selectors))
+	for _, selector := range selectors {
+		out = append(out, selector.Type+":"+selector.Value)
+	}
+	return out
+}
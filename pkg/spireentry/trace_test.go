@@ -0,0 +1,317 @@
+package spireentry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/entrypolicy"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestTraceClusterSPIFFEID(t *testing.T) {
+	now := time.Now()
+	spiffeIDTemplate := "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}"
+	traced := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload", CreationTimestamp: metav1.NewTime(now)},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: spiffeIDTemplate,
+		},
+	}
+	// The older ClusterSPIFFEID is preferred, masking the entry of the
+	// traced one for the pods it selects.
+	older := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "older", CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: spiffeIDTemplate,
+			PodSelector:      &metav1.LabelSelector{MatchLabels: map[string]string{"app": "masked"}},
+		},
+	}
+
+	parentID := spiffeid.RequireFromString("spiffe://example.org/spire/agent/k8s_psat/test/node-uid")
+	currentEntry := func(id, podName string, ttl time.Duration) spireapi.Entry {
+		return spireapi.Entry{
+			ID:          id,
+			SPIFFEID:    spiffeid.RequireFromString("spiffe://example.org/ns/default/pod/" + podName),
+			ParentID:    parentID,
+			Selectors:   []spireapi.Selector{{Type: "k8s", Value: "pod-uid:" + podName + "-uid"}},
+			X509SVIDTTL: ttl,
+		}
+	}
+	// Left behind by the unsupported fields probe of an earlier run; only
+	// the reconcile cleans those up.
+	probeID := spiffeid.RequireFromString("spiffe://example.org/spire-controller-manager/unsupported-fields-test/crashed")
+	probeEntry := spireapi.Entry{
+		ID:        "probe",
+		SPIFFEID:  probeID,
+		ParentID:  probeID,
+		Selectors: []spireapi.Selector{{Type: "a", Value: "1"}},
+	}
+	entryClient := newEntryClient(
+		currentEntry("current-id", "current", 0),
+		currentEntry("stale-id", "stale", time.Minute),
+		probeEntry,
+	)
+	entriesBefore := entryClient.getEntries()
+
+	config := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient:                entryClient,
+		IgnoreNamespaces:           []*regexp.Regexp{regexp.MustCompile("^kube-system$")},
+		GlobalPodExclusionSelector: labels.SelectorFromSet(labels.Set{"excluded": "true"}),
+	}, traced, older, newTestNamespace("default"), newTestNamespace("kube-system"), newTestNode("node"),
+		newTestPod("default", "current", "node", nil),
+		newTestPod("default", "new", "node", nil),
+		newTestPod("default", "stale", "node", nil),
+		newTestPod("default", "excluded", "node", map[string]string{"excluded": "true"}),
+		newTestPod("default", "masked", "node", map[string]string{"app": "masked"}),
+		newTestPod("kube-system", "system", "node", nil),
+	).config
+
+	newTraceEntry := func(podName, id, action string, outdatedFields ...string) *TraceEntry {
+		return &TraceEntry{
+			ID:             id,
+			SPIFFEID:       "spiffe://example.org/ns/default/pod/" + podName,
+			ParentID:       parentID.String(),
+			Selectors:      []string{"k8s:pod-uid:" + podName + "-uid"},
+			Action:         action,
+			OutdatedFields: outdatedFields,
+		}
+	}
+	expectReport := &TraceReport{
+		ClusterSPIFFEID: "workload",
+		Namespaces: []TraceNamespace{
+			{Name: "default"},
+			{Name: "kube-system", Ignored: true},
+		},
+		Pods: []TracePod{
+			{Namespace: "default", Name: "current", Outcome: TracePodRendered, Entry: newTraceEntry("current", "current-id", TraceEntryNone)},
+			{Namespace: "default", Name: "excluded", Outcome: TracePodExcluded},
+			{Namespace: "default", Name: "masked", Outcome: TracePodRendered, Entry: newTraceEntry("masked", "", TraceEntryMasked)},
+			{Namespace: "default", Name: "new", Outcome: TracePodRendered, Entry: newTraceEntry("new", "", TraceEntryCreate)},
+			{Namespace: "default", Name: "stale", Outcome: TracePodRendered, Entry: newTraceEntry("stale", "stale-id", TraceEntryUpdate, "x509SVIDTTL")},
+		},
+		Stats: spirev1alpha1.ClusterSPIFFEIDStats{
+			NamespacesSelected: 2,
+			NamespacesIgnored:  1,
+			PodsSelected:       5,
+			PodsExcluded:       1,
+			EntriesMasked:      1,
+			EntriesToSet:       3,
+		},
+	}
+
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	t.Run("report", func(t *testing.T) {
+		report, err := traceClusterSPIFFEID(ctx, config, nil, "workload")
+		require.NoError(t, err)
+		require.Equal(t, expectReport, report)
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		_, handler := ReconcilerWithTraceHandler(config)
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+
+		resp, err := http.Get(server.URL + "?name=workload")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		report := new(TraceReport)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(report))
+		require.Equal(t, expectReport, report)
+
+		for query, expectStatus := range map[string]int{
+			"":              http.StatusBadRequest,
+			"?name=missing": http.StatusNotFound,
+		} {
+			resp, err := http.Get(server.URL + query)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, expectStatus, resp.StatusCode, "query %q", query)
+		}
+	})
+
+	// Tracing does not touch SPIRE state, nor probe it.
+	require.Equal(t, entriesBefore, entryClient.getEntries())
+	require.Zero(t, entryClient.createCalls)
+	require.Zero(t, entryClient.updateCalls)
+	require.Zero(t, entryClient.deleteCalls)
+	require.Zero(t, entryClient.getUnsupportedFieldsCalls)
+}
+
+func TestTraceUsesKnownUnsupportedFields(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			Hint:             "workload",
+		},
+	}
+	// The server does not support hints, so the current entry has none.
+	entryClient := newEntryClient(spireapi.Entry{
+		ID:        "current-id",
+		SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/ns/default/pod/current"),
+		ParentID:  spiffeid.RequireFromString("spiffe://example.org/spire/agent/k8s_psat/test/node-uid"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "pod-uid:current-uid"}},
+	})
+	entryClient.unsupportedFields[spireapi.HintField] = struct{}{}
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"),
+		newTestPod("default", "current", "node", nil),
+	)
+	handler := traceHandler(r.config, r.knownUnsupportedFields)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	trace := func() *TraceEntry {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/?name=workload", nil).WithContext(ctx))
+		require.Equal(t, http.StatusOK, recorder.Code)
+		report := new(TraceReport)
+		require.NoError(t, json.NewDecoder(recorder.Body).Decode(report))
+		require.Len(t, report.Pods, 1)
+		return report.Pods[0].Entry
+	}
+
+	t.Log("Before the reconciler probed the server, the hint looks outdated")
+	entry := trace()
+	require.Equal(t, TraceEntryUpdate, entry.Action)
+	require.Equal(t, []string{"hint"}, entry.OutdatedFields)
+
+	t.Log("Once it has, the entry is matched as the reconcile matches it")
+	r.reconcile(ctx)
+	require.Zero(t, entryClient.updateCalls)
+	entry = trace()
+	require.Equal(t, TraceEntryNone, entry.Action)
+	require.Empty(t, entry.OutdatedFields)
+}
+
+func TestTraceAppliesPolicies(t *testing.T) {
+	spiffeIDTemplate := "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}"
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	t.Run("entry policy", func(t *testing.T) {
+		clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+			Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: spiffeIDTemplate,
+			},
+		}
+		// The policy rejects the entry of the "rejected" pod.
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Input struct {
+					Entries []struct {
+						SPIFFEID string `json:"spiffeID"`
+					} `json:"entries"`
+				} `json:"input"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			result := make([]entrypolicy.Decision, 0, len(req.Input.Entries))
+			for _, entry := range req.Input.Entries {
+				result = append(result, entrypolicy.Decision{Allowed: !strings.HasSuffix(entry.SPIFFEID, "/rejected")})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"result": result})
+		}))
+		t.Cleanup(server.Close)
+
+		config := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient: newEntryClient(),
+			EntryPolicy: entrypolicy.NewClient(entrypolicy.Config{URL: server.URL}),
+		}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"),
+			newTestPod("default", "allowed", "node", nil),
+			newTestPod("default", "rejected", "node", nil),
+		).config
+		rejectionsBefore := testutil.ToFloat64(metrics.PromCounters[metrics.EntryPolicyRejections])
+
+		report, err := traceClusterSPIFFEID(ctx, config, nil, "workload")
+		require.NoError(t, err)
+		require.Len(t, report.Pods, 2)
+		require.Equal(t, TraceEntryCreate, report.Pods[0].Entry.Action)
+		require.Equal(t, TraceEntryRejected, report.Pods[1].Entry.Action)
+		require.Equal(t, 1, report.Stats.EntryFailures)
+		require.Equal(t, rejectionsBefore, testutil.ToFloat64(metrics.PromCounters[metrics.EntryPolicyRejections]))
+	})
+
+	t.Run("min TTL policy", func(t *testing.T) {
+		clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+			Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: spiffeIDTemplate,
+				TTL:              metav1.Duration{Duration: time.Minute},
+			},
+		}
+		config := newTestEntryReconciler(t, ReconcilerConfig{
+			EntryClient:  newEntryClient(),
+			MinTTLPolicy: &MinTTLPolicy{MinTTL: time.Hour, Action: MinTTLActionReject},
+		}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"),
+			newTestPod("default", "workload", "node", nil),
+		).config
+
+		// The rejected entry fails to render, so no action is reported.
+		report, err := traceClusterSPIFFEID(ctx, config, nil, "workload")
+		require.NoError(t, err)
+		require.Len(t, report.Pods, 1)
+		require.Equal(t, TracePodRenderFailed, report.Pods[0].Outcome)
+		require.Nil(t, report.Pods[0].Entry)
+		require.Zero(t, report.Stats.EntriesToSet)
+	})
+}
+
+func TestTraceDoesNotUpdateMetrics(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	// Fails to render, which the reconcile counts.
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"k8s"},
+		},
+	}
+	config := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: newEntryClient(),
+	}, clusterSPIFFEID, staticEntry, newTestNamespace("default"), newTestNode("node"),
+		newTestPod("default", "workload", "node", nil),
+	).config
+
+	counters := func() map[string]float64 {
+		out := map[string]float64{
+			metrics.StaticEntryRenderFailures: testutil.ToFloat64(metrics.PromStaticEntryRenderFailures.WithLabelValues("selectors")),
+		}
+		for name, counter := range metrics.PromCounters {
+			out[name] = testutil.ToFloat64(counter)
+		}
+		return out
+	}
+	before := counters()
+
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	_, err := traceClusterSPIFFEID(ctx, config, nil, "workload")
+	require.NoError(t, err)
+	require.Equal(t, before, counters())
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// TrustDomainMismatch describes a ClusterSPIFFEID whose SPIFFE ID template
+// renders into a trust domain other than the configured one.
+type TrustDomainMismatch struct {
+	ClusterSPIFFEID string
+	TrustDomain     string
+}
+
+// ValidateTrustDomains lists the ClusterSPIFFEIDs handled by the controller
+// and warns about those whose SPIFFE ID template targets a trust domain other
+// than the configured one. Every entry for such a ClusterSPIFFEID would
+// otherwise fail to render at reconcile time. The template is rendered
+// against a representative pod and node, so templates whose trust domain
+// depends on the pod or node can not be checked reliably.
+func ValidateTrustDomains(ctx context.Context, config ReconcilerConfig, c client.Reader) ([]TrustDomainMismatch, error) {
+	log := log.FromContext(ctx)
+	r := &entryReconciler{config: config}
+
+	var list spirev1alpha1.ClusterSPIFFEIDList
+	if err := c.List(ctx, &list); err != nil {
+		return nil, err
+	}
+
+	data := representativeTemplateData(config)
+
+	var mismatches []TrustDomainMismatch
+	for i := range list.Items {
+		clusterSPIFFEID := &list.Items[i]
+		if !r.reconcileClass(clusterSPIFFEID.Spec.ClassName) {
+			continue
+		}
+		log := log.WithValues(clusterSPIFFEIDLogKey, objectName(clusterSPIFFEID))
+
+		spec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&clusterSPIFFEID.Spec)
+		if err != nil {
+			log.V(1).Info("Skipping trust domain validation of invalid ClusterSPIFFEID", "reason", err)
+			continue
+		}
+		rendered, err := renderTemplate(spec.SPIFFEIDTemplate, data)
+		if err != nil {
+			log.V(1).Info("Skipping trust domain validation of ClusterSPIFFEID that does not render for a representative pod", "reason", err)
+			continue
+		}
+		td, err := trustDomainFromRenderedID(rendered)
+		if err != nil {
+			log.V(1).Info("Skipping trust domain validation of ClusterSPIFFEID that does not render for a representative pod", "reason", err)
+			continue
+		}
+		if err := checkTrustDomain(td, config.TrustDomain); err != nil {
+			log.Error(nil, "ClusterSPIFFEID targets a trust domain other than the configured one; no entries will be created for it", "reason", err)
+			mismatches = append(mismatches, TrustDomainMismatch{
+				ClusterSPIFFEID: clusterSPIFFEID.Name,
+				TrustDomain:     td.Name(),
+			})
+		}
+	}
+	return mismatches, nil
+}
+
+func representativeTemplateData(config ReconcilerConfig) *templateData {
+	return &templateData{
+		TrustDomain:   config.TrustDomain.Name(),
+		ClusterName:   config.ClusterName,
+		ClusterDomain: config.ClusterDomain,
+		PodMeta: &metav1.ObjectMeta{
+			Name:      "pod",
+			Namespace: "default",
+			UID:       "pod-uid",
+		},
+		PodSpec: &corev1.PodSpec{
+			ServiceAccountName: "default",
+			NodeName:           "node",
+		},
+		NodeMeta: &metav1.ObjectMeta{
+			Name: "node",
+			UID:  "node-uid",
+		},
+		NodeSpec:            &corev1.NodeSpec{},
+		InitContainers:      map[string]*corev1.Container{},
+		EphemeralContainers: map[string]*corev1.EphemeralContainer{},
+	}
+}
+
+// trustDomainFromRenderedID extracts the trust domain from a rendered SPIFFE
+// ID. Only the trust domain is validated since the path of an ID rendered for
+// a representative pod may hold placeholder values.
+func trustDomainFromRenderedID(rendered string) (spiffeid.TrustDomain, error) {
+	rest, ok := strings.CutPrefix(rendered, "spiffe://")
+	if !ok {
+		return spiffeid.TrustDomain{}, errors.New("scheme is missing or invalid")
+	}
+	name, _, _ := strings.Cut(rest, "/")
+	td, err := spiffeid.TrustDomainFromString(name)
+	if err != nil {
+		return spiffeid.TrustDomain{}, fmt.Errorf("invalid trust domain: %w", err)
+	}
+	return td, nil
+}
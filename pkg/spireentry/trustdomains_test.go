@@ -0,0 +1,48 @@
+package spireentry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestValidateTrustDomains(t *testing.T) {
+	newClusterSPIFFEID := func(name, spiffeIDTemplate, className string) *spirev1alpha1.ClusterSPIFFEID {
+		return &spirev1alpha1.ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: spiffeIDTemplate,
+				ClassName:        className,
+			},
+		}
+	}
+
+	r := newTestEntryReconciler(t, ReconcilerConfig{},
+		newClusterSPIFFEID("matching", "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}", ""),
+		newClusterSPIFFEID("literal", "spiffe://example.org/workload", ""),
+		newClusterSPIFFEID("mismatched", "spiffe://other.test/ns/{{ .PodMeta.Namespace }}", ""),
+		newClusterSPIFFEID("placeholder-path", "spiffe://other.test/app/{{ index .PodMeta.Labels \"app\" }}", ""),
+		newClusterSPIFFEID("other-class", "spiffe://other.test/workload", "other"),
+	)
+
+	var warnings []string
+	logger := funcr.New(func(prefix, args string) {
+		warnings = append(warnings, args)
+	}, funcr.Options{})
+	ctx := log.IntoContext(context.Background(), logger)
+
+	mismatches, err := ValidateTrustDomains(ctx, r.config, r.config.K8sClient)
+	require.NoError(t, err)
+	require.Equal(t, []TrustDomainMismatch{
+		{ClusterSPIFFEID: "mismatched", TrustDomain: "other.test"},
+		{ClusterSPIFFEID: "placeholder-path", TrustDomain: "other.test"},
+	}, mismatches)
+	require.Len(t, warnings, 2)
+	require.Contains(t, warnings[0], "ClusterSPIFFEID targets a trust domain other than the configured one")
+	require.Contains(t, warnings[0], `expected trust domain \"example.org\" but got \"other.test\"`)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+)
+
+// TTLTierPolicy lets pods pick the TTLs of their SVIDs from a set of approved
+// tiers by naming the tier in an annotation.
+type TTLTierPolicy struct {
+	// Annotation is the pod annotation holding the name of the tier.
+	Annotation string
+
+	// Tiers maps tier names to TTLs.
+	Tiers map[string]TTLTier
+
+	// MaxTTL, if non-zero, is the maximum TTL a tier can specify.
+	MaxTTL time.Duration
+}
+
+// TTLTier are the SVID TTLs of a tier. Zero values leave the TTLs of the
+// ClusterSPIFFEID in place.
+type TTLTier struct {
+	X509SVIDTTL time.Duration
+	JWTSVIDTTL  time.Duration
+}
+
+// Validate checks that the tiers are within bounds.
+func (p *TTLTierPolicy) Validate() error {
+	if p.Annotation == "" {
+		return fmt.Errorf("annotation is required")
+	}
+	for name, tier := range p.Tiers {
+		for _, ttl := range []time.Duration{tier.X509SVIDTTL, tier.JWTSVIDTTL} {
+			switch {
+			case ttl < 0:
+				return fmt.Errorf("tier %q: TTL can not be negative", name)
+			case p.MaxTTL > 0 && ttl > p.MaxTTL:
+				return fmt.Errorf("tier %q: TTL %s exceeds the maximum of %s", name, ttl, p.MaxTTL)
+			}
+		}
+	}
+	return nil
+}
+
+// apply overrides the TTLs of the entry with those of the tier named by the
+// pod annotation, if any. An error is returned if the tier is unknown, in
+// which case the entry is left as is.
+func (p *TTLTierPolicy) apply(entry *spireapi.Entry, pod *corev1.Pod) error {
+	name, ok := pod.Annotations[p.Annotation]
+	if !ok {
+		return nil
+	}
+	tier, ok := p.Tiers[name]
+	if !ok {
+		return fmt.Errorf("unknown TTL tier %q", name)
+	}
+	if tier.X509SVIDTTL != 0 {
+		entry.X509SVIDTTL = tier.X509SVIDTTL
+	}
+	if tier.JWTSVIDTTL != 0 {
+		entry.JWTSVIDTTL = tier.JWTSVIDTTL
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package spireentry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestTTLTierPolicyValidate(t *testing.T) {
+	for _, tt := range []struct {
+		desc      string
+		policy    TTLTierPolicy
+		expectErr string
+	}{
+		{
+			desc: "valid",
+			policy: TTLTierPolicy{
+				Annotation: "example.org/ttl-tier",
+				Tiers:      map[string]TTLTier{"short": {X509SVIDTTL: time.Minute}},
+				MaxTTL:     time.Hour,
+			},
+		},
+		{
+			desc:      "missing annotation",
+			policy:    TTLTierPolicy{},
+			expectErr: "annotation is required",
+		},
+		{
+			desc: "negative TTL",
+			policy: TTLTierPolicy{
+				Annotation: "example.org/ttl-tier",
+				Tiers:      map[string]TTLTier{"broken": {JWTSVIDTTL: -time.Minute}},
+			},
+			expectErr: `tier "broken": TTL can not be negative`,
+		},
+		{
+			desc: "TTL exceeds maximum",
+			policy: TTLTierPolicy{
+				Annotation: "example.org/ttl-tier",
+				Tiers:      map[string]TTLTier{"long": {X509SVIDTTL: 2 * time.Hour}},
+				MaxTTL:     time.Hour,
+			},
+			expectErr: `tier "long": TTL 2h0m0s exceeds the maximum of 1h0m0s`,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTTLTierPolicy(t *testing.T) {
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			TTL:              metav1.Duration{Duration: time.Hour},
+			JWTTTL:           metav1.Duration{Duration: 5 * time.Minute},
+		},
+	}
+	newAnnotatedPod := func(name, tier string) *corev1.Pod {
+		pod := newTestPod("default", name, "node", nil)
+		if tier != "" {
+			pod.Annotations = map[string]string{"example.org/ttl-tier": tier}
+		}
+		return pod
+	}
+
+	entryClient := newEntryClient()
+	r := newTestEntryReconciler(t, ReconcilerConfig{
+		EntryClient: entryClient,
+		TTLTierPolicy: &TTLTierPolicy{
+			Annotation: "example.org/ttl-tier",
+			Tiers: map[string]TTLTier{
+				"short":     {X509SVIDTTL: 10 * time.Minute, JWTSVIDTTL: time.Minute},
+				"short-jwt": {JWTSVIDTTL: time.Minute},
+			},
+		},
+	}, clusterSPIFFEID, newTestNamespace("default"), newTestNode("node"),
+		newAnnotatedPod("none", ""),
+		newAnnotatedPod("short", "short"),
+		newAnnotatedPod("short-jwt", "short-jwt"),
+		newAnnotatedPod("unknown", "unknown"),
+	)
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+	r.reconcile(ctx)
+
+	type ttls struct {
+		X509SVIDTTL time.Duration
+		JWTSVIDTTL  time.Duration
+	}
+	actual := make(map[string]ttls)
+	for _, entry := range entryClient.getEntries() {
+		actual[entry.SPIFFEID.Path()] = ttls{X509SVIDTTL: entry.X509SVIDTTL, JWTSVIDTTL: entry.JWTSVIDTTL}
+	}
+	require.Equal(t, map[string]ttls{
+		"/ns/default/pod/none":      {X509SVIDTTL: time.Hour, JWTSVIDTTL: 5 * time.Minute},
+		"/ns/default/pod/short":     {X509SVIDTTL: 10 * time.Minute, JWTSVIDTTL: time.Minute},
+		"/ns/default/pod/short-jwt": {X509SVIDTTL: time.Hour, JWTSVIDTTL: time.Minute},
+		"/ns/default/pod/unknown":   {X509SVIDTTL: time.Hour, JWTSVIDTTL: 5 * time.Minute},
+	}, actual)
+}
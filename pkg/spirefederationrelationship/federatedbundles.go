@@ -0,0 +1,246 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spirefederationrelationship
+
+import (
+	"context"
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"google.golang.org/grpc/codes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// federatedBundleFinalizer is held by the "oidc_discovery"
+// ClusterFederatedTrustDomains the controller sets a federated bundle for,
+// until the bundle is deleted along with them.
+const federatedBundleFinalizer = "spire.spiffe.io/federated-bundle"
+
+// reconcileFederatedBundles sets the bundles fetched for the
+// "oidc_discovery" ClusterFederatedTrustDomains as federated bundles on the
+// SPIRE server. SPIRE has no federation relationship for these trust
+// domains, so it never polls the discovery documents; the controller is the
+// only one refreshing the bundles.
+//
+// SPIRE does not record who set a federated bundle. The controller records
+// it instead by adding a finalizer to the ClusterFederatedTrustDomain before
+// setting its bundle. Only the bundles of deleted ClusterFederatedTrustDomains
+// holding the finalizer are deleted, so bundles set by other means are never
+// touched. The finalizer is removed once the bundle is deleted, or right away
+// if another ClusterFederatedTrustDomain declares the trust domain.
+func (r *federationRelationshipReconciler) reconcileFederatedBundles(ctx context.Context, declared, otherDeclared map[spiffeid.TrustDomain]*clusterFederatedTrustDomainState, terminating []*spirev1alpha1.ClusterFederatedTrustDomain) {
+	log := log.FromContext(ctx)
+
+	if r.bundleClient == nil {
+		for _, state := range declared {
+			state.setResult(errors.New("federated bundles are not supported by the controller configuration"))
+		}
+		// No bundle could have been set for them.
+		r.removeFederatedBundleFinalizers(ctx, terminating)
+		return
+	}
+
+	currentBundles, err := r.listFederatedBundles(ctx)
+	if err != nil {
+		log.Error(err, "Failed to list SPIRE federated bundles")
+		for _, state := range declared {
+			if !state.Unresolved {
+				state.setResult(err)
+			}
+		}
+		return
+	}
+
+	var toSet []*spiffebundle.Bundle
+	for trustDomain, state := range declared {
+		if state.Unresolved {
+			continue
+		}
+		bundle := state.FederationRelationship.TrustDomainBundle
+		if current, ok := currentBundles[trustDomain]; ok && len(current.X509Authorities()) == 0 && jwtAuthoritiesMatch(current, bundle) {
+			state.setResult(nil)
+			continue
+		}
+		toSet = append(toSet, bundle)
+	}
+
+	var toDelete []spiffeid.TrustDomain
+	toDeleteFor := make(map[spiffeid.TrustDomain][]*spirev1alpha1.ClusterFederatedTrustDomain)
+	var toRelease []*spirev1alpha1.ClusterFederatedTrustDomain
+	for _, clusterFederatedTrustDomain := range terminating {
+		trustDomain, err := spiffeid.TrustDomainFromString(clusterFederatedTrustDomain.Spec.TrustDomain)
+		_, isDeclared := declared[trustDomain]
+		_, isOtherDeclared := otherDeclared[trustDomain]
+		_, hasBundle := currentBundles[trustDomain]
+		if err != nil || !r.isManaged(trustDomain) || isDeclared || isOtherDeclared || !hasBundle {
+			toRelease = append(toRelease, clusterFederatedTrustDomain)
+			continue
+		}
+		if _, ok := toDeleteFor[trustDomain]; !ok {
+			toDelete = append(toDelete, trustDomain)
+		}
+		toDeleteFor[trustDomain] = append(toDeleteFor[trustDomain], clusterFederatedTrustDomain)
+	}
+
+	if r.dryRun {
+		logDryRunFederatedBundles(ctx, toSet, toDelete)
+		r.removeFederatedBundleFinalizers(ctx, toRelease)
+		return
+	}
+	if len(toSet) > 0 && ctx.Err() == nil {
+		toSet = r.addFederatedBundleFinalizers(ctx, toSet, declared)
+		if len(toSet) > 0 {
+			r.setFederatedBundles(ctx, toSet, declared)
+		}
+	}
+	if len(toDelete) > 0 && ctx.Err() == nil {
+		for _, trustDomain := range r.deleteFederatedBundles(ctx, toDelete) {
+			toRelease = append(toRelease, toDeleteFor[trustDomain]...)
+		}
+	}
+	if ctx.Err() == nil {
+		r.removeFederatedBundleFinalizers(ctx, toRelease)
+	}
+}
+
+// addFederatedBundleFinalizers adds the finalizer to the
+// ClusterFederatedTrustDomains of the bundles about to be set, and returns
+// the bundles it was added for. A bundle is not set unless the finalizer
+// records that the controller owns it.
+func (r *federationRelationshipReconciler) addFederatedBundleFinalizers(ctx context.Context, bundles []*spiffebundle.Bundle, declared map[spiffeid.TrustDomain]*clusterFederatedTrustDomainState) []*spiffebundle.Bundle {
+	log := log.FromContext(ctx)
+
+	out := bundles[:0]
+	for _, bundle := range bundles {
+		state := declared[bundle.TrustDomain()]
+		if controllerutil.AddFinalizer(&state.ClusterFederatedTrustDomain, federatedBundleFinalizer) {
+			if err := r.k8sClient.Update(ctx, &state.ClusterFederatedTrustDomain); err != nil {
+				log.Error(err, "Failed to add federated bundle finalizer", clusterFederatedTrustDomainLogKey, objectName(&state.ClusterFederatedTrustDomain))
+				state.setResult(err)
+				continue
+			}
+		}
+		out = append(out, bundle)
+	}
+	return out
+}
+
+// removeFederatedBundleFinalizers removes the finalizer from deleted
+// ClusterFederatedTrustDomains, letting their deletion complete.
+func (r *federationRelationshipReconciler) removeFederatedBundleFinalizers(ctx context.Context, clusterFederatedTrustDomains []*spirev1alpha1.ClusterFederatedTrustDomain) {
+	log := log.FromContext(ctx)
+
+	for _, clusterFederatedTrustDomain := range clusterFederatedTrustDomains {
+		log := log.WithValues(clusterFederatedTrustDomainLogKey, objectName(clusterFederatedTrustDomain))
+		if !controllerutil.RemoveFinalizer(clusterFederatedTrustDomain, federatedBundleFinalizer) {
+			continue
+		}
+		if err := r.k8sClient.Update(ctx, clusterFederatedTrustDomain); client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to remove federated bundle finalizer")
+			continue
+		}
+		log.Info("Removed federated bundle finalizer")
+	}
+}
+
+func (r *federationRelationshipReconciler) listFederatedBundles(ctx context.Context) (map[spiffeid.TrustDomain]*spiffebundle.Bundle, error) {
+	bundles, err := r.bundleClient.ListFederatedBundles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[spiffeid.TrustDomain]*spiffebundle.Bundle, len(bundles))
+	for _, bundle := range bundles {
+		out[bundle.TrustDomain()] = bundle
+	}
+	return out, nil
+}
+
+func (r *federationRelationshipReconciler) setFederatedBundles(ctx context.Context, bundles []*spiffebundle.Bundle, declared map[spiffeid.TrustDomain]*clusterFederatedTrustDomainState) {
+	log := log.FromContext(ctx)
+
+	timer := prometheus.NewTimer(metrics.PromSPIREWriteDuration.WithLabelValues(metrics.OperationSetFederatedBundles))
+	statuses, err := r.bundleClient.SetFederatedBundles(ctx, bundles)
+	timer.ObserveDuration()
+	if err != nil {
+		log.Error(err, "Failed to set federated bundles")
+		for _, bundle := range bundles {
+			declared[bundle.TrustDomain()].setResult(err)
+		}
+		return
+	}
+
+	for i, status := range statuses {
+		switch status.Code {
+		case codes.OK:
+			log.Info("Set federated bundle", trustDomainKey, bundles[i].TrustDomain().Name())
+		default:
+			log.Error(status.Err(), "Failed to set federated bundle", trustDomainKey, bundles[i].TrustDomain().Name())
+		}
+		declared[bundles[i].TrustDomain()].setResult(status.Err())
+	}
+}
+
+// deleteFederatedBundles deletes the federated bundles of the given trust
+// domains and returns the trust domains left without one.
+func (r *federationRelationshipReconciler) deleteFederatedBundles(ctx context.Context, trustDomains []spiffeid.TrustDomain) []spiffeid.TrustDomain {
+	log := log.FromContext(ctx)
+
+	timer := prometheus.NewTimer(metrics.PromSPIREWriteDuration.WithLabelValues(metrics.OperationDeleteFederatedBundles))
+	statuses, err := r.bundleClient.DeleteFederatedBundles(ctx, trustDomains)
+	timer.ObserveDuration()
+	if err != nil {
+		log.Error(err, "Failed to delete federated bundles")
+		return nil
+	}
+
+	var deleted []spiffeid.TrustDomain
+	for i, status := range statuses {
+		switch status.Code {
+		case codes.OK, codes.NotFound:
+			log.Info("Deleted federated bundle", trustDomainKey, trustDomains[i].Name())
+			deleted = append(deleted, trustDomains[i])
+		case codes.FailedPrecondition:
+			// SPIRE keeps bundles that entries federate with. The
+			// deletion is retried on the next pass.
+			log.Info("Keeping federated bundle still federated with by entries", trustDomainKey, trustDomains[i].Name())
+		default:
+			log.Error(status.Err(), "Failed to delete federated bundle", trustDomainKey, trustDomains[i].Name())
+		}
+	}
+	return deleted
+}
+
+// logDryRunFederatedBundles logs the federated bundles that would be set or
+// deleted, in place of writing them to the SPIRE server.
+func logDryRunFederatedBundles(ctx context.Context, toSet []*spiffebundle.Bundle, toDelete []spiffeid.TrustDomain) {
+	log := log.FromContext(ctx)
+	for _, trustDomain := range toDelete {
+		log.Info("Would delete federated bundle (dry run)", trustDomainKey, trustDomain.Name())
+	}
+	for _, bundle := range toSet {
+		log.Info("Would set federated bundle (dry run)", trustDomainKey, bundle.TrustDomain().Name())
+	}
+	if len(toSet) > 0 || len(toDelete) > 0 {
+		log.Info("Dry run; skipped writing federated bundles to the SPIRE server", "toSet", len(toSet), "toDelete", len(toDelete))
+	}
+}
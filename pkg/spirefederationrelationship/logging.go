@@ -48,6 +48,7 @@ func federationRelationshipFields(fr spireapi.FederationRelationship) []interfac
 	case spireapi.HTTPSSPIFFEProfile:
 		fields = append(fields, endpointSPIFFEIDKey, profile.EndpointSPIFFEID.String())
 	case spireapi.HTTPSWebProfile:
+	case spireapi.OIDCDiscoveryProfile:
 	}
 	return fields
 }
@@ -0,0 +1,113 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spirefederationrelationship
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+)
+
+const (
+	oidcFetchTimeout = 10 * time.Second
+
+	// maxOIDCResponseSize bounds how much of the discovery document and JWKS
+	// responses are read.
+	maxOIDCResponseSize = 1 << 20
+)
+
+type oidcDiscoveryDocument struct {
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// fetchOIDCBundle fetches the JWKS referenced by the OIDC discovery document
+// at discoveryURL and returns its keys as the JWT authorities of a bundle for
+// the trust domain.
+func fetchOIDCBundle(ctx context.Context, httpClient *http.Client, td spiffeid.TrustDomain, discoveryURL string) (*spiffebundle.Bundle, error) {
+	ctx, cancel := context.WithTimeout(ctx, oidcFetchTimeout)
+	defer cancel()
+
+	body, err := httpGet(ctx, httpClient, discoveryURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC discovery document: %w", err)
+	}
+	var doc oidcDiscoveryDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode OIDC discovery document: %w", err)
+	}
+	if err := spireapi.ValidateBundleEndpointURL(doc.JWKSURI); err != nil {
+		return nil, fmt.Errorf("invalid OIDC discovery document jwks_uri: %w", err)
+	}
+
+	body, err = httpGet(ctx, httpClient, doc.JWKSURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	jwtBundle, err := jwtbundle.Parse(td, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+	return spiffebundle.FromJWTAuthorities(td, jwtBundle.JWTAuthorities()), nil
+}
+
+func httpGet(ctx context.Context, httpClient *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxOIDCResponseSize))
+}
+
+// jwtAuthoritiesMatch returns true if both bundles hold the same JWT
+// authorities. X.509 authorities are not considered.
+func jwtAuthoritiesMatch(a, b *spiffebundle.Bundle) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	as, bs := a.JWTAuthorities(), b.JWTAuthorities()
+	if len(as) != len(bs) {
+		return false
+	}
+	for keyID, aKey := range as {
+		bKey, ok := bs[keyID]
+		if !ok {
+			return false
+		}
+		equaler, ok := aKey.(interface{ Equal(crypto.PublicKey) bool })
+		if !ok || !equaler.Equal(bKey) {
+			return false
+		}
+	}
+	return true
+}
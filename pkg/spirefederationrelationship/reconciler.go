@@ -18,16 +18,21 @@ package spirefederationrelationship
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"sort"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
 	"github.com/spiffe/spire-controller-manager/pkg/k8sapi"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
 	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
 	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
 	"google.golang.org/grpc/codes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -37,36 +42,90 @@ type ReconcilerConfig struct {
 	ClassName         string
 	WatchClassless    bool
 
+	// ManagedTrustDomains, if non-empty, restricts the federation
+	// relationships created, updated or deleted by the controller to those
+	// for the given trust domains. Relationships for other trust domains are
+	// assumed to be managed externally and are left alone.
+	ManagedTrustDomains []spiffeid.TrustDomain
+
+	// HTTPClient is used to fetch bundles for the "oidc_discovery" profile.
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// FederatedBundleClient is used to set the bundles fetched for the
+	// "oidc_discovery" profile, which have no federation relationship in
+	// SPIRE. ClusterFederatedTrustDomains with that profile fail to
+	// reconcile without it.
+	FederatedBundleClient spireapi.FederatedBundleClient
+
 	// GCInterval how long to sit idle (i.e. untriggered) before doing
 	// another reconcile.
 	GCInterval time.Duration
+
+	// MaxReconcileDuration, if non-zero, is how long a reconcile may run
+	// before it is aborted.
+	MaxReconcileDuration time.Duration
+
+	// InitialReconcileDelay, if non-zero, is how long to wait before the
+	// first reconcile.
+	InitialReconcileDelay time.Duration
+
+	// ReconcileCoalesceDelay, if non-zero, is how long to wait after a
+	// trigger before reconciling, so that a burst of changes to
+	// ClusterFederatedTrustDomains results in a single reconcile.
+	ReconcileCoalesceDelay time.Duration
+
+	// DryRun, if set, logs the federation relationships that would be
+	// created, updated or deleted instead of writing them to the SPIRE
+	// server. ClusterFederatedTrustDomain statuses are still updated, so
+	// that relationships that would be created or updated show as not set.
+	DryRun bool
 }
 
 func Reconciler(config ReconcilerConfig) reconciler.Reconciler {
 	return reconciler.New(reconciler.Config{
 		Kind: "federation relationship",
 		Reconcile: func(ctx context.Context) {
-			Reconcile(ctx, config.TrustDomainClient, config.K8sClient, config.ClassName, config.WatchClassless)
+			Reconcile(ctx, config)
 		},
-		GCInterval: config.GCInterval,
+		GCInterval:    config.GCInterval,
+		MaxDuration:   config.MaxReconcileDuration,
+		InitialDelay:  config.InitialReconcileDelay,
+		CoalesceDelay: config.ReconcileCoalesceDelay,
 	})
 }
 
-func Reconcile(ctx context.Context, trustDomainClient spireapi.TrustDomainClient, k8sClient client.Client, className string, watchClassless bool) {
+func Reconcile(ctx context.Context, config ReconcilerConfig) {
 	r := &federationRelationshipReconciler{
-		trustDomainClient: trustDomainClient,
-		k8sClient:         k8sClient,
-		className:         className,
-		watchClassless:    watchClassless,
+		trustDomainClient: config.TrustDomainClient,
+		bundleClient:      config.FederatedBundleClient,
+		k8sClient:         config.K8sClient,
+		className:         config.ClassName,
+		watchClassless:    config.WatchClassless,
+		httpClient:        config.HTTPClient,
+		dryRun:            config.DryRun,
+	}
+	if r.httpClient == nil {
+		r.httpClient = http.DefaultClient
+	}
+	if len(config.ManagedTrustDomains) > 0 {
+		r.managedTrustDomains = make(map[spiffeid.TrustDomain]struct{}, len(config.ManagedTrustDomains))
+		for _, td := range config.ManagedTrustDomains {
+			r.managedTrustDomains[td] = struct{}{}
+		}
 	}
 	r.reconcile(ctx)
 }
 
 type federationRelationshipReconciler struct {
-	trustDomainClient spireapi.TrustDomainClient
-	k8sClient         client.Client
-	className         string
-	watchClassless    bool
+	trustDomainClient   spireapi.TrustDomainClient
+	bundleClient        spireapi.FederatedBundleClient
+	k8sClient           client.Client
+	className           string
+	watchClassless      bool
+	managedTrustDomains map[spiffeid.TrustDomain]struct{}
+	httpClient          *http.Client
+	dryRun              bool
 }
 
 func (r *federationRelationshipReconciler) reconcile(ctx context.Context) {
@@ -78,17 +137,31 @@ func (r *federationRelationshipReconciler) reconcile(ctx context.Context) {
 		return
 	}
 
-	clusterFederatedTrustDomains, err := r.listClusterFederatedTrustDomains(ctx)
+	clusterFederatedTrustDomains, states, terminating, err := r.listClusterFederatedTrustDomains(ctx)
 	if err != nil {
 		log.Error(err, "Failed to list ClusterFederatedTrustDomains")
 		return
 	}
 
+	// The bundles of "oidc_discovery" trust domains are set as federated
+	// bundles rather than through federation relationships. Relationships
+	// left for them are deleted like any relationship no longer declared.
+	oidcTrustDomains := make(map[spiffeid.TrustDomain]*clusterFederatedTrustDomainState)
+	for trustDomain, clusterFederatedTrustDomain := range clusterFederatedTrustDomains {
+		if _, ok := clusterFederatedTrustDomain.FederationRelationship.BundleEndpointProfile.(spireapi.OIDCDiscoveryProfile); ok {
+			oidcTrustDomains[trustDomain] = clusterFederatedTrustDomain
+			delete(clusterFederatedTrustDomains, trustDomain)
+		}
+	}
+
 	var toDelete []spireapi.FederationRelationship
 	var toCreate []spireapi.FederationRelationship
 	var toUpdate []spireapi.FederationRelationship
 
 	for trustDomain, federationRelationship := range currentRelationships {
+		if !r.isManaged(trustDomain) {
+			continue
+		}
 		if _, ok := clusterFederatedTrustDomains[trustDomain]; !ok {
 			toDelete = append(toDelete, federationRelationship)
 		}
@@ -100,26 +173,60 @@ func (r *federationRelationshipReconciler) reconcile(ctx context.Context) {
 			toCreate = append(toCreate, clusterFederatedTrustDomain.FederationRelationship)
 		case !currentRelationship.Equal(clusterFederatedTrustDomain.FederationRelationship):
 			toUpdate = append(toUpdate, clusterFederatedTrustDomain.FederationRelationship)
+		default:
+			clusterFederatedTrustDomain.NextStatus.Set = true
 		}
 	}
 
-	if len(toDelete) > 0 {
-		r.deleteFederationRelationships(ctx, toDelete)
+	if r.dryRun {
+		logDryRunFederationRelationships(ctx, toCreate, toUpdate, toDelete)
+	} else {
+		if len(toDelete) > 0 && ctx.Err() == nil {
+			r.deleteFederationRelationships(ctx, toDelete)
+		}
+		if len(toCreate) > 0 && ctx.Err() == nil {
+			r.createFederationRelationships(ctx, toCreate, clusterFederatedTrustDomains)
+		}
+		if len(toUpdate) > 0 && ctx.Err() == nil {
+			r.updateFederationRelationships(ctx, toUpdate, clusterFederatedTrustDomains)
+		}
 	}
-	if len(toCreate) > 0 {
-		r.createFederationRelationships(ctx, toCreate)
+	if ctx.Err() == nil {
+		r.reconcileFederatedBundles(ctx, oidcTrustDomains, clusterFederatedTrustDomains, terminating)
 	}
-	if len(toUpdate) > 0 {
-		r.updateFederationRelationships(ctx, toUpdate)
+	if ctx.Err() != nil {
+		log.Info("Reconcile canceled; remaining changes will be applied on the next pass")
+		return
 	}
 
-	// TODO: Status updates
+	// Update the ClusterFederatedTrustDomain statuses
+	for _, state := range states {
+		log := log.WithValues(clusterFederatedTrustDomainLogKey, objectName(&state.ClusterFederatedTrustDomain))
+
+		if state.ClusterFederatedTrustDomain.Status == state.NextStatus {
+			continue
+		}
+		state.ClusterFederatedTrustDomain.Status = state.NextStatus
+		if err := r.k8sClient.Status().Update(ctx, &state.ClusterFederatedTrustDomain); err == nil {
+			log.Info("Updated status")
+		} else {
+			log.Error(err, "Failed to update status")
+		}
+	}
 }
 
 func (r *federationRelationshipReconciler) reconcileClass(className string) bool {
 	return (className == "" && r.watchClassless) || className == r.className
 }
 
+func (r *federationRelationshipReconciler) isManaged(trustDomain spiffeid.TrustDomain) bool {
+	if r.managedTrustDomains == nil {
+		return true
+	}
+	_, ok := r.managedTrustDomains[trustDomain]
+	return ok
+}
+
 func (r *federationRelationshipReconciler) listFederationRelationships(ctx context.Context) (map[spiffeid.TrustDomain]spireapi.FederationRelationship, error) {
 	federationRelationships, err := r.trustDomainClient.ListFederationRelationships(ctx)
 	if err != nil {
@@ -132,12 +239,18 @@ func (r *federationRelationshipReconciler) listFederationRelationships(ctx conte
 	return out, nil
 }
 
-func (r *federationRelationshipReconciler) listClusterFederatedTrustDomains(ctx context.Context) (map[spiffeid.TrustDomain]*clusterFederatedTrustDomainState, error) {
+// listClusterFederatedTrustDomains returns the ClusterFederatedTrustDomains
+// to reconcile by trust domain, along with the state of every
+// ClusterFederatedTrustDomain whose status is maintained by the controller,
+// including those that are invalid or conflict with another. Deleted
+// ClusterFederatedTrustDomains still holding the federated bundle finalizer
+// are returned apart, so that their bundle is deleted.
+func (r *federationRelationshipReconciler) listClusterFederatedTrustDomains(ctx context.Context) (map[spiffeid.TrustDomain]*clusterFederatedTrustDomainState, []*clusterFederatedTrustDomainState, []*spirev1alpha1.ClusterFederatedTrustDomain, error) {
 	log := log.FromContext(ctx)
 
 	clusterFederatedTrustDomains, err := k8sapi.ListClusterFederatedTrustDomains(ctx, r.k8sClient)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	// Sort the cluster federated trust domains by creation date. This provides
@@ -147,40 +260,70 @@ func (r *federationRelationshipReconciler) listClusterFederatedTrustDomains(ctx
 	sortClusterFederatedTrustDomainsByCreationDate(clusterFederatedTrustDomains)
 
 	out := make(map[spiffeid.TrustDomain]*clusterFederatedTrustDomainState, len(clusterFederatedTrustDomains))
+	var states []*clusterFederatedTrustDomainState
+	var terminating []*spirev1alpha1.ClusterFederatedTrustDomain
 	for i := range clusterFederatedTrustDomains {
 		if !(r.reconcileClass(clusterFederatedTrustDomains[i].Spec.ClassName)) {
 			continue
 		}
+		if !clusterFederatedTrustDomains[i].DeletionTimestamp.IsZero() && controllerutil.ContainsFinalizer(&clusterFederatedTrustDomains[i], federatedBundleFinalizer) {
+			terminating = append(terminating, &clusterFederatedTrustDomains[i])
+			continue
+		}
 		log := log.WithValues(clusterFederatedTrustDomainLogKey, objectName(&clusterFederatedTrustDomains[i]))
 
+		state := &clusterFederatedTrustDomainState{
+			ClusterFederatedTrustDomain: clusterFederatedTrustDomains[i],
+		}
+
 		federationRelationship, err := spirev1alpha1.ParseClusterFederatedTrustDomainSpec(&clusterFederatedTrustDomains[i].Spec)
 		if err != nil {
 			log.Error(err, "Ignoring invalid ClusterFederatedTrustDomain")
+			state.NextStatus.Error = fmt.Sprintf("invalid spec: %v", err)
+			states = append(states, state)
 			continue
 		}
+		state.FederationRelationship = *federationRelationship
 
-		state := &clusterFederatedTrustDomainState{
-			ClusterFederatedTrustDomain: clusterFederatedTrustDomains[i],
-			FederationRelationship:      *federationRelationship,
+		if !r.isManaged(federationRelationship.TrustDomain) {
+			log.Info("Ignoring ClusterFederatedTrustDomain for unmanaged trust domain")
+			continue
 		}
+		states = append(states, state)
 
 		if existing, ok := out[federationRelationship.TrustDomain]; ok {
 			log.Info("Ignoring ClusterFederatedTrustDomain with conflicting trust domain",
 				conflictWithKey, objectName(&existing.ClusterFederatedTrustDomain))
+			state.NextStatus.Error = fmt.Sprintf("trust domain conflicts with ClusterFederatedTrustDomain %q", existing.ClusterFederatedTrustDomain.Name)
 			continue
 		}
 
+		if _, ok := federationRelationship.BundleEndpointProfile.(spireapi.OIDCDiscoveryProfile); ok {
+			bundle, err := fetchOIDCBundle(ctx, r.httpClient, federationRelationship.TrustDomain, federationRelationship.BundleEndpointURL)
+			if err != nil {
+				log.Error(err, "Failed to fetch bundle for OIDC discovery profile")
+				state.Unresolved = true
+				state.NextStatus.Error = fmt.Sprintf("failed to fetch bundle: %v", err)
+			}
+			state.FederationRelationship.TrustDomainBundle = bundle
+		}
+
 		out[federationRelationship.TrustDomain] = state
 	}
-	return out, nil
+	return out, states, terminating, nil
 }
 
-func (r *federationRelationshipReconciler) createFederationRelationships(ctx context.Context, federationRelationships []spireapi.FederationRelationship) {
+func (r *federationRelationshipReconciler) createFederationRelationships(ctx context.Context, federationRelationships []spireapi.FederationRelationship, declared map[spiffeid.TrustDomain]*clusterFederatedTrustDomainState) {
 	log := log.FromContext(ctx)
 
+	timer := prometheus.NewTimer(metrics.PromSPIREWriteDuration.WithLabelValues(metrics.OperationCreateFederationRelationships))
 	statuses, err := r.trustDomainClient.CreateFederationRelationships(ctx, federationRelationships)
+	timer.ObserveDuration()
 	if err != nil {
 		log.Error(err, "Failed to create federation relationships")
+		for _, federationRelationship := range federationRelationships {
+			declared[federationRelationship.TrustDomain].setResult(err)
+		}
 		return
 	}
 
@@ -191,15 +334,21 @@ func (r *federationRelationshipReconciler) createFederationRelationships(ctx con
 		default:
 			log.Error(status.Err(), "Failed to create federation relationship", federationRelationshipFields(federationRelationships[i])...)
 		}
+		declared[federationRelationships[i].TrustDomain].setResult(status.Err())
 	}
 }
 
-func (r *federationRelationshipReconciler) updateFederationRelationships(ctx context.Context, federationRelationships []spireapi.FederationRelationship) {
+func (r *federationRelationshipReconciler) updateFederationRelationships(ctx context.Context, federationRelationships []spireapi.FederationRelationship, declared map[spiffeid.TrustDomain]*clusterFederatedTrustDomainState) {
 	log := log.FromContext(ctx)
 
+	timer := prometheus.NewTimer(metrics.PromSPIREWriteDuration.WithLabelValues(metrics.OperationUpdateFederationRelationships))
 	statuses, err := r.trustDomainClient.UpdateFederationRelationships(ctx, federationRelationships)
+	timer.ObserveDuration()
 	if err != nil {
 		log.Error(err, "Failed to update federation relationships")
+		for _, federationRelationship := range federationRelationships {
+			declared[federationRelationship.TrustDomain].setResult(err)
+		}
 		return
 	}
 
@@ -210,13 +359,16 @@ func (r *federationRelationshipReconciler) updateFederationRelationships(ctx con
 		default:
 			log.Error(status.Err(), "Failed to update federation relationship", federationRelationshipFields(federationRelationships[i])...)
 		}
+		declared[federationRelationships[i].TrustDomain].setResult(status.Err())
 	}
 }
 
 func (r *federationRelationshipReconciler) deleteFederationRelationships(ctx context.Context, federationRelationships []spireapi.FederationRelationship) {
 	log := log.FromContext(ctx)
 
+	timer := prometheus.NewTimer(metrics.PromSPIREWriteDuration.WithLabelValues(metrics.OperationDeleteFederationRelationships))
 	statuses, err := r.trustDomainClient.DeleteFederationRelationships(ctx, trustDomainIDsFromFederationRelationships(federationRelationships))
+	timer.ObserveDuration()
 	if err != nil {
 		log.Error(err, "Failed to delete federation relationships")
 		return
@@ -232,6 +384,23 @@ func (r *federationRelationshipReconciler) deleteFederationRelationships(ctx con
 	}
 }
 
+// logDryRunFederationRelationships logs the federation relationships that
+// would be created, updated or deleted, in place of writing them to the SPIRE
+// server.
+func logDryRunFederationRelationships(ctx context.Context, toCreate, toUpdate, toDelete []spireapi.FederationRelationship) {
+	log := log.FromContext(ctx)
+	for _, federationRelationship := range toDelete {
+		log.Info("Would delete federation relationship (dry run)", federationRelationshipFields(federationRelationship)...)
+	}
+	for _, federationRelationship := range toCreate {
+		log.Info("Would create federation relationship (dry run)", federationRelationshipFields(federationRelationship)...)
+	}
+	for _, federationRelationship := range toUpdate {
+		log.Info("Would update federation relationship (dry run)", federationRelationshipFields(federationRelationship)...)
+	}
+	log.Info("Dry run; skipped writing federation relationships to the SPIRE server", "toCreate", len(toCreate), "toUpdate", len(toUpdate), "toDelete", len(toDelete))
+}
+
 func trustDomainIDsFromFederationRelationships(frs []spireapi.FederationRelationship) []spiffeid.TrustDomain {
 	out := make([]spiffeid.TrustDomain, 0, len(frs))
 	for _, fr := range frs {
@@ -244,6 +413,20 @@ type clusterFederatedTrustDomainState struct {
 	ClusterFederatedTrustDomain spirev1alpha1.ClusterFederatedTrustDomain
 	FederationRelationship      spireapi.FederationRelationship
 	NextStatus                  spirev1alpha1.ClusterFederatedTrustDomainStatus
+
+	// Unresolved is set when the bundle for the "oidc_discovery" profile
+	// could not be fetched. The federated bundle, if any, is left as is.
+	Unresolved bool
+}
+
+// setResult records the result of creating or updating the federation
+// relationship in the next status.
+func (s *clusterFederatedTrustDomainState) setResult(err error) {
+	if err != nil {
+		s.NextStatus = spirev1alpha1.ClusterFederatedTrustDomainStatus{Error: err.Error()}
+		return
+	}
+	s.NextStatus = spirev1alpha1.ClusterFederatedTrustDomainStatus{Set: true}
 }
 
 func sortClusterFederatedTrustDomainsByCreationDate(cftds []spirev1alpha1.ClusterFederatedTrustDomain) {
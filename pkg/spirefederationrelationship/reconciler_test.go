@@ -18,26 +18,41 @@ package spirefederationrelationship_test
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"sort"
 	"testing"
 	"time"
 
 	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
 	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
 	"github.com/spiffe/spire-controller-manager/pkg/spirefederationrelationship"
 	"github.com/spiffe/spire-controller-manager/pkg/test/k8stest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 var (
-	td = spiffeid.RequireTrustDomainFromString("td")
+	td         = spiffeid.RequireTrustDomainFromString("td")
+	tdExternal = spiffeid.RequireTrustDomainFromString("external")
 )
 
 func TestReconcile(t *testing.T) {
@@ -88,12 +103,29 @@ func TestReconcile(t *testing.T) {
 		},
 	}
 
+	frExternal := spireapi.FederationRelationship{
+		TrustDomain:           tdExternal,
+		BundleEndpointURL:     "https://external.test/bundle",
+		BundleEndpointProfile: spireapi.HTTPSWebProfile{},
+	}
+	cftdExternal := &spirev1alpha1.ClusterFederatedTrustDomain{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "external",
+		},
+		Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+			TrustDomain:           "external",
+			BundleEndpointURL:     "https://external.test/other-bundle",
+			BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: "https_web"},
+		},
+	}
+
 	for _, tt := range []struct {
-		desc              string
-		withObjects       []runtime.Object
-		withFRs           []spireapi.FederationRelationship
-		expectFRs         []spireapi.FederationRelationship
-		configureTDClient func(tdc *trustDomainClient)
+		desc                string
+		withObjects         []runtime.Object
+		withFRs             []spireapi.FederationRelationship
+		expectFRs           []spireapi.FederationRelationship
+		configureTDClient   func(tdc *trustDomainClient)
+		managedTrustDomains []spiffeid.TrustDomain
 	}{
 		{
 			desc: "nothing to do",
@@ -173,6 +205,25 @@ func TestReconcile(t *testing.T) {
 			},
 			expectFRs: []spireapi.FederationRelationship{fr1},
 		},
+		{
+			desc:                "does not delete unmanaged federation relationship",
+			withFRs:             []spireapi.FederationRelationship{fr1, frExternal},
+			managedTrustDomains: []spiffeid.TrustDomain{td},
+			expectFRs:           []spireapi.FederationRelationship{frExternal},
+		},
+		{
+			desc:                "does not update unmanaged federation relationship",
+			withObjects:         []runtime.Object{cftdExternal},
+			withFRs:             []spireapi.FederationRelationship{frExternal},
+			managedTrustDomains: []spiffeid.TrustDomain{td},
+			expectFRs:           []spireapi.FederationRelationship{frExternal},
+		},
+		{
+			desc:                "does not create unmanaged federation relationship",
+			withObjects:         []runtime.Object{cftd1, cftdExternal},
+			managedTrustDomains: []spiffeid.TrustDomain{td},
+			expectFRs:           []spireapi.FederationRelationship{fr1},
+		},
 		{
 			desc:        "ignores conflicting resources",
 			withObjects: []runtime.Object{cftd1, cftd3},
@@ -191,12 +242,225 @@ func TestReconcile(t *testing.T) {
 			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
 
 			k8sClient := k8stest.NewClientBuilder(t).WithRuntimeObjects(tt.withObjects...).Build()
-			spirefederationrelationship.Reconcile(ctx, tdc, k8sClient, "", false)
+			spirefederationrelationship.Reconcile(ctx, spirefederationrelationship.ReconcilerConfig{
+				TrustDomainClient:   tdc,
+				K8sClient:           k8sClient,
+				ManagedTrustDomains: tt.managedTrustDomains,
+			})
 			assert.Equal(t, tt.expectFRs, tdc.getFederationRelationships())
 		})
 	}
 }
 
+func TestReconcileObservesWriteDurations(t *testing.T) {
+	operations := []string{
+		metrics.OperationCreateFederationRelationships,
+		metrics.OperationUpdateFederationRelationships,
+		metrics.OperationDeleteFederationRelationships,
+	}
+	before := make(map[string]uint64)
+	for _, operation := range operations {
+		before[operation] = writeDurationSamples(t, operation)
+	}
+
+	created := &spirev1alpha1.ClusterFederatedTrustDomain{
+		ObjectMeta: metav1.ObjectMeta{Name: "created"},
+		Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+			TrustDomain:           "created",
+			BundleEndpointURL:     "https://created.test/bundle",
+			BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: "https_web"},
+		},
+	}
+	updated := &spirev1alpha1.ClusterFederatedTrustDomain{
+		ObjectMeta: metav1.ObjectMeta{Name: "td"},
+		Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+			TrustDomain:           "td",
+			BundleEndpointURL:     "https://td.test/other-bundle",
+			BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: "https_web"},
+		},
+	}
+
+	tdc := newTrustDomainClient()
+	for _, fr := range []spireapi.FederationRelationship{
+		{TrustDomain: td, BundleEndpointURL: "https://td.test/bundle", BundleEndpointProfile: spireapi.HTTPSWebProfile{}},
+		{TrustDomain: tdExternal, BundleEndpointURL: "https://external.test/bundle", BundleEndpointProfile: spireapi.HTTPSWebProfile{}},
+	} {
+		tdc.frs[fr.TrustDomain] = fr
+	}
+
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	spirefederationrelationship.Reconcile(ctx, spirefederationrelationship.ReconcilerConfig{
+		TrustDomainClient: tdc,
+		K8sClient:         k8stest.NewClientBuilder(t).WithRuntimeObjects(created, updated).Build(),
+	})
+
+	for _, operation := range operations {
+		assert.Equal(t, before[operation]+1, writeDurationSamples(t, operation), operation)
+	}
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	operations := []string{
+		metrics.OperationCreateFederationRelationships,
+		metrics.OperationUpdateFederationRelationships,
+		metrics.OperationDeleteFederationRelationships,
+	}
+	before := make(map[string]uint64)
+	for _, operation := range operations {
+		before[operation] = writeDurationSamples(t, operation)
+	}
+
+	created := &spirev1alpha1.ClusterFederatedTrustDomain{
+		ObjectMeta: metav1.ObjectMeta{Name: "created"},
+		Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+			TrustDomain:           "created",
+			BundleEndpointURL:     "https://created.test/bundle",
+			BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: "https_web"},
+		},
+	}
+	// Set as of an earlier pass, but no longer matching its spec.
+	updated := &spirev1alpha1.ClusterFederatedTrustDomain{
+		ObjectMeta: metav1.ObjectMeta{Name: "td"},
+		Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+			TrustDomain:           "td",
+			BundleEndpointURL:     "https://td.test/other-bundle",
+			BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: "https_web"},
+		},
+		Status: spirev1alpha1.ClusterFederatedTrustDomainStatus{Set: true},
+	}
+	unchanged := &spirev1alpha1.ClusterFederatedTrustDomain{
+		ObjectMeta: metav1.ObjectMeta{Name: "unchanged"},
+		Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+			TrustDomain:           "unchanged",
+			BundleEndpointURL:     "https://unchanged.test/bundle",
+			BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: "https_web"},
+		},
+	}
+	unchangedTD := spiffeid.RequireTrustDomainFromString("unchanged")
+
+	tdc := newTrustDomainClient()
+	for _, fr := range []spireapi.FederationRelationship{
+		{TrustDomain: td, BundleEndpointURL: "https://td.test/bundle", BundleEndpointProfile: spireapi.HTTPSWebProfile{}},
+		{TrustDomain: tdExternal, BundleEndpointURL: "https://external.test/bundle", BundleEndpointProfile: spireapi.HTTPSWebProfile{}},
+		{TrustDomain: unchangedTD, BundleEndpointURL: "https://unchanged.test/bundle", BundleEndpointProfile: spireapi.HTTPSWebProfile{}},
+	} {
+		tdc.frs[fr.TrustDomain] = fr
+	}
+	frsBefore := tdc.getFederationRelationships()
+
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithRuntimeObjects(created, updated, unchanged).
+		WithStatusSubresource(&spirev1alpha1.ClusterFederatedTrustDomain{}).
+		Build()
+	spirefederationrelationship.Reconcile(ctx, spirefederationrelationship.ReconcilerConfig{
+		TrustDomainClient: tdc,
+		K8sClient:         k8sClient,
+		DryRun:            true,
+	})
+
+	assert.Equal(t, frsBefore, tdc.getFederationRelationships())
+
+	// Statuses reflect what would be set: relationships that would be
+	// created or updated do not match yet.
+	for obj, expectStatus := range map[*spirev1alpha1.ClusterFederatedTrustDomain]spirev1alpha1.ClusterFederatedTrustDomainStatus{
+		created:   {},
+		updated:   {},
+		unchanged: {Set: true},
+	} {
+		actual := new(spirev1alpha1.ClusterFederatedTrustDomain)
+		require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), actual))
+		assert.Equal(t, expectStatus, actual.Status, "status of %s", obj.Name)
+	}
+	for _, operation := range operations {
+		assert.Equal(t, before[operation], writeDurationSamples(t, operation), operation)
+	}
+}
+
+func TestReconcileStatus(t *testing.T) {
+	now := time.Now()
+	newClusterFederatedTrustDomain := func(name, trustDomain string, created time.Time) *spirev1alpha1.ClusterFederatedTrustDomain {
+		return &spirev1alpha1.ClusterFederatedTrustDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.Time{Time: created}},
+			Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+				TrustDomain:           trustDomain,
+				BundleEndpointURL:     "https://" + trustDomain + ".test/bundle",
+				BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: "https_web"},
+			},
+		}
+	}
+	unchanged := newClusterFederatedTrustDomain("unchanged", "unchanged", now)
+	created := newClusterFederatedTrustDomain("created", "created", now)
+	failed := newClusterFederatedTrustDomain("failed", "failed", now)
+	conflicting := newClusterFederatedTrustDomain("conflicting", "created", now.Add(time.Second))
+
+	tdc := newTrustDomainClient()
+	unchangedTD := spiffeid.RequireTrustDomainFromString("unchanged")
+	tdc.frs[unchangedTD] = spireapi.FederationRelationship{
+		TrustDomain:           unchangedTD,
+		BundleEndpointURL:     "https://unchanged.test/bundle",
+		BundleEndpointProfile: spireapi.HTTPSWebProfile{},
+	}
+	tdc.createStatus[spiffeid.RequireTrustDomainFromString("failed")] = spireapi.Status{Code: codes.InvalidArgument, Message: "oh no"}
+
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithRuntimeObjects(unchanged, created, failed, conflicting).
+		WithStatusSubresource(&spirev1alpha1.ClusterFederatedTrustDomain{}).
+		Build()
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	config := spirefederationrelationship.ReconcilerConfig{
+		TrustDomainClient: tdc,
+		K8sClient:         k8sClient,
+	}
+
+	assertStatuses := func(expected map[string]spirev1alpha1.ClusterFederatedTrustDomainStatus) {
+		t.Helper()
+		var list spirev1alpha1.ClusterFederatedTrustDomainList
+		require.NoError(t, k8sClient.List(ctx, &list))
+		actual := make(map[string]spirev1alpha1.ClusterFederatedTrustDomainStatus)
+		for _, item := range list.Items {
+			actual[item.Name] = item.Status
+		}
+		require.Equal(t, expected, actual)
+	}
+
+	spirefederationrelationship.Reconcile(ctx, config)
+	assertStatuses(map[string]spirev1alpha1.ClusterFederatedTrustDomainStatus{
+		"unchanged":   {Set: true},
+		"created":     {Set: true},
+		"failed":      {Error: "rpc error: code = InvalidArgument desc = oh no"},
+		"conflicting": {Error: `trust domain conflicts with ClusterFederatedTrustDomain "created"`},
+	})
+
+	// Once the failure clears, the status reflects the success.
+	delete(tdc.createStatus, spiffeid.RequireTrustDomainFromString("failed"))
+	spirefederationrelationship.Reconcile(ctx, config)
+	assertStatuses(map[string]spirev1alpha1.ClusterFederatedTrustDomainStatus{
+		"unchanged":   {Set: true},
+		"created":     {Set: true},
+		"failed":      {Set: true},
+		"conflicting": {Error: `trust domain conflicts with ClusterFederatedTrustDomain "created"`},
+	})
+
+	// Failures writing the whole batch are reported too.
+	tdc.createError = errors.New("batch failed")
+	require.NoError(t, k8sClient.Create(ctx, newClusterFederatedTrustDomain("batch", "batch", now)))
+	spirefederationrelationship.Reconcile(ctx, config)
+	assertStatuses(map[string]spirev1alpha1.ClusterFederatedTrustDomainStatus{
+		"unchanged":   {Set: true},
+		"created":     {Set: true},
+		"failed":      {Set: true},
+		"conflicting": {Error: `trust domain conflicts with ClusterFederatedTrustDomain "created"`},
+		"batch":       {Error: "batch failed"},
+	})
+}
+
+func writeDurationSamples(t *testing.T, operation string) uint64 {
+	m := new(dto.Metric)
+	require.NoError(t, metrics.PromSPIREWriteDuration.WithLabelValues(operation).(prometheus.Histogram).Write(m))
+	return m.GetHistogram().GetSampleCount()
+}
+
 type trustDomainClient struct {
 	frs          map[spiffeid.TrustDomain]spireapi.FederationRelationship
 	listError    error
@@ -294,3 +558,293 @@ func (t *trustDomainClient) getFederationRelationships() []spireapi.FederationRe
 	})
 	return out
 }
+
+func TestReconcileOIDCDiscovery(t *testing.T) {
+	key1 := generateKey(t)
+	key2 := generateKey(t)
+
+	var servedKey crypto.PublicKey
+	failFetch := false
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failFetch {
+			http.Error(w, "oh no", http.StatusInternalServerError)
+			return
+		}
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_, _ = w.Write([]byte(`{"jwks_uri":"` + server.URL + `/keys"}`))
+		case "/keys":
+			jwks, err := jwtbundle.FromJWTAuthorities(td, map[string]crypto.PublicKey{"kid": servedKey}).Marshal()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write(jwks)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	discoveryURL := server.URL + "/.well-known/openid-configuration"
+	cftd := &spirev1alpha1.ClusterFederatedTrustDomain{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "td",
+		},
+		Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+			TrustDomain:           "td",
+			BundleEndpointURL:     discoveryURL,
+			BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: "oidc_discovery"},
+		},
+	}
+	bundleWithKey := func(key crypto.PublicKey) *spiffebundle.Bundle {
+		return spiffebundle.FromJWTAuthorities(td, map[string]crypto.PublicKey{"kid": key})
+	}
+
+	for _, tt := range []struct {
+		desc             string
+		servedKey        crypto.PublicKey
+		failFetch        bool
+		withBundles      []*spiffebundle.Bundle
+		withFRs          []spireapi.FederationRelationship
+		configureClient  func(fbc *federatedBundleClient)
+		expectKey        crypto.PublicKey
+		expectNoBundles  bool
+		expectStatus     spirev1alpha1.ClusterFederatedTrustDomainStatus
+		expectStatusFunc func(t *testing.T, status spirev1alpha1.ClusterFederatedTrustDomainStatus)
+	}{
+		{
+			desc:         "sets federated bundle with fetched keys",
+			servedKey:    key1,
+			expectKey:    key1,
+			expectStatus: spirev1alpha1.ClusterFederatedTrustDomainStatus{Set: true},
+		},
+		{
+			desc:        "does not set when bundle is unchanged",
+			servedKey:   key1,
+			withBundles: []*spiffebundle.Bundle{bundleWithKey(key1)},
+			configureClient: func(fbc *federatedBundleClient) {
+				fbc.setError = errors.New("unexpected set")
+			},
+			expectKey:    key1,
+			expectStatus: spirev1alpha1.ClusterFederatedTrustDomainStatus{Set: true},
+		},
+		{
+			desc:         "replaces federated bundle when keys rotate",
+			servedKey:    key2,
+			withBundles:  []*spiffebundle.Bundle{bundleWithKey(key1)},
+			expectKey:    key2,
+			expectStatus: spirev1alpha1.ClusterFederatedTrustDomainStatus{Set: true},
+		},
+		{
+			desc:        "keeps existing federated bundle when fetch fails",
+			failFetch:   true,
+			withBundles: []*spiffebundle.Bundle{bundleWithKey(key1)},
+			expectKey:   key1,
+		},
+		{
+			desc:            "does not set federated bundle when fetch fails",
+			failFetch:       true,
+			expectNoBundles: true,
+		},
+		{
+			desc:      "deletes the federation relationship left for the trust domain",
+			servedKey: key1,
+			withFRs: []spireapi.FederationRelationship{{
+				TrustDomain:           td,
+				BundleEndpointURL:     discoveryURL,
+				BundleEndpointProfile: spireapi.HTTPSWebProfile{},
+				TrustDomainBundle:     bundleWithKey(key1),
+			}},
+			expectKey:    key1,
+			expectStatus: spirev1alpha1.ClusterFederatedTrustDomainStatus{Set: true},
+		},
+		{
+			desc:      "reports failure to set federated bundle",
+			servedKey: key1,
+			configureClient: func(fbc *federatedBundleClient) {
+				fbc.setError = errors.New("oh no")
+			},
+			expectNoBundles: true,
+			expectStatus:    spirev1alpha1.ClusterFederatedTrustDomainStatus{Error: "oh no"},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			servedKey = tt.servedKey
+			failFetch = tt.failFetch
+
+			tdc := newTrustDomainClient()
+			for _, fr := range tt.withFRs {
+				tdc.frs[fr.TrustDomain] = fr
+			}
+			fbc := newFederatedBundleClient(tt.withBundles...)
+			if tt.configureClient != nil {
+				tt.configureClient(fbc)
+			}
+
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			k8sClient := k8stest.NewClientBuilder(t).WithRuntimeObjects(cftd).WithStatusSubresource(cftd).Build()
+			spirefederationrelationship.Reconcile(ctx, spirefederationrelationship.ReconcilerConfig{
+				TrustDomainClient:     tdc,
+				FederatedBundleClient: fbc,
+				K8sClient:             k8sClient,
+				HTTPClient:            server.Client(),
+			})
+
+			// SPIRE never gets a federation relationship to poll.
+			assert.Empty(t, tdc.getFederationRelationships())
+
+			if tt.expectNoBundles {
+				assert.Empty(t, fbc.bundles)
+			} else {
+				require.Contains(t, fbc.bundles, td)
+				key, ok := fbc.bundles[td].FindJWTAuthority("kid")
+				require.True(t, ok)
+				assert.True(t, tt.expectKey.(*ecdsa.PublicKey).Equal(key))
+			}
+
+			actual := new(spirev1alpha1.ClusterFederatedTrustDomain)
+			require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(cftd), actual))
+			if tt.failFetch {
+				assert.Contains(t, actual.Status.Error, "failed to fetch bundle")
+			} else {
+				assert.Equal(t, tt.expectStatus, actual.Status)
+			}
+		})
+	}
+}
+
+func TestReconcileOIDCDiscoveryDeletesOwnedBundles(t *testing.T) {
+	key := generateKey(t)
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_, _ = w.Write([]byte(`{"jwks_uri":"` + server.URL + `/keys"}`))
+		case "/keys":
+			jwks, err := jwtbundle.FromJWTAuthorities(td, map[string]crypto.PublicKey{"kid": key}).Marshal()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write(jwks)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cftd := &spirev1alpha1.ClusterFederatedTrustDomain{
+		ObjectMeta: metav1.ObjectMeta{Name: "td"},
+		Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+			TrustDomain:           "td",
+			BundleEndpointURL:     server.URL + "/.well-known/openid-configuration",
+			BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: "oidc_discovery"},
+		},
+	}
+	// Set out-of-band, with JWT authorities only like the bundles set by
+	// the controller.
+	outOfBand := spiffeid.RequireTrustDomainFromString("out-of-band")
+	fbc := newFederatedBundleClient(spiffebundle.FromJWTAuthorities(outOfBand, map[string]crypto.PublicKey{"kid": generateKey(t)}))
+	fbc.deleteStatus[td] = spireapi.Status{Code: codes.FailedPrecondition, Message: "bundle is federated with by entries"}
+
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	k8sClient := k8stest.NewClientBuilder(t).WithRuntimeObjects(cftd).WithStatusSubresource(cftd).Build()
+	reconcile := func() {
+		spirefederationrelationship.Reconcile(ctx, spirefederationrelationship.ReconcilerConfig{
+			TrustDomainClient:     newTrustDomainClient(),
+			FederatedBundleClient: fbc,
+			K8sClient:             k8sClient,
+			HTTPClient:            server.Client(),
+		})
+	}
+
+	t.Log("The finalizer is added before the bundle is set")
+	reconcile()
+	actual := new(spirev1alpha1.ClusterFederatedTrustDomain)
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(cftd), actual))
+	require.Equal(t, []string{"spire.spiffe.io/federated-bundle"}, actual.Finalizers)
+	require.ElementsMatch(t, []spiffeid.TrustDomain{td, outOfBand}, fbc.trustDomains())
+
+	t.Log("The bundle is kept, and so is the finalizer, while entries federate with it")
+	require.NoError(t, k8sClient.Delete(ctx, actual))
+	reconcile()
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(cftd), actual))
+	require.ElementsMatch(t, []spiffeid.TrustDomain{td, outOfBand}, fbc.trustDomains())
+
+	t.Log("The bundle is deleted once no entry federates with it, then the finalizer removed")
+	delete(fbc.deleteStatus, td)
+	reconcile()
+	require.True(t, apierrors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(cftd), actual)))
+
+	// The bundle set out-of-band is never deleted.
+	require.ElementsMatch(t, []spiffeid.TrustDomain{outOfBand}, fbc.trustDomains())
+	require.ElementsMatch(t, []spiffeid.TrustDomain{td, td}, fbc.deleteRequested)
+}
+
+type federatedBundleClient struct {
+	bundles         map[spiffeid.TrustDomain]*spiffebundle.Bundle
+	setError        error
+	deleteStatus    map[spiffeid.TrustDomain]spireapi.Status
+	deleteRequested []spiffeid.TrustDomain
+}
+
+func newFederatedBundleClient(bundles ...*spiffebundle.Bundle) *federatedBundleClient {
+	fbc := &federatedBundleClient{
+		bundles:      make(map[spiffeid.TrustDomain]*spiffebundle.Bundle),
+		deleteStatus: make(map[spiffeid.TrustDomain]spireapi.Status),
+	}
+	for _, bundle := range bundles {
+		fbc.bundles[bundle.TrustDomain()] = bundle
+	}
+	return fbc
+}
+
+func (c *federatedBundleClient) ListFederatedBundles(context.Context) ([]*spiffebundle.Bundle, error) {
+	out := make([]*spiffebundle.Bundle, 0, len(c.bundles))
+	for _, bundle := range c.bundles {
+		out = append(out, bundle)
+	}
+	return out, nil
+}
+
+func (c *federatedBundleClient) SetFederatedBundles(_ context.Context, bundles []*spiffebundle.Bundle) ([]spireapi.Status, error) {
+	if c.setError != nil {
+		return nil, c.setError
+	}
+	out := make([]spireapi.Status, 0, len(bundles))
+	for _, bundle := range bundles {
+		c.bundles[bundle.TrustDomain()] = bundle
+		out = append(out, spireapi.Status{})
+	}
+	return out, nil
+}
+
+func (c *federatedBundleClient) DeleteFederatedBundles(_ context.Context, tds []spiffeid.TrustDomain) ([]spireapi.Status, error) {
+	out := make([]spireapi.Status, 0, len(tds))
+	for _, td := range tds {
+		c.deleteRequested = append(c.deleteRequested, td)
+		st := c.deleteStatus[td]
+		if st.Code == codes.OK {
+			delete(c.bundles, td)
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+func (c *federatedBundleClient) trustDomains() []spiffeid.TrustDomain {
+	out := make([]spiffeid.TrustDomain, 0, len(c.bundles))
+	for td := range c.bundles {
+		out = append(out, td)
+	}
+	return out
+}
+
+func generateKey(t *testing.T) crypto.PublicKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key.Public()
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svidsource
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jpillora/backoff"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	defaultTTL = time.Hour
+
+	minRetryBackoff = time.Second
+	maxRetryBackoff = time.Minute
+)
+
+type Config struct {
+	// ID is the SPIFFE ID of the X509-SVID.
+	ID spiffeid.ID
+
+	// TTL is the requested time-to-live of the X509-SVID. Defaults to 1h.
+	TTL time.Duration
+
+	SVIDClient spireapi.SVIDClient
+	Clock      clock.Clock
+}
+
+// Source maintains an X509-SVID for the controller itself, minted by the
+// SPIRE server. The X509-SVID is rotated at half its lifetime. It implements
+// x509svid.Source so it can be used with the go-spiffe TLS helpers.
+type Source struct {
+	config Config
+
+	mtx       sync.RWMutex
+	svid      *x509svid.SVID
+	rotatedAt time.Time
+	expiresAt time.Time
+}
+
+var _ x509svid.Source = (*Source)(nil)
+
+func New(config Config) *Source {
+	if config.TTL <= 0 {
+		config.TTL = defaultTTL
+	}
+	if config.Clock == nil {
+		config.Clock = clock.RealClock{}
+	}
+	return &Source{
+		config: config,
+	}
+}
+
+// Init mints the initial X509-SVID.
+func (s *Source) Init(ctx context.Context) error {
+	return s.mintX509SVID(ctx)
+}
+
+// Start rotates the X509-SVID until the context is done. Failures to mint
+// are retried with a backoff, while the current X509-SVID remains in use.
+func (s *Source) Start(ctx context.Context) error {
+	log := log.FromContext(ctx).WithName("svid-source")
+
+	retryBackoff := backoff.Backoff{Min: minRetryBackoff, Max: maxRetryBackoff}
+	timer := s.config.Clock.NewTimer(s.rotateIn())
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if err := s.mintX509SVID(ctx); err != nil {
+			retryIn := retryBackoff.Duration()
+			log.Error(err, "Failed to rotate controller X509-SVID", "retryIn", retryIn)
+			timer.Reset(retryIn)
+			continue
+		}
+		retryBackoff.Reset()
+		log.Info("Rotated controller X509-SVID")
+		timer.Reset(s.rotateIn())
+	}
+}
+
+// GetX509SVID returns the current X509-SVID.
+func (s *Source) GetX509SVID() (*x509svid.SVID, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.svid == nil {
+		return nil, errors.New("controller X509-SVID has not been minted yet")
+	}
+	return s.svid, nil
+}
+
+// rotateIn returns how long until the X509-SVID is due for rotation.
+func (s *Source) rotateIn() time.Duration {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	rotateAt := s.rotatedAt.Add(s.expiresAt.Sub(s.rotatedAt) / 2)
+	return max(rotateAt.Sub(s.config.Clock.Now()), 0)
+}
+
+func (s *Source) mintX509SVID(ctx context.Context) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate X509-SVID private key: %w", err)
+	}
+
+	svid, err := s.config.SVIDClient.MintX509SVID(ctx, spireapi.X509SVIDParams{
+		Key: key,
+		ID:  s.config.ID,
+		TTL: s.config.TTL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mint controller X509-SVID: %w", err)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.svid = &x509svid.SVID{
+		ID:           svid.ID,
+		Certificates: svid.CertChain,
+		PrivateKey:   svid.Key,
+	}
+	s.rotatedAt = s.config.Clock.Now()
+	s.expiresAt = svid.ExpiresAt
+	return nil
+}
@@ -0,0 +1,118 @@
+package svidsource_test
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/spiffe/spire-controller-manager/pkg/svidsource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	testclock "k8s.io/utils/clock/testing"
+)
+
+var id = spiffeid.RequireFromString("spiffe://example.org/spire-controller-manager")
+
+func TestSource(t *testing.T) {
+	clock := testclock.NewFakeClock(time.Now())
+	svidClient := &fakeSVIDClient{clock: clock}
+	source := svidsource.New(svidsource.Config{
+		ID:         id,
+		TTL:        time.Hour,
+		SVIDClient: svidClient,
+		Clock:      clock,
+	})
+
+	requireSerial := func(expected int64) {
+		t.Helper()
+		svid, err := source.GetX509SVID()
+		require.NoError(t, err)
+		require.Equal(t, id, svid.ID)
+		require.Equal(t, big.NewInt(expected), svid.Certificates[0].SerialNumber)
+	}
+	waitForMints := func(expected int) {
+		t.Helper()
+		require.Eventually(t, func() bool { return svidClient.getMints() == expected }, time.Minute, time.Millisecond*10)
+		require.Eventually(t, clock.HasWaiters, time.Minute, time.Millisecond*10)
+	}
+
+	_, err := source.GetX509SVID()
+	require.EqualError(t, err, "controller X509-SVID has not been minted yet")
+
+	t.Log("Init mints the initial X509-SVID")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, source.Init(ctx))
+	requireSerial(1)
+
+	errCh := make(chan error)
+	t.Cleanup(func() {
+		cancel()
+		assert.ErrorIs(t, <-errCh, context.Canceled)
+	})
+	go func() {
+		errCh <- source.Start(ctx)
+	}()
+	waitForMints(1)
+
+	t.Log("The X509-SVID is rotated at half its lifetime")
+	clock.Step(30 * time.Minute)
+	waitForMints(2)
+	requireSerial(2)
+
+	t.Log("Failures to rotate are retried while the current X509-SVID remains in use")
+	svidClient.setErr(errors.New("oh no"))
+	clock.Step(30 * time.Minute)
+	waitForMints(3)
+	requireSerial(2)
+
+	svidClient.setErr(nil)
+	clock.Step(time.Second)
+	waitForMints(4)
+	requireSerial(4)
+}
+
+type fakeSVIDClient struct {
+	clock *testclock.FakeClock
+
+	mtx   sync.Mutex
+	mints int
+	err   error
+}
+
+func (c *fakeSVIDClient) MintX509SVID(_ context.Context, params spireapi.X509SVIDParams) (*spireapi.X509SVID, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.mints++
+	if c.err != nil {
+		return nil, c.err
+	}
+	expiresAt := c.clock.Now().Add(params.TTL)
+	return &spireapi.X509SVID{
+		ID:  params.ID,
+		Key: params.Key,
+		CertChain: []*x509.Certificate{{
+			SerialNumber: big.NewInt(int64(c.mints)),
+			NotAfter:     expiresAt,
+		}},
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (c *fakeSVIDClient) getMints() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.mints
+}
+
+func (c *fakeSVIDClient) setErr(err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.err = err
+}
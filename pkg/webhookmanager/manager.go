@@ -32,13 +32,16 @@ import (
 	"time"
 
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
 	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	types "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 	admissionregistrationapiv1 "k8s.io/client-go/kubernetes/typed/admissionregistration/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
 
 	"k8s.io/utils/clock"
@@ -58,6 +61,11 @@ type Config struct {
 	SVIDClient    spireapi.SVIDClient
 	BundleClient  spireapi.BundleClient
 	Clock         clock.WithTicker
+
+	// ServiceClient, if set, is used to check that the services referenced
+	// by the webhook configuration exist whenever the webhook certificate
+	// is minted for their DNS names.
+	ServiceClient corev1client.ServicesGetter
 }
 
 type Manager struct {
@@ -208,6 +216,10 @@ func (m *Manager) mintX509SVIDIfNeeded(ctx context.Context, store cache.Store) e
 		return nil
 	}
 
+	// The certificate is minted for the DNS names of the services, which
+	// is a good time to check that they exist.
+	m.reportMissingWebhookServices(ctx, webhookConfig)
+
 	log.Info("Minting webhook certificate", "reason", reason, "dnsNames", dnsNames)
 	return m.mintX509SVID(ctx, dnsNames)
 }
@@ -284,6 +296,41 @@ func (m *Manager) updateWebhookConfigIfNeeded(ctx context.Context, store cache.S
 	return nil
 }
 
+// reportMissingWebhookServices logs an error and bumps a metric if services
+// referenced by the webhook configuration do not exist. The webhook can not
+// be reached until they do, which is a common misconfiguration.
+func (m *Manager) reportMissingWebhookServices(ctx context.Context, webhookConfig *admissionregistrationv1.ValidatingWebhookConfiguration) {
+	if m.config.ServiceClient == nil {
+		return
+	}
+	log := log.FromContext(ctx)
+	missing, err := m.missingWebhookServices(ctx, webhookConfig)
+	switch {
+	case err != nil:
+		log.Error(err, "Failed to check webhook services")
+	case len(missing) > 0:
+		log.Error(nil, "Webhook configuration references services that do not exist; the webhook will not be reachable", "webhookName", m.config.WebhookName, "services", missing)
+		metrics.PromCounters[metrics.WebhookServicesMissing].Inc()
+	}
+}
+
+// missingWebhookServices returns the services referenced by the webhook
+// configuration that do not exist.
+func (m *Manager) missingWebhookServices(ctx context.Context, webhookConfig *admissionregistrationv1.ValidatingWebhookConfiguration) ([]string, error) {
+	var missing []string
+	for _, service := range webhookServices(webhookConfig) {
+		_, err := m.config.ServiceClient.Services(service.Namespace).Get(ctx, service.Name, metav1.GetOptions{})
+		switch {
+		case err == nil:
+		case apierrors.IsNotFound(err):
+			missing = append(missing, service.String())
+		default:
+			return nil, fmt.Errorf("failed to get webhook service %q: %w", service, err)
+		}
+	}
+	return missing, nil
+}
+
 func (m *Manager) refreshBundle(ctx context.Context) error {
 	bundle, err := m.config.BundleClient.GetBundle(ctx)
 	if err != nil {
@@ -459,3 +506,22 @@ func getWebhookConfigFromStore(store cache.Store, name string) (*admissionregist
 
 	return webhookConfig, true, nil
 }
+
+// webhookServices returns the services referenced by the webhook
+// configuration, sorted and without duplicates.
+func webhookServices(webhookConfig *admissionregistrationv1.ValidatingWebhookConfiguration) []types.NamespacedName {
+	servicesSet := make(map[types.NamespacedName]struct{})
+	for _, webhook := range webhookConfig.Webhooks {
+		if service := webhook.ClientConfig.Service; service != nil && service.Namespace != "" && service.Name != "" {
+			servicesSet[types.NamespacedName{Namespace: service.Namespace, Name: service.Name}] = struct{}{}
+		}
+	}
+	var services []types.NamespacedName
+	for service := range servicesSet {
+		services = append(services, service)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].String() < services[j].String()
+	})
+	return services
+}
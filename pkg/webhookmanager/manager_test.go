@@ -0,0 +1,55 @@
+package webhookmanager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMissingWebhookServices(t *testing.T) {
+	webhookConfig := &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhook"},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{ClientConfig: admissionregistrationv1.WebhookClientConfig{Service: &admissionregistrationv1.ServiceReference{Namespace: "spire", Name: "webhook"}}},
+			{ClientConfig: admissionregistrationv1.WebhookClientConfig{Service: &admissionregistrationv1.ServiceReference{Namespace: "spire", Name: "webhook"}}},
+			{ClientConfig: admissionregistrationv1.WebhookClientConfig{Service: &admissionregistrationv1.ServiceReference{Namespace: "spire", Name: "missing"}}},
+		},
+	}
+	clientset := fake.NewSimpleClientset(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "spire", Name: "webhook"},
+	})
+	ctx := context.Background()
+
+	m := New(Config{WebhookName: "webhook", ServiceClient: clientset.CoreV1()})
+	missing, err := m.missingWebhookServices(ctx, webhookConfig)
+	require.NoError(t, err)
+	require.Equal(t, []string{"spire/missing"}, missing)
+
+	counter := metrics.PromCounters[metrics.WebhookServicesMissing]
+	before := testutil.ToFloat64(counter)
+	m.reportMissingWebhookServices(ctx, webhookConfig)
+	require.Equal(t, before+1, testutil.ToFloat64(counter))
+
+	t.Log("Nothing is reported once the services exist")
+	_, err = clientset.CoreV1().Services("spire").Create(ctx, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "spire", Name: "missing"},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	missing, err = m.missingWebhookServices(ctx, webhookConfig)
+	require.NoError(t, err)
+	require.Empty(t, missing)
+	m.reportMissingWebhookServices(ctx, webhookConfig)
+	require.Equal(t, before+1, testutil.ToFloat64(counter))
+
+	t.Log("Services are not checked without a service client")
+	m = New(Config{WebhookName: "webhook"})
+	m.reportMissingWebhookServices(ctx, webhookConfig)
+	require.Equal(t, before+1, testutil.ToFloat64(counter))
+}